@@ -2,16 +2,30 @@ package auth
 
 type Checker struct {
 	allowedUsers map[int64]bool
+	adminUsers   map[int64]bool
 }
 
-func New(userIDs []int64) *Checker {
+// New 创建鉴权器；adminIDs 为空时所有允许的用户均视为管理员
+func New(userIDs []int64, adminIDs []int64) *Checker {
 	m := make(map[int64]bool, len(userIDs))
 	for _, id := range userIDs {
 		m[id] = true
 	}
-	return &Checker{allowedUsers: m}
+	admins := m
+	if len(adminIDs) > 0 {
+		admins = make(map[int64]bool, len(adminIDs))
+		for _, id := range adminIDs {
+			admins[id] = m[id]
+		}
+	}
+	return &Checker{allowedUsers: m, adminUsers: admins}
 }
 
 func (c *Checker) IsAllowed(userID int64) bool {
 	return c.allowedUsers[userID]
 }
+
+// IsAdmin 是否可执行管理命令
+func (c *Checker) IsAdmin(userID int64) bool {
+	return c.adminUsers[userID]
+}
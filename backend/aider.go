@@ -0,0 +1,26 @@
+package backend
+
+import "github.com/user/tgmux/config"
+
+func newAider(cfg *config.Config) Backend {
+	bc := cfg.Backends.Aider
+	cmd := bc.Command
+	if cmd == "" {
+		cmd = "aider"
+	}
+	return Backend{
+		Type:       TypeAider,
+		Command:    cmd,
+		Args:       bc.Args,
+		LogDirFunc: nil, // 对话记录位于项目目录，由监控器按启动参数定位
+
+		// 第二次 Ctrl-C 会退出 aider
+		InterruptKeys: []string{"C-c"},
+		// (Y)es/(N)o/(A)ll：All 为本次运行中的同类提示都回答是
+		ConfirmKeyMap: map[string][]string{
+			"yes":    {"y", "Enter"},
+			"always": {"a", "Enter"},
+			"no":     {"n", "Enter"},
+		},
+	}
+}
@@ -1,6 +1,14 @@
 package backend
 
-import "github.com/user/tgmux/config"
+import (
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/user/tgmux/config"
+)
 
 type Type string
 
@@ -9,6 +17,11 @@ const (
 	TypeCodex  Type = "codex"
 	TypeGemini Type = "gemini"
 	TypeBash   Type = "bash"
+	TypeAider  Type = "aider"
+	TypeCursor Type = "cursor"
+
+	TypeOpencode       Type = "opencode"
+	TypeClaudeHeadless Type = "claude-headless"
 )
 
 type Backend struct {
@@ -16,38 +29,209 @@ type Backend struct {
 	Command    string
 	Args       []string
 	LogDirFunc func(projectPath string) string // 返回日志监控目录
+
+	InterruptKeys  []string // 中断当前生成的 tmux 按键序列
+	InterruptTwice bool     // 第一次中断未生效时是否再发一次
+
+	// Env 启动前导出的环境变量（内置默认值与 backends.<name>.env 合并，${VAR} 已展开），空值表示 unset
+	Env map[string]string
+
+	// ConfirmKeyMap 回答确认提示的 tmux 按键序列（yes / no / always），为空时使用 defaultConfirmKeys
+	ConfirmKeyMap map[string][]string
+	// ProcessNames 后端在 pane_current_command 中的进程名前缀，为空时任何非 shell 进程都视为后端
+	ProcessNames []string
+
+	// 仅自定义后端（backends.custom）
+	Custom          bool
+	LogFormat       string           // config.LogFormat* 之一，空同 none
+	ConfirmPatterns []*regexp.Regexp // 额外的确认提示
+}
+
+// defaultInterruptKeys 未声明中断序列的后端使用 Ctrl-C
+var defaultInterruptKeys = []string{"C-c"}
+
+// defaultConfirmKeys 未声明确认按键的后端回答 y / n 加回车，不提供"始终允许"
+var defaultConfirmKeys = map[string][]string{
+	"yes": {"y", "Enter"},
+	"no":  {"n", "Enter"},
+}
+
+// menuConfirmKeys 方向键选择的权限菜单（claude、gemini）：
+// 光标默认位于"允许一次"，下一项为"始终允许"，Esc 拒绝
+var menuConfirmKeys = map[string][]string{
+	"yes":    {"Enter"},
+	"always": {"Down", "Enter"},
+	"no":     {"Escape"},
+}
+
+// shells 前台进程回到 shell 说明后端已退出
+var shells = []string{"bash", "zsh", "sh", "fish", "dash", "ksh", "csh", "tcsh"}
+
+// Interrupt 返回后端的中断按键序列
+func (b Backend) Interrupt() []string {
+	if len(b.InterruptKeys) == 0 {
+		return defaultInterruptKeys
+	}
+	return b.InterruptKeys
+}
+
+// ConfirmKeys 返回回答确认提示的按键序列，answer 为 yes / no / always，后端不支持的回答返回 nil
+func (b Backend) ConfirmKeys(answer string) []string {
+	if b.ConfirmKeyMap == nil {
+		return defaultConfirmKeys[answer]
+	}
+	return b.ConfirmKeyMap[answer]
+}
+
+// CanAlways 后端的确认提示是否有"始终允许"选项，没有时确认键盘不显示该按钮
+func (b Backend) CanAlways() bool {
+	return len(b.ConfirmKeys("always")) > 0
+}
+
+// Alive 根据 pane 的前台进程名判断后端是否在运行：空（窗口不存在）或 shell 视为已退出
+func (b Backend) Alive(paneCommand string) bool {
+	if paneCommand == "" || slices.Contains(shells, paneCommand) {
+		return false
+	}
+	if len(b.ProcessNames) == 0 {
+		return true
+	}
+	for _, name := range b.ProcessNames {
+		if strings.HasPrefix(paneCommand, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// Installed 后端命令是否能在 PATH 中找到；命令为空（bash 使用默认 shell）视为可用
+func (b Backend) Installed() bool {
+	fields := strings.Fields(b.Command)
+	if len(fields) == 0 {
+		return true
+	}
+	_, err := exec.LookPath(fields[0])
+	return err == nil
+}
+
+// Detect 根据窗口中的进程命令行（pane 的 shell 及其子孙进程，见 tmux.PaneProcesses）识别后端：
+// 某个进程的命令（或解释器运行的脚本）与后端命令同名时为该后端，否则 pane 进程为 shell 时为 bash
+func Detect(cfg *config.Config, procs []string) (Type, bool) {
+	for _, t := range Types(cfg) {
+		if t == TypeBash || t == TypeClaudeHeadless {
+			continue
+		}
+		fields := strings.Fields(Get(t, cfg).Command)
+		if len(fields) == 0 {
+			continue
+		}
+		name := filepath.Base(fields[0])
+		for _, p := range procs {
+			if runsCommand(p, name) {
+				return t, true
+			}
+		}
+	}
+	if len(procs) > 0 {
+		if fields := strings.Fields(procs[0]); len(fields) > 0 && slices.Contains(shells, strings.TrimPrefix(filepath.Base(fields[0]), "-")) {
+			return TypeBash, true
+		}
+	}
+	return "", false
+}
+
+// runsCommand 进程命令行是否在运行 name：直接执行，或由 node / python 等解释器运行同名脚本
+func runsCommand(cmdline, name string) bool {
+	fields := strings.Fields(cmdline)
+	for i := 0; i < len(fields) && i < 2; i++ {
+		if filepath.Base(fields[i]) == name {
+			return true
+		}
+	}
+	return false
+}
+
+// descriptor 内置后端的注册信息：构造函数与配置块
+type descriptor struct {
+	typ    Type
+	build  func(cfg *config.Config) Backend
+	config func(cfg *config.Config) *config.BackendConfig
+}
+
+// registry 内置后端，顺序即后端选择键盘中的顺序
+var registry = []descriptor{
+	{TypeClaude, newClaude, func(cfg *config.Config) *config.BackendConfig { return &cfg.Backends.Claude }},
+	{TypeCodex, newCodex, func(cfg *config.Config) *config.BackendConfig { return &cfg.Backends.Codex }},
+	{TypeGemini, newGemini, func(cfg *config.Config) *config.BackendConfig { return &cfg.Backends.Gemini }},
+	{TypeOpencode, newOpencode, func(cfg *config.Config) *config.BackendConfig { return &cfg.Backends.Opencode }},
+	{TypeCursor, newCursor, func(cfg *config.Config) *config.BackendConfig { return &cfg.Backends.Cursor }},
+	{TypeBash, newBash, func(cfg *config.Config) *config.BackendConfig { return &cfg.Backends.Bash }},
+	{TypeAider, newAider, func(cfg *config.Config) *config.BackendConfig { return &cfg.Backends.Aider }},
+	{TypeClaudeHeadless, newClaudeHeadless, func(cfg *config.Config) *config.BackendConfig { return &cfg.Backends.ClaudeHeadless }},
+}
+
+// lookup 查找内置后端
+func lookup(t Type) (descriptor, bool) {
+	for _, d := range registry {
+		if d.typ == t {
+			return d, true
+		}
+	}
+	return descriptor{}, false
 }
 
 func AllTypes() []Type {
-	return []Type{TypeClaude, TypeCodex, TypeGemini, TypeBash}
+	types := make([]Type, len(registry))
+	for i, d := range registry {
+		types[i] = d.typ
+	}
+	return types
+}
+
+// Types 返回内置后端与配置中声明的自定义后端（与内置后端重名的忽略）
+func Types(cfg *config.Config) []Type {
+	types := AllTypes()
+	for _, cb := range cfg.Backends.Custom {
+		if _, builtin := lookup(Type(cb.Name)); !builtin {
+			types = append(types, Type(cb.Name))
+		}
+	}
+	return types
 }
 
 func Get(t Type, cfg *config.Config) Backend {
-	switch t {
-	case TypeClaude:
-		return newClaude(cfg)
-	case TypeCodex:
-		return newCodex(cfg)
-	case TypeGemini:
-		return newGemini(cfg)
-	case TypeBash:
-		return newBash(cfg)
-	default:
-		return Backend{Type: t}
+	if d, ok := lookup(t); ok {
+		be := d.build(cfg)
+		be.Env = mergeEnv(be.Env, d.config(cfg).Env)
+		return be
+	}
+	if cb := customConfig(t, cfg); cb != nil {
+		be := newCustom(*cb)
+		be.Env = mergeEnv(nil, cb.Env)
+		return be
 	}
+	return Backend{Type: t}
 }
 
 func IsEnabled(t Type, cfg *config.Config) bool {
-	switch t {
-	case TypeClaude:
-		return cfg.Backends.Claude.IsEnabled()
-	case TypeCodex:
-		return cfg.Backends.Codex.IsEnabled()
-	case TypeGemini:
-		return cfg.Backends.Gemini.IsEnabled()
-	case TypeBash:
-		return cfg.Backends.Bash.IsEnabled()
-	default:
-		return false
+	bc := configFor(t, cfg)
+	return bc != nil && bc.IsEnabled()
+}
+
+// Profiles 返回后端配置的启动参数组合（名称 → 追加参数）
+func Profiles(t Type, cfg *config.Config) map[string][]string {
+	if bc := configFor(t, cfg); bc != nil {
+		return bc.Profiles
+	}
+	return nil
+}
+
+func configFor(t Type, cfg *config.Config) *config.BackendConfig {
+	if d, ok := lookup(t); ok {
+		return d.config(cfg)
+	}
+	if cb := customConfig(t, cfg); cb != nil {
+		return &cb.BackendConfig
 	}
+	return nil
 }
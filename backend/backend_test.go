@@ -0,0 +1,141 @@
+package backend
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/user/tgmux/config"
+)
+
+// testConfig 内置后端使用默认配置，另加一个自定义后端和一个与内置后端重名（应被忽略）的自定义后端
+func testConfig() *config.Config {
+	cfg := &config.Config{}
+	cfg.Backends.Custom = []config.CustomBackendConfig{
+		{Name: "mytool", LogFormat: config.LogFormatPlainAppend},
+		{Name: string(TypeClaude), BackendConfig: config.BackendConfig{Command: "shadow"}},
+	}
+	return cfg
+}
+
+func TestTypesIncludesCustomBackends(t *testing.T) {
+	cfg := testConfig()
+	types := Types(cfg)
+	if !slices.Equal(types[:len(registry)], AllTypes()) {
+		t.Fatalf("Types() = %v, want built-in backends first in registry order", types)
+	}
+	if want := append(AllTypes(), "mytool"); !slices.Equal(types, want) {
+		t.Fatalf("Types() = %v, want %v", types, want)
+	}
+	if be := Get(TypeClaude, cfg); be.Custom || be.Command == "shadow" {
+		t.Fatalf("custom backend named claude overrides the built-in one: %+v", be)
+	}
+}
+
+// TestRegistryHooks 每个后端（内置与自定义）的配置都经由同一组钩子取得：
+// command / args / env / profiles / enabled 来自各自的配置块，按键序列总有可用的默认值
+func TestRegistryHooks(t *testing.T) {
+	cfg := testConfig()
+	disabled := false
+	for _, typ := range Types(cfg) {
+		bc := configFor(typ, cfg)
+		if bc == nil {
+			t.Fatalf("%s: no config block", typ)
+		}
+		bc.Command = "cmd-" + string(typ)
+		bc.Args = []string{"--from", string(typ)}
+		bc.Env = map[string]string{"TGMUX_TEST": string(typ)}
+		bc.Profiles = map[string][]string{"p": {"--profile", string(typ)}}
+		bc.Enabled = &disabled
+	}
+
+	for _, typ := range Types(cfg) {
+		t.Run(string(typ), func(t *testing.T) {
+			be := Get(typ, cfg)
+			if be.Type != typ {
+				t.Errorf("Type = %q", be.Type)
+			}
+			if _, builtin := lookup(typ); be.Custom == builtin {
+				t.Errorf("Custom = %v for builtin=%v", be.Custom, builtin)
+			}
+			if be.Command != "cmd-"+string(typ) {
+				t.Errorf("Command = %q, configured command ignored", be.Command)
+			}
+			if !slices.Equal(be.Args, []string{"--from", string(typ)}) {
+				t.Errorf("Args = %q", be.Args)
+			}
+			if be.Env["TGMUX_TEST"] != string(typ) {
+				t.Errorf("Env = %v, configured env not merged", be.Env)
+			}
+			if p := Profiles(typ, cfg)["p"]; !slices.Equal(p, []string{"--profile", string(typ)}) {
+				t.Errorf("Profiles = %q", p)
+			}
+			if IsEnabled(typ, cfg) {
+				t.Errorf("IsEnabled = true with enabled: false")
+			}
+
+			if len(be.Interrupt()) == 0 {
+				t.Errorf("no interrupt keys")
+			}
+			for _, answer := range []string{"yes", "no"} {
+				if len(be.ConfirmKeys(answer)) == 0 {
+					t.Errorf("no keys for %q", answer)
+				}
+			}
+			if be.CanAlways() != (len(be.ConfirmKeys("always")) > 0) {
+				t.Errorf("CanAlways disagrees with ConfirmKeys(always)")
+			}
+			for _, cmd := range []string{"", "bash", "zsh"} {
+				if be.Alive(cmd) {
+					t.Errorf("Alive(%q) = true", cmd)
+				}
+			}
+		})
+	}
+}
+
+func TestUnknownBackend(t *testing.T) {
+	cfg := testConfig()
+	be := Get("nope", cfg)
+	if be.Type != "nope" || be.Custom || be.Command != "" {
+		t.Fatalf("Get(unknown) = %+v", be)
+	}
+	if configFor("nope", cfg) != nil || IsEnabled("nope", cfg) || Profiles("nope", cfg) != nil {
+		t.Fatalf("unknown backend has a config block")
+	}
+}
+
+func TestConfirmKeysPerBackend(t *testing.T) {
+	cfg := testConfig()
+	tests := []struct {
+		typ       Type
+		interrupt []string
+		yes       []string
+		no        []string
+		always    bool
+	}{
+		{TypeClaude, []string{"Escape"}, []string{"Enter"}, []string{"Escape"}, true},
+		{TypeCodex, []string{"C-c"}, []string{"y"}, []string{"Escape"}, true},
+		{TypeGemini, []string{"Escape"}, []string{"Enter"}, []string{"Escape"}, true},
+		{TypeOpencode, []string{"Escape"}, []string{"Enter"}, []string{"Escape"}, true},
+		{TypeCursor, []string{"C-c"}, []string{"y"}, []string{"n"}, false},
+		{TypeBash, []string{"C-c"}, []string{"y", "Enter"}, []string{"n", "Enter"}, false},
+		{TypeAider, []string{"C-c"}, []string{"y", "Enter"}, []string{"n", "Enter"}, true},
+		{TypeClaudeHeadless, []string{"C-c"}, []string{"y", "Enter"}, []string{"n", "Enter"}, false},
+		{"mytool", []string{"C-c"}, []string{"y", "Enter"}, []string{"n", "Enter"}, false},
+	}
+	for _, tt := range tests {
+		be := Get(tt.typ, cfg)
+		if got := be.Interrupt(); !slices.Equal(got, tt.interrupt) {
+			t.Errorf("%s: Interrupt() = %q, want %q", tt.typ, got, tt.interrupt)
+		}
+		if got := be.ConfirmKeys("yes"); !slices.Equal(got, tt.yes) {
+			t.Errorf("%s: ConfirmKeys(yes) = %q, want %q", tt.typ, got, tt.yes)
+		}
+		if got := be.ConfirmKeys("no"); !slices.Equal(got, tt.no) {
+			t.Errorf("%s: ConfirmKeys(no) = %q, want %q", tt.typ, got, tt.no)
+		}
+		if be.CanAlways() != tt.always {
+			t.Errorf("%s: CanAlways() = %v, want %v", tt.typ, be.CanAlways(), tt.always)
+		}
+	}
+}
@@ -9,5 +9,7 @@ func newBash(cfg *config.Config) Backend {
 		Command:    bc.Command, // 空则使用默认 shell
 		Args:       bc.Args,
 		LogDirFunc: nil, // bash 使用 capture-pane，无日志路径
+
+		InterruptKeys: []string{"C-c"},
 	}
 }
@@ -8,6 +8,11 @@ import (
 	"github.com/user/tgmux/config"
 )
 
+// nestedClaudeEnv 在 claude 会话中启动 tgmux 时继承的变量会让新的 claude 以为自己是嵌套启动而拒绝运行
+func nestedClaudeEnv() map[string]string {
+	return map[string]string{"CLAUDECODE": "", "CLAUDE_CODE": ""}
+}
+
 func newClaude(cfg *config.Config) Backend {
 	bc := cfg.Backends.Claude
 	cmd := bc.Command
@@ -27,5 +32,9 @@ func newClaude(cfg *config.Config) Backend {
 			home, _ := os.UserHomeDir()
 			return filepath.Join(home, ".claude", "projects", encoded)
 		},
+		InterruptKeys:  []string{"Escape"},
+		InterruptTwice: true, // 工具调用中第一次 Esc 可能只关闭提示
+		ConfirmKeyMap:  menuConfirmKeys,
+		Env:            nestedClaudeEnv(),
 	}
 }
@@ -0,0 +1,18 @@
+package backend
+
+import "github.com/user/tgmux/config"
+
+// newClaudeHeadless 无终端的 claude：不在 tmux 中运行，由监控器为每条消息启动 claude -p
+func newClaudeHeadless(cfg *config.Config) Backend {
+	bc := cfg.Backends.ClaudeHeadless
+	cmd := bc.Command
+	if cmd == "" {
+		cmd = "claude"
+	}
+	return Backend{
+		Type:    TypeClaudeHeadless,
+		Command: cmd,
+		Args:    bc.Args,
+		Env:     nestedClaudeEnv(),
+	}
+}
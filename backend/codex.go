@@ -27,5 +27,12 @@ func newCodex(cfg *config.Config) Backend {
 			return filepath.Join(home, ".codex", "sessions",
 				now.Format("2006"), now.Format("01"), now.Format("02"))
 		},
+		InterruptKeys: []string{"C-c"},
+		// 审批菜单的快捷键直接生效，无需回车；a 为本会话内不再询问该命令
+		ConfirmKeyMap: map[string][]string{
+			"yes":    {"y"},
+			"always": {"a"},
+			"no":     {"Escape"},
+		},
 	}
 }
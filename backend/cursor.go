@@ -0,0 +1,24 @@
+package backend
+
+import "github.com/user/tgmux/config"
+
+func newCursor(cfg *config.Config) Backend {
+	bc := cfg.Backends.Cursor
+	cmd := bc.Command
+	if cmd == "" {
+		cmd = "cursor-agent"
+	}
+	return Backend{
+		Type:       TypeCursor,
+		Command:    cmd,
+		Args:       bc.Args,
+		LogDirFunc: nil, // 会话位于 ~/.cursor/chats 下的 SQLite，使用 capture-pane
+
+		InterruptKeys: []string{"C-c"},
+		// "自动运行全部"会切换整个会话的模式，不作为"始终允许"提供
+		ConfirmKeyMap: map[string][]string{
+			"yes": {"y"},
+			"no":  {"n"},
+		},
+	}
+}
@@ -0,0 +1,45 @@
+package backend
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/user/tgmux/config"
+)
+
+// customConfig 查找名为 t 的自定义后端配置
+func customConfig(t Type, cfg *config.Config) *config.CustomBackendConfig {
+	if _, builtin := lookup(t); builtin {
+		return nil
+	}
+	for i := range cfg.Backends.Custom {
+		if Type(cfg.Backends.Custom[i].Name) == t {
+			return &cfg.Backends.Custom[i]
+		}
+	}
+	return nil
+}
+
+func newCustom(cb config.CustomBackendConfig) Backend {
+	be := Backend{
+		Type:      Type(cb.Name),
+		Command:   cb.Command,
+		Args:      cb.Args,
+		Custom:    true,
+		LogFormat: cb.LogFormat,
+	}
+	if pattern := cb.LogDirPattern; pattern != "" {
+		be.LogDirFunc = func(projectPath string) string {
+			// 支持与 claude 相同的 {path_encoded} 占位符
+			dir := strings.ReplaceAll(pattern, "{path_encoded}", strings.ReplaceAll(projectPath, "/", "-"))
+			return expandHome(dir)
+		}
+	}
+	for _, p := range cb.ConfirmPatterns {
+		// 已在加载配置时校验
+		if re, err := regexp.Compile(p); err == nil {
+			be.ConfirmPatterns = append(be.ConfirmPatterns, re)
+		}
+	}
+	return be
+}
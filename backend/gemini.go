@@ -22,5 +22,7 @@ func newGemini(cfg *config.Config) Backend {
 			home, _ := os.UserHomeDir()
 			return filepath.Join(home, ".gemini", "tmp")
 		},
+		InterruptKeys: []string{"Escape"},
+		ConfirmKeyMap: menuConfirmKeys,
 	}
 }
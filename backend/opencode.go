@@ -0,0 +1,39 @@
+package backend
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/user/tgmux/config"
+)
+
+func newOpencode(cfg *config.Config) Backend {
+	bc := cfg.Backends.Opencode
+	cmd := bc.Command
+	if cmd == "" {
+		cmd = "opencode"
+	}
+	return Backend{
+		Type:    TypeOpencode,
+		Command: cmd,
+		Args:    bc.Args,
+		LogDirFunc: func(projectPath string) string {
+			if bc.LogDirPattern != "" && bc.LogDirPattern != "~/.local/share/opencode/storage/" {
+				return expandHome(bc.LogDirPattern)
+			}
+			// 默认: $XDG_DATA_HOME/opencode/storage/，会话按 directory 字段匹配项目
+			dataHome := os.Getenv("XDG_DATA_HOME")
+			if dataHome == "" {
+				home, _ := os.UserHomeDir()
+				dataHome = filepath.Join(home, ".local", "share")
+			}
+			return filepath.Join(dataHome, "opencode", "storage")
+		},
+		InterruptKeys: []string{"Escape"},
+		ConfirmKeyMap: map[string][]string{
+			"yes":    {"Enter"},
+			"always": {"a"},
+			"no":     {"Escape"},
+		},
+	}
+}
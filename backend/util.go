@@ -3,6 +3,7 @@ package backend
 import (
 	"os"
 	"path/filepath"
+	"regexp"
 	"strings"
 )
 
@@ -13,3 +14,23 @@ func expandHome(path string) string {
 	}
 	return path
 }
+
+// envRef 环境变量值中的 ${VAR} 引用
+var envRef = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+
+// mergeEnv 以配置的变量覆盖默认值并展开其中的 ${VAR}，其余 $ 保持原样
+func mergeEnv(defaults, configured map[string]string) map[string]string {
+	if len(defaults) == 0 && len(configured) == 0 {
+		return nil
+	}
+	env := make(map[string]string, len(defaults)+len(configured))
+	for name, value := range defaults {
+		env[name] = value
+	}
+	for name, value := range configured {
+		env[name] = envRef.ReplaceAllStringFunc(value, func(ref string) string {
+			return os.Getenv(envRef.FindStringSubmatch(ref)[1])
+		})
+	}
+	return env
+}
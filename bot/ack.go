@@ -0,0 +1,74 @@
+package bot
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+
+	"github.com/user/tgmux/monitor"
+)
+
+// 送达回执表情：已写入 tmux / 后端已开始回答。
+// ✅ 不在 Telegram 允许的 reaction 列表中，用 👌 代替
+const (
+	ackSentEmoji    = "👀"
+	ackRepliedEmoji = "👌"
+)
+
+// ackTimeout 单次 setMessageReaction 的超时
+const ackTimeout = 10 * time.Second
+
+// ackSent 输入成功写入 tmux 后标记 👀，并记录等待后端回答的消息
+func (b *Bot) ackSent(windowID string, in *queuedInput) {
+	if !b.cfg.Telegram.AckReactions || in.MsgID == 0 {
+		return
+	}
+	b.setReaction(in.ChatID, in.MsgID, ackSentEmoji)
+	b.ackMu.Lock()
+	b.acks[windowID] = append(b.acks[windowID], in)
+	b.ackMu.Unlock()
+}
+
+// ackReplied 后端产生本轮第一条文本输出时，将等待中的消息升级为 👌
+func (b *Bot) ackReplied(windowID string) {
+	b.ackMu.Lock()
+	pending := b.acks[windowID]
+	delete(b.acks, windowID)
+	b.ackMu.Unlock()
+	for _, in := range pending {
+		b.setReaction(in.ChatID, in.MsgID, ackRepliedEmoji)
+	}
+}
+
+// withAck 包装输出 handler，在文本输出时触发回执升级
+func (b *Bot) withAck(windowID string, handler monitor.OutputHandler) monitor.OutputHandler {
+	if !b.cfg.Telegram.AckReactions {
+		return handler
+	}
+	return func(key string, content monitor.ParsedContent) {
+		if content.Type == monitor.ContentText {
+			b.ackReplied(windowID)
+		}
+		handler(key, content)
+	}
+}
+
+// setReaction 设置消息 reaction，失败（如群内禁用 reaction）仅记录调试日志
+func (b *Bot) setReaction(chatID int64, msgID int, emoji string) {
+	ctx, cancel := context.WithTimeout(context.Background(), ackTimeout)
+	defer cancel()
+	_, err := b.bot.SetMessageReaction(ctx, &bot.SetMessageReactionParams{
+		ChatID:    chatID,
+		MessageID: msgID,
+		Reaction: []models.ReactionType{{
+			Type:              models.ReactionTypeTypeEmoji,
+			ReactionTypeEmoji: &models.ReactionTypeEmoji{Emoji: emoji},
+		}},
+	})
+	if err != nil {
+		slog.Debug("set reaction failed", "chat", chatID, "msg", msgID, "error", err)
+	}
+}
@@ -0,0 +1,80 @@
+package bot
+
+import (
+	"context"
+	"log/slog"
+	"slices"
+	"strings"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+
+	"github.com/user/tgmux/i18n"
+)
+
+// handleAutoConfirm /autoconfirm 命令：设置当前会话是否自动确认权限请求
+func (b *Bot) handleAutoConfirm(ctx context.Context, tgBot *bot.Bot, update *models.Update) {
+	if update.Message == nil {
+		return
+	}
+	msg := update.Message
+	key := topicKeyFromMessage(msg)
+	binding, ok := b.store.GetBinding(key)
+	if !ok {
+		b.sendReply(ctx, msg, i18n.T("session.not_bound"))
+		return
+	}
+
+	on := binding.AutoConfirm
+	switch strings.TrimSpace(strings.TrimPrefix(msg.Text, "/autoconfirm")) {
+	case "", "status":
+	case "on":
+		on = true
+	case "off":
+		on = false
+	default:
+		b.sendReply(ctx, msg, i18n.T("autoconfirm.usage"))
+		return
+	}
+	b.store.SetAutoConfirm(key, on)
+	if on {
+		b.sendReply(ctx, msg, i18n.T("autoconfirm.on", strings.Join(b.cfg.Security.AutoConfirmDeny, ", ")))
+	} else {
+		b.sendReply(ctx, msg, i18n.T("autoconfirm.off"))
+	}
+}
+
+// autoConfirm 在以下情况直接回答 yes 并返回要发送的说明，否则返回空：
+// 待确认的工具在 security.auto_approve_tools 中，或会话开启了 /autoconfirm；
+// 提示包含危险操作时一律交给用户确认
+func (b *Bot) autoConfirm(key, windowID, prompt, tool string) string {
+	binding, ok := b.store.GetBinding(key)
+	if !ok || binding.WindowID != windowID {
+		return ""
+	}
+	approved := tool != "" && slices.Contains(b.cfg.Security.AutoApproveTools, tool)
+	if !approved && !binding.AutoConfirm {
+		return ""
+	}
+	if pattern := b.deniedPattern(prompt); pattern != "" {
+		slog.Info("auto-confirm skipped, dangerous pattern", "key", key, "pattern", pattern)
+		return ""
+	}
+	b.handleConfirm(context.Background(), key, windowID, "yes")
+	slog.Info("permission prompt auto-confirmed", "key", key, "window", windowID, "tool", tool)
+	if approved {
+		return i18n.T("autoconfirm.tool", tool)
+	}
+	return i18n.T("autoconfirm.done", truncateRunes(strings.Join(strings.Fields(prompt), " "), 100))
+}
+
+// deniedPattern 返回提示中命中的危险模式（大小写不敏感），未命中为空
+func (b *Bot) deniedPattern(prompt string) string {
+	lower := strings.ToLower(prompt)
+	for _, p := range b.cfg.Security.AutoConfirmDeny {
+		if p != "" && strings.Contains(lower, strings.ToLower(p)) {
+			return p
+		}
+	}
+	return ""
+}
@@ -2,8 +2,14 @@ package bot
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
+	"net/url"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -11,8 +17,11 @@ import (
 	"github.com/go-telegram/bot"
 	"github.com/go-telegram/bot/models"
 	"github.com/user/tgmux/auth"
+	"github.com/user/tgmux/backend"
 	"github.com/user/tgmux/config"
+	"github.com/user/tgmux/i18n"
 	"github.com/user/tgmux/monitor"
+	"github.com/user/tgmux/sanitize"
 	"github.com/user/tgmux/state"
 	"github.com/user/tgmux/tmux"
 )
@@ -28,15 +37,39 @@ type Bot struct {
 	statusPoller *StatusPoller
 	states       map[string]*TopicState
 	statesMu     sync.Mutex
-	sendChans    map[string]chan string
+	sendChans    map[string]chan *queuedInput
+	queued       map[string]*queuedInput // "chatID:msgID" → 尚未发送的输入（用于编辑替换）
 	sendMu       sync.Mutex
+	renameDenied sync.Map // chatID → true，该群缺少管理话题权限，不再尝试重命名
+	worktrees    sync.Map // windowID → worktreeInfo，已关闭会话中可删除的 worktree
+	selfID       int64    // bot 自身的用户 ID（token 前缀）
+	edits        sync.Map // "chatID:msgID" → 编辑后待确认重发的文本
+	forwards     sync.Map // "chatID:msgID" → 待确认发送的转发消息
+
+	acks  map[string][]*queuedInput // windowID → 已送达、等待后端回答的消息
+	ackMu sync.Mutex
+
+	reapWarned sync.Map // topicKey → 空闲提醒发出的时间
+
+	stallTimers map[string]*time.Timer // topicKey → 无输出提醒计时器（转发输入时启动，收到输出时取消）
+	stallMu     sync.Mutex
+
+	backends map[backend.Type]bool // 已启用的后端 → 命令是否已安装（启动时探测）
+
+	// 选定目录后直接使用的后端（dirs.default_backend / dirs.dir_backends），已排除未启用的
+	defaultBackend backend.Type
+	dirBackends    map[string]backend.Type // 目录（已展开 ~）→ 后端
 }
 
 // TopicState 管理每个 topic 的交互状态
 type TopicState struct {
 	Phase       string // "idle" | "awaiting_dir" | "awaiting_path_input" | "awaiting_backend" | "bound"
 	SelectedDir string
+	PromptMsgID int // 当前 /new 流程中最近一条提示消息（/cancel 时删除）
 	UpdatedAt   time.Time
+	Broadcast   *pendingBroadcast // 等待确认的 /broadcast
+	Worktree    *worktreeInfo     // /new 流程中由 /worktree 创建的 worktree
+	ByDefault   bool              // 后端由默认设置选定，创建消息附带更换按钮
 }
 
 func New(cfg *config.Config, store *state.Store, tmuxMgr *tmux.Manager, authChecker *auth.Checker, dispatcher *monitor.Dispatcher) (*Bot, error) {
@@ -47,8 +80,13 @@ func New(cfg *config.Config, store *state.Store, tmuxMgr *tmux.Manager, authChec
 		tmux:       tmuxMgr,
 		dispatcher: dispatcher,
 		states:     make(map[string]*TopicState),
-		sendChans:  make(map[string]chan string),
+		sendChans:  make(map[string]chan *queuedInput),
+		queued:     make(map[string]*queuedInput),
+		acks:       make(map[string][]*queuedInput),
+		backends:   probeBackends(cfg),
 	}
+	b.defaultBackend, b.dirBackends = defaultBackends(cfg, b.backends)
+	registerEnvSecrets(cfg, store)
 
 	opts := []bot.Option{
 		bot.WithDefaultHandler(b.defaultHandler),
@@ -61,26 +99,135 @@ func New(cfg *config.Config, store *state.Store, tmuxMgr *tmux.Manager, authChec
 		return nil, fmt.Errorf("create bot: %w", err)
 	}
 	b.bot = tgBot
-	b.pushers = NewPusherManager(tgBot, cfg.Security.RedactSecrets)
+	if id, _, ok := strings.Cut(cfg.Telegram.Token, ":"); ok {
+		b.selfID, _ = strconv.ParseInt(id, 10, 64)
+	}
+	b.pushers = NewPusherManager(tgBot, cfg.Security.RedactSecrets, store)
+	b.pushers.autoConfirm = b.autoConfirm
+	b.pushers.capture = tmuxMgr.CapturePaneClean
+	b.pushers.promptCooldown = cfg.Monitor.PromptCooldown
+	b.pushers.showUsage = cfg.Monitor.ShowUsage
+	b.pushers.queueSize = cfg.Monitor.PushQueueSize
+	b.pushers.mergeWindow = cfg.Monitor.MergeWindow
+	b.pushers.outputInline = cfg.Monitor.ToolOutputInline
+	b.pushers.maxInline = cfg.Telegram.MaxInlineChars
+	b.pushers.SetPacing(cfg.Monitor.GroupThrottle, cfg.Monitor.PrivateThrottle)
+	b.pushers.filter = b.topicFilter
+	b.pushers.confirmPatterns = customConfirmPatterns(cfg, store)
+	b.pushers.canAlways = func(topicKey string) bool { return b.topicBackend(topicKey).CanAlways() }
 	b.statusPoller = NewStatusPoller(tgBot, tmuxMgr, b.pushers, store, cfg.Monitor.StatusPollInterval)
+	if b.statusPoller != nil {
+		b.statusPoller.capturesPane = dispatcher.CapturesPane
+	}
 
 	// 注册命令
-	b.bot.RegisterHandler(bot.HandlerTypeMessageText, "/new", bot.MatchTypeExact, b.handleNew)
-	b.bot.RegisterHandler(bot.HandlerTypeMessageText, "/session", bot.MatchTypePrefix, b.handleSession)
-	b.bot.RegisterHandler(bot.HandlerTypeMessageText, "/kill", bot.MatchTypeExact, b.handleKill)
-	b.bot.RegisterHandler(bot.HandlerTypeMessageText, "/esc", bot.MatchTypeExact, b.handleEsc)
-	b.bot.RegisterHandler(bot.HandlerTypeMessageText, "/enter", bot.MatchTypeExact, b.handleEnter)
-	b.bot.RegisterHandler(bot.HandlerTypeMessageText, "/screenshot", bot.MatchTypeExact, b.handleScreenshot)
-	b.bot.RegisterHandler(bot.HandlerTypeMessageText, "/cmd", bot.MatchTypePrefix, b.handleCmd)
-	b.bot.RegisterHandler(bot.HandlerTypeMessageText, "/dir", bot.MatchTypePrefix, b.handleDir)
+	b.registerCommands()
 
 	return b, nil
 }
 
+// probeBackends 探测已启用后端的命令是否已安装
+func probeBackends(cfg *config.Config) map[backend.Type]bool {
+	backends := make(map[backend.Type]bool)
+	for _, cb := range cfg.Backends.Custom {
+		if slices.Contains(backend.AllTypes(), backend.Type(cb.Name)) {
+			slog.Warn("custom backend has the name of a built-in backend, ignored", "name", cb.Name)
+		}
+	}
+	for _, t := range backend.Types(cfg) {
+		if !backend.IsEnabled(t, cfg) {
+			continue
+		}
+		be := backend.Get(t, cfg)
+		backends[t] = be.Installed()
+		if !backends[t] {
+			slog.Warn("backend command not found", "backend", t, "command", be.Command)
+		}
+	}
+	return backends
+}
+
+// defaultBackends 读取配置的默认后端，未启用的后端忽略并警告
+func defaultBackends(cfg *config.Config, enabled map[backend.Type]bool) (backend.Type, map[string]backend.Type) {
+	valid := func(name, where string) bool {
+		if _, ok := enabled[backend.Type(name)]; ok {
+			return true
+		}
+		slog.Warn("default backend is not an enabled backend, ignored", "setting", where, "backend", name)
+		return false
+	}
+	var def backend.Type
+	if name := cfg.Dirs.DefaultBackend; name != "" && valid(name, "dirs.default_backend") {
+		def = backend.Type(name)
+	}
+	perDir := make(map[string]backend.Type)
+	for dir, name := range cfg.Dirs.DirBackends {
+		if valid(name, "dirs.dir_backends."+dir) {
+			perDir[filepath.Clean(expandHome(dir))] = backend.Type(name)
+		}
+	}
+	return def, perDir
+}
+
+// secretEnvName 变量名像是存放凭据的
+var secretEnvName = regexp.MustCompile(`(?i)(key|token|secret|passw|auth|credential)`)
+
+// registerEnvSecrets 将后端配置中的凭据（按变量名判断，或带用户名密码的 URL，如代理地址）
+// 以及 /env 保存的全部值登记为需遮蔽的值，导出命令会出现在终端里，不能随终端输出推送到 Telegram
+func registerEnvSecrets(cfg *config.Config, store *state.Store) {
+	for _, t := range backend.Types(cfg) {
+		for name, value := range backend.Get(t, cfg).Env {
+			if u, err := url.Parse(value); err == nil && u.User != nil {
+				sanitize.AddSecret(value)
+			} else if secretEnvName.MatchString(name) {
+				sanitize.AddSecret(value)
+			}
+		}
+	}
+	for _, env := range store.AllEnv() {
+		for _, value := range env {
+			sanitize.AddSecret(value)
+		}
+	}
+}
+
+// customConfirmPatterns 返回按 topic 查询其自定义后端额外确认提示正则的函数（正则在启动时编译一次）
+func customConfirmPatterns(cfg *config.Config, store *state.Store) func(topicKey string) []*regexp.Regexp {
+	patterns := make(map[string][]*regexp.Regexp)
+	for _, t := range backend.Types(cfg) {
+		if ps := backend.Get(t, cfg).ConfirmPatterns; len(ps) > 0 {
+			patterns[string(t)] = ps
+		}
+	}
+	return func(topicKey string) []*regexp.Regexp {
+		binding, ok := store.GetBinding(topicKey)
+		if !ok {
+			return nil
+		}
+		return patterns[binding.Backend]
+	}
+}
+
+// backendUnavailable 返回后端不可用的原因，可用时返回空
+func (b *Bot) backendUnavailable(t backend.Type) string {
+	installed, enabled := b.backends[t]
+	switch {
+	case !enabled:
+		return i18n.T("backend.disabled", t)
+	case !installed:
+		return i18n.T("backend.not_installed", t)
+	}
+	return ""
+}
+
 // Start 启动 bot polling 并恢复已有绑定的监控
 func (b *Bot) Start(ctx context.Context) {
 	b.recoverBindings(ctx)
+	b.publishCommands(ctx)
 	b.statusPoller.Start(ctx)
+	go b.runScheduler(ctx)
+	go b.runReaper(ctx)
+	go b.dispatcher.RunWatchdog(ctx)
 	slog.Info("bot starting polling")
 	b.bot.Start(ctx)
 }
@@ -94,20 +241,21 @@ func (b *Bot) recoverBindings(ctx context.Context) {
 
 	slog.Info("recovering bindings", "count", len(bindings))
 	for key, binding := range bindings {
-		if !b.tmux.IsWindowAlive(binding.WindowID) {
+		if !b.windowAlive(binding) {
 			slog.Info("window dead during recovery, marking disconnected", "key", key, "window", binding.WindowID)
 			binding.Status = "disconnected"
 			b.store.SetBinding(key, binding)
 			continue
 		}
 
-		if !b.tmux.IsBackendAlive(binding.WindowID) {
+		if !b.backendAlive(binding) {
 			slog.Info("backend exited during recovery, removing binding", "key", key, "window", binding.WindowID)
 			b.store.DeleteBinding(key)
 			b.store.DeleteOffset(key)
 			continue
 		}
 
+		b.restorePane(key, binding)
 		b.getOrCreateSendChan(binding.WindowID)
 
 		chatID, threadID, isPrivate := parseTopicKey(key)
@@ -115,7 +263,7 @@ func (b *Bot) recoverBindings(ctx context.Context) {
 			continue
 		}
 
-		handler := b.pushers.OutputHandler(ctx, key, chatID, threadID, isPrivate, binding.WindowID)
+		handler := b.outputHandler(ctx, key, chatID, threadID, isPrivate, binding.WindowID)
 		b.dispatcher.StartMonitor(ctx, key, binding, handler)
 
 		b.setPhase(key, "bound")
@@ -126,10 +274,20 @@ func (b *Bot) recoverBindings(ctx context.Context) {
 // StartMonitorForBinding 为新创建/绑定的会话启动监控
 func (b *Bot) StartMonitorForBinding(ctx context.Context, key string, binding state.Binding, chatID int64, threadID int) {
 	isPrivate := strings.HasPrefix(key, "dm:")
-	handler := b.pushers.OutputHandler(ctx, key, chatID, threadID, isPrivate, binding.WindowID)
+	handler := b.outputHandler(ctx, key, chatID, threadID, isPrivate, binding.WindowID)
 	b.dispatcher.StartMonitor(ctx, key, binding, handler)
 }
 
+// outputHandler 组装监控输出 handler：推送、送达回执与活动时间记录
+func (b *Bot) outputHandler(ctx context.Context, key string, chatID int64, threadID int, isPrivate bool, windowID string) monitor.OutputHandler {
+	handler := b.withAck(windowID, b.pushers.OutputHandler(ctx, key, chatID, threadID, isPrivate, windowID))
+	return func(k string, content monitor.ParsedContent) {
+		b.store.TouchBinding(key)
+		b.disarmStall(key)
+		handler(k, content)
+	}
+}
+
 // Dispatcher returns the dispatcher for external access
 func (b *Bot) Dispatcher() *monitor.Dispatcher {
 	return b.dispatcher
@@ -146,6 +304,8 @@ func (b *Bot) authMiddleware(next bot.HandlerFunc) bot.HandlerFunc {
 		var userID int64
 		if update.Message != nil {
 			userID = update.Message.From.ID
+		} else if update.EditedMessage != nil && update.EditedMessage.From != nil {
+			userID = update.EditedMessage.From.ID
 		} else if update.CallbackQuery != nil {
 			userID = update.CallbackQuery.From.ID
 		}
@@ -206,6 +366,59 @@ func parseTopicKey(key string) (chatID int64, threadID int, isPrivate bool) {
 	return 0, 0, false
 }
 
+// syncTopicName 将论坛 Topic 重命名为会话名（需开启 telegram.sync_topic_names）
+func (b *Bot) syncTopicName(ctx context.Context, key, name string) {
+	if !b.cfg.Telegram.SyncTopicNames || !strings.HasPrefix(key, "topic:") || name == "" {
+		return
+	}
+	chatID, threadID, _ := parseTopicKey(key)
+	if _, denied := b.renameDenied.Load(chatID); denied {
+		return
+	}
+	_, err := b.bot.EditForumTopic(ctx, &bot.EditForumTopicParams{
+		ChatID:          chatID,
+		MessageThreadID: threadID,
+		Name:            truncateRunes(name, maxTopicNameLen),
+	})
+	if err == nil {
+		return
+	}
+	if errors.Is(err, bot.ErrorForbidden) || strings.Contains(err.Error(), "not enough rights") {
+		// 缺少权限只记录一次，之后跳过该群
+		b.renameDenied.Store(chatID, true)
+		slog.Warn("no rights to rename forum topics, disabling topic name sync for chat", "chat", chatID, "error", err)
+		return
+	}
+	if !strings.Contains(err.Error(), "TOPIC_NOT_MODIFIED") {
+		slog.Warn("rename forum topic failed", "key", key, "error", err)
+	}
+}
+
+// createSessionTopic 在论坛 General 中新建会话时为其单独创建 Topic（需开启 telegram.auto_topics），
+// 并在 General 中发送跳转链接；返回新 Topic 的 key 与 threadID，失败时 ok 为 false
+func (b *Bot) createSessionTopic(ctx context.Context, key, name string) (topicKey string, threadID int, ok bool) {
+	if !b.cfg.Telegram.AutoTopics || !strings.HasPrefix(key, "general:") {
+		return "", 0, false
+	}
+	chatID, _, _ := parseTopicKey(key)
+	topic, err := b.bot.CreateForumTopic(ctx, &bot.CreateForumTopicParams{
+		ChatID: chatID,
+		Name:   truncateRunes(name, maxTopicNameLen),
+	})
+	if err != nil {
+		// 非论坛群或缺少管理话题权限：沿用 General
+		slog.Warn("create forum topic failed, keeping session in general", "chat", chatID, "error", err)
+		return "", 0, false
+	}
+
+	topicKey = fmt.Sprintf("topic:%d:%d", chatID, topic.MessageThreadID)
+	link := fmt.Sprintf("https://t.me/c/%s/%d", strings.TrimPrefix(strconv.FormatInt(chatID, 10), "-100"), topic.MessageThreadID)
+	kb := models.InlineKeyboardMarkup{InlineKeyboard: [][]models.InlineKeyboardButton{{{Text: i18n.T("topic.open"), URL: link}}}}
+	b.sendMsg(ctx, chatID, 0, i18n.T("topic.created", topic.Name), &kb)
+	slog.Info("forum topic created for session", "chat", chatID, "thread", topic.MessageThreadID)
+	return topicKey, topic.MessageThreadID, true
+}
+
 // unbind 清理绑定及相关资源
 func (b *Bot) unbind(key string, binding state.Binding) {
 	b.store.DeleteBinding(key)
@@ -214,9 +427,29 @@ func (b *Bot) unbind(key string, binding state.Binding) {
 	b.dispatcher.StopMonitor(key)
 	b.pushers.StopPusher(key)
 	b.statusPoller.RemoveStatus(key)
+	b.reapWarned.Delete(key)
+	b.store.ClearPause(key)
+	b.store.ClearMute(key)
 	b.setPhase(key, "idle")
 }
 
+// unbindKeepSettings 解绑后立即在同一话题重建会话时使用，保留 /filter、/pushmode、/env 设置
+func (b *Bot) unbindKeepSettings(key string, binding state.Binding) {
+	filter, hasFilter := b.store.GetFilter(key)
+	mode := b.store.GetPushMode(key)
+	env := b.store.GetEnv(key)
+	b.unbind(key, binding)
+	if hasFilter {
+		b.store.SetFilter(key, filter)
+	}
+	if mode != "" {
+		b.store.SetPushMode(key, mode)
+	}
+	for name, value := range env {
+		b.store.SetEnv(key, name, value)
+	}
+}
+
 func (b *Bot) getOrCreateState(key string) *TopicState {
 	b.statesMu.Lock()
 	defer b.statesMu.Unlock()
@@ -244,22 +477,138 @@ func (b *Bot) setPhase(key string, phase string) {
 	s.UpdatedAt = time.Now()
 }
 
-func (b *Bot) getOrCreateSendChan(windowID string) chan string {
+// setPromptMsg 记录 /new 流程中最近发出的提示消息
+func (b *Bot) setPromptMsg(key string, msgID int) {
+	if msgID == 0 {
+		return
+	}
+	b.statesMu.Lock()
+	defer b.statesMu.Unlock()
+	if s, ok := b.states[key]; ok {
+		s.PromptMsgID = msgID
+	}
+}
+
+// resetFlow 中止 /new 流程，回到 idle，返回待清理的提示消息 ID 及中止前的状态
+func (b *Bot) resetFlow(key string) (promptMsgID int, prevPhase string) {
+	b.statesMu.Lock()
+	defer b.statesMu.Unlock()
+	s, ok := b.states[key]
+	if !ok {
+		return 0, "idle"
+	}
+	promptMsgID, prevPhase = s.PromptMsgID, s.Phase
+	s.Phase = "idle"
+	s.SelectedDir = ""
+	s.Worktree = nil
+	s.PromptMsgID = 0
+	s.UpdatedAt = time.Now()
+	return promptMsgID, prevPhase
+}
+
+// queuedInput 输入队列中的一条消息，MsgID 非 0 时发送前可被编辑后的内容替换
+type queuedInput struct {
+	ChatID int64
+	MsgID  int
+	Text   string
+}
+
+func queuedKey(chatID int64, msgID int) string {
+	return fmt.Sprintf("%d:%d", chatID, msgID)
+}
+
+func (b *Bot) getOrCreateSendChan(windowID string) chan *queuedInput {
 	b.sendMu.Lock()
 	defer b.sendMu.Unlock()
 	ch, ok := b.sendChans[windowID]
 	if !ok {
-		ch = make(chan string, 100)
+		ch = make(chan *queuedInput, 100)
 		b.sendChans[windowID] = ch
 		go b.sendLoop(windowID, ch)
 	}
 	return ch
 }
 
-func (b *Bot) sendLoop(windowID string, ch chan string) {
-	for text := range ch {
-		if err := b.tmux.SendText(windowID, text); err != nil {
+// enqueueInput 将用户消息放入窗口输入队列，并记录消息 ID 以便编辑时替换
+func (b *Bot) enqueueInput(windowID string, msg *models.Message, text string) {
+	in := &queuedInput{ChatID: msg.Chat.ID, MsgID: msg.ID, Text: text}
+	key := topicKeyFromMessage(msg)
+	b.store.TouchBinding(key)
+	b.armStall(key, windowID)
+	ch := b.getOrCreateSendChan(windowID)
+	b.sendMu.Lock()
+	b.queued[queuedKey(in.ChatID, in.MsgID)] = in
+	b.sendMu.Unlock()
+	ch <- in
+}
+
+// replaceQueued 若消息仍在输入队列中则替换其内容，返回是否替换成功
+func (b *Bot) replaceQueued(chatID int64, msgID int, text string) bool {
+	b.sendMu.Lock()
+	defer b.sendMu.Unlock()
+	in, ok := b.queued[queuedKey(chatID, msgID)]
+	if ok {
+		in.Text = text
+	}
+	return ok
+}
+
+// dequeued 消息离开队列后不再可替换，返回最终内容
+func (b *Bot) dequeued(in *queuedInput) string {
+	b.sendMu.Lock()
+	defer b.sendMu.Unlock()
+	if in.MsgID != 0 {
+		delete(b.queued, queuedKey(in.ChatID, in.MsgID))
+	}
+	return in.Text
+}
+
+func (b *Bot) sendLoop(windowID string, ch chan *queuedInput) {
+	for in := range ch {
+		text := b.dequeued(in)
+		var err error
+		if hm := b.dispatcher.Headless(windowID); hm != nil {
+			err = hm.Send(text)
+		} else {
+			err = b.tmux.SendText(windowID, text)
+		}
+		if err != nil {
 			slog.Error("send to tmux failed", "window", windowID, "error", err)
+			continue
+		}
+		b.dispatcher.NotifyInput(windowID)
+		b.ackSent(windowID, in)
+	}
+}
+
+// sendQueueLen 返回窗口输入队列中等待发送的消息数
+func (b *Bot) sendQueueLen(windowID string) int {
+	b.sendMu.Lock()
+	defer b.sendMu.Unlock()
+	if ch, ok := b.sendChans[windowID]; ok {
+		return len(ch)
+	}
+	return 0
+}
+
+// drainSendChan 丢弃窗口输入队列中尚未发送的消息，返回丢弃条数
+func (b *Bot) drainSendChan(windowID string) int {
+	b.sendMu.Lock()
+	defer b.sendMu.Unlock()
+	ch, ok := b.sendChans[windowID]
+	if !ok {
+		return 0
+	}
+	n := 0
+	for {
+		select {
+		case in := <-ch:
+			if in.MsgID != 0 {
+				delete(b.queued, queuedKey(in.ChatID, in.MsgID))
+			}
+			n++
+		default:
+			return n
 		}
 	}
 }
@@ -276,7 +625,7 @@ func (b *Bot) closeSendChan(windowID string) {
 // DrainSendChans 优雅关闭所有发送 channel
 func (b *Bot) DrainSendChans() {
 	b.sendMu.Lock()
-	chans := make(map[string]chan string, len(b.sendChans))
+	chans := make(map[string]chan *queuedInput, len(b.sendChans))
 	for k, v := range b.sendChans {
 		chans[k] = v
 	}
@@ -294,10 +643,24 @@ func (b *Bot) sendReply(ctx context.Context, msg *models.Message, text string) {
 	if msg.MessageThreadID != 0 {
 		params.MessageThreadID = msg.MessageThreadID
 	}
-	b.bot.SendMessage(ctx, params)
+	b.pushers.sendAndRecord(ctx, params)
+}
+
+// sendReplyHTML 以 HTML 格式发送回复（调用方负责转义）
+func (b *Bot) sendReplyHTML(ctx context.Context, msg *models.Message, text string) {
+	params := &bot.SendMessageParams{
+		ChatID:    msg.Chat.ID,
+		Text:      text,
+		ParseMode: models.ParseModeHTML,
+	}
+	if msg.MessageThreadID != 0 {
+		params.MessageThreadID = msg.MessageThreadID
+	}
+	b.pushers.sendAndRecord(ctx, params)
 }
 
-func (b *Bot) sendReplyWithKeyboard(ctx context.Context, msg *models.Message, text string, kb models.InlineKeyboardMarkup) {
+// sendReplyWithKeyboard 发送带键盘的回复，返回消息 ID（失败为 0）
+func (b *Bot) sendReplyWithKeyboard(ctx context.Context, msg *models.Message, text string, kb models.InlineKeyboardMarkup) int {
 	params := &bot.SendMessageParams{
 		ChatID:      msg.Chat.ID,
 		Text:        text,
@@ -306,5 +669,5 @@ func (b *Bot) sendReplyWithKeyboard(ctx context.Context, msg *models.Message, te
 	if msg.MessageThreadID != 0 {
 		params.MessageThreadID = msg.MessageThreadID
 	}
-	b.bot.SendMessage(ctx, params)
+	return b.pushers.sendAndRecord(ctx, params)
 }
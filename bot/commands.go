@@ -0,0 +1,180 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+
+	"github.com/user/tgmux/i18n"
+)
+
+// Command 命令定义，注册 handler、setMyCommands 与 /help 共用同一张表
+type Command struct {
+	Name         string // 不含 "/"
+	Args         string // 参数说明，如 "[N]"
+	Description  string
+	NeedsBinding bool // 是否需要当前 Topic 已绑定会话
+	TakesArgs    bool // 是否接受参数（命令词后跟空白）
+	Handler      bot.HandlerFunc
+}
+
+// commands 返回所有命令（按 /help 展示顺序）
+func (b *Bot) commands() []Command {
+	return []Command{
+		{Name: "new", Description: i18n.T("cmd.new"), Handler: b.handleNew},
+		{Name: "cancel", Description: i18n.T("cmd.cancel"), Handler: b.handleCancel},
+		{Name: "worktree", Args: i18n.T("args.branch"), Description: i18n.T("cmd.worktree"), TakesArgs: true, Handler: b.handleWorktree},
+		{Name: "session", Args: "[list]", Description: i18n.T("cmd.session"), TakesArgs: true, Handler: b.handleSession},
+		{Name: "dir", Args: i18n.T("args.dir"), Description: i18n.T("cmd.dir"), TakesArgs: true, Handler: b.handleDir},
+		{Name: "ping", Description: i18n.T("cmd.ping"), Handler: b.handlePing},
+		{Name: "clear", Args: "[N]", Description: i18n.T("cmd.clear"), TakesArgs: true, Handler: b.handleClear},
+		{Name: "env", Args: "set|unset|list", Description: i18n.T("cmd.env"), TakesArgs: true, Handler: b.handleEnv},
+		{Name: "broadcast", Args: i18n.T("args.broadcast"), Description: i18n.T("cmd.broadcast"), TakesArgs: true, Handler: b.handleBroadcast},
+		{Name: "help", Description: i18n.T("cmd.help"), Handler: b.handleHelp},
+		{Name: "clone", Description: i18n.T("cmd.clone"), NeedsBinding: true, Handler: b.handleClone},
+		{Name: "kill", Args: "[now]", Description: i18n.T("cmd.kill"), NeedsBinding: true, TakesArgs: true, Handler: b.handleKill},
+		{Name: "archive", Description: i18n.T("cmd.archive"), NeedsBinding: true, Handler: b.handleArchive},
+		{Name: "interrupt", Description: i18n.T("cmd.interrupt"), NeedsBinding: true, Handler: b.handleInterrupt},
+		{Name: "esc", Description: i18n.T("cmd.esc"), NeedsBinding: true, Handler: b.handleEsc},
+		{Name: "enter", Description: i18n.T("cmd.enter"), NeedsBinding: true, Handler: b.handleEnter},
+		{Name: "key", Args: i18n.T("args.keys"), Description: i18n.T("cmd.key"), NeedsBinding: true, TakesArgs: true, Handler: b.handleKey},
+		{Name: "ctrl", Args: i18n.T("args.letter"), Description: i18n.T("cmd.ctrl"), NeedsBinding: true, TakesArgs: true, Handler: b.handleCtrl},
+		{Name: "screenshot", Description: i18n.T("cmd.screenshot"), NeedsBinding: true, Handler: b.handleScreenshot},
+		{Name: "panes", Description: i18n.T("cmd.panes"), NeedsBinding: true, Handler: b.handlePanes},
+		{Name: "cmd", Args: i18n.T("args.command"), Description: i18n.T("cmd.cmd"), NeedsBinding: true, TakesArgs: true, Handler: b.handleCmd},
+		{Name: "logs", Args: "[N]", Description: i18n.T("cmd.logs"), NeedsBinding: true, TakesArgs: true, Handler: b.handleLogs},
+		{Name: "transcript", Args: "[md|txt|json] [thinking]", Description: i18n.T("cmd.transcript"), NeedsBinding: true, TakesArgs: true, Handler: b.handleTranscript},
+		{Name: "queue", Args: "[clear]", Description: i18n.T("cmd.queue"), NeedsBinding: true, TakesArgs: true, Handler: b.handleQueue},
+		{Name: "git", Args: "status|log [N]|branch", Description: i18n.T("cmd.git"), NeedsBinding: true, TakesArgs: true, Handler: b.handleGit},
+		{Name: "top", Description: i18n.T("cmd.top"), NeedsBinding: true, Handler: b.handleTop},
+		{Name: "search", Args: i18n.T("args.query"), Description: i18n.T("cmd.search"), NeedsBinding: true, TakesArgs: true, Handler: b.handleSearch},
+		{Name: "schedule", Args: i18n.T("args.schedule"), Description: i18n.T("cmd.schedule"), NeedsBinding: true, TakesArgs: true, Handler: b.handleSchedule},
+		{Name: "stats", Description: i18n.T("cmd.stats"), NeedsBinding: true, Handler: b.handleStats},
+		{Name: "pause", Description: i18n.T("cmd.pause"), NeedsBinding: true, Handler: b.handlePause},
+		{Name: "resume", Description: i18n.T("cmd.resume"), NeedsBinding: true, Handler: b.handleResume},
+		{Name: "mute", Args: i18n.T("args.duration"), Description: i18n.T("cmd.mute"), NeedsBinding: true, TakesArgs: true, Handler: b.handleMute},
+		{Name: "unmute", Description: i18n.T("cmd.unmute"), NeedsBinding: true, Handler: b.handleUnmute},
+		{Name: "filter", Args: "thinking|tools|results on|off", Description: i18n.T("cmd.filter"), NeedsBinding: true, TakesArgs: true, Handler: b.handleFilter},
+		{Name: "pushmode", Args: "[stream|messages]", Description: i18n.T("cmd.pushmode"), NeedsBinding: true, TakesArgs: true, Handler: b.handlePushMode},
+		{Name: "autoconfirm", Args: "on|off|status", Description: i18n.T("cmd.autoconfirm"), NeedsBinding: true, TakesArgs: true, Handler: b.handleAutoConfirm},
+		{Name: "keyboard", Args: "[on|off]", Description: i18n.T("cmd.keyboard"), TakesArgs: true, Handler: b.handleKeyboard},
+		{Name: "keepalive", Args: "[on|off]", Description: i18n.T("cmd.keepalive"), NeedsBinding: true, TakesArgs: true, Handler: b.handleKeepAlive},
+	}
+}
+
+// registerCommands 按命令表注册 handler
+func (b *Bot) registerCommands() {
+	for _, c := range b.commands() {
+		name, takesArgs := c.Name, c.TakesArgs
+		b.bot.RegisterHandlerMatchFunc(func(update *models.Update) bool {
+			return update.Message != nil && matchCommand(update.Message.Text, name, takesArgs)
+		}, c.Handler)
+	}
+}
+
+// matchCommand 消息是否为该命令：按完整命令词匹配，/keyboard 不会被当作 /key 的参数
+func matchCommand(text, name string, takesArgs bool) bool {
+	rest, ok := strings.CutPrefix(text, "/"+name)
+	if !ok {
+		return false
+	}
+	if rest == "" {
+		return true
+	}
+	r, _ := utf8.DecodeRuneInString(rest)
+	return takesArgs && unicode.IsSpace(r)
+}
+
+// publishCommands 通过 setMyCommands 注册命令列表，供客户端自动补全：
+// 作用于允许用户的私聊及所有群聊
+func (b *Bot) publishCommands(ctx context.Context) {
+	cmds := b.commands()
+	list := make([]models.BotCommand, 0, len(cmds))
+	for _, c := range cmds {
+		list = append(list, models.BotCommand{Command: c.Name, Description: c.Description})
+	}
+
+	scopes := []models.BotCommandScope{&models.BotCommandScopeAllGroupChats{}}
+	for _, id := range b.cfg.Telegram.AllowedUsers {
+		scopes = append(scopes, &models.BotCommandScopeChat{ChatID: id})
+	}
+	for _, scope := range scopes {
+		if _, err := b.bot.SetMyCommands(ctx, &bot.SetMyCommandsParams{Commands: list, Scope: scope}); err != nil {
+			slog.Warn("set my commands failed", "scope", fmt.Sprintf("%T", scope), "error", err)
+		}
+	}
+	slog.Info("bot commands published", "count", len(list), "scopes", len(scopes))
+}
+
+// phaseHints 各状态下的下一步操作提示（消息目录标识符）
+var phaseHints = map[string]string{
+	"idle":                "hint.idle",
+	"awaiting_dir":        "hint.awaiting_dir",
+	"awaiting_path_input": "hint.awaiting_path_input",
+	"awaiting_backend":    "hint.awaiting_backend",
+	"bound":               "hint.bound",
+}
+
+// handleHelp /help 命令：按当前 Topic 状态生成命令列表
+func (b *Bot) handleHelp(ctx context.Context, tgBot *bot.Bot, update *models.Update) {
+	if update.Message == nil {
+		return
+	}
+	msg := update.Message
+	key := topicKeyFromMessage(msg)
+	phase := b.getOrCreateState(key).Phase
+	binding, bound := b.store.GetBinding(key)
+
+	var lines []string
+	if bound {
+		lines = append(lines, i18n.T("help.bound", binding.DisplayName))
+		phase = "bound"
+	} else {
+		lines = append(lines, i18n.T("help.unbound"))
+	}
+	lines = append(lines, i18n.T("help.phase", phase))
+	if hint, ok := phaseHints[phase]; ok {
+		lines = append(lines, i18n.T("help.next", i18n.T(hint)))
+	}
+
+	var general, session []string
+	for _, c := range b.commands() {
+		line := "/" + c.Name
+		if c.Args != "" {
+			line += " " + c.Args
+		}
+		line += " — " + c.Description
+		if c.NeedsBinding {
+			if !bound {
+				line = "🔒 " + line
+			}
+			session = append(session, line)
+		} else {
+			general = append(general, line)
+		}
+	}
+
+	// 已绑定时会话命令放在前面，未绑定时通用命令放在前面
+	sessionTitle := i18n.T("help.session_commands")
+	if !bound {
+		sessionTitle = i18n.T("help.session_commands_locked")
+	}
+	if bound {
+		lines = append(lines, sessionTitle)
+		lines = append(lines, session...)
+		lines = append(lines, i18n.T("help.general_commands"))
+		lines = append(lines, general...)
+	} else {
+		lines = append(lines, i18n.T("help.general_commands"))
+		lines = append(lines, general...)
+		lines = append(lines, sessionTitle)
+		lines = append(lines, session...)
+	}
+	b.sendReply(ctx, msg, strings.Join(lines, "\n"))
+}
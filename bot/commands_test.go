@@ -0,0 +1,55 @@
+package bot
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMatchCommand(t *testing.T) {
+	tests := []struct {
+		text      string
+		name      string
+		takesArgs bool
+		want      bool
+	}{
+		{"/key", "key", true, true},
+		{"/key Enter", "key", true, true},
+		{"/key\nEnter", "key", true, true},
+		{"/keyboard off", "key", true, false},
+		{"/keyboard", "key", true, false},
+		{"/keyboard off", "keyboard", true, true},
+		{"/ping", "ping", false, true},
+		{"/ping now", "ping", false, false},
+		{"/pingx", "ping", false, false},
+		{"key", "key", true, false},
+	}
+	for _, tt := range tests {
+		if got := matchCommand(tt.text, tt.name, tt.takesArgs); got != tt.want {
+			t.Errorf("matchCommand(%q, %q, %v) = %v, want %v", tt.text, tt.name, tt.takesArgs, got, tt.want)
+		}
+	}
+}
+
+// TestCommandPrefixes 命令名互为前缀时（如 key / keyboard），较短的命令不能接收较长命令的消息
+func TestCommandPrefixes(t *testing.T) {
+	cmds := (&Bot{}).commands()
+	seen := make(map[string]bool)
+	for _, c := range cmds {
+		if seen[c.Name] {
+			t.Errorf("duplicate command /%s", c.Name)
+		}
+		seen[c.Name] = true
+	}
+	for _, c := range cmds {
+		for _, other := range cmds {
+			if other.Name == c.Name || !strings.HasPrefix(other.Name, c.Name) {
+				continue
+			}
+			for _, text := range []string{"/" + other.Name, "/" + other.Name + " on"} {
+				if matchCommand(text, c.Name, c.TakesArgs) {
+					t.Errorf("%q is routed to /%s", text, c.Name)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,107 @@
+package bot
+
+import (
+	"context"
+	"strings"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+
+	"github.com/user/tgmux/i18n"
+	"github.com/user/tgmux/monitor"
+	"github.com/user/tgmux/state"
+)
+
+// filterAllows 过滤设置是否推送该类型的内容；回答、提示等其他类型总是推送
+func filterAllows(f state.Filter, t monitor.ContentType) bool {
+	switch t {
+	case monitor.ContentThinking:
+		return f.Thinking
+	case monitor.ContentToolUse, monitor.ContentTodo:
+		return f.ToolCalls
+	case monitor.ContentToolResult, monitor.ContentImage:
+		return f.ToolResults
+	}
+	return true
+}
+
+// topicFilter 返回 topic 的输出过滤设置，未设置时使用 monitor.default_filters
+func (b *Bot) topicFilter(key string) state.Filter {
+	if f, ok := b.store.GetFilter(key); ok {
+		return f
+	}
+	d := b.cfg.Monitor.DefaultFilters
+	return state.Filter{Thinking: d.Thinking, ToolCalls: d.ToolCalls, ToolResults: d.ToolResults}
+}
+
+// filterSummary 过滤设置的一行说明
+func filterSummary(f state.Filter) string {
+	label := func(on bool) string {
+		if on {
+			return i18n.T("filter.shown")
+		}
+		return i18n.T("filter.hidden")
+	}
+	return i18n.T("filter.status", label(f.Thinking), label(f.ToolCalls), label(f.ToolResults))
+}
+
+// handleFilter /filter 命令：按 Topic 设置推送哪些内容。
+// /filter [status] 查看；/filter thinking|tools|results on|off 修改
+func (b *Bot) handleFilter(ctx context.Context, tgBot *bot.Bot, update *models.Update) {
+	if update.Message == nil {
+		return
+	}
+	msg := update.Message
+	key := topicKeyFromMessage(msg)
+	f := b.topicFilter(key)
+
+	args := strings.Fields(strings.TrimPrefix(msg.Text, "/filter"))
+	if len(args) == 0 || (len(args) == 1 && args[0] == "status") {
+		b.sendReply(ctx, msg, filterSummary(f))
+		return
+	}
+	if len(args) != 2 || (args[1] != "on" && args[1] != "off") {
+		b.sendReply(ctx, msg, i18n.T("filter.usage"))
+		return
+	}
+	on := args[1] == "on"
+	switch args[0] {
+	case "thinking":
+		f.Thinking = on
+	case "tools":
+		f.ToolCalls = on
+	case "results":
+		f.ToolResults = on
+	default:
+		b.sendReply(ctx, msg, i18n.T("filter.usage"))
+		return
+	}
+	b.store.SetFilter(key, f)
+	b.sendReply(ctx, msg, filterSummary(f))
+}
+
+// handlePushMode /pushmode 命令：按 Topic 设置推送方式。
+// /pushmode 查看；/pushmode stream 回答与思考追加编辑到同一条消息；/pushmode messages 每段一条（默认）
+func (b *Bot) handlePushMode(ctx context.Context, tgBot *bot.Bot, update *models.Update) {
+	if update.Message == nil {
+		return
+	}
+	msg := update.Message
+	key := topicKeyFromMessage(msg)
+
+	switch strings.TrimSpace(strings.TrimPrefix(msg.Text, "/pushmode")) {
+	case "":
+	case "stream":
+		b.store.SetPushMode(key, state.PushModeStream)
+	case "messages":
+		b.store.SetPushMode(key, "")
+	default:
+		b.sendReply(ctx, msg, i18n.T("pushmode.usage"))
+		return
+	}
+	if b.store.GetPushMode(key) == state.PushModeStream {
+		b.sendReply(ctx, msg, i18n.T("pushmode.stream"))
+	} else {
+		b.sendReply(ctx, msg, i18n.T("pushmode.messages"))
+	}
+}
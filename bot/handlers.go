@@ -3,21 +3,68 @@ package bot
 import (
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
+	"html"
+	"io"
 	"log/slog"
 	"os"
+	"os/exec"
 	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
 	"strings"
 	"time"
+	"unicode/utf8"
 
 	"github.com/go-telegram/bot"
 	"github.com/go-telegram/bot/models"
 	"github.com/user/tgmux/backend"
+	"github.com/user/tgmux/i18n"
+	"github.com/user/tgmux/monitor"
+	"github.com/user/tgmux/sanitize"
 	"github.com/user/tgmux/state"
+	"github.com/user/tgmux/tmux"
 )
 
+const (
+	defaultLogsCount = 5    // /logs 默认条数
+	maxLogsCount     = 50   // /logs 最大条数
+	maxLogsPaneUnits = 4000 // /logs 截取终端内容的长度（转义后的 UTF-16 码元）
+
+	defaultClearCount = 50 // /clear 默认条数
+
+	maxSearchHits = 10 // /search 最多返回条数
+
+	maxResumeSessions = 10 // /resume 列出的历史会话数
+
+	defaultGitLogCount = 10              // /git log 默认条数
+	maxGitLogCount     = 50              // /git log 最大条数
+	maxGitOutput       = 3500            // /git 输出最大字符数
+	gitTimeout         = 5 * time.Second // git 命令超时
+
+	worktreeTimeout = 30 * time.Second // git worktree add/remove 超时
+
+	maxTopProcesses = 20 // /top 最多显示的进程数
+
+	broadcastConfirmTTL = 5 * time.Minute // /broadcast 确认有效期
+
+	maxTopicNameLen = 128 // Telegram 论坛 Topic 名称长度上限
+
+	maxReplyQuoteLen = 300 // 回复 bot 消息时引用的最大字符数
+)
+
+// envNamePattern 合法的环境变量名
+var envNamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
 // defaultHandler 处理非命令的文本消息（也接收未匹配的 /命令，会自动转发到 tmux）
 func (b *Bot) defaultHandler(ctx context.Context, tgBot *bot.Bot, update *models.Update) {
+	if update.EditedMessage != nil {
+		b.handleEdited(ctx, update.EditedMessage)
+		return
+	}
 	if update.Message == nil {
 		return
 	}
@@ -28,12 +75,40 @@ func (b *Bot) defaultHandler(ctx context.Context, tgBot *bot.Bot, update *models
 		return
 	}
 
+	// 转发的消息需确认后才发送给后端
+	if update.Message.ForwardOrigin != nil {
+		if _, bound := b.store.GetBinding(topicKeyFromMessage(update.Message)); bound {
+			b.handleForwarded(ctx, update.Message)
+			return
+		}
+	}
+
+	if len(update.Message.Photo) > 0 {
+		b.handlePhoto(ctx, update.Message, "")
+		return
+	}
+	if update.Message.Document != nil {
+		b.handleDocument(ctx, update.Message, "")
+		return
+	}
+
+	if update.Message.Voice != nil {
+		b.handleVoice(ctx, update.Message)
+		return
+	}
+
 	if update.Message.Text == "" {
 		return
 	}
-	msg := update.Message
+	if b.interceptReplyButton(ctx, tgBot, update) {
+		return
+	}
+	b.handleText(ctx, update.Message, update.Message.Text)
+}
+
+// handleText 处理用户输入的文本（键入或语音识别结果）：推进状态机或转发到后端
+func (b *Bot) handleText(ctx context.Context, msg *models.Message, text string) {
 	key := topicKeyFromMessage(msg)
-	text := msg.Text
 
 	// 检查状态机
 	ts := b.getOrCreateState(key)
@@ -42,7 +117,11 @@ func (b *Bot) defaultHandler(ctx context.Context, tgBot *bot.Bot, update *models
 		// 用户输入了路径
 		path := strings.TrimSpace(text)
 		if path == "" {
-			b.sendReply(ctx, msg, "路径不能为空，请重新输入：")
+			b.sendReply(ctx, msg, i18n.T("flow.path_empty"))
+			return
+		}
+		if !safePath(path) {
+			b.sendReply(ctx, msg, i18n.T("flow.path_invalid"))
 			return
 		}
 		// 展开 ~
@@ -52,21 +131,19 @@ func (b *Bot) defaultHandler(ctx context.Context, tgBot *bot.Bot, update *models
 		}
 		// 校验路径存在
 		if info, err := os.Stat(path); err != nil || !info.IsDir() {
-			b.sendReply(ctx, msg, fmt.Sprintf("目录不存在: %s\n请重新输入：", path))
+			b.sendReply(ctx, msg, i18n.T("flow.dir_not_found", path))
 			return
 		}
 		ts.SelectedDir = path
-		b.setPhase(key, "awaiting_backend")
-		kb := BackendKeyboard()
-		b.sendReplyWithKeyboard(ctx, msg, "🚀 选择启动命令：", kb)
+		b.chooseBackend(ctx, key, msg.Chat.ID, msg.MessageThreadID, "")
 		return
 
 	case "awaiting_dir":
-		b.sendReply(ctx, msg, "请点击按钮选择目录，或点击 [📁 输入路径...] 手动输入")
+		b.sendReply(ctx, msg, i18n.T("flow.use_dir_buttons"))
 		return
 
 	case "awaiting_backend":
-		b.sendReply(ctx, msg, "请点击按钮选择后端")
+		b.sendReply(ctx, msg, i18n.T("flow.use_backend_buttons"))
 		return
 	}
 
@@ -76,19 +153,19 @@ func (b *Bot) defaultHandler(ctx context.Context, tgBot *bot.Bot, update *models
 	binding, ok := b.store.GetBinding(key)
 	if ok {
 		// 已绑定 - 检查窗口是否存活
-		if !b.tmux.IsWindowAlive(binding.WindowID) {
+		if !b.windowAlive(binding) {
 			// 窗口已死 - 自动解绑
 			b.unbind(key, binding)
 			slog.Info("window dead, auto unbinding", "key", key, "window", binding.WindowID)
-			b.sendReply(ctx, msg, "⚠️ 会话已断开，已自动解绑")
+			b.sendReply(ctx, msg, i18n.T("session.disconnected_unbound"))
 			b.handleUnbound(ctx, msg, key)
 			return
 		}
 		// 窗口存活但后端进程可能已退出（回到 shell）
-		if !b.tmux.IsBackendAlive(binding.WindowID) {
+		if !b.backendAlive(binding) {
 			b.unbind(key, binding)
 			slog.Info("backend exited, auto unbinding", "key", key, "window", binding.WindowID)
-			b.sendReply(ctx, msg, "⚠️ 后端进程已退出，已自动解绑")
+			b.sendReply(ctx, msg, i18n.T("session.backend_exited_unbound"))
 			b.handleUnbound(ctx, msg, key)
 			return
 		}
@@ -96,16 +173,18 @@ func (b *Bot) defaultHandler(ctx context.Context, tgBot *bot.Bot, update *models
 		if strings.HasPrefix(text, "!") && len(text) > 1 {
 			cmdText := strings.TrimSpace(text[1:])
 			if err := b.tmux.SendKeys(binding.WindowID, cmdText); err != nil {
-				b.sendReply(ctx, msg, fmt.Sprintf("发送命令失败: %v", err))
+				b.sendReply(ctx, msg, i18n.T("shell.send_failed", err))
 				return
 			}
 			b.tmux.SendEnter(binding.WindowID)
 			return
 		}
 
-		// 窗口和后端都存活 - 转发消息到 tmux
-		ch := b.getOrCreateSendChan(binding.WindowID)
-		ch <- text
+		// 窗口和后端都存活 - 转发消息到 tmux（回复 bot 消息时附带被引用的内容）
+		if quote := b.replyQuote(key, msg); quote != "" {
+			text = quote + "\n\n" + text
+		}
+		b.enqueueInput(binding.WindowID, msg, text)
 		return
 	}
 
@@ -113,6 +192,141 @@ func (b *Bot) defaultHandler(ctx context.Context, tgBot *bot.Bot, update *models
 	b.handleUnbound(ctx, msg, key)
 }
 
+// replyQuote 用户回复 bot 输出时，生成被回复内容的引用块；状态消息、带键盘的消息等不引用
+func (b *Bot) replyQuote(key string, msg *models.Message) string {
+	r := msg.ReplyToMessage
+	if r == nil || r.From == nil || r.From.ID != b.selfID || r.ForumTopicCreated != nil {
+		return ""
+	}
+	if len(r.ReplyMarkup.InlineKeyboard) > 0 || b.statusPoller.IsStatusMessage(key, r.ID) {
+		return ""
+	}
+	quoted := strings.TrimSpace(html.UnescapeString(r.Text))
+	if quoted == "" {
+		quoted = strings.TrimSpace(html.UnescapeString(r.Caption))
+	}
+	if quoted == "" {
+		return ""
+	}
+	lines := strings.Split(truncateRunes(quoted, maxReplyQuoteLen), "\n")
+	lines[0] = "(replying to) " + lines[0]
+	return "> " + strings.Join(lines, "\n> ")
+}
+
+// handleEdited 用户编辑已发送的消息：仍在输入队列中则直接替换，否则询问是否重发
+func (b *Bot) handleEdited(ctx context.Context, msg *models.Message) {
+	text := msg.Text
+	if text == "" || strings.HasPrefix(text, "/") || strings.HasPrefix(text, "!") {
+		return
+	}
+	key := topicKeyFromMessage(msg)
+	if _, ok := b.store.GetBinding(key); !ok {
+		return
+	}
+	if quote := b.replyQuote(key, msg); quote != "" {
+		text = quote + "\n\n" + text
+	}
+	if b.replaceQueued(msg.Chat.ID, msg.ID, text) {
+		b.sendReply(ctx, msg, i18n.T("edit.replaced"))
+		return
+	}
+
+	b.edits.Store(queuedKey(msg.Chat.ID, msg.ID), text)
+	kb := models.InlineKeyboardMarkup{InlineKeyboard: [][]models.InlineKeyboardButton{
+		{
+			{Text: i18n.T("edit.resend_button"), CallbackData: fmt.Sprintf("resend:%d", msg.ID)},
+			{Text: i18n.T("common.ignore"), CallbackData: fmt.Sprintf("resend_no:%d", msg.ID)},
+		},
+	}}
+	b.sendReplyWithKeyboard(ctx, msg, i18n.T("edit.resend_prompt"), kb)
+}
+
+// resendEdited 确认后将编辑后的内容发送到当前会话
+func (b *Bot) resendEdited(ctx context.Context, key string, chatID int64, threadID int, msgID string) {
+	id, _ := strconv.Atoi(msgID)
+	v, ok := b.edits.LoadAndDelete(queuedKey(chatID, id))
+	if !ok {
+		b.sendMsg(ctx, chatID, threadID, i18n.T("edit.expired"), nil)
+		return
+	}
+	binding, ok := b.store.GetBinding(key)
+	if !ok || !b.windowAlive(binding) {
+		b.sendMsg(ctx, chatID, threadID, i18n.T("session.none_available"), nil)
+		return
+	}
+	b.getOrCreateSendChan(binding.WindowID) <- &queuedInput{Text: v.(string)}
+	b.sendMsg(ctx, chatID, threadID, i18n.T("edit.resent"), nil)
+}
+
+// handleForwarded 转发的消息：预览并确认后再发送给后端
+func (b *Bot) handleForwarded(ctx context.Context, msg *models.Message) {
+	var preview string
+	switch {
+	case msg.Text != "":
+		preview = truncateRunes(msg.Text, 200)
+	case len(msg.Photo) > 0:
+		preview = i18n.T("forward.photo") + msg.Caption
+	case msg.Document != nil:
+		preview = i18n.T("forward.document") + msg.Document.FileName
+	default:
+		return
+	}
+	b.forwards.Store(queuedKey(msg.Chat.ID, msg.ID), msg)
+	kb := models.InlineKeyboardMarkup{InlineKeyboard: [][]models.InlineKeyboardButton{
+		{
+			{Text: i18n.T("common.send"), CallbackData: fmt.Sprintf("fwd:%d", msg.ID)},
+			{Text: i18n.T("common.ignore_button"), CallbackData: fmt.Sprintf("fwd_no:%d", msg.ID)},
+		},
+	}}
+	b.sendReplyWithKeyboard(ctx, msg, i18n.T("forward.prompt", forwardOriginName(msg.ForwardOrigin), preview), kb)
+}
+
+// sendForwarded 确认后发送转发的消息，附件走上传流程
+func (b *Bot) sendForwarded(ctx context.Context, key string, chatID int64, threadID int, msgID string) {
+	id, _ := strconv.Atoi(msgID)
+	v, ok := b.forwards.LoadAndDelete(queuedKey(chatID, id))
+	if !ok {
+		b.sendMsg(ctx, chatID, threadID, i18n.T("forward.expired"), nil)
+		return
+	}
+	msg := v.(*models.Message)
+	prefix := i18n.T("forward.header", forwardOriginName(msg.ForwardOrigin))
+	switch {
+	case len(msg.Photo) > 0:
+		b.handlePhoto(ctx, msg, prefix)
+	case msg.Document != nil:
+		b.handleDocument(ctx, msg, prefix)
+	default:
+		binding, ok := b.store.GetBinding(key)
+		if !ok || !b.windowAlive(binding) {
+			b.sendMsg(ctx, chatID, threadID, i18n.T("session.none_available"), nil)
+			return
+		}
+		b.enqueueInput(binding.WindowID, msg, prefix+msg.Text)
+		b.sendMsg(ctx, chatID, threadID, i18n.T("forward.sent"), nil)
+	}
+}
+
+// forwardOriginName 转发来源的显示名称
+func forwardOriginName(o *models.MessageOrigin) string {
+	switch {
+	case o.MessageOriginUser != nil:
+		u := o.MessageOriginUser.SenderUser
+		name := strings.TrimSpace(u.FirstName + " " + u.LastName)
+		if u.Username != "" {
+			name += " (@" + u.Username + ")"
+		}
+		return name
+	case o.MessageOriginHiddenUser != nil:
+		return o.MessageOriginHiddenUser.SenderUserName
+	case o.MessageOriginChat != nil:
+		return o.MessageOriginChat.SenderChat.Title
+	case o.MessageOriginChannel != nil:
+		return o.MessageOriginChannel.Chat.Title
+	}
+	return i18n.T("forward.unknown_origin")
+}
+
 // handleUnbound 处理未绑定 topic 的消息
 func (b *Bot) handleUnbound(ctx context.Context, msg *models.Message, key string) {
 	windows, err := b.tmux.ListWindows()
@@ -123,34 +337,60 @@ func (b *Bot) handleUnbound(ctx context.Context, msg *models.Message, key string
 	}
 
 	// 有已有窗口 - 展示列表
+	kb := SessionListKeyboard(b.sessionInfos(windows), 0)
+	b.sendReplyWithKeyboard(ctx, msg, i18n.T("unbound.choose"), kb)
+}
+
+// sessionInfos 汇总 tmux 窗口与绑定信息；窗口已不存在的绑定排在最后
+func (b *Bot) sessionInfos(windows []tmux.WindowInfo) []SessionInfo {
+	byWindow := make(map[string]string) // windowID -> topicKey
 	allBindings := b.store.AllBindings()
-	boundWindows := make(map[string]string) // windowID -> topicKey
 	for tk, bd := range allBindings {
-		boundWindows[bd.WindowID] = tk
+		byWindow[bd.WindowID] = tk
 	}
 
+	alive := make(map[string]bool, len(windows))
 	var sessions []SessionInfo
 	for _, w := range windows {
-		si := SessionInfo{
-			WindowID:    w.ID,
-			DisplayName: w.Name,
-		}
-		if tk, ok := boundWindows[w.ID]; ok {
+		alive[w.ID] = true
+		si := SessionInfo{WindowID: w.ID, DisplayName: w.Name, ProjectPath: w.Path, Alive: true}
+		if tk, ok := byWindow[w.ID]; ok {
+			bd := allBindings[tk]
 			si.BoundTopic = tk
+			si.Backend, si.CreatedAt = bd.Backend, bd.CreatedAt
+			if bd.ProjectPath != "" {
+				si.ProjectPath = bd.ProjectPath
+			}
 		}
 		sessions = append(sessions, si)
 	}
-
-	kb := SessionListKeyboard(sessions)
-	b.sendReplyWithKeyboard(ctx, msg, "该 Topic 尚未绑定会话，请选择：", kb)
+	var dead []SessionInfo
+	for tk, bd := range allBindings {
+		if alive[bd.WindowID] {
+			continue
+		}
+		// claude-headless 没有 tmux 窗口，仍是可用的会话
+		dead = append(dead, SessionInfo{
+			WindowID:    bd.WindowID,
+			DisplayName: bd.DisplayName,
+			BoundTopic:  tk,
+			Backend:     bd.Backend,
+			ProjectPath: bd.ProjectPath,
+			CreatedAt:   bd.CreatedAt,
+			Alive:       isHeadless(bd),
+		})
+	}
+	// 固定顺序，翻页时各页内容保持稳定
+	sort.Slice(dead, func(i, j int) bool { return dead[i].BoundTopic < dead[j].BoundTopic })
+	return append(sessions, dead...)
 }
 
 // startNewFlow 进入 /new 两步创建流程
 func (b *Bot) startNewFlow(ctx context.Context, msg *models.Message, key string) {
 	b.setPhase(key, "awaiting_dir")
 	dirs := b.store.GetDirs()
-	kb := DirKeyboard(dirs.Favorites, dirs.Recent)
-	b.sendReplyWithKeyboard(ctx, msg, "📂 选择项目目录：", kb)
+	kb := DirKeyboard(dirs.Favorites, dirs.Recent, 0, b.cfg.Dirs.PageSize, b.pathData)
+	b.setPromptMsg(key, b.sendReplyWithKeyboard(ctx, msg, i18n.T("flow.choose_dir"), kb))
 }
 
 // handleNew /new 命令
@@ -162,6 +402,24 @@ func (b *Bot) handleNew(ctx context.Context, tgBot *bot.Bot, update *models.Upda
 	b.startNewFlow(ctx, update.Message, key)
 }
 
+// handleCancel /cancel 命令：中止 /new 流程
+func (b *Bot) handleCancel(ctx context.Context, tgBot *bot.Bot, update *models.Update) {
+	if update.Message == nil {
+		return
+	}
+	msg := update.Message
+	key := topicKeyFromMessage(msg)
+	if phase := b.getOrCreateState(key).Phase; phase == "idle" || phase == "bound" {
+		b.sendReply(ctx, msg, i18n.T("flow.nothing_to_cancel"))
+		return
+	}
+	promptMsgID, _ := b.resetFlow(key)
+	if promptMsgID != 0 {
+		b.bot.DeleteMessage(ctx, &bot.DeleteMessageParams{ChatID: msg.Chat.ID, MessageID: promptMsgID})
+	}
+	b.sendReply(ctx, msg, i18n.T("common.cancelled"))
+}
+
 // handleSession /session 命令
 func (b *Bot) handleSession(ctx context.Context, tgBot *bot.Bot, update *models.Update) {
 	if update.Message == nil {
@@ -175,11 +433,11 @@ func (b *Bot) handleSession(ctx context.Context, tgBot *bot.Bot, update *models.
 		// 列出所有窗口
 		windows, err := b.tmux.ListWindows()
 		if err != nil {
-			b.sendReply(ctx, msg, fmt.Sprintf("获取窗口列表失败: %v", err))
+			b.sendReply(ctx, msg, i18n.T("session.list_failed", err))
 			return
 		}
 		if len(windows) == 0 {
-			b.sendReply(ctx, msg, "🖥 当前没有 tmux 窗口")
+			b.sendReply(ctx, msg, i18n.T("session.no_windows"))
 			return
 		}
 		allBindings := b.store.AllBindings()
@@ -188,12 +446,12 @@ func (b *Bot) handleSession(ctx context.Context, tgBot *bot.Bot, update *models.
 			boundWindows[bd.WindowID] = tk
 		}
 		var lines []string
-		lines = append(lines, "🖥 所有 tmux 窗口\n")
+		lines = append(lines, i18n.T("session.all_windows"))
 		for _, w := range windows {
 			if tk, ok := boundWindows[w.ID]; ok {
-				lines = append(lines, fmt.Sprintf("%s  %s  ← 已绑定 %s", w.ID, w.Name, tk))
+				lines = append(lines, i18n.T("session.window_bound", w.ID, w.Name, tk))
 			} else {
-				lines = append(lines, fmt.Sprintf("%s  %s  ← 未绑定", w.ID, w.Name))
+				lines = append(lines, i18n.T("session.window_unbound", w.ID, w.Name))
 			}
 		}
 		b.sendReply(ctx, msg, strings.Join(lines, "\n"))
@@ -203,16 +461,21 @@ func (b *Bot) handleSession(ctx context.Context, tgBot *bot.Bot, update *models.
 	// 默认：显示当前绑定详情
 	binding, ok := b.store.GetBinding(key)
 	if !ok {
-		b.sendReply(ctx, msg, "当前 Topic 尚未绑定会话\n使用 /new 创建新会话")
+		b.sendReply(ctx, msg, i18n.T("session.not_bound_hint"))
 		return
 	}
-	alive := "运行中"
-	if !b.tmux.IsWindowAlive(binding.WindowID) {
-		alive = "已断开"
+	alive := i18n.T("session.running")
+	if !b.windowAlive(binding) {
+		alive = i18n.T("session.disconnected")
 	}
 	ago := time.Since(binding.CreatedAt).Truncate(time.Minute)
-	reply := fmt.Sprintf("📋 当前会话信息\n├─ 窗口:    %s\n├─ 后端:    %s\n├─ 目录:    %s\n├─ 状态:    %s\n└─ 创建于:  %s ago",
-		binding.WindowID, binding.Backend, binding.ProjectPath, alive, ago)
+	backendName := binding.Backend
+	if binding.Profile != "" {
+		backendName += " (" + binding.Profile + ")"
+	}
+	reply := i18n.T("session.info",
+		binding.WindowID, backendName, binding.ProjectPath, alive, ago)
+	reply += "\n" + filterSummary(b.topicFilter(key))
 	b.sendReply(ctx, msg, reply)
 }
 
@@ -225,13 +488,75 @@ func (b *Bot) handleKill(ctx context.Context, tgBot *bot.Bot, update *models.Upd
 	key := topicKeyFromMessage(msg)
 	binding, ok := b.store.GetBinding(key)
 	if !ok {
-		b.sendReply(ctx, msg, "当前 Topic 尚未绑定会话")
+		b.sendReply(ctx, msg, i18n.T("session.not_bound"))
 		return
 	}
-	// 关闭窗口
-	b.tmux.KillWindow(binding.WindowID)
-	b.unbind(key, binding)
-	b.sendReply(ctx, msg, fmt.Sprintf("✅ 已关闭会话 %s", binding.DisplayName))
+	switch strings.TrimSpace(strings.TrimPrefix(msg.Text, "/kill")) {
+	case "":
+		text, kb := b.killPrompt(binding.WindowID)
+		b.sendReplyWithKeyboard(ctx, msg, text, kb)
+	case "now", "--force":
+		b.killSession(ctx, msg.Chat.ID, msg.MessageThreadID, binding.WindowID)
+	default:
+		b.sendReply(ctx, msg, i18n.T("kill.usage"))
+	}
+}
+
+// killPrompt 生成关闭窗口前的二次确认文本与键盘
+func (b *Bot) killPrompt(windowID string) (string, models.InlineKeyboardMarkup) {
+	name, running := windowID, ""
+	for _, bd := range b.store.AllBindings() {
+		if bd.WindowID == windowID {
+			name = bd.DisplayName
+			running = i18n.T("kill.running_for", time.Since(bd.CreatedAt).Truncate(time.Minute))
+			break
+		}
+	}
+	if running == "" {
+		windows, _ := b.tmux.ListWindows()
+		for _, w := range windows {
+			if w.ID == windowID {
+				name = w.Name
+				break
+			}
+		}
+	}
+	kb := models.InlineKeyboardMarkup{InlineKeyboard: [][]models.InlineKeyboardButton{
+		{
+			{Text: i18n.T("kill.confirm_button"), CallbackData: "killyes:" + windowID},
+			{Text: i18n.T("common.cancel_button"), CallbackData: "killno:" + windowID},
+		},
+	}}
+	return i18n.T("kill.confirm", name) + running, kb
+}
+
+// killSession 关闭窗口并解绑所有绑定到该窗口的 Topic；worktree 会话可选删除 worktree
+func (b *Bot) killSession(ctx context.Context, chatID int64, threadID int, windowID string) {
+	b.tmux.KillWindow(windowID)
+	var killed state.Binding
+	found := false
+	for tk, bd := range b.store.AllBindings() {
+		if bd.WindowID == windowID {
+			b.unbind(tk, bd)
+			killed, found = bd, true
+		}
+	}
+	if !found {
+		b.sendMsg(ctx, chatID, threadID, i18n.T("archive.killed"), nil)
+		return
+	}
+	if killed.WorktreeRepo == "" {
+		b.sendMsg(ctx, chatID, threadID, i18n.T("kill.done", killed.DisplayName), nil)
+		return
+	}
+	b.worktrees.Store(windowID, worktreeInfo{Repo: killed.WorktreeRepo, Path: killed.ProjectPath})
+	kb := models.InlineKeyboardMarkup{InlineKeyboard: [][]models.InlineKeyboardButton{
+		{
+			{Text: i18n.T("kill.remove_worktree_button"), CallbackData: "wtrm:" + windowID},
+			{Text: i18n.T("common.keep"), CallbackData: "noop"},
+		},
+	}}
+	b.sendMsg(ctx, chatID, threadID, i18n.T("kill.done_worktree", killed.DisplayName, killed.ProjectPath), &kb)
 }
 
 // handleEsc /esc 命令
@@ -243,11 +568,11 @@ func (b *Bot) handleEsc(ctx context.Context, tgBot *bot.Bot, update *models.Upda
 	key := topicKeyFromMessage(msg)
 	binding, ok := b.store.GetBinding(key)
 	if !ok {
-		b.sendReply(ctx, msg, "当前 Topic 尚未绑定会话")
+		b.sendReply(ctx, msg, i18n.T("session.not_bound"))
 		return
 	}
 	b.tmux.SendEscape(binding.WindowID)
-	b.sendReply(ctx, msg, "⎋ 已发送 Escape")
+	b.sendReply(ctx, msg, i18n.T("keys.esc_sent"))
 }
 
 // handleEnter /enter 命令
@@ -259,7 +584,7 @@ func (b *Bot) handleEnter(ctx context.Context, tgBot *bot.Bot, update *models.Up
 	key := topicKeyFromMessage(msg)
 	binding, ok := b.store.GetBinding(key)
 	if !ok {
-		b.sendReply(ctx, msg, "当前 Topic 尚未绑定会话")
+		b.sendReply(ctx, msg, i18n.T("session.not_bound"))
 		return
 	}
 	b.tmux.SendEnter(binding.WindowID)
@@ -274,7 +599,7 @@ func (b *Bot) handleScreenshot(ctx context.Context, tgBot *bot.Bot, update *mode
 	key := topicKeyFromMessage(msg)
 	binding, ok := b.store.GetBinding(key)
 	if !ok {
-		b.sendReply(ctx, msg, "当前 Topic 尚未绑定会话")
+		b.sendReply(ctx, msg, i18n.T("session.not_bound"))
 		return
 	}
 
@@ -305,7 +630,7 @@ func (b *Bot) sendScreenshotToChat(ctx context.Context, chatID int64, threadID i
 		if threadID != 0 {
 			params.MessageThreadID = threadID
 		}
-		b.bot.SendMessage(ctx, params)
+		b.pushers.sendAndRecord(ctx, params)
 		return
 	}
 
@@ -318,7 +643,9 @@ func (b *Bot) sendScreenshotToChat(ctx context.Context, chatID int64, threadID i
 	if threadID != 0 {
 		params.MessageThreadID = threadID
 	}
-	b.bot.SendPhoto(ctx, params)
+	if resp, err := b.bot.SendPhoto(ctx, params); err == nil {
+		b.pushers.RecordSent(chatID, threadID, resp.ID)
+	}
 }
 
 // handleCmd /cmd 命令
@@ -330,237 +657,1573 @@ func (b *Bot) handleCmd(ctx context.Context, tgBot *bot.Bot, update *models.Upda
 	key := topicKeyFromMessage(msg)
 	binding, ok := b.store.GetBinding(key)
 	if !ok {
-		b.sendReply(ctx, msg, "当前 Topic 尚未绑定会话")
+		b.sendReply(ctx, msg, i18n.T("session.not_bound"))
 		return
 	}
 	// 提取 /cmd 后的参数
 	arg := strings.TrimSpace(strings.TrimPrefix(msg.Text, "/cmd"))
 	if arg == "" {
-		b.sendReply(ctx, msg, "用法: /cmd <命令>\n例如: /cmd config")
+		b.sendReply(ctx, msg, i18n.T("cmd.usage"))
 		return
 	}
 	// 发送为后端原生命令
 	cmdText := "/" + arg
-	ch := b.getOrCreateSendChan(binding.WindowID)
-	ch <- cmdText
+	b.getOrCreateSendChan(binding.WindowID) <- &queuedInput{Text: cmdText}
 }
 
-// handleDir /dir 命令
-func (b *Bot) handleDir(ctx context.Context, tgBot *bot.Bot, update *models.Update) {
+// handleLogs /logs [N] 命令：重新推送最近 N 个助手输出块
+func (b *Bot) handleLogs(ctx context.Context, tgBot *bot.Bot, update *models.Update) {
 	if update.Message == nil {
 		return
 	}
 	msg := update.Message
-	text := strings.TrimSpace(strings.TrimPrefix(msg.Text, "/dir"))
-
-	if strings.HasPrefix(text, "add ") {
-		path := strings.TrimSpace(strings.TrimPrefix(text, "add "))
-		if path == "" {
-			b.sendReply(ctx, msg, "用法: /dir add <路径>")
-			return
-		}
-		b.store.AddFavorite(expandHome(path))
-		b.sendReply(ctx, msg, fmt.Sprintf("⭐ 已收藏: %s", path))
+	key := topicKeyFromMessage(msg)
+	binding, ok := b.store.GetBinding(key)
+	if !ok {
+		b.sendReply(ctx, msg, i18n.T("session.not_bound"))
 		return
 	}
 
-	if strings.HasPrefix(text, "rm ") {
-		path := strings.TrimSpace(strings.TrimPrefix(text, "rm "))
-		if path == "" {
-			b.sendReply(ctx, msg, "用法: /dir rm <路径>")
+	n := defaultLogsCount
+	if arg := strings.TrimSpace(strings.TrimPrefix(msg.Text, "/logs")); arg != "" {
+		v, err := strconv.Atoi(arg)
+		if err != nil || v <= 0 {
+			b.sendReply(ctx, msg, i18n.T("logs.usage"))
 			return
 		}
-		b.store.RemoveFavorite(expandHome(path))
-		b.sendReply(ctx, msg, fmt.Sprintf("🗑 已移除收藏: %s", path))
-		return
+		n = min(v, maxLogsCount)
 	}
 
-	if strings.HasPrefix(text, "browse") {
-		path := strings.TrimSpace(strings.TrimPrefix(text, "browse"))
-		if path == "" {
-			path, _ = os.UserHomeDir()
-		}
-		path = expandHome(path)
-		entries, err := listSubDirs(path)
+	bt := backend.Type(binding.Backend)
+	if logFile := b.dispatcher.LogFile(key); logFile != "" && (bt == backend.TypeClaude || bt == backend.TypeCodex) {
+		contents, err := monitor.ReadRecent(logFile, bt, n)
 		if err != nil {
-			b.sendReply(ctx, msg, fmt.Sprintf("浏览失败: %v", err))
+			slog.Warn("read recent logs failed", "key", key, "file", logFile, "error", err)
+		}
+		if len(contents) > 0 {
+			b.pushers.Replay(ctx, key, msg.Chat.ID, msg.MessageThreadID, contents)
 			return
 		}
-		kb := BrowseDirKeyboard(path, entries)
-		b.sendReplyWithKeyboard(ctx, msg, fmt.Sprintf("📂 %s", path), kb)
-		return
 	}
 
-	// 默认：列出收藏+最近
-	dirs := b.store.GetDirs()
-	var lines []string
-	lines = append(lines, "📂 目录管理\n")
-	if len(dirs.Favorites) > 0 {
-		lines = append(lines, "⭐ 收藏:")
-		for _, f := range dirs.Favorites {
-			lines = append(lines, "  "+f)
-		}
+	// 降级：bash / pane 监控的会话直接截取终端内容
+	text, err := b.tmux.CapturePaneClean(binding.WindowID)
+	if err != nil {
+		b.sendReply(ctx, msg, i18n.T("logs.failed", err))
+		return
 	}
-	if len(dirs.Recent) > 0 {
-		lines = append(lines, "\n🕐 最近使用:")
-		for _, r := range dirs.Recent {
-			lines = append(lines, "  "+r)
-		}
+	text = strings.TrimSpace(text)
+	if text == "" {
+		b.sendReply(ctx, msg, i18n.T("logs.empty"))
+		return
 	}
-	if len(dirs.Favorites) == 0 && len(dirs.Recent) == 0 {
-		lines = append(lines, "暂无目录记录\n使用 /dir add <路径> 添加收藏\n使用 /dir browse 浏览目录")
+	// 以 <pre> 发送：终端内容中的 ``` 不会破坏格式
+	params := &bot.SendMessageParams{
+		ChatID:    msg.Chat.ID,
+		Text:      "<pre>" + escapeHTML(paneTail(text, maxLogsPaneUnits)) + "</pre>",
+		ParseMode: models.ParseModeHTML,
 	}
-	b.sendReply(ctx, msg, strings.Join(lines, "\n"))
+	if msg.MessageThreadID != 0 {
+		params.MessageThreadID = msg.MessageThreadID
+	}
+	b.pushers.sendAndRecord(ctx, params)
 }
 
-// handleCallback 处理内联键盘回调
-func (b *Bot) handleCallback(ctx context.Context, tgBot *bot.Bot, update *models.Update) {
-	if update.CallbackQuery == nil {
+// paneTail 返回终端内容的末尾部分，HTML 转义后不超过 n 个 UTF-16 码元，不切开字符
+func paneTail(s string, n int) string {
+	units, i := 0, len(s)
+	for i > 0 {
+		r, size := utf8.DecodeLastRuneInString(s[:i])
+		w := utf16Len(escapeHTML(string(r)))
+		if units+w > n {
+			break
+		}
+		units += w
+		i -= size
+	}
+	return s[i:]
+}
+
+// handleTranscript /transcript [md|txt|json] [thinking] 命令：导出完整会话记录
+func (b *Bot) handleTranscript(ctx context.Context, tgBot *bot.Bot, update *models.Update) {
+	if update.Message == nil {
 		return
 	}
-	cq := update.CallbackQuery
-	key := topicKeyFromCallback(cq)
-	if key == "" {
-		slog.Warn("callback: empty key, ignoring", "data", cq.Data)
+	msg := update.Message
+	key := topicKeyFromMessage(msg)
+	binding, ok := b.store.GetBinding(key)
+	if !ok {
+		b.sendReply(ctx, msg, i18n.T("session.not_bound"))
 		return
 	}
-	data := cq.Data
-	slog.Info("handleCallback", "key", key, "data", data)
 
-	// Answer callback 消除加载状态
-	tgBot.AnswerCallbackQuery(ctx, &bot.AnswerCallbackQueryParams{CallbackQueryID: cq.ID})
+	format := monitor.TranscriptMarkdown
+	withThinking := false
+	for _, arg := range strings.Fields(strings.TrimPrefix(msg.Text, "/transcript")) {
+		switch arg {
+		case "md", "txt", "json":
+			format = monitor.TranscriptFormat(arg)
+		case "thinking":
+			withThinking = true
+		default:
+			b.sendReply(ctx, msg, i18n.T("transcript.usage"))
+			return
+		}
+	}
 
-	// 获取原始消息用于回复
-	var chatID int64
-	var threadID int
-	if msg := cq.Message.Message; msg != nil {
-		chatID = msg.Chat.ID
-		threadID = msg.MessageThreadID
+	if err := b.uploadTranscript(ctx, key, binding, msg.Chat.ID, msg.MessageThreadID, format, withThinking); errors.Is(err, errNoTranscript) {
+		b.sendReply(ctx, msg, i18n.T("transcript.none"))
+	} else if err != nil {
+		b.sendReply(ctx, msg, err.Error())
 	}
+}
 
-	switch {
-	case strings.HasPrefix(data, "backend:"):
-		backendType := backend.Type(strings.TrimPrefix(data, "backend:"))
-		b.createSession(ctx, key, chatID, threadID, backendType)
+// errNoTranscript 会话没有可导出的日志
+var errNoTranscript = errors.New("no transcript log file")
 
-	case strings.HasPrefix(data, "dir:"):
-		dirPath := strings.TrimPrefix(data, "dir:")
-		ts := b.getOrCreateState(key)
-		ts.SelectedDir = dirPath
-		b.setPhase(key, "awaiting_backend")
-		kb := BackendKeyboard()
-		b.sendMsg(ctx, chatID, threadID, "🚀 选择启动命令：", &kb)
+// uploadTranscript 导出会话记录并以文件形式上传到 topic
+func (b *Bot) uploadTranscript(ctx context.Context, key string, binding state.Binding, chatID int64, threadID int, format monitor.TranscriptFormat, withThinking bool) error {
+	bt := backend.Type(binding.Backend)
+	logFile := b.dispatcher.LogFile(key)
+	if logFile == "" || (bt != backend.TypeClaude && bt != backend.TypeCodex && bt != backend.TypeGemini) {
+		return errNoTranscript
+	}
 
-	case data == "dir_input":
-		b.setPhase(key, "awaiting_path_input")
-		b.sendMsg(ctx, chatID, threadID, "请输入项目目录的完整路径：", nil)
+	// 写入临时文件后上传，避免在内存中拼接大字符串
+	tmp, err := os.CreateTemp("", "tgmux-transcript-*."+string(format))
+	if err != nil {
+		return fmt.Errorf("%s: %w", i18n.T("transcript.export_failed"), err)
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
 
-	case strings.HasPrefix(data, "bind:"):
-		windowID := strings.TrimPrefix(data, "bind:")
-		b.bindExisting(ctx, key, chatID, threadID, windowID)
+	if err := monitor.WriteTranscript(tmp, logFile, bt, format, withThinking); err != nil {
+		return fmt.Errorf("%s: %w", i18n.T("transcript.export_failed"), err)
+	}
+	if _, err := tmp.Seek(0, io.SeekStart); err != nil {
+		return fmt.Errorf("%s: %w", i18n.T("transcript.export_failed"), err)
+	}
 
-	case data == "new_session":
-		b.setPhase(key, "awaiting_dir")
-		dirs := b.store.GetDirs()
-		kb := DirKeyboard(dirs.Favorites, dirs.Recent)
-		b.sendMsg(ctx, chatID, threadID, "📂 选择项目目录：", &kb)
+	filename := fmt.Sprintf("transcript-%s-%s.%s", binding.Backend, filepath.Base(binding.ProjectPath), format)
+	params := &bot.SendDocumentParams{
+		ChatID:   chatID,
+		Document: &models.InputFileUpload{Filename: filename, Data: tmp},
+	}
+	if threadID != 0 {
+		params.MessageThreadID = threadID
+	}
+	if _, err := b.bot.SendDocument(ctx, params); err != nil {
+		slog.Error("send transcript failed", "key", key, "error", err)
+		return fmt.Errorf("%s: %w", i18n.T("transcript.upload_failed"), err)
+	}
+	return nil
+}
 
-	case strings.HasPrefix(data, "confirm:"):
-		parts := strings.SplitN(strings.TrimPrefix(data, "confirm:"), ":", 2)
-		if len(parts) == 2 {
-			b.handleConfirm(ctx, key, parts[1], parts[0])
-		}
+// handleArchive /archive 命令：导出会话记录、关闭窗口、解绑，论坛 Topic 同时关闭
+func (b *Bot) handleArchive(ctx context.Context, tgBot *bot.Bot, update *models.Update) {
+	if update.Message == nil {
+		return
+	}
+	msg := update.Message
+	key := topicKeyFromMessage(msg)
+	binding, ok := b.store.GetBinding(key)
+	if !ok {
+		b.sendReply(ctx, msg, i18n.T("session.not_bound"))
+		return
+	}
 
-	case strings.HasPrefix(data, "browse:"):
-		dirPath := strings.TrimPrefix(data, "browse:")
-		entries, err := listSubDirs(dirPath)
-		if err != nil {
-			return
-		}
-		kb := BrowseDirKeyboard(dirPath, entries)
-		b.sendMsg(ctx, chatID, threadID, fmt.Sprintf("📂 %s", dirPath), &kb)
+	// 各步骤失败只记录，不中断后续清理
+	var report []string
+	switch err := b.uploadTranscript(ctx, key, binding, msg.Chat.ID, msg.MessageThreadID, monitor.TranscriptMarkdown, false); {
+	case err == nil:
+		report = append(report, i18n.T("archive.uploaded"))
+	case errors.Is(err, errNoTranscript):
+		report = append(report, i18n.T("archive.no_transcript"))
+	default:
+		report = append(report, i18n.T("archive.transcript_failed")+err.Error())
+	}
 
-	case strings.HasPrefix(data, "fav:"):
-		dirPath := strings.TrimPrefix(data, "fav:")
-		b.store.AddFavorite(dirPath)
-		b.sendMsg(ctx, chatID, threadID, fmt.Sprintf("⭐ 已收藏: %s", dirPath), nil)
+	if err := b.tmux.KillWindow(binding.WindowID); err != nil {
+		report = append(report, i18n.T("archive.kill_failed", err))
+	} else {
+		report = append(report, i18n.T("archive.killed"))
+	}
+	b.unbind(key, binding)
+	report = append(report, i18n.T("archive.unbound"))
 
-	case strings.HasPrefix(data, "kill:"):
-		windowID := strings.TrimPrefix(data, "kill:")
-		b.tmux.KillWindow(windowID)
-		// 清理所有绑定到该窗口的 binding
-		for tk, bd := range b.store.AllBindings() {
-			if bd.WindowID == windowID {
-				b.unbind(tk, bd)
-			}
-		}
-		b.sendMsg(ctx, chatID, threadID, "✅ 已关闭窗口", nil)
+	closeTopic := strings.HasPrefix(key, "topic:")
+	if closeTopic {
+		// 先发送报告，关闭后的 Topic 无法再发消息
+		report = append(report, i18n.T("archive.closing_topic"))
+	}
+	b.sendReply(ctx, msg, i18n.T("archive.done", binding.DisplayName, strings.Join(report, "\n")))
 
-	case strings.HasPrefix(data, "ss:"):
-		// 截图控制键盘回调
-		parts := strings.SplitN(strings.TrimPrefix(data, "ss:"), ":", 2)
-		if len(parts) == 2 {
-			b.handleScreenshotAction(ctx, chatID, threadID, parts[0], parts[1])
+	if closeTopic {
+		if _, err := tgBot.CloseForumTopic(ctx, &bot.CloseForumTopicParams{ChatID: msg.Chat.ID, MessageThreadID: msg.MessageThreadID}); err != nil {
+			slog.Warn("close forum topic failed", "key", key, "error", err)
+			b.sendReply(ctx, msg, i18n.T("archive.close_topic_failed", err))
 		}
+	}
+	slog.Info("session archived", "key", key, "window", binding.WindowID)
+}
 
-	case strings.HasPrefix(data, "nav:"):
-		// 交互式导航键盘回调
-		parts := strings.SplitN(strings.TrimPrefix(data, "nav:"), ":", 2)
+// handlePause /pause 命令：暂停推送输出（后端继续运行）
+func (b *Bot) handlePause(ctx context.Context, tgBot *bot.Bot, update *models.Update) {
+	if update.Message == nil {
+		return
+	}
+	msg := update.Message
+	key := topicKeyFromMessage(msg)
+	if _, ok := b.store.GetBinding(key); !ok {
+		b.sendReply(ctx, msg, i18n.T("session.not_bound"))
+		return
+	}
+	if _, paused := b.store.GetPause(key); paused {
+		b.sendReply(ctx, msg, i18n.T("pause.already"))
+		return
+	}
+	b.store.SetPause(key, time.Now())
+	b.sendReply(ctx, msg, i18n.T("pause.done"))
+}
+
+// handleResume /resume 命令：恢复推送并汇总暂停期间跳过的消息
+func (b *Bot) handleResume(ctx context.Context, tgBot *bot.Bot, update *models.Update) {
+	if update.Message == nil {
+		return
+	}
+	msg := update.Message
+	key := topicKeyFromMessage(msg)
+	if p, ok := b.store.ClearPause(key); ok {
+		b.sendReply(ctx, msg, i18n.T("pause.resumed")+pauseSummary(p))
+		return
+	}
+
+	// 未暂停时：claude 会话显示历史会话选择
+	binding, ok := b.store.GetBinding(key)
+	if !ok || backend.Type(binding.Backend) != backend.TypeClaude || binding.ProjectPath == "" {
+		b.sendReply(ctx, msg, i18n.T("resume.not_paused"))
+		return
+	}
+	be := backend.Get(backend.TypeClaude, b.cfg)
+	sessions, err := monitor.ListClaudeSessions(be.LogDirFunc(binding.ProjectPath), maxResumeSessions)
+	if err != nil || len(sessions) == 0 {
+		b.sendReply(ctx, msg, i18n.T("resume.no_sessions"))
+		return
+	}
+	var rows [][]models.InlineKeyboardButton
+	for _, s := range sessions {
+		label := s.ModTime.Format("01-02 15:04")
+		if s.Preview != "" {
+			label += " · " + truncateRunes(s.Preview, 40)
+		}
+		rows = append(rows, []models.InlineKeyboardButton{{Text: label, CallbackData: "resume:" + s.UUID}})
+	}
+	rows = append(rows, []models.InlineKeyboardButton{{Text: i18n.T("common.cancel_button"), CallbackData: "noop"}})
+	b.sendReplyWithKeyboard(ctx, msg, i18n.T("resume.choose"), models.InlineKeyboardMarkup{InlineKeyboard: rows})
+}
+
+// resumeSession 在新窗口中以 claude --resume 恢复历史会话，并替换当前绑定
+func (b *Bot) resumeSession(ctx context.Context, key string, chatID int64, threadID int, sessionID string) {
+	binding, ok := b.store.GetBinding(key)
+	if !ok {
+		b.sendMsg(ctx, chatID, threadID, i18n.T("session.not_bound"), nil)
+		return
+	}
+	be := backend.Get(backend.TypeClaude, b.cfg)
+	logFile := filepath.Join(be.LogDirFunc(binding.ProjectPath), sessionID+".jsonl")
+	if _, err := os.Stat(logFile); err != nil {
+		b.sendMsg(ctx, chatID, threadID, i18n.T("resume.file_missing"), nil)
+		return
+	}
+	b.unbindKeepSettings(key, binding)
+	b.startSession(ctx, key, chatID, threadID, backend.TypeClaude, binding.ProjectPath, sessionOpts{
+		extraArgs:  []string{"--resume", sessionID},
+		resumeFile: logFile,
+	})
+}
+
+// pauseSummary 生成暂停期间的跳过统计，如 "暂停 12m，跳过 34 条消息（5 条回答，20 次工具调用）"
+func pauseSummary(p state.Pause) string {
+	dur := time.Since(p.Since).Truncate(time.Minute)
+	if dur < time.Minute {
+		dur = time.Since(p.Since).Truncate(time.Second)
+	}
+	return i18n.T("pause.summary", dur, skippedSummary(p.Skipped))
+}
+
+// skippedSummary 按类别汇总跳过的消息数
+func skippedSummary(skipped map[string]int) string {
+	total := 0
+	for _, n := range skipped {
+		total += n
+	}
+	summary := i18n.T("skipped.total", total)
+
+	var parts []string
+	if n := skipped[kindAnswers]; n > 0 {
+		parts = append(parts, i18n.T("skipped.answers", n))
+	}
+	if n := skipped[kindThinking]; n > 0 {
+		parts = append(parts, i18n.T("skipped.thinking", n))
+	}
+	if n := skipped[kindToolCalls]; n > 0 {
+		parts = append(parts, i18n.T("skipped.tool_calls", n))
+	}
+	if n := skipped[kindToolResults]; n > 0 {
+		parts = append(parts, i18n.T("skipped.tool_results", n))
+	}
+	if len(parts) > 0 {
+		summary += i18n.T("skipped.detail", strings.Join(parts, i18n.T("common.list_sep")))
+	}
+	return summary
+}
+
+// handleMute /mute [时长] 命令：静音指定时长，无参数时显示剩余时间
+func (b *Bot) handleMute(ctx context.Context, tgBot *bot.Bot, update *models.Update) {
+	if update.Message == nil {
+		return
+	}
+	msg := update.Message
+	key := topicKeyFromMessage(msg)
+	arg := strings.TrimSpace(strings.TrimPrefix(msg.Text, "/mute"))
+
+	if arg == "" {
+		m, ok := b.store.GetMute(key)
+		if !ok || !time.Now().Before(m.Until) {
+			b.sendReply(ctx, msg, i18n.T("mute.not_muted_usage"))
+			return
+		}
+		remaining := time.Until(m.Until).Truncate(time.Second)
+		b.sendReply(ctx, msg, i18n.T("mute.active", remaining, m.Until.Format("15:04")))
+		return
+	}
+
+	if _, ok := b.store.GetBinding(key); !ok {
+		b.sendReply(ctx, msg, i18n.T("session.not_bound"))
+		return
+	}
+	dur, err := time.ParseDuration(arg)
+	if err != nil || dur <= 0 {
+		b.sendReply(ctx, msg, i18n.T("mute.invalid"))
+		return
+	}
+	until := time.Now().Add(dur)
+	b.store.SetMute(key, until)
+	b.sendReply(ctx, msg, i18n.T("mute.done", dur, until.Format("15:04")))
+}
+
+// handleUnmute /unmute 命令：提前解除静音
+func (b *Bot) handleUnmute(ctx context.Context, tgBot *bot.Bot, update *models.Update) {
+	if update.Message == nil {
+		return
+	}
+	msg := update.Message
+	key := topicKeyFromMessage(msg)
+	m, ok := b.store.ClearMute(key)
+	if !ok {
+		b.sendReply(ctx, msg, i18n.T("mute.not_muted"))
+		return
+	}
+	b.sendReply(ctx, msg, i18n.T("mute.unmuted")+skippedSummary(m.Skipped))
+}
+
+// handleStats /stats 命令：显示当前会话的 token 用量
+func (b *Bot) handleStats(ctx context.Context, tgBot *bot.Bot, update *models.Update) {
+	if update.Message == nil {
+		return
+	}
+	msg := update.Message
+	key := topicKeyFromMessage(msg)
+	binding, ok := b.store.GetBinding(key)
+	if !ok {
+		b.sendReply(ctx, msg, i18n.T("session.not_bound"))
+		return
+	}
+	bt := backend.Type(binding.Backend)
+	if bt != backend.TypeClaude && bt != backend.TypeCodex {
+		b.sendReply(ctx, msg, i18n.T("stats.unsupported", binding.Backend))
+		return
+	}
+
+	u := b.dispatcher.Usage(key)
+	dur := time.Since(binding.CreatedAt).Truncate(time.Minute)
+	reply := i18n.T("stats.report",
+		formatCount(u.InputTokens), formatCount(u.OutputTokens), formatCount(u.CacheReadTokens), formatCount(u.CacheWriteTokens),
+		u.Turns, u.ToolCalls, dur)
+	b.sendReply(ctx, msg, reply)
+}
+
+// formatCount 千分位格式化数字，如 1234567 → "1,234,567"
+func formatCount(n int64) string {
+	s := strconv.FormatInt(n, 10)
+	if n < 0 {
+		return "-" + formatCount(-n)
+	}
+	for i := len(s) - 3; i > 0; i -= 3 {
+		s = s[:i] + "," + s[i:]
+	}
+	return s
+}
+
+// handlePing /ping 命令：报告 bot → tmux → 后端 → 监控 → 推送 全链路状态
+func (b *Bot) handlePing(ctx context.Context, tgBot *bot.Bot, update *models.Update) {
+	if update.Message == nil {
+		return
+	}
+	msg := update.Message
+	key := topicKeyFromMessage(msg)
+
+	okMark := func(ok bool) string {
+		if ok {
+			return "✅"
+		}
+		return "❌"
+	}
+
+	start := time.Now()
+	_, err := b.bot.GetMe(ctx)
+	apiLatency := time.Since(start).Truncate(time.Millisecond)
+
+	var lines []string
+	lines = append(lines, i18n.T("ping.pong"))
+	lines = append(lines, i18n.T("ping.telegram_api", okMark(err == nil), apiLatency))
+	if rl := b.pushers.RateLimited(); rl > 0 {
+		lines = append(lines, i18n.T("ping.rate_limited", rl.Truncate(time.Second)))
+	}
+	sessionAlive := b.tmux.SessionAlive()
+
+	binding, ok := b.store.GetBinding(key)
+	if !ok {
+		windows, _ := b.tmux.ListWindows()
+		lines = append(lines, i18n.T("ping.tmux_session", okMark(sessionAlive)))
+		lines = append(lines, i18n.T("ping.windows_unbound", len(windows)))
+		b.sendReply(ctx, msg, strings.Join(lines, "\n"))
+		return
+	}
+
+	windowAlive := b.windowAlive(binding)
+	paneCmd := "-"
+	if windowAlive {
+		if c := b.tmux.PaneCommand(binding.WindowID); c != "" {
+			paneCmd = c
+		}
+	}
+	lastSent := i18n.T("ping.never")
+	if t := b.pushers.LastSent(key); !t.IsZero() {
+		lastSent = i18n.T("ping.ago", time.Since(t).Truncate(time.Second))
+	}
+
+	lines = append(lines, i18n.T("ping.tmux_session", okMark(sessionAlive)))
+	lines = append(lines, i18n.T("ping.window", binding.WindowID, okMark(windowAlive)))
+	lines = append(lines, i18n.T("ping.backend", okMark(windowAlive && b.backendAlive(binding)), paneCmd))
+	lines = append(lines, i18n.T("ping.monitor", okMark(b.dispatcher.HasMonitor(key))))
+	if iv, ok := b.dispatcher.PollInterval(key); ok {
+		lines = append(lines, i18n.T("ping.poll_interval", iv))
+	}
+	lines = append(lines, i18n.T("ping.push_queue", b.pushers.QueueLen(key)))
+	lines = append(lines, i18n.T("ping.last_push", lastSent))
+	b.sendReply(ctx, msg, strings.Join(lines, "\n"))
+}
+
+// handleSearch /search <关键词> 命令：在会话 JSONL 历史中搜索助手回答
+func (b *Bot) handleSearch(ctx context.Context, tgBot *bot.Bot, update *models.Update) {
+	if update.Message == nil {
+		return
+	}
+	msg := update.Message
+	key := topicKeyFromMessage(msg)
+	binding, ok := b.store.GetBinding(key)
+	if !ok {
+		b.sendReply(ctx, msg, i18n.T("session.not_bound"))
+		return
+	}
+	query := strings.TrimSpace(strings.TrimPrefix(msg.Text, "/search"))
+	if query == "" {
+		b.sendReply(ctx, msg, i18n.T("search.usage"))
+		return
+	}
+
+	bt := backend.Type(binding.Backend)
+	logFile := b.dispatcher.LogFile(key)
+	if (bt != backend.TypeClaude && bt != backend.TypeCodex) || logFile == "" {
+		b.sendReply(ctx, msg, i18n.T("search.unsupported", binding.Backend))
+		return
+	}
+
+	hits, err := monitor.SearchSession(logFile, bt, query, maxSearchHits)
+	if err != nil {
+		b.sendReply(ctx, msg, i18n.T("search.failed", err))
+		return
+	}
+	if len(hits) == 0 {
+		b.sendReply(ctx, msg, i18n.T("search.not_found", query))
+		return
+	}
+
+	var lines []string
+	lines = append(lines, i18n.T("search.results", query, len(hits)))
+	for _, h := range hits {
+		when := "?"
+		if !h.Timestamp.IsZero() {
+			when = h.Timestamp.Local().Format("01-02 15:04")
+		}
+		lines = append(lines, fmt.Sprintf("\n[%s] %s", when, h.Snippet))
+	}
+	b.pushers.Replay(ctx, key, msg.Chat.ID, msg.MessageThreadID, []monitor.ParsedContent{
+		{Type: monitor.ContentText, Text: strings.Join(lines, "\n")},
+	})
+}
+
+// handleGit /git status|log [N]|branch 命令：在项目目录直接执行 git（不经过 tmux 窗口）
+func (b *Bot) handleGit(ctx context.Context, tgBot *bot.Bot, update *models.Update) {
+	if update.Message == nil {
+		return
+	}
+	msg := update.Message
+	key := topicKeyFromMessage(msg)
+	binding, ok := b.store.GetBinding(key)
+	if !ok {
+		b.sendReply(ctx, msg, i18n.T("session.not_bound"))
+		return
+	}
+	if binding.ProjectPath == "" {
+		b.sendReply(ctx, msg, i18n.T("git.no_project"))
+		return
+	}
+
+	usage := i18n.T("git.usage")
+	fields := strings.Fields(strings.TrimPrefix(msg.Text, "/git"))
+	if len(fields) == 0 {
+		b.sendReply(ctx, msg, usage)
+		return
+	}
+	var args []string
+	switch fields[0] {
+	case "status":
+		args = []string{"status", "--short", "--branch"}
+	case "log":
+		n := defaultGitLogCount
+		if len(fields) > 1 {
+			v, err := strconv.Atoi(fields[1])
+			if err != nil || v <= 0 {
+				b.sendReply(ctx, msg, usage)
+				return
+			}
+			n = min(v, maxGitLogCount)
+		}
+		args = []string{"log", "--oneline", "--decorate", "-n", strconv.Itoa(n)}
+	case "branch":
+		args = []string{"branch", "-vv"}
+	default:
+		b.sendReply(ctx, msg, usage)
+		return
+	}
+
+	out, err := runGit(ctx, binding.ProjectPath, gitTimeout, args...)
+	if err != nil {
+		b.sendReply(ctx, msg, i18n.T("git.failed", fields[0], err))
+		return
+	}
+	if out == "" {
+		out = i18n.T("git.no_output")
+	}
+	if r := []rune(out); len(r) > maxGitOutput {
+		out = string(r[:maxGitOutput]) + i18n.T("common.truncated")
+	}
+	b.sendReplyHTML(ctx, msg, fmt.Sprintf("<b>git %s</b> @ %s\n<pre>%s</pre>", fields[0], escapeHTML(binding.ProjectPath), escapeHTML(out)))
+}
+
+// handleTop /top 命令：显示后端进程树的 CPU / 内存 / 运行时长
+func (b *Bot) handleTop(ctx context.Context, tgBot *bot.Bot, update *models.Update) {
+	if update.Message == nil {
+		return
+	}
+	msg := update.Message
+	key := topicKeyFromMessage(msg)
+	binding, ok := b.store.GetBinding(key)
+	if !ok {
+		b.sendReply(ctx, msg, i18n.T("session.not_bound"))
+		return
+	}
+	pid, err := b.tmux.PanePID(binding.WindowID)
+	if err != nil {
+		b.sendReply(ctx, msg, i18n.T("top.pane_failed", err))
+		return
+	}
+	procs, err := tmux.ProcessTree(pid)
+	if err != nil {
+		b.sendReply(ctx, msg, i18n.T("top.ps_failed", err))
+		return
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%-7s %5s %7s %11s  %s\n", "PID", "CPU%", "RSS", "ELAPSED", "COMMAND")
+	var totalCPU float64
+	var totalRSS int64
+	for i, p := range procs {
+		if i >= maxTopProcesses {
+			sb.WriteString(i18n.T("top.more", len(procs)-i))
+			break
+		}
+		cmdName := strings.Repeat(" ", p.Depth) + filepath.Base(p.Command)
+		fmt.Fprintf(&sb, "%-7d %5.1f %7s %11s  %s\n", p.PID, p.CPU, formatRSS(p.RSSKB), p.Elapsed, truncateRunes(cmdName, 24))
+	}
+	for _, p := range procs {
+		totalCPU += p.CPU
+		totalRSS += p.RSSKB
+	}
+	sb.WriteString(i18n.T("top.total", len(procs), totalCPU, formatRSS(totalRSS)))
+	b.sendReplyHTML(ctx, msg, fmt.Sprintf("📈 %s\n<pre>%s</pre>", escapeHTML(binding.DisplayName), escapeHTML(sb.String())))
+}
+
+// formatRSS 将 KB 格式化为 K/M/G
+func formatRSS(kb int64) string {
+	switch {
+	case kb >= 1024*1024:
+		return fmt.Sprintf("%.1fG", float64(kb)/(1024*1024))
+	case kb >= 1024:
+		return fmt.Sprintf("%.1fM", float64(kb)/1024)
+	default:
+		return fmt.Sprintf("%dK", kb)
+	}
+}
+
+// runGit 在 dir 中执行 git 命令，超时后终止；非仓库目录返回友好错误
+func runGit(ctx context.Context, dir string, timeout time.Duration, args ...string) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0", "GIT_PAGER=cat")
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() == context.DeadlineExceeded {
+			return "", errors.New(i18n.T("common.timeout", timeout))
+		}
+		errText := strings.TrimSpace(stderr.String())
+		if strings.Contains(errText, "not a git repository") {
+			return "", errors.New(i18n.T("git.not_repo", dir))
+		}
+		if errText != "" {
+			return "", fmt.Errorf("%s", errText)
+		}
+		return "", err
+	}
+	return strings.TrimRight(stdout.String(), "\n"), nil
+}
+
+// handleEnv /env set|unset|list 命令：管理当前 Topic 启动后端前导出的环境变量
+func (b *Bot) handleEnv(ctx context.Context, tgBot *bot.Bot, update *models.Update) {
+	if update.Message == nil {
+		return
+	}
+	msg := update.Message
+	key := topicKeyFromMessage(msg)
+
+	usage := i18n.T("env.usage")
+	sub, rest, _ := strings.Cut(strings.TrimSpace(strings.TrimPrefix(msg.Text, "/env")), " ")
+	rest = strings.TrimSpace(rest)
+	switch sub {
+	case "set":
+		name, value, ok := strings.Cut(rest, "=")
+		if !ok || !envNamePattern.MatchString(name) {
+			b.sendReply(ctx, msg, usage)
+			return
+		}
+		b.store.SetEnv(key, name, value)
+		sanitize.AddSecret(value)
+		// 删除包含明文值的原消息（失败忽略）
+		tgBot.DeleteMessage(ctx, &bot.DeleteMessageParams{ChatID: msg.Chat.ID, MessageID: msg.ID})
+		b.sendReply(ctx, msg, i18n.T("env.set", name))
+	case "unset":
+		if !envNamePattern.MatchString(rest) {
+			b.sendReply(ctx, msg, usage)
+			return
+		}
+		if !b.store.UnsetEnv(key, rest) {
+			b.sendReply(ctx, msg, i18n.T("env.not_set", rest))
+			return
+		}
+		b.sendReply(ctx, msg, i18n.T("env.unset", rest))
+	case "list", "":
+		env := b.store.GetEnv(key)
+		if len(env) == 0 {
+			b.sendReply(ctx, msg, i18n.T("env.empty"))
+			return
+		}
+		names := make([]string, 0, len(env))
+		for name := range env {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		lines := []string{i18n.T("env.list")}
+		for _, name := range names {
+			lines = append(lines, fmt.Sprintf("%s=%s", name, sanitize.Mask(env[name])))
+		}
+		b.sendReply(ctx, msg, strings.Join(lines, "\n"))
+	default:
+		b.sendReply(ctx, msg, usage)
+	}
+}
+
+// shellQuote 用单引号包裹值，供 shell 安全使用
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// envCommand 生成设置环境变量的 shell 命令：先应用后端配置的变量（空值为 unset），
+// 再导出 /env 设置的变量，同名时后者生效；没有变量时返回空
+func envCommand(backendEnv map[string]string, topicEnv state.EnvVars) string {
+	var cmds []string
+	for _, name := range sortedKeys(backendEnv) {
+		if backendEnv[name] == "" {
+			cmds = append(cmds, "unset "+name)
+		} else {
+			cmds = append(cmds, fmt.Sprintf("export %s=%s", name, shellQuote(backendEnv[name])))
+		}
+	}
+	for _, name := range sortedKeys(topicEnv) {
+		cmds = append(cmds, fmt.Sprintf("export %s=%s", name, shellQuote(topicEnv[name])))
+	}
+	return strings.Join(cmds, "; ")
+}
+
+// sortedKeys 按名称排序的键
+func sortedKeys[M ~map[string]V, V any](m M) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// safePath 路径中没有换行等控制字符：以按键输入到 shell 时，换行会把命令提前提交，引号也无法保护
+func safePath(path string) bool {
+	return !strings.ContainsAny(path, "\r\n\x00")
+}
+
+// pendingBroadcast 等待确认的广播
+type pendingBroadcast struct {
+	Text      string
+	SkipBash  bool
+	CreatedAt time.Time
+}
+
+// handleBroadcast /broadcast [--no-bash] <消息> 命令：确认后发送到所有已绑定会话（仅管理员）
+func (b *Bot) handleBroadcast(ctx context.Context, tgBot *bot.Bot, update *models.Update) {
+	if update.Message == nil {
+		return
+	}
+	msg := update.Message
+	if msg.From == nil || !b.auth.IsAdmin(msg.From.ID) {
+		b.sendReply(ctx, msg, i18n.T("broadcast.admin_only"))
+		return
+	}
+	key := topicKeyFromMessage(msg)
+	text := strings.TrimSpace(strings.TrimPrefix(msg.Text, "/broadcast"))
+	skipBash := false
+	if rest, ok := strings.CutPrefix(text, "--no-bash"); ok {
+		skipBash = true
+		text = strings.TrimSpace(rest)
+	}
+	if text == "" {
+		b.sendReply(ctx, msg, i18n.T("broadcast.usage"))
+		return
+	}
+
+	bindings := b.store.AllBindings()
+	if len(bindings) == 0 {
+		b.sendReply(ctx, msg, i18n.T("broadcast.no_bindings"))
+		return
+	}
+	targets, bashCount := 0, 0
+	for _, binding := range bindings {
+		if backend.Type(binding.Backend) == backend.TypeBash {
+			bashCount++
+			if skipBash {
+				continue
+			}
+		}
+		targets++
+	}
+
+	ts := b.getOrCreateState(key)
+	b.statesMu.Lock()
+	ts.Broadcast = &pendingBroadcast{Text: text, SkipBash: skipBash, CreatedAt: time.Now()}
+	b.statesMu.Unlock()
+
+	prompt := i18n.T("broadcast.confirm", targets, truncateRunes(text, 200))
+	if bashCount > 0 && !skipBash {
+		prompt += i18n.T("broadcast.bash_warning", bashCount)
+	}
+	kb := models.InlineKeyboardMarkup{InlineKeyboard: [][]models.InlineKeyboardButton{
+		{
+			{Text: i18n.T("common.send"), CallbackData: "broadcast:yes"},
+			{Text: i18n.T("common.cancel_button"), CallbackData: "broadcast:no"},
+		},
+	}}
+	b.sendReplyWithKeyboard(ctx, msg, prompt, kb)
+}
+
+// confirmBroadcast 执行已确认的广播并回复投递报告
+func (b *Bot) confirmBroadcast(ctx context.Context, key string, chatID int64, threadID int, userID int64, confirmed bool) {
+	b.statesMu.Lock()
+	var pb *pendingBroadcast
+	if s, ok := b.states[key]; ok {
+		pb, s.Broadcast = s.Broadcast, nil
+	}
+	b.statesMu.Unlock()
+
+	if !confirmed {
+		b.sendMsg(ctx, chatID, threadID, i18n.T("broadcast.cancelled"), nil)
+		return
+	}
+	if !b.auth.IsAdmin(userID) {
+		b.sendMsg(ctx, chatID, threadID, i18n.T("broadcast.admin_only"), nil)
+		return
+	}
+	if pb == nil || time.Since(pb.CreatedAt) > broadcastConfirmTTL {
+		b.sendMsg(ctx, chatID, threadID, i18n.T("broadcast.expired"), nil)
+		return
+	}
+
+	bindings := b.store.AllBindings()
+	keys := make([]string, 0, len(bindings))
+	for k := range bindings {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	sent := 0
+	lines := []string{}
+	for _, k := range keys {
+		binding := bindings[k]
+		status := i18n.T("broadcast.sent")
+		switch {
+		case pb.SkipBash && backend.Type(binding.Backend) == backend.TypeBash:
+			status = i18n.T("broadcast.skipped_bash")
+		case !b.windowAlive(binding):
+			status = i18n.T("broadcast.window_closed")
+		default:
+			select {
+			case b.getOrCreateSendChan(binding.WindowID) <- &queuedInput{Text: pb.Text}:
+				sent++
+			default:
+				status = i18n.T("broadcast.queue_full")
+			}
+		}
+		lines = append(lines, fmt.Sprintf("%s — %s", binding.DisplayName, status))
+	}
+	slog.Info("broadcast sent", "from", key, "sent", sent, "total", len(keys))
+	b.sendMsg(ctx, chatID, threadID, i18n.T("broadcast.done", sent, len(keys), strings.Join(lines, "\n")), nil)
+}
+
+// worktreeInfo git worktree 及其主仓库
+type worktreeInfo struct {
+	Repo string
+	Path string
+}
+
+// handleWorktree /worktree <分支> 命令：在 /new 流程中为所选仓库创建 worktree 并改用该目录
+func (b *Bot) handleWorktree(ctx context.Context, tgBot *bot.Bot, update *models.Update) {
+	if update.Message == nil {
+		return
+	}
+	msg := update.Message
+	key := topicKeyFromMessage(msg)
+	branch := strings.TrimSpace(strings.TrimPrefix(msg.Text, "/worktree"))
+	if branch == "" || strings.ContainsAny(branch, " \t") {
+		b.sendReply(ctx, msg, i18n.T("worktree.usage"))
+		return
+	}
+	ts := b.getOrCreateState(key)
+	if ts.Phase != "awaiting_backend" || ts.SelectedDir == "" {
+		b.sendReply(ctx, msg, i18n.T("worktree.need_dir"))
+		return
+	}
+
+	repo, err := runGit(ctx, ts.SelectedDir, gitTimeout, "rev-parse", "--show-toplevel")
+	if err != nil {
+		b.sendReply(ctx, msg, fmt.Sprintf("❌ %v", err))
+		return
+	}
+	wtPath := filepath.Join(filepath.Dir(repo), filepath.Base(repo)+"-"+strings.ReplaceAll(branch, "/", "-"))
+
+	// 分支已存在则直接检出，否则基于当前 HEAD 新建
+	args := []string{"worktree", "add", "-b", branch, wtPath}
+	if _, err := runGit(ctx, repo, gitTimeout, "rev-parse", "--verify", "--quiet", "refs/heads/"+branch); err == nil {
+		args = []string{"worktree", "add", wtPath, branch}
+	}
+	if _, err := runGit(ctx, repo, worktreeTimeout, args...); err != nil {
+		b.sendReply(ctx, msg, i18n.T("worktree.add_failed", err))
+		return
+	}
+
+	ts.SelectedDir = wtPath
+	ts.Worktree = &worktreeInfo{Repo: repo, Path: wtPath}
+	b.chooseBackend(ctx, key, msg.Chat.ID, msg.MessageThreadID, i18n.T("worktree.created", wtPath, branch))
+}
+
+// removeWorktree 删除已关闭会话的 worktree
+func (b *Bot) removeWorktree(ctx context.Context, chatID int64, threadID int, windowID string) {
+	v, ok := b.worktrees.LoadAndDelete(windowID)
+	if !ok {
+		b.sendMsg(ctx, chatID, threadID, i18n.T("worktree.expired"), nil)
+		return
+	}
+	wt := v.(worktreeInfo)
+	if _, err := runGit(ctx, wt.Repo, worktreeTimeout, "worktree", "remove", wt.Path); err != nil {
+		b.sendMsg(ctx, chatID, threadID, i18n.T("worktree.remove_failed", err), nil)
+		return
+	}
+	b.sendMsg(ctx, chatID, threadID, i18n.T("worktree.removed", wt.Path), nil)
+}
+
+// handleClone /clone 命令：以相同目录和后端再开一个会话
+func (b *Bot) handleClone(ctx context.Context, tgBot *bot.Bot, update *models.Update) {
+	if update.Message == nil {
+		return
+	}
+	msg := update.Message
+	key := topicKeyFromMessage(msg)
+	binding, ok := b.store.GetBinding(key)
+	if !ok {
+		b.sendReply(ctx, msg, i18n.T("session.not_bound"))
+		return
+	}
+	if binding.ProjectPath == "" || binding.Backend == "unknown" {
+		b.sendReply(ctx, msg, i18n.T("clone.missing_info"))
+		return
+	}
+
+	// 论坛群组：创建未绑定的窗口，可在其他 Topic 中绑定
+	if strings.HasPrefix(key, "topic:") {
+		windowID, err := b.launchBackend(backend.Type(binding.Backend), binding.ProjectPath, nil, b.store.GetEnv(key))
+		if err != nil {
+			b.sendReply(ctx, msg, i18n.T("session.create_window_failed", err))
+			return
+		}
+		b.store.AddRecent(binding.ProjectPath)
+		kb := models.InlineKeyboardMarkup{InlineKeyboard: [][]models.InlineKeyboardButton{
+			{{Text: i18n.T("clone.bind_here"), CallbackData: fmt.Sprintf("bind:%s", windowID)}},
+		}}
+		b.sendReplyWithKeyboard(ctx, msg, i18n.T("clone.created_unbound", binding.Backend, binding.ProjectPath, windowID), kb)
+		return
+	}
+
+	// 私聊 / 普通群：替换当前绑定前先确认
+	kb := models.InlineKeyboardMarkup{InlineKeyboard: [][]models.InlineKeyboardButton{
+		{
+			{Text: i18n.T("clone.replace_button"), CallbackData: "clone:yes"},
+			{Text: i18n.T("common.cancel_button"), CallbackData: "noop"},
+		},
+	}}
+	b.sendReplyWithKeyboard(ctx, msg, i18n.T("clone.confirm_replace", binding.Backend, binding.ProjectPath), kb)
+}
+
+// cloneReplace 克隆当前会话并替换当前 topic 的绑定
+func (b *Bot) cloneReplace(ctx context.Context, key string, chatID int64, threadID int) {
+	binding, ok := b.store.GetBinding(key)
+	if !ok {
+		b.sendMsg(ctx, chatID, threadID, i18n.T("session.not_bound"), nil)
+		return
+	}
+	b.unbindKeepSettings(key, binding)
+	b.getOrCreateState(key).SelectedDir = binding.ProjectPath
+	b.createSession(ctx, key, chatID, threadID, backend.Type(binding.Backend), binding.Profile)
+}
+
+// handleQueue /queue [clear] 命令：查看或清空输入/输出队列
+func (b *Bot) handleQueue(ctx context.Context, tgBot *bot.Bot, update *models.Update) {
+	if update.Message == nil {
+		return
+	}
+	msg := update.Message
+	key := topicKeyFromMessage(msg)
+	binding, ok := b.store.GetBinding(key)
+	if !ok {
+		b.sendReply(ctx, msg, i18n.T("session.not_bound"))
+		return
+	}
+
+	switch arg := strings.TrimSpace(strings.TrimPrefix(msg.Text, "/queue")); arg {
+	case "":
+	case "clear":
+		n := b.drainSendChan(binding.WindowID)
+		b.sendReply(ctx, msg, i18n.T("queue.cleared", n))
+		return
+	default:
+		b.sendReply(ctx, msg, i18n.T("queue.usage"))
+		return
+	}
+
+	rateLimit := i18n.T("common.no")
+	if until := b.pushers.RateLimitedUntil(); !until.IsZero() {
+		rateLimit = i18n.T("queue.rate_limited", until.Format("15:04:05"), time.Until(until).Truncate(time.Second))
+	}
+	reply := i18n.T("queue.status",
+		b.sendQueueLen(binding.WindowID), b.pushers.QueueLen(key), rateLimit, b.pushers.Overflowed(key))
+	b.sendReply(ctx, msg, reply)
+}
+
+// handleClear /clear [N] 命令：删除当前 thread 中 bot 最近发送的 N 条消息
+func (b *Bot) handleClear(ctx context.Context, tgBot *bot.Bot, update *models.Update) {
+	if update.Message == nil {
+		return
+	}
+	msg := update.Message
+	n := defaultClearCount
+	if arg := strings.TrimSpace(strings.TrimPrefix(msg.Text, "/clear")); arg != "" {
+		v, err := strconv.Atoi(arg)
+		if err != nil || v <= 0 {
+			b.sendReply(ctx, msg, i18n.T("clear.usage"))
+			return
+		}
+		n = min(v, sentLogCap)
+	}
+
+	deleted, failed := b.pushers.DeleteRecent(ctx, msg.Chat.ID, msg.MessageThreadID, n)
+	if deleted == 0 && failed == 0 {
+		b.sendReply(ctx, msg, i18n.T("clear.nothing"))
+		return
+	}
+	reply := i18n.T("clear.done", deleted)
+	if failed > 0 {
+		reply += i18n.T("clear.failed", failed)
+	}
+	// 回执本身不计入记录，避免下次 /clear 只删掉回执
+	params := &bot.SendMessageParams{ChatID: msg.Chat.ID, Text: reply}
+	if msg.MessageThreadID != 0 {
+		params.MessageThreadID = msg.MessageThreadID
+	}
+	b.bot.SendMessage(ctx, params)
+}
+
+// handleDir /dir 命令
+func (b *Bot) handleDir(ctx context.Context, tgBot *bot.Bot, update *models.Update) {
+	if update.Message == nil {
+		return
+	}
+	msg := update.Message
+	text := strings.TrimSpace(strings.TrimPrefix(msg.Text, "/dir"))
+
+	if strings.HasPrefix(text, "add ") {
+		path := strings.TrimSpace(strings.TrimPrefix(text, "add "))
+		if path == "" {
+			b.sendReply(ctx, msg, i18n.T("dir.add_usage"))
+			return
+		}
+		if !safePath(path) {
+			b.sendReply(ctx, msg, i18n.T("flow.path_invalid"))
+			return
+		}
+		b.store.AddFavorite(expandHome(path))
+		b.sendReply(ctx, msg, i18n.T("dir.favorited", path))
+		return
+	}
+
+	if strings.HasPrefix(text, "rm ") {
+		path := strings.TrimSpace(strings.TrimPrefix(text, "rm "))
+		if path == "" {
+			b.sendReply(ctx, msg, i18n.T("dir.rm_usage"))
+			return
+		}
+		b.store.RemoveFavorite(expandHome(path))
+		b.sendReply(ctx, msg, i18n.T("dir.unfavorited", path))
+		return
+	}
+
+	if strings.HasPrefix(text, "browse") {
+		path := strings.TrimSpace(strings.TrimPrefix(text, "browse"))
+		if path == "" {
+			path, _ = os.UserHomeDir()
+		}
+		path = expandHome(path)
+		entries, err := listSubDirs(path, false, b.cfg.Dirs.Ignore)
+		if err != nil {
+			b.sendReply(ctx, msg, i18n.T("dir.browse_failed", err))
+			return
+		}
+		kb := BrowseDirKeyboard(path, entries, 0, b.cfg.Dirs.PageSize, false, b.pathData)
+		b.sendReplyWithKeyboard(ctx, msg, fmt.Sprintf("📂 %s", path), kb)
+		return
+	}
+
+	// 默认：列出收藏+最近
+	dirs := b.store.GetDirs()
+	var lines []string
+	lines = append(lines, i18n.T("dir.title"))
+	if len(dirs.Favorites) > 0 {
+		lines = append(lines, i18n.T("dir.favorites"))
+		for _, f := range dirs.Favorites {
+			lines = append(lines, "  "+f)
+		}
+	}
+	if len(dirs.Recent) > 0 {
+		lines = append(lines, i18n.T("dir.recent"))
+		for _, r := range dirs.Recent {
+			lines = append(lines, "  "+r)
+		}
+	}
+	if len(dirs.Favorites) == 0 && len(dirs.Recent) == 0 {
+		lines = append(lines, i18n.T("dir.empty"))
+	}
+	b.sendReply(ctx, msg, strings.Join(lines, "\n"))
+}
+
+// handleCallback 处理内联键盘回调
+func (b *Bot) handleCallback(ctx context.Context, tgBot *bot.Bot, update *models.Update) {
+	if update.CallbackQuery == nil {
+		return
+	}
+	cq := update.CallbackQuery
+	key := topicKeyFromCallback(cq)
+	if key == "" {
+		slog.Warn("callback: empty key, ignoring", "data", cq.Data)
+		return
+	}
+	data := cq.Data
+	slog.Info("handleCallback", "key", key, "data", data)
+
+	// 用户已响应，之后相同的交互界面/确认提示需要重新通知
+	b.pushers.ResetPrompt(key)
+
+	// Answer callback 消除加载状态；选择不可用的后端时弹出原因
+	answer := &bot.AnswerCallbackQueryParams{CallbackQueryID: cq.ID}
+	if t, ok := strings.CutPrefix(data, "backend:"); ok {
+		answer.Text = b.backendUnavailable(backend.Type(t))
+		answer.ShowAlert = answer.Text != ""
+	}
+	tgBot.AnswerCallbackQuery(ctx, answer)
+
+	// 获取原始消息用于回复
+	var chatID int64
+	var threadID int
+	if msg := cq.Message.Message; msg != nil {
+		chatID = msg.Chat.ID
+		threadID = msg.MessageThreadID
+	}
+
+	data, ok := b.resolvePathData(ctx, chatID, threadID, data)
+	if !ok {
+		return
+	}
+
+	switch {
+	case strings.HasPrefix(data, "backend:"):
+		if answer.Text != "" {
+			break
+		}
+		backendType := backend.Type(strings.TrimPrefix(data, "backend:"))
+		// 配置了启动参数组合时先选择组合
+		if profiles := backend.Profiles(backendType, b.cfg); len(profiles) > 0 {
+			kb := ProfileKeyboard(backendType, profiles)
+			b.setPromptMsg(key, b.sendMsg(ctx, chatID, threadID, i18n.T("flow.choose_profile", backendType), &kb))
+			break
+		}
+		b.createSession(ctx, key, chatID, threadID, backendType, "")
+
+	case strings.HasPrefix(data, "profile:"):
+		bt, profile, _ := strings.Cut(strings.TrimPrefix(data, "profile:"), ":")
+		b.createSession(ctx, key, chatID, threadID, backend.Type(bt), profile)
+
+	case strings.HasPrefix(data, "dir:"):
+		dirPath := strings.TrimPrefix(data, "dir:")
+		ts := b.getOrCreateState(key)
+		ts.SelectedDir = dirPath
+		b.chooseBackend(ctx, key, chatID, threadID, "")
+
+	case strings.HasPrefix(data, "rebackend:"):
+		b.rechooseBackend(ctx, key, chatID, threadID, strings.TrimPrefix(data, "rebackend:"))
+
+	case data == "dir_input":
+		b.setPhase(key, "awaiting_path_input")
+		b.setPromptMsg(key, b.sendMsg(ctx, chatID, threadID, i18n.T("flow.enter_path"), nil))
+
+	case data == "cancel_flow":
+		b.resetFlow(key)
+		if msg := cq.Message.Message; msg != nil {
+			tgBot.DeleteMessage(ctx, &bot.DeleteMessageParams{ChatID: chatID, MessageID: msg.ID})
+		}
+		b.sendMsg(ctx, chatID, threadID, i18n.T("common.cancelled"), nil)
+
+	case data == "clone:yes":
+		b.cloneReplace(ctx, key, chatID, threadID)
+
+	case data == "broadcast:yes", data == "broadcast:no":
+		b.confirmBroadcast(ctx, key, chatID, threadID, cq.From.ID, data == "broadcast:yes")
+
+	case strings.HasPrefix(data, "reap:"):
+		b.handleReapCallback(ctx, key, chatID, threadID, data)
+
+	case strings.HasPrefix(data, "wtrm:"):
+		b.removeWorktree(ctx, chatID, threadID, strings.TrimPrefix(data, "wtrm:"))
+
+	case strings.HasPrefix(data, "resend:"):
+		b.resendEdited(ctx, key, chatID, threadID, strings.TrimPrefix(data, "resend:"))
+
+	case strings.HasPrefix(data, "resend_no:"):
+		id, _ := strconv.Atoi(strings.TrimPrefix(data, "resend_no:"))
+		b.edits.Delete(queuedKey(chatID, id))
+		if msg := cq.Message.Message; msg != nil {
+			tgBot.DeleteMessage(ctx, &bot.DeleteMessageParams{ChatID: chatID, MessageID: msg.ID})
+		}
+
+	case strings.HasPrefix(data, "fwd:"):
+		b.sendForwarded(ctx, key, chatID, threadID, strings.TrimPrefix(data, "fwd:"))
+
+	case strings.HasPrefix(data, "fwd_no:"):
+		id, _ := strconv.Atoi(strings.TrimPrefix(data, "fwd_no:"))
+		b.forwards.Delete(queuedKey(chatID, id))
+		if msg := cq.Message.Message; msg != nil {
+			tgBot.DeleteMessage(ctx, &bot.DeleteMessageParams{ChatID: chatID, MessageID: msg.ID})
+		}
+
+	case strings.HasPrefix(data, "resume:"):
+		b.resumeSession(ctx, key, chatID, threadID, strings.TrimPrefix(data, "resume:"))
+
+	case strings.HasPrefix(data, "bind:"):
+		windowID := strings.TrimPrefix(data, "bind:")
+		b.bindExisting(ctx, key, chatID, threadID, windowID)
+
+	case data == "new_session":
+		b.setPhase(key, "awaiting_dir")
+		dirs := b.store.GetDirs()
+		kb := DirKeyboard(dirs.Favorites, dirs.Recent, 0, b.cfg.Dirs.PageSize, b.pathData)
+		b.setPromptMsg(key, b.sendMsg(ctx, chatID, threadID, i18n.T("flow.choose_dir"), &kb))
+
+	case strings.HasPrefix(data, "dirpage:"):
+		// 原地翻页，页码完全由 callback data 携带
+		page, _ := strconv.Atoi(strings.TrimPrefix(data, "dirpage:"))
+		dirs := b.store.GetDirs()
+		if msg := cq.Message.Message; msg != nil {
+			tgBot.EditMessageReplyMarkup(ctx, &bot.EditMessageReplyMarkupParams{
+				ChatID:      chatID,
+				MessageID:   msg.ID,
+				ReplyMarkup: DirKeyboard(dirs.Favorites, dirs.Recent, page, b.cfg.Dirs.PageSize, b.pathData),
+			})
+		}
+
+	case strings.HasPrefix(data, "confirm:"):
+		parts := strings.SplitN(strings.TrimPrefix(data, "confirm:"), ":", 2)
+		if len(parts) == 2 {
+			b.handleConfirm(ctx, key, parts[1], parts[0])
+		}
+
+	case strings.HasPrefix(data, "browse:"), strings.HasPrefix(data, "hbrowse:"):
+		_, dirPath, _ := strings.Cut(data, ":")
+		if msg := cq.Message.Message; msg != nil {
+			b.editBrowse(ctx, chatID, msg.ID, dirPath, 0, data[0] == 'h')
+		}
+
+	case strings.HasPrefix(data, "browsepage:"), strings.HasPrefix(data, "hbrowsepage:"):
+		_, rest, _ := strings.Cut(data, ":")
+		pageStr, dirPath, _ := strings.Cut(rest, ":")
+		page, _ := strconv.Atoi(pageStr)
+		if msg := cq.Message.Message; msg != nil {
+			b.editBrowse(ctx, chatID, msg.ID, dirPath, page, data[0] == 'h')
+		}
+
+	case strings.HasPrefix(data, "fav:"):
+		dirPath := strings.TrimPrefix(data, "fav:")
+		b.store.AddFavorite(dirPath)
+		b.sendMsg(ctx, chatID, threadID, i18n.T("dir.favorited", dirPath), nil)
+
+	case strings.HasPrefix(data, "sesspage:"):
+		page, _ := strconv.Atoi(strings.TrimPrefix(data, "sesspage:"))
+		b.editSessionList(ctx, cq, page)
+
+	case strings.HasPrefix(data, "clean:"):
+		// 清理窗口已不存在的残留绑定
+		windowID := strings.TrimPrefix(data, "clean:")
+		if !b.tmux.IsWindowAlive(windowID) {
+			for tk, bd := range b.store.AllBindings() {
+				if bd.WindowID == windowID {
+					b.unbind(tk, bd)
+				}
+			}
+		}
+		b.editSessionList(ctx, cq, 0)
+
+	case strings.HasPrefix(data, "kill:"):
+		// 先改为二次确认，避免误触
+		text, kb := b.killPrompt(strings.TrimPrefix(data, "kill:"))
+		if msg := cq.Message.Message; msg != nil {
+			tgBot.EditMessageText(ctx, &bot.EditMessageTextParams{ChatID: chatID, MessageID: msg.ID, Text: text, ReplyMarkup: kb})
+		}
+
+	case strings.HasPrefix(data, "killyes:"):
+		if msg := cq.Message.Message; msg != nil {
+			tgBot.DeleteMessage(ctx, &bot.DeleteMessageParams{ChatID: chatID, MessageID: msg.ID})
+		}
+		b.killSession(ctx, chatID, threadID, strings.TrimPrefix(data, "killyes:"))
+
+	case strings.HasPrefix(data, "killno:"):
+		if msg := cq.Message.Message; msg != nil {
+			tgBot.EditMessageText(ctx, &bot.EditMessageTextParams{ChatID: chatID, MessageID: msg.ID, Text: i18n.T("common.cancelled")})
+		}
+
+	case strings.HasPrefix(data, "ctrl:"):
+		parts := strings.SplitN(strings.TrimPrefix(data, "ctrl:"), ":", 2)
+		if len(parts) == 2 {
+			b.sendCtrl(ctx, chatID, threadID, parts[1], parts[0])
+		}
+
+	case strings.HasPrefix(data, "ss:"):
+		// 截图控制键盘回调
+		parts := strings.SplitN(strings.TrimPrefix(data, "ss:"), ":", 2)
+		if len(parts) == 2 {
+			b.handleScreenshotAction(ctx, key, chatID, threadID, parts[0], parts[1])
+		}
+
+	case strings.HasPrefix(data, "plan:"):
+		// 计划审批回调：plan:<approve|keep|reject>:<windowID>
+		parts := strings.SplitN(strings.TrimPrefix(data, "plan:"), ":", 2)
+		if len(parts) == 2 {
+			b.handlePlanAction(ctx, key, chatID, threadID, parts[0], parts[1])
+		}
+
+	case strings.HasPrefix(data, "menu:"):
+		// 菜单选项回调：menu:key:<数字>:<windowID> / menu:move:<偏移>:<windowID>
+		parts := strings.SplitN(strings.TrimPrefix(data, "menu:"), ":", 3)
+		if len(parts) == 3 {
+			b.handleMenuOption(ctx, chatID, threadID, parts[0], parts[1], parts[2])
+		}
+
+	case strings.HasPrefix(data, "out:"):
+		b.showToolOutput(ctx, chatID, threadID, strings.TrimPrefix(data, "out:"))
+
+	case strings.HasPrefix(data, "pane:"):
+		b.selectPane(ctx, key, chatID, threadID, strings.TrimPrefix(data, "pane:"))
+
+	case strings.HasPrefix(data, "nav:"):
+		// 交互式导航键盘回调
+		parts := strings.SplitN(strings.TrimPrefix(data, "nav:"), ":", 2)
 		if len(parts) == 2 {
 			b.handleNavAction(ctx, chatID, threadID, parts[0], parts[1])
 		}
 	}
 }
 
-// createSession 创建新会话
-func (b *Bot) createSession(ctx context.Context, key string, chatID int64, threadID int, backendType backend.Type) {
-	ts := b.getOrCreateState(key)
-	if ts.SelectedDir == "" {
-		b.sendMsg(ctx, chatID, threadID, "错误：未选择目录", nil)
-		return
-	}
-
+// launchBackend 创建 tmux 窗口，cd 到项目目录并启动后端命令，返回窗口 ID
+func (b *Bot) launchBackend(backendType backend.Type, dir string, extraArgs []string, env state.EnvVars) (string, error) {
 	be := backend.Get(backendType, b.cfg)
-	dirName := filepath.Base(ts.SelectedDir)
-	windowName := fmt.Sprintf("%s-%s", backendType, dirName)
+	if backendType == backend.TypeClaudeHeadless {
+		return newHeadlessWindowID(), nil
+	}
+	if !safePath(dir) {
+		return "", errors.New(i18n.T("flow.path_invalid"))
+	}
+	windowName := b.uniqueWindowName(fmt.Sprintf("%s-%s", backendType, filepath.Base(dir)))
 
 	// 创建 tmux 窗口
 	windowID, err := b.tmux.NewWindow(windowName)
 	if err != nil {
-		b.sendMsg(ctx, chatID, threadID, fmt.Sprintf("创建窗口失败: %v", err), nil)
-		return
+		return "", err
 	}
 
 	// cd 到项目目录
-	b.tmux.SendKeys(windowID, fmt.Sprintf("cd %s", ts.SelectedDir))
+	b.tmux.SendKeys(windowID, "cd "+shellQuote(dir))
 	b.tmux.SendEnter(windowID)
 
-	// 清理可能阻止嵌套启动的环境变量
-	b.tmux.SendKeys(windowID, "unset CLAUDECODE CLAUDE_CODE 2>/dev/null; true")
-	b.tmux.SendEnter(windowID)
+	// 导出后端配置与 /env 设置的环境变量（前导空格避免写入 shell 历史）
+	if cmd := envCommand(be.Env, env); cmd != "" {
+		b.tmux.SendKeys(windowID, " "+cmd)
+		b.tmux.SendEnter(windowID)
+	}
 
 	// 启动后端命令（bash 跳过）
 	if backendType != backend.TypeBash && be.Command != "" {
-		time.Sleep(500 * time.Millisecond) // 等待 cd + unset 完成
+		// 等待 cd + unset 完成，否则命令可能在提示符出现前输入而被吞掉或打乱
+		if !b.waitShellReady(windowID, dir) {
+			b.tmux.KillWindow(windowID)
+			return "", errors.New(i18n.T("session.shell_not_ready", shellReadyTimeout))
+		}
 		cmd := be.Command
-		if len(be.Args) > 0 {
-			cmd += " " + strings.Join(be.Args, " ")
+		if args := append(append([]string{}, be.Args...), extraArgs...); len(args) > 0 {
+			cmd += " " + strings.Join(args, " ")
 		}
 		b.tmux.SendKeys(windowID, cmd)
 		b.tmux.SendEnter(windowID)
 	}
+	return windowID, nil
+}
+
+const (
+	// shellReadyTimeout 等待新窗口的 shell 执行完 cd 等准备命令的时长
+	shellReadyTimeout = 10 * time.Second
+	// shellReadyPoll 检查 shell 是否就绪的间隔
+	shellReadyPoll = 100 * time.Millisecond
+)
+
+// waitShellReady 让 shell 输出一行带随机标记与当前目录的哨兵，等到它出现且目录为 dir 时返回 true：
+// 此时之前的命令都已执行完，shell 在等待输入。超时（shell 卡住、cd 失败）返回 false
+func (b *Bot) waitShellReady(windowID, dir string) bool {
+	token := "tgmux-ready-" + strconv.FormatInt(time.Now().UnixNano(), 36)
+	// 输入行中是 $PWD 字面量，只有执行后的输出才会包含展开的目录
+	b.tmux.SendKeys(windowID, fmt.Sprintf(" echo %s:$PWD", token))
+	b.tmux.SendEnter(windowID)
+	want := token + ":" + filepath.Clean(dir)
+	for deadline := time.Now().Add(shellReadyTimeout); time.Now().Before(deadline); time.Sleep(shellReadyPoll) {
+		pane, err := b.tmux.CapturePaneJoined(windowID)
+		if err != nil {
+			continue
+		}
+		for _, line := range strings.Split(pane, "\n") {
+			if strings.TrimSpace(line) == want {
+				return true
+			}
+		}
+	}
+	slog.Warn("shell not ready before launching backend", "window", windowID, "dir", dir)
+	return false
+}
+
+// uniqueWindowName 窗口名已存在时追加数字后缀，如 claude-app-2
+func (b *Bot) uniqueWindowName(name string) string {
+	windows, _ := b.tmux.ListWindows()
+	taken := make(map[string]bool, len(windows))
+	for _, w := range windows {
+		taken[w.Name] = true
+	}
+	if !taken[name] {
+		return name
+	}
+	for i := 2; ; i++ {
+		candidate := fmt.Sprintf("%s-%d", name, i)
+		if !taken[candidate] {
+			return candidate
+		}
+	}
+}
+
+// chooseBackend 目录已选定：该目录有默认后端时直接创建会话，否则显示后端选择键盘。
+// note 为可选的前置说明（如已创建 worktree），直接创建时单独发送
+func (b *Bot) chooseBackend(ctx context.Context, key string, chatID int64, threadID int, note string) {
+	ts := b.getOrCreateState(key)
+	b.setPhase(key, "awaiting_backend")
+	if bt := b.defaultBackendFor(ts.SelectedDir); bt != "" {
+		if note != "" {
+			b.sendMsg(ctx, chatID, threadID, note, nil)
+		}
+		ts.ByDefault = true
+		b.createSession(ctx, key, chatID, threadID, bt, "")
+		return
+	}
+	text := i18n.T("flow.choose_backend")
+	if note != "" {
+		text = note + "\n" + text
+	}
+	kb := BackendKeyboard(backend.Types(b.cfg), b.backends)
+	b.setPromptMsg(key, b.sendMsg(ctx, chatID, threadID, text, &kb))
+}
+
+// defaultBackendFor 返回目录的默认后端（dirs.dir_backends 优先），没有时返回空
+func (b *Bot) defaultBackendFor(dir string) backend.Type {
+	if bt, ok := b.dirBackends[filepath.Clean(dir)]; ok {
+		return bt
+	}
+	return b.defaultBackend
+}
+
+// rechooseBackend 关闭以默认后端创建的会话，回到同一目录的后端选择
+func (b *Bot) rechooseBackend(ctx context.Context, key string, chatID int64, threadID int, windowID string) {
+	binding, ok := b.store.GetBinding(key)
+	if !ok || binding.WindowID != windowID {
+		b.sendMsg(ctx, chatID, threadID, i18n.T("flow.change_backend_expired"), nil)
+		return
+	}
+	b.tmux.KillWindow(windowID)
+	b.unbindKeepSettings(key, binding)
+	ts := b.getOrCreateState(key)
+	ts.SelectedDir = binding.ProjectPath
+	if binding.WorktreeRepo != "" {
+		ts.Worktree = &worktreeInfo{Repo: binding.WorktreeRepo, Path: binding.ProjectPath}
+	}
+	b.setPhase(key, "awaiting_backend")
+	kb := BackendKeyboard(backend.Types(b.cfg), b.backends)
+	b.setPromptMsg(key, b.sendMsg(ctx, chatID, threadID, i18n.T("flow.choose_backend"), &kb))
+}
+
+// createSession 创建新会话
+func (b *Bot) createSession(ctx context.Context, key string, chatID int64, threadID int, backendType backend.Type, profile string) {
+	// 旧键盘上可能还留着已停用的后端
+	if reason := b.backendUnavailable(backendType); reason != "" {
+		b.sendMsg(ctx, chatID, threadID, reason, nil)
+		return
+	}
+	ts := b.getOrCreateState(key)
+	if ts.SelectedDir == "" {
+		b.sendMsg(ctx, chatID, threadID, i18n.T("flow.no_dir"), nil)
+		return
+	}
+	var opts sessionOpts
+	if profile != "" {
+		args, ok := backend.Profiles(backendType, b.cfg)[profile]
+		if !ok {
+			b.sendMsg(ctx, chatID, threadID, i18n.T("flow.profile_not_found", profile), nil)
+			return
+		}
+		opts.extraArgs = args
+		opts.profile = profile
+	}
+	if ts.Worktree != nil && ts.Worktree.Path == ts.SelectedDir {
+		opts.worktreeRepo = ts.Worktree.Repo
+	}
+	ts.Worktree = nil
+	opts.byDefault, ts.ByDefault = ts.ByDefault, false
+	b.startSession(ctx, key, chatID, threadID, backendType, ts.SelectedDir, opts)
+}
+
+// sessionOpts 创建会话的附加选项
+type sessionOpts struct {
+	extraArgs    []string // 追加到后端命令后的参数
+	resumeFile   string   // 预锁定监控的 JSONL 文件（从末尾开始读取，不等待新文件）
+	worktreeRepo string   // dir 为该仓库的 worktree
+	profile      string   // 选择的启动参数组合，记录到绑定
+	byDefault    bool     // 后端由默认设置选定，创建消息附带更换按钮
+}
+
+// startSession 启动后端窗口并绑定到 topic
+func (b *Bot) startSession(ctx context.Context, key string, chatID int64, threadID int, backendType backend.Type, dir string, opts sessionOpts) {
+	dirName := filepath.Base(dir)
+	windowID, err := b.launchBackend(backendType, dir, opts.extraArgs, b.store.GetEnv(key))
+	if err != nil {
+		b.sendMsg(ctx, chatID, threadID, i18n.T("session.create_window_failed", err), nil)
+		return
+	}
 
 	// 设置绑定
 	binding := state.Binding{
 		WindowID:    windowID,
 		Backend:     string(backendType),
-		ProjectPath: ts.SelectedDir,
+		ProjectPath: dir,
 		DisplayName: fmt.Sprintf("%s @ %s", backendType, dirName),
 		CreatedAt:   time.Now(),
 		Status:      "running",
 	}
+	binding.WorktreeRepo = opts.worktreeRepo
+	binding.Profile = opts.profile
+	b.trackPane(&binding)
+
+	// 论坛 General 中新建的会话移到独立 Topic
+	if topicKey, topicThread, ok := b.createSessionTopic(ctx, key, binding.DisplayName); ok {
+		b.resetFlow(key)
+		key, threadID = topicKey, topicThread
+	}
 	b.store.SetBinding(key, binding)
-	b.store.AddRecent(ts.SelectedDir)
+	b.store.AddRecent(dir)
+
+	// 恢复已有会话：从文件末尾开始监控，避免重放历史
+	if opts.resumeFile != "" {
+		if info, err := os.Stat(opts.resumeFile); err == nil {
+			b.store.SetOffset(key, state.Offset{File: opts.resumeFile, ByteOffset: info.Size()})
+		}
+	}
 
 	// 初始化串行发送 channel
 	b.getOrCreateSendChan(windowID)
@@ -571,15 +2234,23 @@ func (b *Bot) createSession(ctx context.Context, key string, chatID int64, threa
 	// 重置状态机
 	b.setPhase(key, "bound")
 
-	b.sendMsg(ctx, chatID, threadID, fmt.Sprintf("✅ 已创建 %s 会话 @ %s", backendType, ts.SelectedDir), nil)
-	slog.Info("session created", "key", key, "backend", backendType, "dir", ts.SelectedDir, "window", windowID)
+	if opts.byDefault {
+		kb := models.InlineKeyboardMarkup{InlineKeyboard: [][]models.InlineKeyboardButton{
+			{{Text: i18n.T("kb.rebackend"), CallbackData: "rebackend:" + windowID}},
+		}}
+		b.sendMsg(ctx, chatID, threadID, i18n.T("session.created", backendType, dir)+"\n"+i18n.T("flow.default_backend_note", backendType), &kb)
+	} else {
+		b.sendMsg(ctx, chatID, threadID, i18n.T("session.created", backendType, dir), nil)
+	}
+	b.showReplyKeyboard(ctx, chatID, threadID)
+	b.syncTopicName(ctx, key, binding.DisplayName)
+	slog.Info("session created", "key", key, "backend", backendType, "dir", dir, "window", windowID)
 }
 
 // bindExisting 绑定已有窗口
 func (b *Bot) bindExisting(ctx context.Context, key string, chatID int64, threadID int, windowID string) {
-	// 检查后端是否还在运行
-	if !b.tmux.IsBackendAlive(windowID) {
-		b.sendMsg(ctx, chatID, threadID, "⚠️ 该窗口的后端进程已退出，无法绑定", nil)
+	if b.tmux.PaneCommand(windowID) == "" {
+		b.sendMsg(ctx, chatID, threadID, i18n.T("bind.backend_exited"), nil)
 		return
 	}
 
@@ -593,39 +2264,65 @@ func (b *Bot) bindExisting(ctx context.Context, key string, chatID int64, thread
 		}
 	}
 
+	// 按窗口中运行的进程识别后端，识别不出时使用终端捕获
+	bt, detected := backend.Detect(b.cfg, b.tmux.PaneProcesses(windowID))
+	if !detected {
+		bt = "unknown"
+	}
 	binding := state.Binding{
 		WindowID:    windowID,
-		Backend:     "unknown",
-		ProjectPath: "",
+		Backend:     string(bt),
+		ProjectPath: b.tmux.PanePath(windowID),
 		DisplayName: windowName,
 		CreatedAt:   time.Now(),
 		Status:      "running",
 	}
+	b.trackPane(&binding)
 	b.store.SetBinding(key, binding)
+	if binding.ProjectPath != "" {
+		b.store.AddRecent(binding.ProjectPath)
+	}
 	b.getOrCreateSendChan(windowID)
 
+	// 会话已在进行：从当前会话文件的末尾开始监控，只推送之后的输出
+	if be := backend.Get(bt, b.cfg); (bt == backend.TypeClaude || bt == backend.TypeCodex) && be.LogDirFunc != nil && binding.ProjectPath != "" {
+		if file := monitor.LatestLog(be.LogDirFunc(binding.ProjectPath), bt); file != "" {
+			if info, err := os.Stat(file); err == nil {
+				b.store.SetOffset(key, state.Offset{File: file, ByteOffset: info.Size()})
+			}
+		}
+	}
+
 	// 启动输出监控
 	b.StartMonitorForBinding(ctx, key, binding, chatID, threadID)
 
 	b.setPhase(key, "bound")
 
-	b.sendMsg(ctx, chatID, threadID, fmt.Sprintf("🔗 已绑定到窗口 %s (%s)", windowID, windowName), nil)
+	text := i18n.T("bind.done", windowID, windowName) + "\n"
+	if detected {
+		text += i18n.T("bind.detected", bt)
+	} else {
+		text += i18n.T("bind.undetected")
+	}
+	b.sendMsg(ctx, chatID, threadID, text, nil)
+	b.showReplyKeyboard(ctx, chatID, threadID)
+	b.syncTopicName(ctx, key, binding.DisplayName)
 }
 
-// handleConfirm 处理权限确认
+// handleConfirm 处理权限确认，按键序列由话题的后端决定
 func (b *Bot) handleConfirm(ctx context.Context, key string, windowID string, action string) {
-	switch action {
-	case "yes":
-		b.tmux.SendKeys(windowID, "y")
-		b.tmux.SendEnter(windowID)
-	case "no":
-		b.tmux.SendKeys(windowID, "n")
-		b.tmux.SendEnter(windowID)
-	case "always":
-		// 发送 always allow（具体命令因后端而异）
-		b.tmux.SendKeys(windowID, "!")
-		b.tmux.SendEnter(windowID)
+	for _, k := range b.topicBackend(key).ConfirmKeys(action) {
+		b.tmux.SendSpecialKey(windowID, k)
+	}
+}
+
+// topicBackend 返回话题绑定的后端，未绑定（或绑定的后端未知）时为零值，确认提示使用默认的 y / n
+func (b *Bot) topicBackend(key string) backend.Backend {
+	binding, ok := b.store.GetBinding(key)
+	if !ok {
+		return backend.Backend{}
 	}
+	return backend.Get(backend.Type(binding.Backend), b.cfg)
 }
 
 // handleTopicClosed 论坛话题关闭时自动清理
@@ -653,14 +2350,183 @@ var specialKeyMap = map[string]string{
 	"ctrlc": "C-c",
 }
 
+// tmuxKeyNames /key 命令允许发送的 tmux 键名
+var tmuxKeyNames = []string{
+	"Up", "Down", "Left", "Right",
+	"Enter", "Escape", "Tab", "BTab", "Space", "BSpace",
+	"Home", "End", "PageUp", "PageDown", "Insert", "Delete",
+	"F1", "F2", "F3", "F4", "F5", "F6", "F7", "F8", "F9", "F10", "F11", "F12",
+}
+
+// resolveKeyName 将用户输入的键名解析为 tmux 键名，支持大小写不敏感、
+// specialKeyMap 中的别名（esc、ctrlc 等）以及 C-<字母>/M-<字母> 组合键
+func resolveKeyName(name string) (string, bool) {
+	for _, k := range tmuxKeyNames {
+		if strings.EqualFold(k, name) {
+			return k, true
+		}
+	}
+	if k, ok := specialKeyMap[strings.ToLower(name)]; ok {
+		return k, true
+	}
+	if len(name) == 3 && name[1] == '-' {
+		mod := strings.ToUpper(name[:1])
+		c := strings.ToLower(name[2:])
+		if (mod == "C" || mod == "M") && c[0] >= 'a' && c[0] <= 'z' {
+			return mod + "-" + c, true
+		}
+	}
+	return "", false
+}
+
+// handleInterrupt /interrupt 命令：按后端声明的按键序列中断当前生成
+func (b *Bot) handleInterrupt(ctx context.Context, tgBot *bot.Bot, update *models.Update) {
+	if update.Message == nil {
+		return
+	}
+	msg := update.Message
+	key := topicKeyFromMessage(msg)
+	binding, ok := b.store.GetBinding(key)
+	if !ok {
+		b.sendReply(ctx, msg, i18n.T("session.not_bound"))
+		return
+	}
+	if !b.windowAlive(binding) {
+		b.sendReply(ctx, msg, i18n.T("session.window_gone"))
+		return
+	}
+
+	if isHeadless(binding) {
+		if hm := b.dispatcher.Headless(binding.WindowID); hm != nil && hm.Interrupt() {
+			b.sendReply(ctx, msg, i18n.T("interrupt.done", "SIGINT"))
+		} else {
+			b.sendReply(ctx, msg, i18n.T("interrupt.idle"))
+		}
+		return
+	}
+
+	bt := backend.Type(binding.Backend)
+	be := backend.Get(bt, b.cfg)
+	keys := be.Interrupt()
+
+	attempts := 1
+	if be.InterruptTwice {
+		attempts = 2
+	}
+	for i := 0; i < attempts; i++ {
+		for _, k := range keys {
+			if err := b.tmux.SendSpecialKey(binding.WindowID, k); err != nil {
+				b.sendReply(ctx, msg, i18n.T("interrupt.failed", err))
+				return
+			}
+		}
+		time.Sleep(500 * time.Millisecond)
+		if !b.isBusy(bt, binding.WindowID) {
+			b.sendReply(ctx, msg, i18n.T("interrupt.done", strings.Join(keys, " ")))
+			return
+		}
+	}
+	b.sendReply(ctx, msg, i18n.T("interrupt.still_busy"))
+}
+
+// isBusy 判断后端是否仍在执行：bash 看前台进程是否回到 shell，其余看终端状态行
+func (b *Bot) isBusy(bt backend.Type, windowID string) bool {
+	if bt == backend.TypeBash {
+		return backend.Get(bt, b.cfg).Alive(b.tmux.PaneCommand(windowID))
+	}
+	text, err := b.tmux.CapturePaneJoined(windowID)
+	if err != nil {
+		return false
+	}
+	return monitor.DetectBusy(text)
+}
+
+// handleKey /key <键名>... 命令：按顺序发送任意 tmux 键
+func (b *Bot) handleKey(ctx context.Context, tgBot *bot.Bot, update *models.Update) {
+	if update.Message == nil {
+		return
+	}
+	msg := update.Message
+	key := topicKeyFromMessage(msg)
+	binding, ok := b.store.GetBinding(key)
+	if !ok {
+		b.sendReply(ctx, msg, i18n.T("session.not_bound"))
+		return
+	}
+
+	usage := i18n.T("key.usage", strings.Join(tmuxKeyNames, ", "))
+	args := strings.Fields(strings.TrimPrefix(msg.Text, "/key"))
+	if len(args) == 0 {
+		b.sendReply(ctx, msg, usage)
+		return
+	}
+
+	// 先全部校验，避免发送一半
+	keys := make([]string, 0, len(args))
+	for _, arg := range args {
+		k, ok := resolveKeyName(arg)
+		if !ok {
+			b.sendReply(ctx, msg, i18n.T("key.unknown", arg, usage))
+			return
+		}
+		keys = append(keys, k)
+	}
+	for _, k := range keys {
+		if err := b.tmux.SendSpecialKey(binding.WindowID, k); err != nil {
+			b.sendReply(ctx, msg, i18n.T("key.failed", err))
+			return
+		}
+	}
+	b.sendReply(ctx, msg, i18n.T("key.sent", strings.Join(keys, " ")))
+}
+
+// handleCtrl /ctrl <字母> 命令：发送 C-<字母> 组合键
+func (b *Bot) handleCtrl(ctx context.Context, tgBot *bot.Bot, update *models.Update) {
+	if update.Message == nil {
+		return
+	}
+	msg := update.Message
+	key := topicKeyFromMessage(msg)
+	binding, ok := b.store.GetBinding(key)
+	if !ok {
+		b.sendReply(ctx, msg, i18n.T("session.not_bound"))
+		return
+	}
+	if !b.windowAlive(binding) {
+		b.sendReply(ctx, msg, i18n.T("session.window_gone"))
+		return
+	}
+
+	letter := strings.ToLower(strings.TrimSpace(strings.TrimPrefix(msg.Text, "/ctrl")))
+	if len(letter) != 1 || letter[0] < 'a' || letter[0] > 'z' {
+		b.sendReply(ctx, msg, i18n.T("ctrl.usage"))
+		return
+	}
+
+	// Ctrl-D 可能直接退出后端，需要二次确认
+	if letter == "d" {
+		kb := CtrlConfirmKeyboard(letter, binding.WindowID)
+		b.sendReplyWithKeyboard(ctx, msg, i18n.T("ctrl.confirm_d"), kb)
+		return
+	}
+	b.sendCtrl(ctx, msg.Chat.ID, msg.MessageThreadID, binding.WindowID, letter)
+}
+
+// sendCtrl 发送 C-<字母> 并回执
+func (b *Bot) sendCtrl(ctx context.Context, chatID int64, threadID int, windowID string, letter string) {
+	if err := b.tmux.SendSpecialKey(windowID, "C-"+letter); err != nil {
+		b.sendMsg(ctx, chatID, threadID, i18n.T("key.failed", err), nil)
+		return
+	}
+	b.sendMsg(ctx, chatID, threadID, i18n.T("ctrl.sent", strings.ToUpper(letter)), nil)
+}
+
 // handleScreenshotAction 处理截图控制键盘按钮
-func (b *Bot) handleScreenshotAction(ctx context.Context, chatID int64, threadID int, action string, windowID string) {
+func (b *Bot) handleScreenshotAction(ctx context.Context, key string, chatID int64, threadID int, action string, windowID string) {
 	if action == "y" {
-		b.tmux.SendKeys(windowID, "y")
-		b.tmux.SendEnter(windowID)
+		b.handleConfirm(ctx, key, windowID, "yes")
 	} else if action == "n" {
-		b.tmux.SendKeys(windowID, "n")
-		b.tmux.SendEnter(windowID)
+		b.handleConfirm(ctx, key, windowID, "no")
 	} else if action != "refresh" {
 		if keyName, ok := specialKeyMap[action]; ok {
 			b.tmux.SendSpecialKey(windowID, keyName)
@@ -683,8 +2549,32 @@ func (b *Bot) handleNavAction(ctx context.Context, chatID int64, threadID int, a
 	b.sendScreenshotToChat(ctx, chatID, threadID, windowID)
 }
 
-// sendMsg 发送消息到指定 chat/thread
-func (b *Bot) sendMsg(ctx context.Context, chatID int64, threadID int, text string, kb *models.InlineKeyboardMarkup) {
+// handleMenuOption 选择交互式菜单的选项：发送数字键，或移动光标后回车，完成后截图
+func (b *Bot) handleMenuOption(ctx context.Context, chatID int64, threadID int, kind, arg, windowID string) {
+	switch kind {
+	case "key":
+		b.tmux.SendKeys(windowID, arg)
+	case "move":
+		delta, err := strconv.Atoi(arg)
+		if err != nil {
+			return
+		}
+		keyName := "Down"
+		if delta < 0 {
+			keyName, delta = "Up", -delta
+		}
+		for i := 0; i < delta; i++ {
+			b.tmux.SendSpecialKey(windowID, keyName)
+		}
+		b.tmux.SendEnter(windowID)
+	default:
+		return
+	}
+	b.handleNavAction(ctx, chatID, threadID, "refresh", windowID)
+}
+
+// sendMsg 发送消息到指定 chat/thread，返回消息 ID（失败为 0）
+func (b *Bot) sendMsg(ctx context.Context, chatID int64, threadID int, text string, kb *models.InlineKeyboardMarkup) int {
 	params := &bot.SendMessageParams{
 		ChatID: chatID,
 		Text:   text,
@@ -695,7 +2585,7 @@ func (b *Bot) sendMsg(ctx context.Context, chatID int64, threadID int, text stri
 	if kb != nil {
 		params.ReplyMarkup = *kb
 	}
-	b.bot.SendMessage(ctx, params)
+	return b.pushers.sendAndRecord(ctx, params)
 }
 
 // expandHome 展开 ~ 路径
@@ -707,17 +2597,56 @@ func expandHome(path string) string {
 	return path
 }
 
-// listSubDirs 列出目录下的子目录
-func listSubDirs(path string) ([]DirEntry, error) {
+// editSessionList 在原消息上刷新会话列表键盘
+func (b *Bot) editSessionList(ctx context.Context, cq *models.CallbackQuery, page int) {
+	msg := cq.Message.Message
+	if msg == nil {
+		return
+	}
+	windows, _ := b.tmux.ListWindows()
+	b.bot.EditMessageReplyMarkup(ctx, &bot.EditMessageReplyMarkupParams{
+		ChatID:      msg.Chat.ID,
+		MessageID:   msg.ID,
+		ReplyMarkup: SessionListKeyboard(b.sessionInfos(windows), page),
+	})
+}
+
+// editBrowse 在原消息上切换到指定目录的某一页
+func (b *Bot) editBrowse(ctx context.Context, chatID int64, msgID int, dirPath string, page int, showHidden bool) {
+	entries, err := listSubDirs(dirPath, showHidden, b.cfg.Dirs.Ignore)
+	if err != nil {
+		return
+	}
+	b.bot.EditMessageText(ctx, &bot.EditMessageTextParams{
+		ChatID:      chatID,
+		MessageID:   msgID,
+		Text:        fmt.Sprintf("📂 %s", dirPath),
+		ReplyMarkup: BrowseDirKeyboard(dirPath, entries, page, b.cfg.Dirs.PageSize, showHidden, b.pathData),
+	})
+}
+
+// listSubDirs 列出目录下的子目录（含指向目录的符号链接），按名称大小写不敏感排序；
+// showHidden 为 false 时跳过以 . 开头的目录，ignore 中的目录名始终跳过
+func listSubDirs(path string, showHidden bool, ignore []string) ([]DirEntry, error) {
 	entries, err := os.ReadDir(path)
 	if err != nil {
 		return nil, err
 	}
 	var dirs []DirEntry
 	for _, e := range entries {
-		if e.IsDir() && !strings.HasPrefix(e.Name(), ".") {
+		if (!showHidden && strings.HasPrefix(e.Name(), ".")) || slices.Contains(ignore, e.Name()) {
+			continue
+		}
+		isDir := e.IsDir()
+		if !isDir && e.Type()&os.ModeSymlink != 0 {
+			if info, err := os.Stat(filepath.Join(path, e.Name())); err == nil {
+				isDir = info.IsDir()
+			}
+		}
+		if isDir {
 			dirs = append(dirs, DirEntry{Name: e.Name(), IsDir: true})
 		}
 	}
+	sort.Slice(dirs, func(i, j int) bool { return strings.ToLower(dirs[i].Name) < strings.ToLower(dirs[j].Name) })
 	return dirs, nil
 }
@@ -0,0 +1,138 @@
+package bot
+
+import (
+	"os/exec"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/user/tgmux/state"
+)
+
+func TestShellQuote(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"", `''`},
+		{"plain", `'plain'`},
+		{"with space", `'with space'`},
+		{"it's", `'it'\''s'`},
+		{"$HOME and `id`", "'$HOME and `id`'"},
+		{`a"b\c`, `'a"b\c'`},
+	}
+	for _, tt := range tests {
+		if got := shellQuote(tt.in); got != tt.want {
+			t.Errorf("shellQuote(%q) = %s, want %s", tt.in, got, tt.want)
+		}
+	}
+}
+
+// TestShellQuoteShell 引号包裹后的值经 shell 解析应原样还原
+func TestShellQuoteShell(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("no sh")
+	}
+	for _, in := range []string{"with space", "it's", "'$HOME'", "$(id) `id` ${x}", "a\\b\"c", "end'"} {
+		out, err := exec.Command("sh", "-c", "printf %s "+shellQuote(in)).Output()
+		if err != nil {
+			t.Fatalf("sh: %v", err)
+		}
+		if string(out) != in {
+			t.Errorf("sh round trip of %q = %q", in, out)
+		}
+	}
+}
+
+func TestEnvCommand(t *testing.T) {
+	tests := []struct {
+		name       string
+		backendEnv map[string]string
+		topicEnv   state.EnvVars
+		want       string
+	}{
+		{"empty", nil, nil, ""},
+		{"unset", map[string]string{"PROXY": ""}, nil, "unset PROXY"},
+		{"space", nil, state.EnvVars{"A": "x y"}, "export A='x y'"},
+		{"quote", nil, state.EnvVars{"A": "it's"}, `export A='it'\''s'`},
+		{"dollar", nil, state.EnvVars{"A": "$HOME"}, "export A='$HOME'"},
+		{
+			"topic after backend",
+			map[string]string{"B": "1", "A": ""},
+			state.EnvVars{"B": "2"},
+			"unset A; export B='1'; export B='2'",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := envCommand(tt.backendEnv, tt.topicEnv); got != tt.want {
+				t.Errorf("envCommand = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSafePath(t *testing.T) {
+	tests := []struct {
+		path string
+		want bool
+	}{
+		{"/tmp/with space", true},
+		{"/tmp/it's", true},
+		{"/tmp/$HOME", true},
+		{"/tmp/a\nb", false},
+		{"/tmp/a\rb", false},
+		{"/tmp/a\x00b", false},
+	}
+	for _, tt := range tests {
+		if got := safePath(tt.path); got != tt.want {
+			t.Errorf("safePath(%q) = %v, want %v", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestPaneTail(t *testing.T) {
+	tests := []struct {
+		s    string
+		n    int
+		want string
+	}{
+		{"short", 10, "short"},
+		{"abc中文", 2, "中文"},
+		{"──┤ 完成", 4, "┤ 完成"},
+		{"a😀b", 2, "b"},
+		{"a😀b", 3, "😀b"},
+		{"x<y", 5, "<y"},
+		{"x<y", 4, "y"},
+	}
+	for _, tt := range tests {
+		got := paneTail(tt.s, tt.n)
+		if got != tt.want {
+			t.Errorf("paneTail(%q, %d) = %q, want %q", tt.s, tt.n, got, tt.want)
+		}
+		if n := utf16Len(escapeHTML(got)); n > tt.n || !utf8.ValidString(got) {
+			t.Errorf("paneTail(%q, %d) is %d escaped units or cuts a character", tt.s, tt.n, n)
+		}
+	}
+}
+
+// TestDeleteBindingClearsTopicSettings 解绑后 /filter、/pushmode、/env 的设置不会被同一话题的新会话继承
+func TestDeleteBindingClearsTopicSettings(t *testing.T) {
+	store := newTestStore(t)
+	key := "1:42"
+	store.SetBinding(key, state.Binding{WindowID: "@1", Backend: "claude"})
+	store.SetFilter(key, state.Filter{Thinking: true})
+	store.SetPushMode(key, state.PushModeStream)
+	store.SetEnv(key, "API_KEY", "secret")
+
+	store.DeleteBinding(key)
+
+	if _, ok := store.GetFilter(key); ok {
+		t.Errorf("filter survived DeleteBinding")
+	}
+	if mode := store.GetPushMode(key); mode != "" {
+		t.Errorf("push mode = %q after DeleteBinding", mode)
+	}
+	if env := store.GetEnv(key); len(env) != 0 {
+		t.Errorf("env = %v after DeleteBinding", env)
+	}
+}
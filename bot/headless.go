@@ -0,0 +1,34 @@
+package bot
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/user/tgmux/backend"
+	"github.com/user/tgmux/state"
+)
+
+// isHeadless 会话是否为不在 tmux 中运行的 claude-headless
+func isHeadless(binding state.Binding) bool {
+	return backend.Type(binding.Backend) == backend.TypeClaudeHeadless
+}
+
+// newHeadlessWindowID claude-headless 会话没有 tmux 窗口，以唯一的占位 ID 代替，
+// 输入队列、监控等仍按窗口 ID 组织
+func newHeadlessWindowID() string {
+	return fmt.Sprintf("headless-%d", time.Now().UnixNano())
+}
+
+// windowAlive 会话的窗口是否存在；claude-headless 没有窗口，总是视为存在
+func (b *Bot) windowAlive(binding state.Binding) bool {
+	return isHeadless(binding) || b.tmux.IsWindowAlive(binding.WindowID)
+}
+
+// backendAlive 后端是否仍在运行；claude-headless 每条消息单独启动进程，总是视为可用
+func (b *Bot) backendAlive(binding state.Binding) bool {
+	if isHeadless(binding) {
+		return true
+	}
+	be := backend.Get(backend.Type(binding.Backend), b.cfg)
+	return be.Alive(b.tmux.PaneCommand(binding.WindowID))
+}
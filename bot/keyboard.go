@@ -2,40 +2,129 @@ package bot
 
 import (
 	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
 
 	"github.com/go-telegram/bot/models"
+
+	"github.com/user/tgmux/backend"
+	"github.com/user/tgmux/i18n"
+	"github.com/user/tgmux/monitor"
 )
 
 // SessionInfo 用于会话列表展示
 type SessionInfo struct {
 	WindowID    string
 	DisplayName string
-	BoundTopic  string // 如果已绑定，显示 topic key；否则为空
+	BoundTopic  string    // 如果已绑定，显示 topic key；否则为空
+	Backend     string    // 后端类型，未绑定时未知为空
+	ProjectPath string    // 项目目录，未知时为空
+	CreatedAt   time.Time // 会话创建时间，未知时为零值
+	Alive       bool      // 窗口是否仍存在
 }
 
-// BackendKeyboard 后端选择键盘
-func BackendKeyboard() models.InlineKeyboardMarkup {
-	return models.InlineKeyboardMarkup{
-		InlineKeyboard: [][]models.InlineKeyboardButton{
-			{
-				{Text: "claude", CallbackData: "backend:claude"},
-				{Text: "codex", CallbackData: "backend:codex"},
-				{Text: "gemini", CallbackData: "backend:gemini"},
-				{Text: "bash", CallbackData: "backend:bash"},
-			},
-		},
+// sessionPageSize 会话列表每页行数
+const sessionPageSize = 8
+
+// label 会话列表中的展示文本，如 "claude · myproj · 2h"
+func (s SessionInfo) label() string {
+	var parts []string
+	if s.Backend != "" {
+		parts = append(parts, s.Backend)
+	}
+	if s.ProjectPath != "" {
+		parts = append(parts, filepath.Base(s.ProjectPath))
+	}
+	if !s.CreatedAt.IsZero() {
+		parts = append(parts, shortAge(time.Since(s.CreatedAt)))
+	}
+	if len(parts) == 0 {
+		return s.DisplayName
+	}
+	return strings.Join(parts, " · ")
+}
+
+// shortAge 将时长压缩为单一单位，如 45s、15m、2h、3d
+func shortAge(d time.Duration) string {
+	switch {
+	case d < time.Minute:
+		return fmt.Sprintf("%ds", int(d.Seconds()))
+	case d < time.Hour:
+		return fmt.Sprintf("%dm", int(d.Minutes()))
+	case d < 24*time.Hour:
+		return fmt.Sprintf("%dh", int(d.Hours()))
+	default:
+		return fmt.Sprintf("%dd", int(d.Hours()/24))
 	}
 }
 
-// DirKeyboard 目录选择键盘
-func DirKeyboard(favorites []string, recent []string) models.InlineKeyboardMarkup {
+// backendsPerRow 后端选择键盘每行的按钮数
+const backendsPerRow = 4
+
+// BackendKeyboard 后端选择键盘，按 types 的顺序列出 backends 中的后端（已启用），
+// 未安装的后端显示为不可用，点击时由回调弹出提示
+func BackendKeyboard(types []backend.Type, backends map[backend.Type]bool) models.InlineKeyboardMarkup {
 	var rows [][]models.InlineKeyboardButton
+	var row []models.InlineKeyboardButton
+	for _, t := range types {
+		installed, enabled := backends[t]
+		if !enabled {
+			continue
+		}
+		text := string(t)
+		if !installed {
+			text = "🚫 " + text
+		}
+		row = append(row, models.InlineKeyboardButton{Text: text, CallbackData: "backend:" + string(t)})
+		if len(row) == backendsPerRow {
+			rows = append(rows, row)
+			row = nil
+		}
+	}
+	if len(row) > 0 {
+		rows = append(rows, row)
+	}
+	rows = append(rows, []models.InlineKeyboardButton{
+		{Text: i18n.T("common.cancel_button"), CallbackData: "cancel_flow"},
+	})
+	return models.InlineKeyboardMarkup{InlineKeyboard: rows}
+}
+
+// ProfileKeyboard 启动参数选择键盘，callback data 为 profile:<后端>:<名称>，名称为空表示默认参数
+func ProfileKeyboard(backendType backend.Type, profiles map[string][]string) models.InlineKeyboardMarkup {
+	names := make([]string, 0, len(profiles))
+	for name := range profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	rows := [][]models.InlineKeyboardButton{
+		{{Text: i18n.T("kb.default"), CallbackData: fmt.Sprintf("profile:%s:", backendType)}},
+	}
+	for _, name := range names {
+		rows = append(rows, []models.InlineKeyboardButton{
+			{Text: fmt.Sprintf("%s (%s)", name, strings.Join(profiles[name], " ")), CallbackData: fmt.Sprintf("profile:%s:%s", backendType, name)},
+		})
+	}
+	rows = append(rows, []models.InlineKeyboardButton{
+		{Text: i18n.T("common.cancel_button"), CallbackData: "cancel_flow"},
+	})
+	return models.InlineKeyboardMarkup{InlineKeyboard: rows}
+}
+
+// DirKeyboard 目录选择键盘：收藏在前、最近使用在后，每页最多 pageSize 个目录，
+// 翻页按钮的 callback data 为 dirpage:<页码>，不需要服务端保存分页状态；
+// 目录按钮的 callback data 由 pathData 生成（过长的路径换成令牌）
+func DirKeyboard(favorites []string, recent []string, page, pageSize int, pathData PathDataFunc) models.InlineKeyboardMarkup {
+	var dirRows [][]models.InlineKeyboardButton
 
 	// 收藏目录
 	for _, dir := range favorites {
 		short := shortenPath(dir)
-		rows = append(rows, []models.InlineKeyboardButton{
-			{Text: fmt.Sprintf("⭐ %s", short), CallbackData: fmt.Sprintf("dir:%s", dir)},
+		dirRows = append(dirRows, []models.InlineKeyboardButton{
+			{Text: fmt.Sprintf("⭐ %s", short), CallbackData: pathData("dir", dir)},
 		})
 	}
 
@@ -49,27 +138,81 @@ func DirKeyboard(favorites []string, recent []string) models.InlineKeyboardMarku
 			continue
 		}
 		short := shortenPath(dir)
-		rows = append(rows, []models.InlineKeyboardButton{
-			{Text: fmt.Sprintf("🕐 %s", short), CallbackData: fmt.Sprintf("dir:%s", dir)},
+		dirRows = append(dirRows, []models.InlineKeyboardButton{
+			{Text: fmt.Sprintf("🕐 %s", short), CallbackData: pathData("dir", dir)},
 		})
 	}
 
+	// 分页
+	if pageSize <= 0 {
+		pageSize = max(len(dirRows), 1)
+	}
+	pages := max(1, (len(dirRows)+pageSize-1)/pageSize)
+	page = max(0, min(page, pages-1))
+	start := page * pageSize
+	end := min(start+pageSize, len(dirRows))
+	rows := dirRows[start:end]
+
+	if pages > 1 {
+		var nav []models.InlineKeyboardButton
+		if page > 0 {
+			nav = append(nav, models.InlineKeyboardButton{Text: i18n.T("kb.prev_page"), CallbackData: fmt.Sprintf("dirpage:%d", page-1)})
+		}
+		nav = append(nav, models.InlineKeyboardButton{Text: fmt.Sprintf("%d/%d", page+1, pages), CallbackData: "noop"})
+		if page < pages-1 {
+			nav = append(nav, models.InlineKeyboardButton{Text: i18n.T("kb.next_page"), CallbackData: fmt.Sprintf("dirpage:%d", page+1)})
+		}
+		rows = append(rows, nav)
+	}
+
 	// 输入路径按钮
 	rows = append(rows, []models.InlineKeyboardButton{
-		{Text: "📁 输入路径...", CallbackData: "dir_input"},
+		{Text: i18n.T("kb.enter_path"), CallbackData: "dir_input"},
+		{Text: i18n.T("common.cancel_button"), CallbackData: "cancel_flow"},
 	})
 
 	return models.InlineKeyboardMarkup{InlineKeyboard: rows}
 }
 
-// ConfirmKeyboard 权限确认键盘
-func ConfirmKeyboard(windowID string) models.InlineKeyboardMarkup {
+// ConfirmKeyboard 权限确认键盘，always 为 false 时（后端没有"始终允许"）不显示该按钮
+func ConfirmKeyboard(windowID string, always bool) models.InlineKeyboardMarkup {
+	return models.InlineKeyboardMarkup{
+		InlineKeyboard: [][]models.InlineKeyboardButton{confirmRow(windowID, always)},
+	}
+}
+
+// confirmRow 是 / 否 / 始终允许按钮行
+func confirmRow(windowID string, always bool) []models.InlineKeyboardButton {
+	row := []models.InlineKeyboardButton{
+		{Text: i18n.T("kb.yes"), CallbackData: fmt.Sprintf("confirm:yes:%s", windowID)},
+		{Text: i18n.T("kb.no"), CallbackData: fmt.Sprintf("confirm:no:%s", windowID)},
+	}
+	if always {
+		row = append(row, models.InlineKeyboardButton{Text: i18n.T("kb.always"), CallbackData: fmt.Sprintf("confirm:always:%s", windowID)})
+	}
+	return row
+}
+
+// PlanKeyboard 计划审批键盘，callback data 为 plan:<approve|keep|reject>:<windowID>
+func PlanKeyboard(windowID string) models.InlineKeyboardMarkup {
+	return models.InlineKeyboardMarkup{
+		InlineKeyboard: [][]models.InlineKeyboardButton{
+			{
+				{Text: i18n.T("kb.approve"), CallbackData: fmt.Sprintf("plan:approve:%s", windowID)},
+				{Text: i18n.T("kb.replan"), CallbackData: fmt.Sprintf("plan:keep:%s", windowID)},
+				{Text: i18n.T("kb.reject"), CallbackData: fmt.Sprintf("plan:reject:%s", windowID)},
+			},
+		},
+	}
+}
+
+// CtrlConfirmKeyboard 控制键二次确认键盘（用于 Ctrl-D 等可能终止后端的组合键）
+func CtrlConfirmKeyboard(letter string, windowID string) models.InlineKeyboardMarkup {
 	return models.InlineKeyboardMarkup{
 		InlineKeyboard: [][]models.InlineKeyboardButton{
 			{
-				{Text: "✅ Yes", CallbackData: fmt.Sprintf("confirm:yes:%s", windowID)},
-				{Text: "❌ No", CallbackData: fmt.Sprintf("confirm:no:%s", windowID)},
-				{Text: "🔓 Always", CallbackData: fmt.Sprintf("confirm:always:%s", windowID)},
+				{Text: i18n.T("kb.send_ctrl", strings.ToUpper(letter)), CallbackData: fmt.Sprintf("ctrl:%s:%s", letter, windowID)},
+				{Text: i18n.T("common.cancel_button"), CallbackData: "noop"},
 			},
 		},
 	}
@@ -95,14 +238,27 @@ func ScreenshotKeyboard(windowID string) models.InlineKeyboardMarkup {
 				{Text: "Ctrl-C", CallbackData: fmt.Sprintf("ss:ctrlc:%s", windowID)},
 				{Text: "y", CallbackData: fmt.Sprintf("ss:y:%s", windowID)},
 				{Text: "n", CallbackData: fmt.Sprintf("ss:n:%s", windowID)},
-				{Text: "🔄 Refresh", CallbackData: fmt.Sprintf("ss:refresh:%s", windowID)},
+				{Text: i18n.T("kb.refresh"), CallbackData: fmt.Sprintf("ss:refresh:%s", windowID)},
 			},
 		},
 	}
 }
 
-// InteractiveKeyboard 交互式界面导航键盘
-func InteractiveKeyboard(windowID string) models.InlineKeyboardMarkup {
+// TurnDoneKeyboard 回合结束通知的快捷操作：截图、继续（回车）、关闭会话
+func TurnDoneKeyboard(windowID string) models.InlineKeyboardMarkup {
+	return models.InlineKeyboardMarkup{
+		InlineKeyboard: [][]models.InlineKeyboardButton{
+			{
+				{Text: i18n.T("kb.screenshot"), CallbackData: fmt.Sprintf("ss:refresh:%s", windowID)},
+				{Text: i18n.T("kb.continue"), CallbackData: fmt.Sprintf("nav:enter:%s", windowID)},
+				{Text: i18n.T("kb.kill"), CallbackData: fmt.Sprintf("kill:%s", windowID)},
+			},
+		},
+	}
+}
+
+// InteractiveKeyboard 交互式界面导航键盘，always 同 ConfirmKeyboard
+func InteractiveKeyboard(windowID string, always bool) models.InlineKeyboardMarkup {
 	return models.InlineKeyboardMarkup{
 		InlineKeyboard: [][]models.InlineKeyboardButton{
 			{
@@ -118,61 +274,134 @@ func InteractiveKeyboard(windowID string) models.InlineKeyboardMarkup {
 			},
 			{
 				{Text: "Esc", CallbackData: fmt.Sprintf("nav:esc:%s", windowID)},
-				{Text: "🔄 Refresh", CallbackData: fmt.Sprintf("nav:refresh:%s", windowID)},
-			},
-			{
-				{Text: "✅ Yes", CallbackData: fmt.Sprintf("confirm:yes:%s", windowID)},
-				{Text: "❌ No", CallbackData: fmt.Sprintf("confirm:no:%s", windowID)},
-				{Text: "🔓 Always", CallbackData: fmt.Sprintf("confirm:always:%s", windowID)},
+				{Text: i18n.T("kb.refresh"), CallbackData: fmt.Sprintf("nav:refresh:%s", windowID)},
 			},
+			confirmRow(windowID, always),
 		},
 	}
 }
 
-// SessionListKeyboard 会话列表键盘（含绑定/关闭按钮）
-func SessionListKeyboard(sessions []SessionInfo) models.InlineKeyboardMarkup {
+// MenuKeyboard 交互式菜单键盘，每个选项一个按钮：
+// 带编号的选项直接发送数字键（menu:key:<数字>:<windowID>），
+// 其余按光标位置移动后回车（menu:move:<偏移>:<windowID>）
+func MenuKeyboard(windowID string, menu monitor.Menu) models.InlineKeyboardMarkup {
 	var rows [][]models.InlineKeyboardButton
-	for _, s := range sessions {
-		if s.BoundTopic != "" {
+	for i, opt := range menu.Options {
+		text := truncateRunes(opt.Label, 40)
+		data := fmt.Sprintf("menu:move:%d:%s", i-menu.Cursor, windowID)
+		if opt.Number > 0 && opt.Number < 10 {
+			text = fmt.Sprintf("%d. %s", opt.Number, text)
+			data = fmt.Sprintf("menu:key:%d:%s", opt.Number, windowID)
+		}
+		rows = append(rows, []models.InlineKeyboardButton{{Text: text, CallbackData: data}})
+	}
+	rows = append(rows, []models.InlineKeyboardButton{
+		{Text: "Esc", CallbackData: fmt.Sprintf("nav:esc:%s", windowID)},
+		{Text: i18n.T("kb.refresh"), CallbackData: fmt.Sprintf("nav:refresh:%s", windowID)},
+	})
+	return models.InlineKeyboardMarkup{InlineKeyboard: rows}
+}
+
+// SessionListKeyboard 会话列表键盘，每页 sessionPageSize 行，
+// 翻页按钮的 callback data 为 sesspage:<页码>
+func SessionListKeyboard(sessions []SessionInfo, page int) models.InlineKeyboardMarkup {
+	pages := max(1, (len(sessions)+sessionPageSize-1)/sessionPageSize)
+	page = max(0, min(page, pages-1))
+
+	var rows [][]models.InlineKeyboardButton
+	for _, s := range sessions[page*sessionPageSize : min((page+1)*sessionPageSize, len(sessions))] {
+		switch {
+		case !s.Alive:
+			// 窗口已不存在：[清理] 残留绑定
+			rows = append(rows, []models.InlineKeyboardButton{
+				{Text: fmt.Sprintf("⚫ %s", s.label()), CallbackData: "noop"},
+				{Text: i18n.T("kb.clean"), CallbackData: fmt.Sprintf("clean:%s", s.WindowID)},
+			})
+		case s.BoundTopic != "":
 			// 已绑定：显示名 + [关闭]
 			rows = append(rows, []models.InlineKeyboardButton{
-				{Text: fmt.Sprintf("🔗 %s", s.DisplayName), CallbackData: "noop"},
-				{Text: "❌ 关闭", CallbackData: fmt.Sprintf("kill:%s", s.WindowID)},
+				{Text: fmt.Sprintf("🔗 %s", s.label()), CallbackData: "noop"},
+				{Text: i18n.T("kb.close"), CallbackData: fmt.Sprintf("kill:%s", s.WindowID)},
 			})
-		} else {
+		default:
 			// 未绑定：显示名 + [绑定]
 			rows = append(rows, []models.InlineKeyboardButton{
-				{Text: fmt.Sprintf("💤 %s", s.DisplayName), CallbackData: "noop"},
-				{Text: "🔗 绑定", CallbackData: fmt.Sprintf("bind:%s", s.WindowID)},
+				{Text: fmt.Sprintf("💤 %s", s.label()), CallbackData: "noop"},
+				{Text: i18n.T("kb.bind"), CallbackData: fmt.Sprintf("bind:%s", s.WindowID)},
 			})
 		}
 	}
+	if pages > 1 {
+		var nav []models.InlineKeyboardButton
+		if page > 0 {
+			nav = append(nav, models.InlineKeyboardButton{Text: i18n.T("kb.prev_page"), CallbackData: fmt.Sprintf("sesspage:%d", page-1)})
+		}
+		nav = append(nav, models.InlineKeyboardButton{Text: fmt.Sprintf("%d/%d", page+1, pages), CallbackData: "noop"})
+		if page < pages-1 {
+			nav = append(nav, models.InlineKeyboardButton{Text: i18n.T("kb.next_page"), CallbackData: fmt.Sprintf("sesspage:%d", page+1)})
+		}
+		rows = append(rows, nav)
+	}
 	// 新建会话按钮
 	rows = append(rows, []models.InlineKeyboardButton{
-		{Text: "➕ 新建会话", CallbackData: "new_session"},
+		{Text: i18n.T("kb.new_session"), CallbackData: "new_session"},
 	})
 	return models.InlineKeyboardMarkup{InlineKeyboard: rows}
 }
 
-// BrowseDirKeyboard 目录浏览键盘
-func BrowseDirKeyboard(currentPath string, entries []DirEntry) models.InlineKeyboardMarkup {
+// BrowseDirKeyboard 目录浏览键盘，每页最多 pageSize 个子目录，路径回调由 pathData 生成。
+// 显示隐藏目录时浏览与翻页回调带 h 前缀（hbrowse / hbrowsepage），进入子目录后保持
+func BrowseDirKeyboard(currentPath string, entries []DirEntry, page, pageSize int, showHidden bool, pathData PathDataFunc) models.InlineKeyboardMarkup {
+	browse, browsePage, toggle := "browse", "browsepage", i18n.T("kb.show_hidden")
+	if showHidden {
+		browse, browsePage, toggle = "hbrowse", "hbrowsepage", i18n.T("kb.hide_hidden")
+	}
+
+	if pageSize <= 0 {
+		pageSize = max(len(entries), 1)
+	}
+	pages := max(1, (len(entries)+pageSize-1)/pageSize)
+	page = max(0, min(page, pages-1))
+
 	var rows [][]models.InlineKeyboardButton
-	for _, entry := range entries {
-		fullPath := fmt.Sprintf("%s/%s", currentPath, entry.Name)
+	// 页码标题行
+	if pages > 1 {
 		rows = append(rows, []models.InlineKeyboardButton{
-			{Text: fmt.Sprintf("📂 %s", entry.Name), CallbackData: fmt.Sprintf("browse:%s", fullPath)},
-			{Text: "⭐", CallbackData: fmt.Sprintf("fav:%s", fullPath)},
+			{Text: i18n.T("kb.page", page+1, pages), CallbackData: "noop"},
 		})
 	}
-	// 选择当前目录
+	for _, entry := range entries[page*pageSize : min((page+1)*pageSize, len(entries))] {
+		fullPath := fmt.Sprintf("%s/%s", strings.TrimSuffix(currentPath, "/"), entry.Name)
+		rows = append(rows, []models.InlineKeyboardButton{
+			{Text: fmt.Sprintf("📂 %s", entry.Name), CallbackData: pathData(browse, fullPath)},
+			{Text: "⭐", CallbackData: pathData("fav", fullPath)},
+		})
+	}
+	// 翻页，callback data 为 [h]browsepage:<页码>:<路径>
+	if pages > 1 {
+		var nav []models.InlineKeyboardButton
+		if page > 0 {
+			nav = append(nav, models.InlineKeyboardButton{Text: i18n.T("kb.prev_page"), CallbackData: pathData(fmt.Sprintf("%s:%d", browsePage, page-1), currentPath)})
+		}
+		if page < pages-1 {
+			nav = append(nav, models.InlineKeyboardButton{Text: i18n.T("kb.next_page"), CallbackData: pathData(fmt.Sprintf("%s:%d", browsePage, page+1), currentPath)})
+		}
+		rows = append(rows, nav)
+	}
+	// 选择当前目录 / 切换隐藏目录
+	hiddenToggle := "hbrowse"
+	if showHidden {
+		hiddenToggle = "browse"
+	}
 	rows = append(rows, []models.InlineKeyboardButton{
-		{Text: "✅ 选择此目录", CallbackData: fmt.Sprintf("dir:%s", currentPath)},
+		{Text: i18n.T("kb.select_dir"), CallbackData: pathData("dir", currentPath)},
+		{Text: toggle, CallbackData: pathData(hiddenToggle, currentPath)},
 	})
 	// 返回上级
 	if currentPath != "/" {
 		parent := parentDir(currentPath)
 		rows = append(rows, []models.InlineKeyboardButton{
-			{Text: "⬆️ 返回上级", CallbackData: fmt.Sprintf("browse:%s", parent)},
+			{Text: i18n.T("kb.parent_dir"), CallbackData: pathData(browse, parent)},
 		})
 	}
 	return models.InlineKeyboardMarkup{InlineKeyboard: rows}
@@ -0,0 +1,128 @@
+package bot
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+	"time"
+
+	tgbot "github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+	"github.com/user/tgmux/monitor"
+	"github.com/user/tgmux/state"
+)
+
+const (
+	// liveEditInterval 流式消息两次编辑的最小间隔
+	liveEditInterval = 2 * time.Second
+	// liveMax 流式消息的最大长度（UTF-16 码元），超过后另起一条
+	liveMax = 4000
+)
+
+// liveMessage 流式推送模式下追加回答或思考的消息：追加内容累积后节流编辑，只在 worker goroutine 中访问
+type liveMessage struct {
+	msgID  int
+	kind   monitor.ContentType
+	text   string // 整条消息的 HTML，含尚未显示的追加内容
+	shown  int    // 消息当前显示的 text 字节数
+	open   bool   // 追加了最终回答（带用量脚注）后为 false
+	editAt time.Time
+	due    <-chan time.Time // 可以写入待追加内容时触发，没有待写入时为 nil
+}
+
+// streaming Topic 是否使用流式推送模式
+func (p *StreamPusher) streaming() bool {
+	return p.store != nil && p.store.GetPushMode(p.topicKey) == state.PushModeStream
+}
+
+// startLive 将刚发送的消息设为流式消息
+func (p *StreamPusher) startLive(msgID int, kind monitor.ContentType, text string, open bool) {
+	p.live = liveMessage{msgID: msgID, kind: kind, text: text, shown: len(text), open: open, editAt: time.Now()}
+}
+
+// appendLive 将格式化后的文本追加到流式消息并安排编辑，返回 false 时需作为新消息发送
+func (p *StreamPusher) appendLive(kind monitor.ContentType, text, footer string) bool {
+	l := &p.live
+	if l.msgID == 0 || !l.open || l.kind != kind {
+		return false
+	}
+	add := "\n\n" + text + footerHTML(footer)
+	if utf16Len(l.text)+utf16Len(add) > liveMax {
+		return false
+	}
+	l.text += add
+	l.open = footer == ""
+	if l.due == nil {
+		l.due = time.After(time.Until(l.editAt.Add(liveEditInterval)))
+	}
+	return true
+}
+
+// flushLive 将待追加内容写入流式消息；编辑失败（已删除、超过 48 小时等）时
+// 待追加部分作为新消息发送，并成为新的流式消息
+func (p *StreamPusher) flushLive(ctx context.Context) {
+	l := &p.live
+	l.due = nil
+	if l.msgID == 0 || l.shown == len(l.text) {
+		return
+	}
+	if err := p.rateLimiter.WaitChat(ctx, p.chatID); err != nil {
+		return
+	}
+	// 不用 editWithRetry：无法编辑的消息改用纯文本重试会显示出标记
+	params := &tgbot.EditMessageTextParams{
+		ChatID:             p.chatID,
+		MessageID:          l.msgID,
+		Text:               l.text,
+		ParseMode:          models.ParseModeHTML,
+		LinkPreviewOptions: &models.LinkPreviewOptions{IsDisabled: boolPtr(true)},
+	}
+	_, err := p.tgBot.EditMessageText(ctx, params)
+	if retryAfter := parseRetryAfter(err); retryAfter > 0 {
+		p.rateLimiter.BackOff(retryAfter)
+		if waitErr := p.rateLimiter.Wait(ctx); waitErr != nil {
+			return
+		}
+		_, err = p.tgBot.EditMessageText(ctx, params)
+	}
+	l.editAt = time.Now()
+	if err == nil || strings.Contains(err.Error(), "message is not modified") {
+		p.lastSent.Store(time.Now().UnixMilli())
+		l.shown = len(l.text)
+		return
+	}
+
+	slog.Warn("live message edit failed, sending as new message", "error", err)
+	pending := strings.TrimPrefix(l.text[l.shown:], "\n\n")
+	kind, open := l.kind, l.open
+	p.live = liveMessage{}
+	if err := p.rateLimiter.WaitChat(ctx, p.chatID); err != nil {
+		return
+	}
+	sendParams := &tgbot.SendMessageParams{
+		ChatID:             p.chatID,
+		Text:               pending,
+		ParseMode:          models.ParseModeHTML,
+		LinkPreviewOptions: &models.LinkPreviewOptions{IsDisabled: boolPtr(true)},
+	}
+	if p.threadID != 0 {
+		sendParams.MessageThreadID = p.threadID
+	}
+	resp, err := p.sendWithRetry(ctx, sendParams)
+	if err != nil {
+		slog.Error("sendMessage failed", "error", err)
+		return
+	}
+	p.lastSent.Store(time.Now().UnixMilli())
+	p.sent.Record(p.chatID, p.threadID, resp.ID)
+	p.startLive(resp.ID, kind, pending, open)
+}
+
+// endLive 写入待追加内容并结束流式消息，之后的输出另起一条
+func (p *StreamPusher) endLive(ctx context.Context) {
+	if p.live.msgID == 0 {
+		return
+	}
+	p.flushLive(ctx)
+	p.live = liveMessage{}
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"regexp"
 	"strings"
+	"unicode/utf8"
 )
 
 // escapeHTML escapes HTML special characters for safe embedding
@@ -85,3 +86,167 @@ func toHTML(text string) string {
 
 	return text
 }
+
+// htmlToken Telegram HTML 中不可拆开的单元：标签、实体或单个字符
+type htmlToken struct {
+	text    string
+	tag     string // 小写标签名，文本与实体为空
+	closing bool
+}
+
+// openTag 文本中某处尚未关闭的元素及其原始开始标签
+type openTag struct {
+	name string
+	open string
+}
+
+// tokenizeHTML 将 Telegram HTML 拆为标签、实体与字符
+func tokenizeHTML(s string) []htmlToken {
+	var tokens []htmlToken
+	for i := 0; i < len(s); {
+		switch s[i] {
+		case '<':
+			if end := strings.IndexByte(s[i:], '>'); end > 0 {
+				raw := s[i : i+end+1]
+				name := strings.TrimPrefix(raw[1:len(raw)-1], "/")
+				if j := strings.IndexAny(name, " \t\n"); j >= 0 {
+					name = name[:j]
+				}
+				tokens = append(tokens, htmlToken{text: raw, tag: strings.ToLower(name), closing: raw[1] == '/'})
+				i += end + 1
+				continue
+			}
+		case '&':
+			if end := strings.IndexByte(s[i:], ';'); end > 0 && end <= 10 {
+				tokens = append(tokens, htmlToken{text: s[i : i+end+1]})
+				i += end + 1
+				continue
+			}
+		}
+		_, size := utf8.DecodeRuneInString(s[i:])
+		tokens = append(tokens, htmlToken{text: s[i : i+size]})
+		i += size
+	}
+	return tokens
+}
+
+// applyTag 返回 tok 之后仍打开的元素，不修改传入的切片
+func applyTag(stack []openTag, tok htmlToken) []openTag {
+	switch {
+	case tok.tag == "":
+		return stack
+	case !tok.closing:
+		return append(stack[:len(stack):len(stack)], openTag{name: tok.tag, open: tok.text})
+	}
+	for i := len(stack) - 1; i >= 0; i-- {
+		if stack[i].name == tok.tag {
+			return stack[:i:i]
+		}
+	}
+	return stack
+}
+
+// closingTags 由内向外关闭打开的元素
+func closingTags(stack []openTag) string {
+	var b strings.Builder
+	for i := len(stack) - 1; i >= 0; i-- {
+		b.WriteString("</" + stack[i].name + ">")
+	}
+	return b.String()
+}
+
+// openingTags 按原顺序重新打开元素，保留属性
+func openingTags(stack []openTag) string {
+	var b strings.Builder
+	for _, t := range stack {
+		b.WriteString(t.open)
+	}
+	return b.String()
+}
+
+// splitHTML 将 Telegram HTML 拆分为不超过 maxLen 个 UTF-16 码元（含标记）的段，不切开标签与实体；
+// 切分处打开的元素在段末关闭、下一段开头重新打开，代码块保留语言；
+// 与 splitMessage 相同，在保留至少半段的前提下优先在代码块后、其次在换行后切分
+func splitHTML(s string, maxLen int) []string {
+	if utf16Len(s) <= maxLen {
+		return []string{s}
+	}
+	type cut struct {
+		token, bytes, units int
+		stack               []openTag
+	}
+	tokens := tokenizeHTML(s)
+	var chunks []string
+	var stack []openTag
+	for i := 0; i < len(tokens); {
+		var b strings.Builder
+		b.WriteString(openingTags(stack))
+		n := utf16Len(b.String())
+		start := i
+		var afterBlock, afterLine *cut
+		for ; i < len(tokens); i++ {
+			tok := tokens[i]
+			next := applyTag(stack, tok)
+			size := utf16Len(tok.text)
+			if n+size+utf16Len(closingTags(next)) > maxLen {
+				if i > start {
+					break
+				}
+				// 仅重新打开的元素就放不下下一个单元：去掉标记，其余按纯文本拆分
+				return append(chunks, splitHTMLText(tokens[i:], maxLen)...)
+			}
+			b.WriteString(tok.text)
+			n += size
+			stack = next
+			switch {
+			case tok.tag == "pre" && tok.closing:
+				afterBlock = &cut{i + 1, b.Len(), n, stack}
+			case tok.text == "\n":
+				afterLine = &cut{i + 1, b.Len(), n, stack}
+			}
+		}
+		chunk := b.String()
+		if i < len(tokens) {
+			for _, c := range []*cut{afterBlock, afterLine} {
+				if c != nil && c.units > n/2 {
+					chunk, i, stack = chunk[:c.bytes], c.token, c.stack
+					break
+				}
+			}
+		}
+		chunks = append(chunks, chunk+closingTags(stack))
+	}
+	return chunks
+}
+
+// splitHTMLText 去掉所有标签、保留完整实体，按不超过 maxLen 个 UTF-16 码元拆分；标记本身放不下时使用
+func splitHTMLText(tokens []htmlToken, maxLen int) []string {
+	var chunks []string
+	var b strings.Builder
+	n := 0
+	for _, tok := range tokens {
+		if tok.tag != "" {
+			continue
+		}
+		size := utf16Len(tok.text)
+		if n+size > maxLen && n > 0 {
+			chunks = append(chunks, b.String())
+			b.Reset()
+			n = 0
+		}
+		b.WriteString(tok.text)
+		n += size
+	}
+	if n > 0 {
+		chunks = append(chunks, b.String())
+	}
+	return chunks
+}
+
+// truncateHTML 将 HTML 截短到不超过 n 个 UTF-16 码元，截断时以 "..." 结尾，不切开标签与实体
+func truncateHTML(s string, n int) string {
+	if utf16Len(s) <= n {
+		return s
+	}
+	return splitHTML(s, n-3)[0] + "..."
+}
@@ -0,0 +1,107 @@
+package bot
+
+import (
+	"strings"
+	"testing"
+)
+
+// stripTags 去掉 Telegram HTML 的所有标签，返回其文本
+func stripTags(s string) string {
+	var b strings.Builder
+	for _, tok := range tokenizeHTML(s) {
+		if tok.tag == "" {
+			b.WriteString(tok.text)
+		}
+	}
+	return b.String()
+}
+
+// checkHTMLChunks 检查每段都不超长且自身标签配对正确，各段合起来不丢失原文
+func checkHTMLChunks(t *testing.T, s string, chunks []string, maxLen int) {
+	t.Helper()
+	var text strings.Builder
+	for i, chunk := range chunks {
+		if n := utf16Len(chunk); n > maxLen {
+			t.Errorf("chunk %d is %d UTF-16 units, over %d", i, n, maxLen)
+		}
+		var open []string
+		for _, tok := range tokenizeHTML(chunk) {
+			switch {
+			case tok.tag == "":
+			case !tok.closing:
+				open = append(open, tok.tag)
+			case len(open) == 0 || open[len(open)-1] != tok.tag:
+				t.Fatalf("chunk %d closes <%s> out of order: %q", i, tok.tag, chunk)
+			default:
+				open = open[:len(open)-1]
+			}
+		}
+		if len(open) > 0 {
+			t.Errorf("chunk %d leaves %v open", i, open)
+		}
+		text.WriteString(stripTags(chunk))
+	}
+	if got, want := text.String(), stripTags(s); got != want {
+		t.Errorf("chunks lost text: got %d bytes, want %d", len(got), len(want))
+	}
+}
+
+func TestSplitHTMLNestedFormatting(t *testing.T) {
+	var b strings.Builder
+	for i := 0; b.Len() < 10000; i++ {
+		b.WriteString("<b>bold <i>italic <u>under &amp; <code>x &lt; y</code></u> ")
+		b.WriteString(`<a href="https://example.com/path?q=1&amp;r=2">link</a></i></b>` + "\n")
+		if i%5 == 0 {
+			b.WriteString(`<pre><code class="language-go">func main() {` + "\n" + strings.Repeat("\tfmt.Println(\"&quot;hi&quot;\")\n", 8) + "}</code></pre>\n")
+		}
+		if i%7 == 0 {
+			b.WriteString("<blockquote>quoted " + strings.Repeat("<s>gone</s> ", 30) + "</blockquote>\n")
+		}
+	}
+	s := b.String()
+	for _, maxLen := range []int{4096, 1000, 200} {
+		chunks := splitHTML(s, maxLen)
+		if len(chunks) < 2 {
+			t.Fatalf("maxLen %d: expected several chunks, got %d", maxLen, len(chunks))
+		}
+		checkHTMLChunks(t, s, chunks, maxLen)
+	}
+}
+
+func TestSplitHTMLReopensLanguage(t *testing.T) {
+	s := `<pre><code class="language-python">` + strings.Repeat("print('x')\n", 400) + "</code></pre>"
+	chunks := splitHTML(s, 1000)
+	for i, chunk := range chunks {
+		if !strings.HasPrefix(chunk, `<pre><code class="language-python">`) {
+			t.Errorf("chunk %d does not reopen the code block: %.60q", i, chunk)
+		}
+	}
+	checkHTMLChunks(t, s, chunks, 1000)
+}
+
+// TestSplitHTMLMarkupTooLong 重新打开的标签放不下下一个单元时
+func TestSplitHTMLMarkupTooLong(t *testing.T) {
+	const maxLen = 60
+	open := `<a href="https://example.com/` + strings.Repeat("p", maxLen-len(`<a href="https://example.com/">`)-len("</a>")-1) + `">`
+	if n := len(open) + len("x") + len("</a>"); n != maxLen {
+		t.Fatalf("setup: open tag, one character and the closing tag are %d, want %d", n, maxLen)
+	}
+	s := open + "x" + strings.Repeat("&amp;", 40) + "</a> tail"
+	chunks := splitHTML(s, maxLen)
+	checkHTMLChunks(t, s, chunks, maxLen)
+
+	// 单个开始标签就超过限制
+	s = `<a href="https://example.com/` + strings.Repeat("q", 100) + `">link text</a>`
+	checkHTMLChunks(t, s, splitHTML(s, maxLen), maxLen)
+}
+
+func TestTruncateHTML(t *testing.T) {
+	s := "<b>" + strings.Repeat("word ", 100) + "</b>"
+	got := truncateHTML(s, 50)
+	if utf16Len(got) > 50 || !strings.HasSuffix(got, "</b>...") {
+		t.Errorf("truncateHTML = %q", got)
+	}
+	if truncateHTML("<i>short</i>", 50) != "<i>short</i>" {
+		t.Errorf("short input changed")
+	}
+}
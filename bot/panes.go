@@ -0,0 +1,116 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+
+	"github.com/user/tgmux/i18n"
+	"github.com/user/tgmux/state"
+)
+
+// trackPane 记录窗口当前的活动 pane 为会话所在的 pane，之后即使窗口被拆分也针对它操作
+func (b *Bot) trackPane(binding *state.Binding) {
+	if isHeadless(*binding) {
+		return
+	}
+	paneID, err := b.tmux.ActivePane(binding.WindowID)
+	if err != nil {
+		slog.Warn("get active pane failed", "window", binding.WindowID, "error", err)
+		return
+	}
+	binding.PaneID = paneID
+	b.tmux.SetPane(binding.WindowID, paneID)
+}
+
+// restorePane 恢复绑定时重新指定 pane；记录的 pane 已不存在时改回窗口的活动 pane
+func (b *Bot) restorePane(key string, binding state.Binding) {
+	if binding.PaneID == "" {
+		return
+	}
+	panes, err := b.tmux.ListPanes(binding.WindowID)
+	if err != nil {
+		return
+	}
+	for _, p := range panes {
+		if p.ID == binding.PaneID {
+			b.tmux.SetPane(binding.WindowID, binding.PaneID)
+			return
+		}
+	}
+	slog.Info("bound pane gone, using active pane", "key", key, "pane", binding.PaneID)
+	b.store.SetBindingPane(key, "")
+}
+
+// handlePanes /panes 命令：列出绑定窗口中的 pane，点击切换当前 Topic 针对的 pane
+func (b *Bot) handlePanes(ctx context.Context, tgBot *bot.Bot, update *models.Update) {
+	if update.Message == nil {
+		return
+	}
+	msg := update.Message
+	key := topicKeyFromMessage(msg)
+	binding, ok := b.store.GetBinding(key)
+	if !ok {
+		b.sendReply(ctx, msg, i18n.T("session.not_bound"))
+		return
+	}
+	panes, err := b.tmux.ListPanes(binding.WindowID)
+	if err != nil {
+		b.sendReply(ctx, msg, i18n.T("panes.failed", err))
+		return
+	}
+
+	current := b.tmux.Pane(binding.WindowID)
+	lines := []string{i18n.T("panes.title", binding.WindowID)}
+	var rows [][]models.InlineKeyboardButton
+	for _, p := range panes {
+		targeted := p.ID == current || current == "" && p.Active
+		mark := "▫️"
+		if targeted {
+			mark = "👉"
+		}
+		lines = append(lines, fmt.Sprintf("%s %s  #%s  %s  %s", mark, p.ID, p.Index, p.Command, p.Size))
+		if !targeted {
+			rows = append(rows, []models.InlineKeyboardButton{{
+				Text:         i18n.T("panes.switch", p.ID, p.Command),
+				CallbackData: "pane:" + p.ID,
+			}})
+		}
+	}
+	text := strings.Join(lines, "\n")
+	if len(rows) == 0 {
+		b.sendReply(ctx, msg, text)
+		return
+	}
+	b.sendReplyWithKeyboard(ctx, msg, text, models.InlineKeyboardMarkup{InlineKeyboard: rows})
+}
+
+// selectPane 切换 Topic 针对的 pane，并按新 pane 重启输出监控
+func (b *Bot) selectPane(ctx context.Context, key string, chatID int64, threadID int, paneID string) {
+	binding, ok := b.store.GetBinding(key)
+	if !ok {
+		b.sendMsg(ctx, chatID, threadID, i18n.T("session.not_bound"), nil)
+		return
+	}
+	panes, err := b.tmux.ListPanes(binding.WindowID)
+	if err != nil {
+		b.sendMsg(ctx, chatID, threadID, i18n.T("panes.failed", err), nil)
+		return
+	}
+	for _, p := range panes {
+		if p.ID != paneID {
+			continue
+		}
+		binding.PaneID = paneID
+		b.store.SetBindingPane(key, paneID)
+		b.tmux.SetPane(binding.WindowID, paneID)
+		b.StartMonitorForBinding(ctx, key, binding, chatID, threadID)
+		b.sendMsg(ctx, chatID, threadID, i18n.T("panes.switched", paneID, p.Command), nil)
+		return
+	}
+	b.sendMsg(ctx, chatID, threadID, i18n.T("panes.gone", paneID), nil)
+}
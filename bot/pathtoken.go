@@ -0,0 +1,41 @@
+package bot
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/user/tgmux/i18n"
+)
+
+// maxCallbackData Telegram callback_data 的字节上限
+const maxCallbackData = 64
+
+// pathTokenTTL 路径令牌有效期，超过后旧键盘上的按钮失效
+const pathTokenTTL = 7 * 24 * time.Hour
+
+// PathDataFunc 生成带路径的 callback data：action:<路径> 或 action#<令牌>
+type PathDataFunc func(action, path string) string
+
+// pathData 路径放得下时直接编码为 action:<路径>，否则登记令牌编码为 action#<令牌>
+func (b *Bot) pathData(action, path string) string {
+	if data := action + ":" + path; len(data) <= maxCallbackData {
+		return data
+	}
+	return action + "#" + b.store.RegisterPathToken(path, pathTokenTTL)
+}
+
+// resolvePathData 将 action#<令牌> 还原为 action:<路径>；非令牌形式原样返回。
+// 令牌不存在或已过期时提示用户并返回 false
+func (b *Bot) resolvePathData(ctx context.Context, chatID int64, threadID int, data string) (string, bool) {
+	action, token, ok := strings.Cut(data, "#")
+	if !ok || strings.Contains(action, "/") {
+		return data, true
+	}
+	path, ok := b.store.ResolvePathToken(token, pathTokenTTL)
+	if !ok {
+		b.sendMsg(ctx, chatID, threadID, i18n.T("dir.token_expired"), nil)
+		return "", false
+	}
+	return action + ":" + path, true
+}
@@ -0,0 +1,51 @@
+package bot
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/user/tgmux/state"
+)
+
+func newTestStore(t *testing.T) *state.Store {
+	t.Helper()
+	store := state.New(filepath.Join(t.TempDir(), "state.json"), 10)
+	t.Cleanup(store.Close)
+	return store
+}
+
+func TestPathDataRoundTrip(t *testing.T) {
+	b := &Bot{store: newTestStore(t)}
+	// 120 个字符的路径，远超 callback_data 的 64 字节上限
+	long := "/home/user/projects/my project/" + strings.Repeat("sub-dir/", 11)
+	long += strings.Repeat("x", 120-len(long))
+
+	tests := []struct {
+		name  string
+		path  string
+		token bool
+	}{
+		{"short", "/tmp/work", false},
+		{"long", long, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data := b.pathData("cd", tt.path)
+			if len(data) > maxCallbackData {
+				t.Fatalf("callback data is %d bytes, over the %d limit", len(data), maxCallbackData)
+			}
+			if got := strings.Contains(data, "#"); got != tt.token {
+				t.Fatalf("token encoding = %v, want %v (%q)", got, tt.token, data)
+			}
+			resolved, ok := b.resolvePathData(context.Background(), 0, 0, data)
+			if !ok {
+				t.Fatalf("resolvePathData(%q) failed", data)
+			}
+			if resolved != "cd:"+tt.path {
+				t.Errorf("round trip = %q, want %q", resolved, "cd:"+tt.path)
+			}
+		})
+	}
+}
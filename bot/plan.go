@@ -0,0 +1,44 @@
+package bot
+
+import (
+	"context"
+
+	"github.com/user/tgmux/backend"
+	"github.com/user/tgmux/i18n"
+)
+
+// planKeys 各后端计划审批提示的按键（tmux 键名），按钮 → 按键序列。
+// claude 的提示为 1. Yes, and auto-accept edits / 2. Yes, and manually approve edits / 3. No, keep planning，Esc 拒绝
+var planKeys = map[backend.Type]map[string][]string{
+	backend.TypeClaude: {
+		"approve": {"1"},
+		"keep":    {"3"},
+		"reject":  {"Escape"},
+	},
+}
+
+// planReplies 各按钮执行后的回复（消息目录标识符）
+var planReplies = map[string]string{
+	"approve": "plan.approved",
+	"keep":    "plan.kept",
+	"reject":  "plan.rejected",
+}
+
+// handlePlanAction 处理计划审批按钮：按当前会话的后端发送对应按键
+func (b *Bot) handlePlanAction(ctx context.Context, key string, chatID int64, threadID int, action, windowID string) {
+	binding, ok := b.store.GetBinding(key)
+	if !ok || binding.WindowID != windowID {
+		b.sendMsg(ctx, chatID, threadID, i18n.T("plan.expired"), nil)
+		return
+	}
+	keys, ok := planKeys[backend.Type(binding.Backend)][action]
+	if !ok {
+		b.sendMsg(ctx, chatID, threadID, i18n.T("plan.unsupported", binding.Backend), nil)
+		return
+	}
+	for _, k := range keys {
+		b.tmux.SendSpecialKey(windowID, k)
+	}
+	b.store.TouchBinding(key)
+	b.sendMsg(ctx, chatID, threadID, i18n.T(planReplies[action]), nil)
+}
@@ -0,0 +1,134 @@
+package bot
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+
+	"github.com/user/tgmux/i18n"
+)
+
+// reapCheckInterval 空闲会话检查间隔
+const reapCheckInterval = time.Minute
+
+// runReaper 定期检查空闲会话：超过 monitor.idle_kill_after 先提醒，
+// 提醒后 monitor.idle_kill_grace 内无人响应则关闭窗口并解绑
+func (b *Bot) runReaper(ctx context.Context) {
+	if b.cfg.Monitor.IdleKillAfter <= 0 {
+		return
+	}
+	ticker := time.NewTicker(reapCheckInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			b.reapIdle(ctx)
+		}
+	}
+}
+
+func (b *Bot) reapIdle(ctx context.Context) {
+	now := time.Now()
+	for key, binding := range b.store.AllBindings() {
+		if binding.KeepAlive || binding.Status != "running" {
+			b.reapWarned.Delete(key)
+			continue
+		}
+		last := binding.LastActivity
+		if last.IsZero() {
+			last = binding.CreatedAt
+		}
+		idle := now.Sub(last)
+		if idle < b.cfg.Monitor.IdleKillAfter {
+			// 提醒后有了新活动，重新计时
+			b.reapWarned.Delete(key)
+			continue
+		}
+
+		chatID, threadID, _ := parseTopicKey(key)
+		if chatID == 0 {
+			continue
+		}
+		if v, warned := b.reapWarned.Load(key); warned {
+			if now.Sub(v.(time.Time)) < b.cfg.Monitor.IdleKillGrace {
+				continue
+			}
+			b.reapWarned.Delete(key)
+			b.tmux.KillWindow(binding.WindowID)
+			b.unbind(key, binding)
+			b.sendMsg(ctx, chatID, threadID, i18n.T("reap.killed", binding.DisplayName, idle.Truncate(time.Minute)), nil)
+			slog.Info("idle session reaped", "key", key, "window", binding.WindowID, "idle", idle)
+			continue
+		}
+
+		b.reapWarned.Store(key, now)
+		kb := models.InlineKeyboardMarkup{InlineKeyboard: [][]models.InlineKeyboardButton{
+			{
+				{Text: i18n.T("reap.keep_button"), CallbackData: "reap:keep:" + binding.WindowID},
+				{Text: i18n.T("reap.kill_button"), CallbackData: "reap:kill:" + binding.WindowID},
+			},
+		}}
+		b.sendMsg(ctx, chatID, threadID, i18n.T("reap.warning", binding.DisplayName, idle.Truncate(time.Minute), b.cfg.Monitor.IdleKillGrace), &kb)
+		slog.Info("idle session warned", "key", key, "window", binding.WindowID, "idle", idle)
+	}
+}
+
+// handleReapCallback 处理空闲提醒的按钮：reap:keep:<windowID> / reap:kill:<windowID>
+func (b *Bot) handleReapCallback(ctx context.Context, key string, chatID int64, threadID int, data string) {
+	action, windowID, _ := strings.Cut(strings.TrimPrefix(data, "reap:"), ":")
+	binding, ok := b.store.GetBinding(key)
+	if !ok || binding.WindowID != windowID {
+		b.sendMsg(ctx, chatID, threadID, i18n.T("reap.expired"), nil)
+		return
+	}
+	b.reapWarned.Delete(key)
+	if action == "kill" {
+		b.tmux.KillWindow(binding.WindowID)
+		b.unbind(key, binding)
+		b.sendMsg(ctx, chatID, threadID, i18n.T("kill.done", binding.DisplayName), nil)
+		return
+	}
+	b.store.TouchBinding(key)
+	b.sendMsg(ctx, chatID, threadID, i18n.T("reap.kept"), nil)
+}
+
+// handleKeepAlive /keepalive 命令：设置当前会话是否豁免空闲回收
+func (b *Bot) handleKeepAlive(ctx context.Context, tgBot *bot.Bot, update *models.Update) {
+	if update.Message == nil {
+		return
+	}
+	msg := update.Message
+	key := topicKeyFromMessage(msg)
+	binding, ok := b.store.GetBinding(key)
+	if !ok {
+		b.sendReply(ctx, msg, i18n.T("session.not_bound"))
+		return
+	}
+
+	switch strings.TrimSpace(strings.TrimPrefix(msg.Text, "/keepalive")) {
+	case "":
+		if b.cfg.Monitor.IdleKillAfter <= 0 {
+			b.sendReply(ctx, msg, i18n.T("keepalive.disabled"))
+		} else if binding.KeepAlive {
+			b.sendReply(ctx, msg, i18n.T("keepalive.on"))
+		} else {
+			b.sendReply(ctx, msg, i18n.T("keepalive.off", b.cfg.Monitor.IdleKillAfter))
+		}
+	case "on":
+		b.store.SetKeepAlive(key, true)
+		b.reapWarned.Delete(key)
+		b.sendReply(ctx, msg, i18n.T("keepalive.on"))
+	case "off":
+		b.store.SetKeepAlive(key, false)
+		b.store.TouchBinding(key)
+		b.sendReply(ctx, msg, i18n.T("keepalive.off", b.cfg.Monitor.IdleKillAfter))
+	default:
+		b.sendReply(ctx, msg, i18n.T("keepalive.usage"))
+	}
+}
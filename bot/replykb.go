@@ -0,0 +1,98 @@
+package bot
+
+import (
+	"context"
+	"strings"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+
+	"github.com/user/tgmux/i18n"
+)
+
+// replyButton 常驻快捷键盘的一个按钮：按钮文本（消息目录标识符）及对应的命令 handler
+type replyButton struct {
+	label   string
+	handler func(b *Bot) bot.HandlerFunc
+}
+
+// replyButtons 常驻快捷键盘的按钮，按行排列
+var replyButtons = [][]replyButton{
+	{
+		{label: "replykb.screenshot", handler: func(b *Bot) bot.HandlerFunc { return b.handleScreenshot }},
+		{label: "replykb.esc", handler: func(b *Bot) bot.HandlerFunc { return b.handleEsc }},
+	},
+	{
+		{label: "replykb.enter", handler: func(b *Bot) bot.HandlerFunc { return b.handleEnter }},
+		{label: "replykb.status", handler: func(b *Bot) bot.HandlerFunc { return b.handleSession }},
+	},
+}
+
+// ReplyKeyboard 常驻快捷键盘（非内联），按钮文本由 interceptReplyButton 拦截
+func ReplyKeyboard() models.ReplyKeyboardMarkup {
+	var rows [][]models.KeyboardButton
+	for _, line := range replyButtons {
+		var row []models.KeyboardButton
+		for _, btn := range line {
+			row = append(row, models.KeyboardButton{Text: i18n.T(btn.label)})
+		}
+		rows = append(rows, row)
+	}
+	return models.ReplyKeyboardMarkup{Keyboard: rows, IsPersistent: true, ResizeKeyboard: true}
+}
+
+// interceptReplyButton 消息文本与快捷键盘按钮一致时执行对应操作并返回 true。
+// 不论是否启用或当前是否绑定都会拦截，避免旧键盘的按钮文本被当作输入转发给后端
+func (b *Bot) interceptReplyButton(ctx context.Context, tgBot *bot.Bot, update *models.Update) bool {
+	text := strings.TrimSpace(update.Message.Text)
+	for _, line := range replyButtons {
+		for _, btn := range line {
+			if text == i18n.T(btn.label) {
+				btn.handler(b)(ctx, tgBot, update)
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// showReplyKeyboard 绑定后附上常驻快捷键盘（需开启 telegram.reply_keyboard）
+func (b *Bot) showReplyKeyboard(ctx context.Context, chatID int64, threadID int) {
+	if !b.cfg.Telegram.ReplyKeyboard {
+		return
+	}
+	b.pushers.sendAndRecord(ctx, &bot.SendMessageParams{
+		ChatID:          chatID,
+		MessageThreadID: threadID,
+		Text:            i18n.T("replykb.shown"),
+		ReplyMarkup:     ReplyKeyboard(),
+	})
+}
+
+// handleKeyboard /keyboard on|off 命令：显示或移除常驻快捷键盘
+func (b *Bot) handleKeyboard(ctx context.Context, tgBot *bot.Bot, update *models.Update) {
+	if update.Message == nil {
+		return
+	}
+	msg := update.Message
+	params := &bot.SendMessageParams{
+		ChatID:          msg.Chat.ID,
+		MessageThreadID: msg.MessageThreadID,
+	}
+	switch strings.TrimSpace(strings.TrimPrefix(msg.Text, "/keyboard")) {
+	case "", "on":
+		if _, ok := b.store.GetBinding(topicKeyFromMessage(msg)); !ok {
+			b.sendReply(ctx, msg, i18n.T("session.not_bound"))
+			return
+		}
+		params.Text = i18n.T("replykb.shown")
+		params.ReplyMarkup = ReplyKeyboard()
+	case "off":
+		params.Text = i18n.T("replykb.removed")
+		params.ReplyMarkup = models.ReplyKeyboardRemove{RemoveKeyboard: true}
+	default:
+		b.sendReply(ctx, msg, i18n.T("replykb.usage"))
+		return
+	}
+	b.pushers.sendAndRecord(ctx, params)
+}
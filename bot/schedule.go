@@ -0,0 +1,120 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+
+	"github.com/user/tgmux/i18n"
+)
+
+// scheduleCheckInterval 定时消息检查间隔
+const scheduleCheckInterval = 15 * time.Second
+
+// handleSchedule /schedule 命令：定时发送消息到当前会话
+func (b *Bot) handleSchedule(ctx context.Context, tgBot *bot.Bot, update *models.Update) {
+	if update.Message == nil {
+		return
+	}
+	msg := update.Message
+	key := topicKeyFromMessage(msg)
+	arg := strings.TrimSpace(strings.TrimPrefix(msg.Text, "/schedule"))
+	when, text, _ := strings.Cut(arg, " ")
+	text = strings.TrimSpace(text)
+
+	switch when {
+	case "", "list":
+		list := b.store.ListSchedules(key)
+		if len(list) == 0 {
+			b.sendReply(ctx, msg, i18n.T("schedule.none")+i18n.T("schedule.usage"))
+			return
+		}
+		lines := []string{i18n.T("schedule.list")}
+		for _, sc := range list {
+			lines = append(lines, fmt.Sprintf("#%d %s — %s", sc.ID, sc.FireAt.Format("01-02 15:04"), truncateRunes(sc.Text, 60)))
+		}
+		b.sendReply(ctx, msg, strings.Join(lines, "\n"))
+		return
+	case "cancel":
+		id, err := strconv.Atoi(strings.TrimPrefix(text, "#"))
+		if err != nil {
+			b.sendReply(ctx, msg, i18n.T("schedule.usage"))
+			return
+		}
+		if !b.store.CancelSchedule(key, id) {
+			b.sendReply(ctx, msg, i18n.T("schedule.not_found", id))
+			return
+		}
+		b.sendReply(ctx, msg, i18n.T("schedule.cancelled", id))
+		return
+	}
+
+	if _, ok := b.store.GetBinding(key); !ok {
+		b.sendReply(ctx, msg, i18n.T("session.not_bound"))
+		return
+	}
+	fireAt, err := parseFireTime(when, time.Now())
+	if err != nil || text == "" {
+		b.sendReply(ctx, msg, i18n.T("schedule.usage"))
+		return
+	}
+	sc := b.store.AddSchedule(key, text, fireAt)
+	b.sendReply(ctx, msg, i18n.T("schedule.created", sc.ID, fireAt.Format("01-02 15:04"), time.Until(fireAt).Truncate(time.Minute)))
+}
+
+// parseFireTime 解析时长（如 30m、8h）或当天时刻 HH:MM（已过则为次日）
+func parseFireTime(s string, now time.Time) (time.Time, error) {
+	if d, err := time.ParseDuration(s); err == nil {
+		if d <= 0 {
+			return time.Time{}, fmt.Errorf("duration must be positive")
+		}
+		return now.Add(d), nil
+	}
+	t, err := time.ParseInLocation("15:04", s, now.Location())
+	if err != nil {
+		return time.Time{}, err
+	}
+	fireAt := time.Date(now.Year(), now.Month(), now.Day(), t.Hour(), t.Minute(), 0, 0, now.Location())
+	if !fireAt.After(now) {
+		fireAt = fireAt.AddDate(0, 0, 1)
+	}
+	return fireAt, nil
+}
+
+// runScheduler 定期检查到期的定时消息并发送到对应窗口
+func (b *Bot) runScheduler(ctx context.Context) {
+	ticker := time.NewTicker(scheduleCheckInterval)
+	defer ticker.Stop()
+	for {
+		b.fireDueSchedules(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (b *Bot) fireDueSchedules(ctx context.Context) {
+	for _, sc := range b.store.PopDueSchedules(time.Now()) {
+		chatID, threadID, _ := parseTopicKey(sc.TopicKey)
+		if chatID == 0 {
+			continue
+		}
+		binding, ok := b.store.GetBinding(sc.TopicKey)
+		if !ok || !b.windowAlive(binding) || !b.backendAlive(binding) {
+			slog.Info("scheduled prompt dropped, session gone", "key", sc.TopicKey, "id", sc.ID)
+			b.sendMsg(ctx, chatID, threadID, i18n.T("schedule.dropped", sc.ID, sc.Text), nil)
+			continue
+		}
+		b.getOrCreateSendChan(binding.WindowID) <- &queuedInput{Text: sc.Text}
+		slog.Info("scheduled prompt sent", "key", sc.TopicKey, "id", sc.ID)
+		b.sendMsg(ctx, chatID, threadID, i18n.T("schedule.sent", sc.ID, truncateRunes(sc.Text, 100)), nil)
+	}
+}
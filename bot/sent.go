@@ -0,0 +1,55 @@
+package bot
+
+import (
+	"fmt"
+	"sync"
+)
+
+// sentLogCap 每个 Topic 记录的已发送消息 ID 数
+const sentLogCap = 500
+
+// SentLog 按聊天 Topic 记录 bot 最近发送的消息 ID（有上限的环形缓冲，仅在内存中），供之后删除
+type SentLog struct {
+	mu    sync.Mutex
+	rings map[string][]int
+}
+
+func NewSentLog() *SentLog {
+	return &SentLog{rings: make(map[string][]int)}
+}
+
+func sentLogKey(chatID int64, threadID int) string {
+	return fmt.Sprintf("%d:%d", chatID, threadID)
+}
+
+// Record 记录一条已发送消息的 ID，已满时淘汰最早的
+func (l *SentLog) Record(chatID int64, threadID int, msgID int) {
+	if l == nil || msgID == 0 {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	k := sentLogKey(chatID, threadID)
+	ring := append(l.rings[k], msgID)
+	if len(ring) > sentLogCap {
+		ring = ring[len(ring)-sentLogCap:]
+	}
+	l.rings[k] = ring
+}
+
+// PopRecent 取出最多 n 个最近的消息 ID，最新的在前
+func (l *SentLog) PopRecent(chatID int64, threadID int, n int) []int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	k := sentLogKey(chatID, threadID)
+	ring := l.rings[k]
+	if n > len(ring) {
+		n = len(ring)
+	}
+	ids := make([]int, 0, n)
+	for i := len(ring) - 1; i >= len(ring)-n; i-- {
+		ids = append(ids, ring[i])
+	}
+	l.rings[k] = ring[:len(ring)-n]
+	return ids
+}
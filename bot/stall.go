@@ -0,0 +1,63 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/go-telegram/bot/models"
+
+	"github.com/user/tgmux/i18n"
+)
+
+// armStall 转发输入后启动无输出提醒计时（monitor.stall_alert_after，负数为关闭）。
+// 已在计时中则保留原计时，从最早一条未得到输出的输入算起
+func (b *Bot) armStall(key, windowID string) {
+	after := b.cfg.Monitor.StallAlertAfter
+	if after <= 0 {
+		return
+	}
+	b.stallMu.Lock()
+	defer b.stallMu.Unlock()
+	if _, armed := b.stallTimers[key]; armed {
+		return
+	}
+	if b.stallTimers == nil {
+		b.stallTimers = make(map[string]*time.Timer)
+	}
+	b.stallTimers[key] = time.AfterFunc(after, func() {
+		b.fireStall(key, windowID, after)
+	})
+}
+
+// disarmStall 收到会话的任何输出后取消提醒
+func (b *Bot) disarmStall(key string) {
+	b.stallMu.Lock()
+	defer b.stallMu.Unlock()
+	if t, ok := b.stallTimers[key]; ok {
+		t.Stop()
+		delete(b.stallTimers, key)
+	}
+}
+
+// fireStall 输入后超时仍无输出：发送一次提醒和截图按钮，下次转发输入时重新计时
+func (b *Bot) fireStall(key, windowID string, after time.Duration) {
+	b.stallMu.Lock()
+	delete(b.stallTimers, key)
+	b.stallMu.Unlock()
+
+	binding, ok := b.store.GetBinding(key)
+	if !ok || binding.WindowID != windowID {
+		return
+	}
+	chatID, threadID, _ := parseTopicKey(key)
+	if chatID == 0 {
+		return
+	}
+	kb := models.InlineKeyboardMarkup{InlineKeyboard: [][]models.InlineKeyboardButton{
+		{{Text: i18n.T("kb.screenshot"), CallbackData: fmt.Sprintf("ss:refresh:%s", windowID)}},
+	}}
+	b.sendMsg(context.Background(), chatID, threadID, i18n.T("stall.alert", after), &kb)
+	slog.Info("stall alert sent", "key", key, "window", windowID, "after", after)
+}
@@ -24,15 +24,18 @@ type StatusEntry struct {
 // Bash backend uses PaneMonitor which already captures terminal changes.
 // A nil StatusPoller is safe to call — all methods are no-ops.
 type StatusPoller struct {
-	tgBot   *tgbot.Bot
-	tmuxMgr *tmux.Manager
-	pushers *PusherManager
-	store   *state.Store
+	tgBot    *tgbot.Bot
+	tmuxMgr  *tmux.Manager
+	pushers  *PusherManager
+	store    *state.Store
 	interval time.Duration
 
 	mu       sync.Mutex
 	statuses map[string]*StatusEntry // topicKey -> entry
 	cancel   context.CancelFunc
+
+	// capturesPane Topic 的监控器是否已在推送终端内容（自定义后端或降级的终端捕获）
+	capturesPane func(topicKey string) bool
 }
 
 // NewStatusPoller creates a status poller. Returns nil if interval <= 0 (disabled).
@@ -81,6 +84,17 @@ func (sp *StatusPoller) RemoveStatus(key string) {
 	sp.mu.Unlock()
 }
 
+// IsStatusMessage msgID 是否为 Topic 当前的状态消息
+func (sp *StatusPoller) IsStatusMessage(key string, msgID int) bool {
+	if sp == nil {
+		return false
+	}
+	sp.mu.Lock()
+	defer sp.mu.Unlock()
+	entry, ok := sp.statuses[key]
+	return ok && entry.MessageID == msgID
+}
+
 func (sp *StatusPoller) loop(ctx context.Context) {
 	ticker := time.NewTicker(sp.interval)
 	defer ticker.Stop()
@@ -105,6 +119,9 @@ func (sp *StatusPoller) pollAll(ctx context.Context) {
 		if binding.Backend == "bash" {
 			continue
 		}
+		if sp.capturesPane != nil && sp.capturesPane(key) {
+			continue
+		}
 
 		sp.pollOne(ctx, key, binding)
 	}
@@ -116,8 +133,13 @@ func (sp *StatusPoller) pollOne(ctx context.Context, key string, binding state.B
 		return
 	}
 
+	// Topic 静音期间跳过
+	if m, ok := sp.store.GetMute(key); ok && time.Now().Before(m.Until) {
+		return
+	}
+
 	// Capture pane content
-	text, err := sp.tmuxMgr.CapturePaneClean(binding.WindowID)
+	text, err := sp.tmuxMgr.CapturePaneJoined(binding.WindowID)
 	if err != nil {
 		return
 	}
@@ -157,9 +179,16 @@ func (sp *StatusPoller) pollOne(ctx context.Context, key string, binding state.B
 		if threadID != 0 {
 			params.MessageThreadID = threadID
 		}
+		if sp.pushers.WaitChat(ctx, chatID) != nil {
+			return
+		}
 		resp, err := sp.tgBot.SendMessage(ctx, params)
+		if retryAfter := parseRetryAfter(err); retryAfter > 0 {
+			sp.pushers.BackOff(retryAfter)
+		}
 		if err == nil {
 			entry.MessageID = resp.ID
+			sp.pushers.RecordSent(chatID, threadID, resp.ID)
 		}
 	} else {
 		params := &tgbot.EditMessageTextParams{
@@ -167,7 +196,13 @@ func (sp *StatusPoller) pollOne(ctx context.Context, key string, binding state.B
 			MessageID: entry.MessageID,
 			Text:      displayText,
 		}
+		if sp.pushers.WaitChat(ctx, chatID) != nil {
+			return
+		}
 		_, err := sp.tgBot.EditMessageText(ctx, params)
+		if retryAfter := parseRetryAfter(err); retryAfter > 0 {
+			sp.pushers.BackOff(retryAfter)
+		}
 		if err != nil {
 			slog.Debug("status edit failed, will send new next time", "key", key, "error", err)
 			entry.MessageID = 0
@@ -1,10 +1,17 @@
 package bot
 
 import (
+	"bytes"
 	"context"
 	"errors"
+	"fmt"
+	"hash/fnv"
+	"io"
 	"log/slog"
 	"math/rand"
+	"os"
+	"regexp"
+	"strconv"
 	"strings"
 	"sync"
 	"sync/atomic"
@@ -13,17 +20,90 @@ import (
 
 	tgbot "github.com/go-telegram/bot"
 	"github.com/go-telegram/bot/models"
+	"github.com/user/tgmux/i18n"
 	"github.com/user/tgmux/monitor"
 	"github.com/user/tgmux/sanitize"
+	"github.com/user/tgmux/state"
 )
 
-// RateLimiter implements global 429 rate limiting across all pushers
+// chatBurst 每个聊天可连续发送的消息数，超过后开始按间隔发送
+const chatBurst = 3
+
+// RateLimiter 所有 pusher 共用的 429 全局限流，并按聊天预先控制发送间隔，避免被 Telegram 拒绝
 type RateLimiter struct {
 	pauseUntil atomic.Int64 // unix timestamp ms
+
+	// 每个聊天一个令牌桶：每个间隔补充一个令牌，最多 chatBurst 个；间隔为 0 时不限速
+	mu              sync.Mutex
+	buckets         map[int64]*chatBucket
+	groupInterval   time.Duration
+	privateInterval time.Duration
+}
+
+// chatBucket 聊天在 last 时刻的可用令牌数，已预约发送时为负
+type chatBucket struct {
+	tokens float64
+	last   time.Time
 }
 
 func NewRateLimiter() *RateLimiter {
-	return &RateLimiter{}
+	return &RateLimiter{buckets: make(map[int64]*chatBucket)}
+}
+
+// SetPacing 设置群聊与私聊中消息的最小平均间隔
+func (r *RateLimiter) SetPacing(group, private time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.groupInterval, r.privateInterval = group, private
+}
+
+// reserve 从聊天的令牌桶取一个令牌，返回使用前需等待的时长
+func (r *RateLimiter) reserve(chatID int64) time.Duration {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	interval := r.privateInterval
+	if chatID < 0 {
+		// 群组与超级群组的 ID 为负数
+		interval = r.groupInterval
+	}
+	if interval <= 0 {
+		return 0
+	}
+	now := time.Now()
+	b, ok := r.buckets[chatID]
+	if !ok {
+		b = &chatBucket{tokens: chatBurst, last: now}
+		r.buckets[chatID] = b
+	}
+	b.tokens = min(chatBurst, b.tokens+float64(now.Sub(b.last))/float64(interval))
+	b.last = now
+	b.tokens--
+	if b.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-b.tokens * float64(interval))
+}
+
+// WaitChat 等待 429 暂停结束且聊天有空闲发送额度；消息与编辑都计入额度
+func (r *RateLimiter) WaitChat(ctx context.Context, chatID int64) error {
+	if err := r.Wait(ctx); err != nil {
+		return err
+	}
+	if wait := r.reserve(chatID); wait > 0 {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(wait):
+		}
+		// 等待期间可能又收到 429
+		return r.Wait(ctx)
+	}
+	return nil
+}
+
+// Note 记录一次未经等待的发送（直接回复），队列中的输出据此调整间隔
+func (r *RateLimiter) Note(chatID int64) {
+	r.reserve(chatID)
 }
 
 // Wait blocks until the 429 pause period expires
@@ -46,6 +126,28 @@ func (r *RateLimiter) Wait(ctx context.Context) error {
 	}
 }
 
+// PausedUntil 返回当前 429 暂停的结束时间，未暂停时为零值
+func (r *RateLimiter) PausedUntil() time.Time {
+	until := r.pauseUntil.Load()
+	if until == 0 || time.Now().UnixMilli() >= until {
+		return time.Time{}
+	}
+	return time.UnixMilli(until)
+}
+
+// Remaining 返回全局 429 暂停的剩余时长，未暂停时为 0
+func (r *RateLimiter) Remaining() time.Duration {
+	until := r.pauseUntil.Load()
+	if until == 0 {
+		return 0
+	}
+	d := time.Until(time.UnixMilli(until))
+	if d < 0 {
+		return 0
+	}
+	return d
+}
+
 // BackOff sets the global pause until time with jitter
 func (r *RateLimiter) BackOff(retryAfterSec int) {
 	if retryAfterSec <= 0 {
@@ -65,6 +167,20 @@ type MessageTask struct {
 	ContentType monitor.ContentType
 	ToolUseID   string // for tool_result pairing
 	ToolName    string // tool name for result stats
+	// 附在最后一段上的键盘（可选）
+	ReplyMarkup *models.InlineKeyboardMarkup
+	// 附在最后一段末尾的纯文本脚注（每条回答的用量，可选）
+	Footer string
+	// ContentImage 的图片数据，Text 作为说明文字
+	Photo []byte
+	// 工具结果的原始输出：折叠在统计行下方或放在按钮后
+	Output string
+
+	// Prompt 需要用户操作的消息：Text 已格式化，按 ParseMode 原样发送
+	Prompt    bool
+	ParseMode models.ParseMode
+	// 提示所针对的 tool_use：队列中直到该调用的输出先发送
+	AfterToolUse string
 }
 
 // StreamPusher sends messages to a Telegram chat via a FIFO queue.
@@ -75,23 +191,58 @@ type StreamPusher struct {
 	tgBot       *tgbot.Bot
 	rateLimiter *RateLimiter
 	redact      bool
+	sent        *SentLog
 
-	queue      chan MessageTask
-	cancel     context.CancelFunc
-	wg         sync.WaitGroup
+	queue        chan MessageTask
+	priority     chan MessageTask // 需要用户操作的提示，先于队列中的输出发送
+	cancel       context.CancelFunc
+	wg           sync.WaitGroup
 	toolMsgIDs   map[string]int    // tool_use_id → Telegram message_id for edit pairing
 	toolNames    map[string]string // tool_use_id → tool name
 	toolMsgTexts map[string]string // tool_use_id → original sent text
+	lastSent     atomic.Int64      // 最近一次成功发送的 unix 毫秒时间
+
+	todoMsgID int // Topic 的 TodoWrite 清单消息，更新时原地编辑
+	// 最近的终端进度消息，发送其他内容前由新的进度替换
+	progressMsgID int
+	// 最近发送的 tool_use，针对它的提示无需再等待
+	lastToolUse string
+
+	// mergeWindow 回答 / 思考消息发送前等待同类消息合并的时长（0 为不等待）
+	mergeWindow time.Duration
+	// promptWake 有提示入队时提前结束合并等待
+	promptWake chan struct{}
+
+	// 不超过 outputInline 个字符的工具输出折叠内嵌，更长的存入 outputs 由按钮查看
+	outputInline int
+	outputs      *ToolOutputs
+	// 超过 maxInline 个字符的回答以文件发送（0 为总是拆分成多条消息）
+	maxInline int
+
+	// 流式推送模式下追加回答 / 思考的消息（见 livemsg.go）
+	live liveMessage
+
+	// 队列已满时未能入队的消息，按跳过计数分类，队列清空后汇总
+	overflowMu    sync.Mutex
+	overflow      map[string]int
+	overflowTotal atomic.Int64 // 累计以汇总代替发送的消息数
+
+	// 持久化未配对的 tool_use，重启后到达的结果仍能编辑原消息
+	store    *state.Store
+	topicKey string
 }
 
-func NewStreamPusher(chatID int64, threadID int, tgBot *tgbot.Bot, rl *RateLimiter, redact bool) *StreamPusher {
+func NewStreamPusher(chatID int64, threadID int, tgBot *tgbot.Bot, rl *RateLimiter, redact bool, sent *SentLog, queueSize int) *StreamPusher {
 	return &StreamPusher{
-		chatID:      chatID,
-		threadID:    threadID,
-		tgBot:       tgBot,
-		rateLimiter: rl,
-		redact:      redact,
-		queue:       make(chan MessageTask, 100),
+		chatID:       chatID,
+		threadID:     threadID,
+		tgBot:        tgBot,
+		rateLimiter:  rl,
+		redact:       redact,
+		sent:         sent,
+		queue:        make(chan MessageTask, queueSize),
+		priority:     make(chan MessageTask, 20),
+		promptWake:   make(chan struct{}, 1),
 		toolMsgIDs:   make(map[string]int),
 		toolNames:    make(map[string]string),
 		toolMsgTexts: make(map[string]string),
@@ -113,15 +264,64 @@ func (p *StreamPusher) Stop() {
 	p.wg.Wait()
 }
 
-// Enqueue adds a message task to the queue
+// textEnqueueWait 队列已满时回答与思考等待空位的时长
+const textEnqueueWait = 10 * time.Second
+
+// Enqueue 将消息加入队列；队列已满时回答与思考等待空位，其他消息计数并在队列清空后汇总
 func (p *StreamPusher) Enqueue(task MessageTask) {
 	select {
 	case p.queue <- task:
+		return
 	default:
-		slog.Warn("message queue full, dropping", "chat", p.chatID)
+	}
+	if task.ContentType == monitor.ContentText || task.ContentType == monitor.ContentThinking {
+		timer := time.NewTimer(textEnqueueWait)
+		defer timer.Stop()
+		select {
+		case p.queue <- task:
+			return
+		case <-timer.C:
+		}
+	}
+	slog.Warn("message queue full, summarizing", "chat", p.chatID, "type", task.ContentType)
+	p.overflowMu.Lock()
+	if p.overflow == nil {
+		p.overflow = make(map[string]int)
+	}
+	p.overflow[contentKind(task.ContentType)]++
+	p.overflowMu.Unlock()
+	p.overflowTotal.Add(1)
+}
+
+// sendOverflow 发送未能入队消息的汇总（如有）
+func (p *StreamPusher) sendOverflow(ctx context.Context) {
+	p.overflowMu.Lock()
+	skipped := p.overflow
+	p.overflow = nil
+	p.overflowMu.Unlock()
+	if len(skipped) > 0 {
+		p.sendMessage(ctx, MessageTask{Text: i18n.T("push.overflow", skippedSummary(skipped)), ContentType: monitor.ContentSystem})
 	}
 }
 
+// EnqueuePriority 加入一条提示，先于队列中的输出发送
+func (p *StreamPusher) EnqueuePriority(task MessageTask) {
+	select {
+	case p.priority <- task:
+	default:
+		slog.Warn("priority queue full, dropping", "chat", p.chatID)
+	}
+	select {
+	case p.promptWake <- struct{}{}:
+	default:
+	}
+}
+
+// pending 返回两个队列中等待的消息数
+func (p *StreamPusher) pending() int {
+	return len(p.queue) + len(p.priority)
+}
+
 // sendWithRetry sends a message with HTML ParseMode, falling back to plain text on format errors, and retrying on 429
 func (p *StreamPusher) sendWithRetry(ctx context.Context, params *tgbot.SendMessageParams) (*models.Message, error) {
 	resp, err := p.tgBot.SendMessage(ctx, params)
@@ -179,22 +379,62 @@ func (p *StreamPusher) editWithRetry(ctx context.Context, params *tgbot.EditMess
 func (p *StreamPusher) worker(ctx context.Context) {
 	defer p.wg.Done()
 	for {
+		// 提示总是先于已入队的输出发送
+		select {
+		case task := <-p.priority:
+			p.sendPriority(ctx, task)
+			continue
+		default:
+		}
 		select {
 		case <-ctx.Done():
-			p.drain()
+			p.drain(nil, nil)
 			return
+		case task := <-p.priority:
+			p.sendPriority(ctx, task)
+		case <-p.live.due:
+			p.flushLive(ctx)
 		case task := <-p.queue:
-			merged, overflow := p.tryMerge(task)
+			merged, overflow := p.tryMerge(ctx, task)
+			if ctx.Err() != nil {
+				// 合并等待期间被停止：已收集的内容与剩余消息一起发送
+				p.drain(&merged, overflow)
+				return
+			}
 			p.sendMessage(ctx, merged)
 			if overflow != nil {
 				p.sendMessage(ctx, *overflow)
 			}
+			if len(p.queue) == 0 {
+				p.sendOverflow(ctx)
+			}
+		}
+	}
+}
+
+// sendPriority 发送提示；所针对的 tool_use 仍在队列中时，先发送直到该调用的输出，提示不会早于它
+func (p *StreamPusher) sendPriority(ctx context.Context, task MessageTask) {
+	if id := task.AfterToolUse; id != "" && id != p.lastToolUse {
+		if _, sent := p.toolMsgIDs[id]; !sent {
+		flush:
+			for {
+				select {
+				case queued := <-p.queue:
+					p.sendMessage(ctx, queued)
+					if queued.ContentType == monitor.ContentToolUse && queued.ToolUseID == id {
+						break flush
+					}
+				default:
+					break flush
+				}
+			}
 		}
 	}
+	p.sendMessage(ctx, task)
 }
 
-// tryMerge attempts to merge consecutive same-type text messages from the queue
-func (p *StreamPusher) tryMerge(first MessageTask) (MessageTask, *MessageTask) {
+// tryMerge 合并队列中连续的同类文本消息；有合并窗口时再等待该时长，遇到其他类型、达到长度上限或有提示时提前结束
+func (p *StreamPusher) tryMerge(ctx context.Context, first MessageTask) (MessageTask, *MessageTask) {
 	// Only merge text and thinking messages
 	if first.ContentType != monitor.ContentText && first.ContentType != monitor.ContentThinking {
 		return first, nil
@@ -202,30 +442,69 @@ func (p *StreamPusher) tryMerge(first MessageTask) (MessageTask, *MessageTask) {
 
 	const mergeMax = 3800
 	text := first.Text
+	footer := first.Footer // 只保留一个脚注：最后一条带脚注的消息的
+
+	// nil channel 永不触发：没有合并窗口时只合并已在队列中的消息
+	var windowEnd <-chan time.Time
+	if p.mergeWindow > 0 && len(p.priority) == 0 {
+		timer := time.NewTimer(p.mergeWindow)
+		defer timer.Stop()
+		windowEnd = timer.C
+		// 丢弃已发送提示遗留的唤醒
+		select {
+		case <-p.promptWake:
+		default:
+		}
+	}
 
 	for {
+		var next MessageTask
 		select {
-		case next := <-p.queue:
-			if next.ContentType != first.ContentType || utf8.RuneCountInString(text)+utf8.RuneCountInString(next.Text)+2 > mergeMax {
-				// Can't merge - return overflow
-				return MessageTask{Text: text, ContentType: first.ContentType}, &next
-			}
-			text += "\n\n" + next.Text
+		case next = <-p.queue:
 		default:
-			// No more messages in queue
-			return MessageTask{Text: text, ContentType: first.ContentType}, nil
+			if windowEnd == nil {
+				// No more messages in queue
+				return MessageTask{Text: text, ContentType: first.ContentType, Footer: footer}, nil
+			}
+			select {
+			case next = <-p.queue:
+			case <-windowEnd:
+				return MessageTask{Text: text, ContentType: first.ContentType, Footer: footer}, nil
+			case <-p.promptWake:
+				return MessageTask{Text: text, ContentType: first.ContentType, Footer: footer}, nil
+			case <-ctx.Done():
+				return MessageTask{Text: text, ContentType: first.ContentType, Footer: footer}, nil
+			}
+		}
+		// 按转义后的长度计算：转为 HTML 后 &amp; 等实体会变长
+		if next.ContentType != first.ContentType || utf16Len(escapeHTML(text))+utf16Len(escapeHTML(next.Text))+2 > mergeMax {
+			// Can't merge - return overflow
+			return MessageTask{Text: text, ContentType: first.ContentType, Footer: footer}, &next
+		}
+		text += "\n\n" + next.Text
+		if next.Footer != "" {
+			footer = next.Footer
 		}
 	}
 }
 
-func (p *StreamPusher) drain() {
+// drain pusher 停止后发送两个队列中剩余的消息，先发送 worker 已取出的（没有时为 nil）
+func (p *StreamPusher) drain(taken, overflow *MessageTask) {
 	drainCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
+	for _, task := range []*MessageTask{taken, overflow} {
+		if task != nil {
+			p.sendMessage(drainCtx, *task)
+		}
+	}
 	for {
 		select {
+		case task := <-p.priority:
+			p.sendMessage(drainCtx, task)
 		case task := <-p.queue:
 			p.sendMessage(drainCtx, task)
 		default:
+			p.endLive(drainCtx)
 			return
 		}
 	}
@@ -233,41 +512,87 @@ func (p *StreamPusher) drain() {
 
 func (p *StreamPusher) sendMessage(ctx context.Context, task MessageTask) {
 	text := sanitize.Redact(task.Text, p.redact)
+
+	// 流式模式下回答与思考追加到同一条消息，其他内容先结束该消息
+	live := p.streaming() && !task.Prompt &&
+		(task.ContentType == monitor.ContentText || task.ContentType == monitor.ContentThinking)
+	if !live {
+		p.endLive(ctx)
+	}
+
+	// 图片结果：以图片发送，失败时改为文字摘要
+	if task.ContentType == monitor.ContentImage {
+		if p.sendPhoto(ctx, task.Photo, text) {
+			p.forgetTool(task.ToolUseID)
+			return
+		}
+		task.ContentType = monitor.ContentToolResult
+	}
+
 	if strings.TrimSpace(text) == "" {
 		return
 	}
 
+	// TodoWrite 清单：原地编辑上一条，失败时发送新消息
+	if task.ContentType == monitor.ContentTodo && p.todoMsgID != 0 && p.editTodo(ctx, text) {
+		return
+	}
+	// 终端进度：之后没有发送过其他消息时替换上一条进度
+	if task.ContentType == monitor.ContentProgress && p.progressMsgID != 0 && p.editProgress(ctx, text) {
+		return
+	}
+
 	// tool_result: try to edit the paired tool_use message
+	output := sanitize.Redact(task.Output, p.redact)
 	if task.ContentType == monitor.ContentToolResult && task.ToolUseID != "" {
 		if msgID, ok := p.toolMsgIDs[task.ToolUseID]; ok {
 			origText := p.toolMsgTexts[task.ToolUseID]
-			delete(p.toolMsgIDs, task.ToolUseID)
-			delete(p.toolNames, task.ToolUseID)
-			delete(p.toolMsgTexts, task.ToolUseID)
-			p.editToolMessage(ctx, msgID, origText, text)
+			p.forgetTool(task.ToolUseID)
+			p.editToolMessage(ctx, msgID, origText, text, output)
 			return
 		}
 	}
+	if task.ContentType == monitor.ContentToolResult && output != "" {
+		p.sendToolResult(ctx, text, output)
+		return
+	}
 
-	// Split long messages
-	chunks := splitMessage(text, 4096)
-	for i, chunk := range chunks {
-		if err := p.rateLimiter.Wait(ctx); err != nil {
+	// 过长的回答以文件发送并附开头预览，不拆成多条消息
+	if task.ContentType == monitor.ContentText && !task.Prompt && p.maxInline > 0 && utf8.RuneCountInString(text) > p.maxInline {
+		p.endLive(ctx)
+		if p.sendDocument(ctx, text, task.Footer) {
 			return
 		}
+	}
 
-		// Apply formatting based on content type
-		var parseMode models.ParseMode
-		switch task.ContentType {
-		case monitor.ContentText:
-			chunk = toHTML(chunk)
-			parseMode = models.ParseModeHTML
-		case monitor.ContentThinking:
-			// Already has HTML blockquote tags from OutputHandler
-			parseMode = models.ParseModeHTML
-		case monitor.ContentToolUse, monitor.ContentToolResult:
-			chunk = escapeHTML(chunk)
-			parseMode = models.ParseModeHTML
+	// Apply formatting based on content type
+	var parseMode models.ParseMode
+	switch ct := task.ContentType; {
+	case task.Prompt:
+		// 调用方已格式化
+		parseMode = task.ParseMode
+	case ct == monitor.ContentText:
+		text = toHTML(text)
+		parseMode = models.ParseModeHTML
+	case ct == monitor.ContentThinking:
+		// Already has HTML blockquote tags from OutputHandler
+		parseMode = models.ParseModeHTML
+	case ct == monitor.ContentToolUse, ct == monitor.ContentToolResult, ct == monitor.ContentSystem, ct == monitor.ContentTodo, ct == monitor.ContentProgress:
+		text = escapeHTML(text)
+		parseMode = models.ParseModeHTML
+	}
+
+	if live {
+		if p.appendLive(task.ContentType, text, task.Footer) {
+			return
+		}
+		p.endLive(ctx)
+	}
+
+	chunks := messageChunks(text, parseMode, task.Footer)
+	for i, chunk := range chunks {
+		if err := p.rateLimiter.WaitChat(ctx, p.chatID); err != nil {
+			return
 		}
 
 		params := &tgbot.SendMessageParams{
@@ -279,25 +604,228 @@ func (p *StreamPusher) sendMessage(ctx context.Context, task MessageTask) {
 		if p.threadID != 0 {
 			params.MessageThreadID = p.threadID
 		}
+		if task.ReplyMarkup != nil && i == len(chunks)-1 {
+			params.ReplyMarkup = *task.ReplyMarkup
+		}
 
 		resp, err := p.sendWithRetry(ctx, params)
 		if err != nil {
 			slog.Error("sendMessage failed", "error", err)
 			return
 		}
+		p.lastSent.Store(time.Now().UnixMilli())
+		p.sent.Record(p.chatID, p.threadID, resp.ID)
 		slog.Info("message sent", "chat", p.chatID, "thread", p.threadID, "msgID", resp.ID, "textLen", len(chunk), "type", task.ContentType)
 
+		if task.ContentType == monitor.ContentTodo && i == len(chunks)-1 {
+			p.todoMsgID = resp.ID
+		}
+		if live && i == len(chunks)-1 {
+			p.startLive(resp.ID, task.ContentType, params.Text, task.Footer == "")
+		}
+		p.progressMsgID = 0
+		if task.ContentType == monitor.ContentProgress && i == len(chunks)-1 {
+			p.progressMsgID = resp.ID
+		}
+
 		// tool_use: record the last chunk's msg ID + text for later edit pairing
 		if task.ContentType == monitor.ContentToolUse && task.ToolUseID != "" && i == len(chunks)-1 {
+			p.lastToolUse = task.ToolUseID
 			p.toolMsgIDs[task.ToolUseID] = resp.ID
 			p.toolNames[task.ToolUseID] = task.ToolName
 			p.toolMsgTexts[task.ToolUseID] = chunk
+			if p.store != nil {
+				p.store.SetPendingToolMsg(p.topicKey, task.ToolUseID, resp.ID, chunk)
+			}
+		}
+	}
+}
+
+// restoreTools 恢复重启前发送、仍在等待结果的 tool_use 消息
+func (p *StreamPusher) restoreTools() {
+	for id, t := range p.store.PendingTools(p.topicKey) {
+		if t.MsgID == 0 {
+			continue
+		}
+		p.toolMsgIDs[id] = t.MsgID
+		p.toolNames[id] = t.Name
+		p.toolMsgTexts[id] = t.Text
+	}
+}
+
+// forgetTool 结果送达后删除 tool_use 配对
+func (p *StreamPusher) forgetTool(toolUseID string) {
+	delete(p.toolMsgIDs, toolUseID)
+	delete(p.toolNames, toolUseID)
+	delete(p.toolMsgTexts, toolUseID)
+	if p.store != nil && toolUseID != "" {
+		p.store.DeletePendingTool(p.topicKey, toolUseID)
+	}
+}
+
+// sendPhoto 上传图片并附说明文字，返回 false 时改为发送文字
+func (p *StreamPusher) sendPhoto(ctx context.Context, photo []byte, caption string) bool {
+	if err := p.rateLimiter.WaitChat(ctx, p.chatID); err != nil {
+		return true
+	}
+	params := &tgbot.SendPhotoParams{
+		ChatID:  p.chatID,
+		Photo:   &models.InputFileUpload{Filename: "image", Data: bytes.NewReader(photo)},
+		Caption: truncateUTF16(strings.TrimSpace(caption), 1024),
+	}
+	if p.threadID != 0 {
+		params.MessageThreadID = p.threadID
+	}
+	resp, err := p.tgBot.SendPhoto(ctx, params)
+	if retryAfter := parseRetryAfter(err); retryAfter > 0 {
+		p.rateLimiter.BackOff(retryAfter)
+		if waitErr := p.rateLimiter.Wait(ctx); waitErr != nil {
+			return true
+		}
+		params.Photo = &models.InputFileUpload{Filename: "image", Data: bytes.NewReader(photo)}
+		resp, err = p.tgBot.SendPhoto(ctx, params)
+	}
+	if err != nil {
+		slog.Warn("sendPhoto failed, sending text summary", "error", err, "bytes", len(photo))
+		return false
+	}
+	p.lastSent.Store(time.Now().UnixMilli())
+	p.sent.Record(p.chatID, p.threadID, resp.ID)
+	p.progressMsgID = 0
+	slog.Info("photo sent", "chat", p.chatID, "thread", p.threadID, "msgID", resp.ID, "bytes", len(photo))
+	return true
+}
+
+// documentPreview 以文件发送的回答在说明文字中预览的字符数
+const documentPreview = 300
+
+// sendDocument 将已脱敏的文本作为 Markdown 文件上传并附开头预览，返回 false 时改为拆分发送
+func (p *StreamPusher) sendDocument(ctx context.Context, text, footer string) bool {
+	tmp, err := os.CreateTemp("", "tgmux-output-*.md")
+	if err != nil {
+		slog.Warn("create temp file failed, sending as messages", "error", err)
+		return false
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+	if _, err := tmp.WriteString(text); err != nil {
+		slog.Warn("write temp file failed, sending as messages", "error", err)
+		return false
+	}
+
+	preview := strings.TrimSpace(text)
+	if utf8.RuneCountInString(preview) > documentPreview {
+		preview = truncateRunes(preview, documentPreview) + "…"
+	}
+	caption := preview + "\n\n" + i18n.T("push.attached", utf8.RuneCountInString(text))
+	if footer != "" {
+		caption += "\n" + footer
+	}
+	params := &tgbot.SendDocumentParams{
+		ChatID:  p.chatID,
+		Caption: truncateUTF16(caption, 1024),
+	}
+	if p.threadID != 0 {
+		params.MessageThreadID = p.threadID
+	}
+	filename := documentName(text, time.Now())
+
+	var resp *models.Message
+	for attempt := 0; attempt < 2; attempt++ {
+		if err := p.rateLimiter.WaitChat(ctx, p.chatID); err != nil {
+			return true
+		}
+		if _, err := tmp.Seek(0, io.SeekStart); err != nil {
+			return false
+		}
+		params.Document = &models.InputFileUpload{Filename: filename, Data: tmp}
+		resp, err = p.tgBot.SendDocument(ctx, params)
+		retryAfter := parseRetryAfter(err)
+		if retryAfter <= 0 {
+			break
+		}
+		p.rateLimiter.BackOff(retryAfter)
+	}
+	if err != nil {
+		slog.Warn("sendDocument failed, sending as messages", "error", err, "bytes", len(text))
+		return false
+	}
+	p.lastSent.Store(time.Now().UnixMilli())
+	p.sent.Record(p.chatID, p.threadID, resp.ID)
+	p.progressMsgID = 0
+	slog.Info("document sent", "chat", p.chatID, "thread", p.threadID, "msgID", resp.ID, "bytes", len(text))
+	return true
+}
+
+// documentName 以文本的第一个 Markdown 标题作为文件名，没有时为 output-<时间戳>.md
+func documentName(text string, now time.Time) string {
+	for _, line := range strings.SplitN(text, "\n", 50) {
+		line = strings.TrimSpace(line)
+		title, ok := strings.CutPrefix(strings.TrimLeft(line, "#"), " ")
+		if !ok || !strings.HasPrefix(line, "#") {
+			continue
 		}
+		title = strings.TrimSpace(title)
+		name := strings.Trim(strings.Map(func(r rune) rune {
+			switch {
+			case r == '/' || r == '\\' || r < ' ' || strings.ContainsRune(`:*?"<>|`, r):
+				return -1
+			case r == ' ':
+				return '-'
+			}
+			return r
+		}, title), "-.")
+		if name != "" {
+			return truncateRunes(name, 60) + ".md"
+		}
+	}
+	return "output-" + now.Format("20060102-150405") + ".md"
+}
+
+// editTodo 替换清单消息的内容，返回 false 时需重新发送
+func (p *StreamPusher) editTodo(ctx context.Context, text string) bool {
+	if !p.editPlain(ctx, p.todoMsgID, text) {
+		p.todoMsgID = 0
+		return false
+	}
+	return true
+}
+
+// editProgress 替换上一条进度消息，返回 false 时需重新发送
+func (p *StreamPusher) editProgress(ctx context.Context, text string) bool {
+	if !p.editPlain(ctx, p.progressMsgID, text) {
+		p.progressMsgID = 0
+		return false
 	}
+	return true
 }
 
-func (p *StreamPusher) editToolMessage(ctx context.Context, msgID int, origText string, resultText string) {
-	if err := p.rateLimiter.Wait(ctx); err != nil {
+// editPlain 以转义后的纯文本替换消息内容
+func (p *StreamPusher) editPlain(ctx context.Context, msgID int, text string) bool {
+	if err := p.rateLimiter.WaitChat(ctx, p.chatID); err != nil {
+		return true
+	}
+	text = truncateHTML(escapeHTML(text), 4096)
+	_, err := p.editWithRetry(ctx, &tgbot.EditMessageTextParams{
+		ChatID:    p.chatID,
+		MessageID: msgID,
+		Text:      text,
+		ParseMode: models.ParseModeHTML,
+	})
+	if err != nil {
+		if strings.Contains(err.Error(), "message is not modified") {
+			return true
+		}
+		slog.Warn("edit message failed, sending as new message", "error", err)
+		return false
+	}
+	p.lastSent.Store(time.Now().UnixMilli())
+	return true
+}
+
+// editToolMessage 将工具结果（及输出）追加到配对的 tool_use 消息
+func (p *StreamPusher) editToolMessage(ctx context.Context, msgID int, origText string, resultText string, output string) {
+	if err := p.rateLimiter.WaitChat(ctx, p.chatID); err != nil {
 		return
 	}
 
@@ -306,32 +834,57 @@ func (p *StreamPusher) editToolMessage(ctx context.Context, msgID int, origText
 		newText = escapeHTML(resultText)
 	}
 
-	if utf8.RuneCountInString(newText) > 4096 {
-		newText = truncateRunes(newText, 4093) + "..."
-	}
+	newText = truncateHTML(newText, 4096)
+	extra, kb := p.toolOutputExtras(utf16Len(newText), output)
 
 	params := &tgbot.EditMessageTextParams{
 		ChatID:             p.chatID,
 		MessageID:          msgID,
-		Text:               newText,
+		Text:               newText + extra,
 		ParseMode:          models.ParseModeHTML,
 		LinkPreviewOptions: &models.LinkPreviewOptions{IsDisabled: boolPtr(true)},
 	}
+	if kb != nil {
+		params.ReplyMarkup = *kb
+	}
 
 	_, err := p.editWithRetry(ctx, params)
+	if err == nil {
+		p.lastSent.Store(time.Now().UnixMilli())
+	}
 	if err != nil {
 		slog.Warn("editMessageText failed, sending as new message", "error", err)
-		sendParams := &tgbot.SendMessageParams{
-			ChatID:             p.chatID,
-			Text:               escapeHTML(resultText),
-			ParseMode:          models.ParseModeHTML,
-			LinkPreviewOptions: &models.LinkPreviewOptions{IsDisabled: boolPtr(true)},
-		}
-		if p.threadID != 0 {
-			sendParams.MessageThreadID = p.threadID
-		}
-		p.sendWithRetry(ctx, sendParams)
+		p.sendToolResult(ctx, resultText, output)
+	}
+}
+
+// sendToolResult 单独发送工具结果，输出折叠内嵌或放在按钮后
+func (p *StreamPusher) sendToolResult(ctx context.Context, resultText string, output string) {
+	if err := p.rateLimiter.WaitChat(ctx, p.chatID); err != nil {
+		return
+	}
+	text := truncateHTML(escapeHTML(resultText), 4096)
+	extra, kb := p.toolOutputExtras(utf16Len(text), output)
+	params := &tgbot.SendMessageParams{
+		ChatID:             p.chatID,
+		Text:               text + extra,
+		ParseMode:          models.ParseModeHTML,
+		LinkPreviewOptions: &models.LinkPreviewOptions{IsDisabled: boolPtr(true)},
+	}
+	if p.threadID != 0 {
+		params.MessageThreadID = p.threadID
 	}
+	if kb != nil {
+		params.ReplyMarkup = *kb
+	}
+	resp, err := p.sendWithRetry(ctx, params)
+	if err != nil {
+		slog.Error("sendMessage failed", "error", err)
+		return
+	}
+	p.lastSent.Store(time.Now().UnixMilli())
+	p.sent.Record(p.chatID, p.threadID, resp.ID)
+	p.progressMsgID = 0
 }
 
 // parseRetryAfter extracts RetryAfter seconds from TooManyRequestsError, returns 0 if not a 429
@@ -345,13 +898,13 @@ func parseRetryAfter(err error) int {
 
 func boolPtr(b bool) *bool { return &b }
 
-// splitMessage splits text into chunks fitting Telegram's limit (maxLen in runes), preferring newline boundaries
+// splitMessage 按 Telegram 的长度限制（maxLen 个 UTF-16 码元）拆分文本，优先在换行处切分
 func splitMessage(text string, maxLen int) []string {
-	if utf8.RuneCountInString(text) <= maxLen {
+	if utf16Len(text) <= maxLen {
 		return []string{text}
 	}
 	var chunks []string
-	for utf8.RuneCountInString(text) > maxLen {
+	for utf16Len(text) > maxLen {
 		splitIdx := findSplitPoint(text, maxLen)
 		chunks = append(chunks, text[:splitIdx])
 		text = text[splitIdx:]
@@ -362,26 +915,30 @@ func splitMessage(text string, maxLen int) []string {
 	return chunks
 }
 
-// findSplitPoint finds a good byte-index split point where the prefix has at most maxLen runes,
-// respecting code blocks and newlines
+// findSplitPoint 寻找切分位置（字节下标），前缀不超过 maxLen 个 UTF-16 码元，优先代码块与换行
 func findSplitPoint(text string, maxLen int) int {
-	runeCount := utf8.RuneCountInString(text)
-	if maxLen >= runeCount {
+	if maxLen >= utf16Len(text) {
 		return len(text)
 	}
 
-	// Find the byte offset corresponding to maxLen runes
-	byteLimit := runeByteOffset(text, maxLen)
+	// maxLen 个码元对应的字节位置，至少包含一个字符
+	byteLimit := utf16ByteOffset(text, maxLen)
+	if byteLimit == 0 {
+		_, byteLimit = utf8.DecodeRuneInString(text)
+	}
 	sub := text[:byteLimit]
 
 	// Look for last ``` before byteLimit
 	lastFence := strings.LastIndex(sub, "```")
 	if lastFence > byteLimit/2 {
+		// 围栏所在行在限制内结束时才在其后切分，否则按换行或硬切
 		nlIdx := strings.Index(text[lastFence:], "\n")
-		if nlIdx >= 0 {
-			return lastFence + nlIdx + 1
+		if nlIdx < 0 {
+			return lastFence
+		}
+		if end := lastFence + nlIdx + 1; end <= byteLimit {
+			return end
 		}
-		return lastFence
 	}
 
 	// Look for last newline before byteLimit
@@ -409,6 +966,40 @@ func truncateRunes(s string, n int) string {
 	return s[:runeByteOffset(s, n)]
 }
 
+// utf16Len 返回 s 的 UTF-16 码元数（Telegram 长度限制的单位），BMP 以外的字符（多数 emoji）占两个
+func utf16Len(s string) int {
+	n := 0
+	for _, r := range s {
+		if r >= 0x10000 {
+			n += 2
+		} else {
+			n++
+		}
+	}
+	return n
+}
+
+// utf16ByteOffset 返回不超过 n 个 UTF-16 码元的最长前缀的结束字节位置，总在字符边界上
+func utf16ByteOffset(s string, n int) int {
+	units := 0
+	for i, r := range s {
+		size := 1
+		if r >= 0x10000 {
+			size = 2
+		}
+		if units+size > n {
+			return i
+		}
+		units += size
+	}
+	return len(s)
+}
+
+// truncateUTF16 返回 s 不超过 n 个 UTF-16 码元的最长前缀
+func truncateUTF16(s string, n int) string {
+	return s[:utf16ByteOffset(s, n)]
+}
+
 // PusherManager manages all active StreamPushers
 type PusherManager struct {
 	mu      sync.Mutex
@@ -416,15 +1007,99 @@ type PusherManager struct {
 	tgBot   *tgbot.Bot
 	rl      *RateLimiter
 	redact  bool
+	store   *state.Store
+	sent    *SentLog
+
+	// autoConfirm 不显示键盘直接回答权限提示，返回要发送的说明；为空时需向用户显示提示
+	autoConfirm func(topicKey, windowID, prompt, tool string) string
+	// capture 返回当前终端内容，用于解析交互菜单
+	capture func(windowID string) (string, error)
+	// filter 返回 Topic 按内容类型的推送过滤（/filter）
+	filter func(topicKey string) state.Filter
+	// confirmPatterns 返回 Topic 的自定义后端声明的额外确认提示正则
+	confirmPatterns func(topicKey string) []*regexp.Regexp
+	// canAlways Topic 的后端是否有"始终允许"选项
+	canAlways func(topicKey string) bool
+
+	// showUsage 在最终回答后附上用量脚注（monitor.show_usage）
+	showUsage bool
+
+	// queueSize 每个 Topic 的输出队列容量（monitor.push_queue_size）
+	queueSize int
+	// mergeWindow 流式文本发送前等待合并的时长（monitor.merge_window）
+	mergeWindow time.Duration
+	// outputInline 折叠内嵌到结果消息中的最大工具输出（monitor.tool_output_inline）
+	outputInline int
+	// outputs 保存较长的工具输出，供"查看输出"按钮使用
+	outputs *ToolOutputs
+	// maxInline 以消息而非文件发送的最长回答（telegram.max_inline_chars）
+	maxInline int
+
+	// promptCooldown 同一 Topic 内相同交互 / 确认提示的重复提醒抑制时长
+	promptCooldown time.Duration
+	promptMu       sync.Mutex
+	lastPrompts    map[string]lastPrompt // topicKey → 最近一次提示
 }
 
-func NewPusherManager(tgBot *tgbot.Bot, redact bool) *PusherManager {
+// lastPrompt 发送给 Topic 的最近一次提示
+type lastPrompt struct {
+	hash uint64
+	at   time.Time
+}
+
+func NewPusherManager(tgBot *tgbot.Bot, redact bool, store *state.Store) *PusherManager {
 	return &PusherManager{
 		pushers: make(map[string]*StreamPusher),
 		tgBot:   tgBot,
 		rl:      NewRateLimiter(),
 		redact:  redact,
+		store:   store,
+		sent:    NewSentLog(),
+		outputs: NewToolOutputs(),
+
+		lastPrompts: make(map[string]lastPrompt),
+	}
+}
+
+// shouldNotifyPrompt 检测到的提示对 Topic 是否为新提示：promptCooldown 内同类同文本的视为重复
+func (pm *PusherManager) shouldNotifyPrompt(topicKey, kind, text string) bool {
+	if pm.promptCooldown <= 0 {
+		return true
+	}
+	h := fnv.New64a()
+	h.Write([]byte(kind))
+	h.Write([]byte{0})
+	h.Write([]byte(strings.TrimSpace(text)))
+	sum := h.Sum64()
+
+	now := time.Now()
+	pm.promptMu.Lock()
+	defer pm.promptMu.Unlock()
+	if last, ok := pm.lastPrompts[topicKey]; ok && last.hash == sum && now.Sub(last.at) < pm.promptCooldown {
+		return false
 	}
+	pm.lastPrompts[topicKey] = lastPrompt{hash: sum, at: now}
+	return true
+}
+
+// customConfirm 文本是否匹配 Topic 自定义后端的确认提示正则
+func (pm *PusherManager) customConfirm(topicKey, text string) bool {
+	if pm.confirmPatterns == nil {
+		return false
+	}
+	for _, re := range pm.confirmPatterns(topicKey) {
+		if re.MatchString(text) {
+			return true
+		}
+	}
+	return false
+}
+
+// ResetPrompt 用户回答后清除 Topic 的最近提示，之后相同文本的新提示仍会提醒
+func (pm *PusherManager) ResetPrompt(topicKey string) {
+	pm.promptMu.Lock()
+	delete(pm.lastPrompts, topicKey)
+	pm.promptMu.Unlock()
 }
 
 // GetOrCreate returns existing pusher or creates a new one
@@ -436,7 +1111,12 @@ func (pm *PusherManager) GetOrCreate(ctx context.Context, topicKey string, chatI
 		return p
 	}
 
-	p := NewStreamPusher(chatID, threadID, pm.tgBot, pm.rl, pm.redact)
+	p := NewStreamPusher(chatID, threadID, pm.tgBot, pm.rl, pm.redact, pm.sent, pm.queueSize)
+	p.store, p.topicKey = pm.store, topicKey
+	p.mergeWindow = pm.mergeWindow
+	p.outputInline, p.outputs = pm.outputInline, pm.outputs
+	p.maxInline = pm.maxInline
+	p.restoreTools()
 	p.Start(ctx)
 	pm.pushers[topicKey] = p
 	return p
@@ -478,59 +1158,393 @@ func (pm *PusherManager) HasPending(topicKey string) bool {
 	pm.mu.Lock()
 	p, ok := pm.pushers[topicKey]
 	pm.mu.Unlock()
-	return ok && len(p.queue) > 0
+	return ok && p.pending() > 0
+}
+
+// QueueLen 返回 Topic 的 pusher 队列中的消息数
+func (pm *PusherManager) QueueLen(topicKey string) int {
+	pm.mu.Lock()
+	p, ok := pm.pushers[topicKey]
+	pm.mu.Unlock()
+	if !ok {
+		return 0
+	}
+	return p.pending()
+}
+
+// Overflowed 返回 Topic 因队列已满而被汇总的消息数
+func (pm *PusherManager) Overflowed(topicKey string) int64 {
+	pm.mu.Lock()
+	p, ok := pm.pushers[topicKey]
+	pm.mu.Unlock()
+	if !ok {
+		return 0
+	}
+	return p.overflowTotal.Load()
+}
+
+// LastSent 返回 Topic 的 pusher 最近一次发送的时间，从未发送时为零值
+func (pm *PusherManager) LastSent(topicKey string) time.Time {
+	pm.mu.Lock()
+	p, ok := pm.pushers[topicKey]
+	pm.mu.Unlock()
+	if !ok {
+		return time.Time{}
+	}
+	ms := p.lastSent.Load()
+	if ms == 0 {
+		return time.Time{}
+	}
+	return time.UnixMilli(ms)
+}
+
+// RecordSent 记录 bot 发送的消息，供 /clear 删除
+func (pm *PusherManager) RecordSent(chatID int64, threadID int, msgID int) {
+	pm.sent.Record(chatID, threadID, msgID)
+}
+
+// sendAndRecord 不经队列直接发送消息并记录其 ID，返回消息 ID，失败时为 0
+func (pm *PusherManager) sendAndRecord(ctx context.Context, params *tgbot.SendMessageParams) int {
+	resp, err := pm.tgBot.SendMessage(ctx, params)
+	if err != nil {
+		slog.Warn("send message failed", "chat", params.ChatID, "error", err)
+		return 0
+	}
+	// 不等待，但计入额度，队列中的输出据此调整间隔
+	pm.rl.Note(resp.Chat.ID)
+	pm.RecordSent(resp.Chat.ID, params.MessageThreadID, resp.ID)
+	return resp.ID
+}
+
+// DeleteRecent 删除 Topic 中最近 n 条 bot 发送的消息（遵守 429 暂停），返回成功与失败的数量
+func (pm *PusherManager) DeleteRecent(ctx context.Context, chatID int64, threadID int, n int) (deleted, failed int) {
+	for _, id := range pm.sent.PopRecent(chatID, threadID, n) {
+		if err := pm.rl.Wait(ctx); err != nil {
+			return deleted, failed
+		}
+		params := &tgbot.DeleteMessageParams{ChatID: chatID, MessageID: id}
+		_, err := pm.tgBot.DeleteMessage(ctx, params)
+		if retryAfter := parseRetryAfter(err); retryAfter > 0 {
+			pm.rl.BackOff(retryAfter)
+			if waitErr := pm.rl.Wait(ctx); waitErr != nil {
+				return deleted, failed
+			}
+			_, err = pm.tgBot.DeleteMessage(ctx, params)
+		}
+		if err != nil {
+			// bot 无法删除超过 48 小时的消息
+			slog.Debug("delete message failed", "chat", chatID, "msgID", id, "error", err)
+			failed++
+			continue
+		}
+		deleted++
+	}
+	return deleted, failed
+}
+
+// SetPacing 设置群聊与私聊的消息间隔（0 为不限速）
+func (pm *PusherManager) SetPacing(group, private time.Duration) {
+	pm.rl.SetPacing(group, private)
+}
+
+// WaitChat pusher 以外的发送方等待可以向聊天发送或编辑消息
+func (pm *PusherManager) WaitChat(ctx context.Context, chatID int64) error {
+	return pm.rl.WaitChat(ctx, chatID)
+}
+
+// BackOff pusher 以外的请求被限流后开始全局 429 暂停
+func (pm *PusherManager) BackOff(retryAfterSec int) {
+	pm.rl.BackOff(retryAfterSec)
+}
+
+// RateLimited 返回全局 429 暂停的剩余时长
+func (pm *PusherManager) RateLimited() time.Duration {
+	return pm.rl.Remaining()
+}
+
+// RateLimitedUntil 返回全局 429 暂停的结束时间，未暂停时为零值
+func (pm *PusherManager) RateLimitedUntil() time.Time {
+	return pm.rl.PausedUntil()
 }
 
 // OutputHandler returns a monitor.OutputHandler that routes to the correct pusher
 func (pm *PusherManager) OutputHandler(ctx context.Context, topicKey string, chatID int64, threadID int, isPrivate bool, windowID string) monitor.OutputHandler {
+	var lastTool string // 最近的工具调用，即权限提示所针对的调用
+	var lastToolSummary, lastToolID string
 	return func(key string, content monitor.ParsedContent) {
+		if content.Type == monitor.ContentToolUse && content.ToolName != "" {
+			lastTool = content.ToolName
+			lastToolSummary = content.Text
+			lastToolID = content.ToolUseID
+		}
+
+		// 计划也是提示：不受暂停影响，且不经过下面的通用检测
+		if content.Type == monitor.ContentPlan {
+			pm.sendPlan(ctx, topicKey, chatID, threadID, windowID, content.Text)
+			return
+		}
+
 		// Check for interactive UI (multi-choice menus, selectors)
 		if monitor.DetectInteractiveUI(content.Text) {
-			kb := InteractiveKeyboard(windowID)
-			params := &tgbot.SendMessageParams{
-				ChatID:      chatID,
-				Text:        "🎮 检测到交互式界面：",
-				ReplyMarkup: kb,
+			if pm.shouldNotifyPrompt(topicKey, "interactive", content.Text) {
+				pm.sendInteractivePrompt(ctx, topicKey, chatID, threadID, windowID, lastToolID)
 			}
-			if threadID != 0 {
-				params.MessageThreadID = threadID
+		} else if monitor.DetectConfirmPrompt(content.Text) || pm.customConfirm(topicKey, content.Text) {
+			// 检测简单确认提示（y/n），自动确认的只留下说明
+			note := ""
+			if pm.autoConfirm != nil {
+				note = pm.autoConfirm(topicKey, windowID, content.Text, lastTool)
 			}
-			pm.tgBot.SendMessage(ctx, params)
-		} else if monitor.DetectConfirmPrompt(content.Text) {
-			// Check for simple confirm prompts (y/n)
-			kb := ConfirmKeyboard(windowID)
-			params := &tgbot.SendMessageParams{
-				ChatID:      chatID,
-				Text:        "🔐 检测到权限确认请求：",
-				ReplyMarkup: kb,
+			if note != "" {
+				pm.sendAndRecord(ctx, &tgbot.SendMessageParams{
+					ChatID:          chatID,
+					MessageThreadID: threadID,
+					Text:            note,
+				})
+			} else if pm.shouldNotifyPrompt(topicKey, "confirm", content.Text) {
+				// 显示待批准的内容：等待中的工具调用，终端捕获的会话为提示所在的行
+				excerpt := lastToolSummary
+				if excerpt == "" {
+					excerpt = monitor.ConfirmContext(content.Text)
+				}
+				pm.sendConfirmPrompt(ctx, topicKey, chatID, threadID, windowID, lastTool, lastToolID, excerpt)
 			}
-			if threadID != 0 {
-				params.MessageThreadID = threadID
+		}
+
+		// 回合结束通知与提示一样不受暂停影响，但遵守静音
+		if content.Type == monitor.ContentTurnDone {
+			if m, ok := pm.store.GetMute(topicKey); ok && time.Now().Before(m.Until) {
+				return
 			}
-			pm.tgBot.SendMessage(ctx, params)
+			kb := TurnDoneKeyboard(windowID)
+			pm.GetOrCreate(ctx, topicKey, chatID, threadID).Enqueue(MessageTask{
+				Text:        i18n.T("turn.done", content.Elapsed.Round(time.Second), content.ToolCalls),
+				ContentType: content.Type,
+				ReplyMarkup: &kb,
+			})
+			return
+		}
+
+		// 错误总是送达 Topic（即使暂停或静音），并先于队列中的输出
+		if content.Type == monitor.ContentSystem && content.Level == monitor.LevelError {
+			pm.GetOrCreate(ctx, topicKey, chatID, threadID).EnqueuePriority(systemTask(content))
+			return
+		}
+
+		// 被过滤的内容类型直接丢弃，上面的提示检测已对其执行过
+		if pm.filter != nil && !filterAllows(pm.filter(topicKey), content.Type) {
+			return
+		}
+
+		// 已暂停的 Topic：只计数不发送（上面的提示仍会送达）
+		if pm.store.CountPaused(topicKey, contentKind(content.Type)) {
+			return
 		}
 
 		p := pm.GetOrCreate(ctx, topicKey, chatID, threadID)
 
-		switch content.Type {
-		case monitor.ContentThinking:
-			formatted := "<blockquote expandable>💭 " + escapeHTML(content.Text) + "</blockquote>"
+		// 已静音的 Topic：到期前只计数，之后解除静音并汇总
+		if m, ok := pm.store.GetMute(topicKey); ok {
+			if time.Now().Before(m.Until) {
+				pm.store.CountMuted(topicKey, contentKind(content.Type))
+				return
+			}
+			if expired, ok := pm.store.ClearMute(topicKey); ok {
+				p.Enqueue(MessageTask{Text: i18n.T("push.mute_ended") + skippedSummary(expired.Skipped), ContentType: monitor.ContentText})
+			}
+		}
+
+		if !pm.showUsage {
+			content.Usage = nil
+		}
+		enqueueContent(p, content)
+	}
+}
+
+// menuTextMax 选项提示中引用的菜单内容上限
+const menuTextMax = 2000
+
+// sendInteractivePrompt 为从终端解析出的每个菜单选项显示一个按钮，无法解析时使用方向键键盘
+func (pm *PusherManager) sendInteractivePrompt(ctx context.Context, topicKey string, chatID int64, threadID int, windowID, toolUseID string) {
+	kb := InteractiveKeyboard(windowID, pm.alwaysAllowed(topicKey))
+	task := MessageTask{Text: i18n.T("push.interactive"), Prompt: true, ReplyMarkup: &kb, AfterToolUse: toolUseID}
+	if pm.capture != nil {
+		if pane, err := pm.capture(windowID); err == nil {
+			if menu, ok := monitor.ParseMenu(pane); ok {
+				kb = MenuKeyboard(windowID, menu)
+				task.Text = i18n.T("push.menu") + "\n<pre>" + escapeHTML(truncateRunes(menu.Text, menuTextMax)) + "</pre>"
+				task.ParseMode = models.ParseModeHTML
+			}
+		}
+	}
+	pm.GetOrCreate(ctx, topicKey, chatID, threadID).EnqueuePriority(task)
+}
+
+// planTextMax 计划 markdown 的长度上限，渲染后不超过 Telegram 的限制
+const planTextMax = 3500
+
+// sendPlan 将计划模式的计划渲染为 HTML，附批准 / 保持 / 拒绝键盘
+func (pm *PusherManager) sendPlan(ctx context.Context, topicKey string, chatID int64, threadID int, windowID, plan string) {
+	text := plan
+	if len([]rune(text)) > planTextMax {
+		text = truncateRunes(text, planTextMax) + "\n…"
+	}
+	kb := PlanKeyboard(windowID)
+	pm.GetOrCreate(ctx, topicKey, chatID, threadID).EnqueuePriority(MessageTask{
+		Text:        i18n.T("push.plan") + "\n\n" + toHTML(text),
+		Prompt:      true,
+		ParseMode:   models.ParseModeHTML,
+		ReplyMarkup: &kb,
+	})
+}
+
+// alwaysAllowed 确认键盘是否为 Topic 提供"始终允许"
+func (pm *PusherManager) alwaysAllowed(topicKey string) bool {
+	return pm.canAlways != nil && pm.canAlways(topicKey)
+}
+
+// confirmExcerptMax 确认请求中引用的提示内容上限
+const confirmExcerptMax = 400
+
+// sendConfirmPrompt 为权限提示显示 是 / 否（/ 始终）键盘，已知时注明等待中的工具并引用待批准的内容
+func (pm *PusherManager) sendConfirmPrompt(ctx context.Context, topicKey string, chatID int64, threadID int, windowID, tool, toolUseID, excerpt string) {
+	text := i18n.T("push.permission")
+	if tool != "" {
+		text = i18n.T("push.permission_tool", escapeHTML(tool))
+	}
+	if excerpt = strings.TrimSpace(excerpt); excerpt != "" {
+		text += "\n<pre>" + escapeHTML(truncateRunes(excerpt, confirmExcerptMax)) + "</pre>"
+	}
+	kb := ConfirmKeyboard(windowID, pm.alwaysAllowed(topicKey))
+	pm.GetOrCreate(ctx, topicKey, chatID, threadID).EnqueuePriority(MessageTask{
+		Text:         text,
+		Prompt:       true,
+		ParseMode:    models.ParseModeHTML,
+		ReplyMarkup:  &kb,
+		AfterToolUse: toolUseID,
+	})
+}
+
+// Topic 暂停期间的跳过计数分类
+const (
+	kindAnswers     = "answers"
+	kindThinking    = "thinking"
+	kindToolCalls   = "tool_calls"
+	kindToolResults = "tool_results"
+)
+
+// contentKind 内容类型对应的跳过计数分类
+func contentKind(t monitor.ContentType) string {
+	switch t {
+	case monitor.ContentThinking:
+		return kindThinking
+	case monitor.ContentToolUse:
+		return kindToolCalls
+	case monitor.ContentToolResult, monitor.ContentImage:
+		return kindToolResults
+	default:
+		return kindAnswers
+	}
+}
+
+// Replay 通过 Topic 的 pusher 重新发送已解析的内容
+func (pm *PusherManager) Replay(ctx context.Context, topicKey string, chatID int64, threadID int, contents []monitor.ParsedContent) {
+	p := pm.GetOrCreate(ctx, topicKey, chatID, threadID)
+	for _, c := range contents {
+		c.ToolUseID = "" // 重放的工具调用不会再收到配对的结果
+		if !pm.showUsage {
+			c.Usage = nil
+		}
+		enqueueContent(p, c)
+	}
+}
+
+// enqueueContent 按类型格式化解析后的内容并加入 pusher 队列
+func enqueueContent(p *StreamPusher, content monitor.ParsedContent) {
+	switch content.Type {
+	case monitor.ContentThinking:
+		for _, formatted := range formatThinking(content.Text) {
 			p.Enqueue(MessageTask{Text: formatted, ContentType: content.Type})
-		case monitor.ContentText:
-			p.Enqueue(MessageTask{Text: content.Text, ContentType: content.Type})
-		case monitor.ContentToolUse:
-			p.Enqueue(MessageTask{
-				Text:        "🔧 " + content.Text,
-				ContentType: content.Type,
-				ToolUseID:   content.ToolUseID,
-				ToolName:    content.ToolName,
-			})
-		case monitor.ContentToolResult:
-			p.Enqueue(MessageTask{
-				Text:        content.Text,
-				ContentType: content.Type,
-				ToolUseID:   content.ToolUseID,
-			})
 		}
+	case monitor.ContentText:
+		p.Enqueue(MessageTask{Text: content.Text, ContentType: content.Type, Footer: usageFooter(content.Usage)})
+	case monitor.ContentToolUse:
+		p.Enqueue(MessageTask{
+			Text:        "🔧 " + content.Text,
+			ContentType: content.Type,
+			ToolUseID:   content.ToolUseID,
+			ToolName:    content.ToolName,
+		})
+	case monitor.ContentToolResult:
+		p.Enqueue(MessageTask{
+			Text:        content.Text,
+			ContentType: content.Type,
+			ToolUseID:   content.ToolUseID,
+			Output:      content.Output,
+		})
+	case monitor.ContentTodo, monitor.ContentProgress:
+		p.Enqueue(MessageTask{Text: content.Text, ContentType: content.Type})
+	case monitor.ContentImage:
+		p.Enqueue(MessageTask{
+			Text:        content.Text,
+			ContentType: content.Type,
+			ToolUseID:   content.ToolUseID,
+			Photo:       content.Image,
+		})
+	case monitor.ContentSystem:
+		p.Enqueue(systemTask(content))
+	}
+}
+
+// systemTask 格式化系统通知：带警告标记的纯文本，不转换 markdown
+func systemTask(content monitor.ParsedContent) MessageTask {
+	return MessageTask{Text: "⚠️ " + content.Text, ContentType: content.Type}
+}
+
+// usageFooter 格式化回答的 token 用量，如 "· 12.3k in / 1.8k out / cache 85%"；
+// 输入为整个提示（未缓存 + 缓存读取 + 缓存写入），u 为 nil 时为空
+func usageFooter(u *state.Usage) string {
+	if u == nil {
+		return ""
+	}
+	in := u.InputTokens + u.CacheReadTokens + u.CacheWriteTokens
+	cache := 0
+	if in > 0 {
+		cache = int(u.CacheReadTokens * 100 / in)
+	}
+	return i18n.T("push.usage_footer", shortCount(in), shortCount(u.OutputTokens), cache)
+}
+
+// messageChunks 将消息拆分为不超过 4096 个 UTF-16 码元的段，为脚注留出空间（追加在 HTML 消息的最后一段）；
+// HTML 在标签与实体之间切分
+func messageChunks(text string, parseMode models.ParseMode, footer string) []string {
+	footer = footerHTML(footer)
+	if parseMode != models.ParseModeHTML {
+		return splitMessage(text, 4096-utf16Len(footer))
+	}
+	chunks := splitHTML(text, 4096-utf16Len(footer))
+	if n := len(chunks); n > 0 {
+		chunks[n-1] += footer
+	}
+	return chunks
+}
+
+// footerHTML 脚注追加到消息末尾的标记，没有脚注时为空
+func footerHTML(footer string) string {
+	if footer == "" {
+		return ""
+	}
+	return "\n<i>" + escapeHTML(footer) + "</i>"
+}
+
+// shortCount 缩写 token 数：950、12.3k、1.2M
+func shortCount(n int64) string {
+	switch {
+	case n >= 1_000_000:
+		return fmt.Sprintf("%.1fM", float64(n)/1_000_000)
+	case n >= 1000:
+		return fmt.Sprintf("%.1fk", float64(n)/1000)
+	default:
+		return strconv.FormatInt(n, 10)
 	}
 }
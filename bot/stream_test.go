@@ -0,0 +1,374 @@
+package bot
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	tgbot "github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+	"github.com/user/tgmux/i18n"
+	"github.com/user/tgmux/monitor"
+)
+
+func TestUTF16Len(t *testing.T) {
+	tests := []struct {
+		in   string
+		want int
+	}{
+		{"", 0},
+		{"abc", 3},
+		{"中文", 2},
+		{"ＡＢＣ", 3},
+		{"😀", 2},
+		{"👍🏽", 4},
+		{"a😀中", 4},
+	}
+	for _, tt := range tests {
+		if got := utf16Len(tt.in); got != tt.want {
+			t.Errorf("utf16Len(%q) = %d, want %d", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestTruncateUTF16KeepsSurrogatePairs(t *testing.T) {
+	s := strings.Repeat("😀", 3)
+	for n := 0; n <= 6; n++ {
+		got := truncateUTF16(s, n)
+		if !utf8.ValidString(got) || utf16Len(got) != n/2*2 {
+			t.Errorf("truncateUTF16(%d) = %q", n, got)
+		}
+	}
+}
+
+// checkChunks 检查纯文本各段不超长、不切开字符且不丢失内容
+func checkChunks(t *testing.T, s string, chunks []string, maxLen int) {
+	t.Helper()
+	for i, chunk := range chunks {
+		if n := utf16Len(chunk); n > maxLen {
+			t.Errorf("chunk %d is %d UTF-16 units, over %d", i, n, maxLen)
+		}
+		if !utf8.ValidString(chunk) {
+			t.Errorf("chunk %d cuts a character", i)
+		}
+	}
+	if strings.Join(chunks, "") != s {
+		t.Errorf("chunks do not add up to the input")
+	}
+}
+
+func TestSplitNearLimit(t *testing.T) {
+	tests := []struct {
+		name   string
+		text   string
+		chunks int
+	}{
+		// 2048 个 emoji 正好 4096 个码元，但有 8192 字节
+		{"emoji at limit", strings.Repeat("😀", 2048), 1},
+		{"emoji over limit", strings.Repeat("😀", 2048) + "a", 2},
+		{"emoji odd boundary", "a" + strings.Repeat("😀", 2048), 2},
+		// 全角字符一个码元、三个字节
+		{"fullwidth at limit", strings.Repeat("中", 4096), 1},
+		{"fullwidth over limit", strings.Repeat("中", 4097), 2},
+		{"mixed lines", strings.Repeat("😀中ａ\n", 1700), 3},
+		// 围栏位于后半段，其所在行越过限制
+		{"fence then long line", strings.Repeat("a", 3500) + "\n```" + strings.Repeat("b", 1000) + "\nend", 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			chunks := splitMessage(tt.text, 4096)
+			if len(chunks) != tt.chunks {
+				t.Errorf("splitMessage: %d chunks, want %d", len(chunks), tt.chunks)
+			}
+			checkChunks(t, tt.text, chunks, 4096)
+
+			html := "<b>" + escapeHTML(tt.text) + "</b>"
+			checkHTMLChunks(t, html, splitHTML(html, 4096), 4096)
+		})
+	}
+}
+
+func TestMessageChunksFooterBudget(t *testing.T) {
+	footer := "· 12.3k in / 1.8k out / cache 85% 😀 <&>"
+	for _, text := range []string{
+		strings.Repeat("😀", 2040),
+		strings.Repeat("中", 4090),
+		strings.Repeat("ａ😀&amp;", 1000),
+	} {
+		chunks := messageChunks(text, models.ParseModeHTML, footer)
+		for i, chunk := range chunks {
+			if n := utf16Len(chunk); n > 4096 {
+				t.Errorf("chunk %d is %d UTF-16 units", i, n)
+			}
+		}
+		if last := chunks[len(chunks)-1]; !strings.HasSuffix(last, footerHTML(footer)) {
+			t.Errorf("footer missing from the last chunk")
+		}
+	}
+	if chunks := messageChunks("plain", "", footer); len(chunks) != 1 || chunks[0] != "plain" {
+		t.Errorf("plain message chunks = %q", chunks)
+	}
+}
+
+// fakeTelegram 记录所发消息文本的 Bot API 服务器；block 期间每个请求记录后阻塞到 release
+type fakeTelegram struct {
+	mu    sync.Mutex
+	texts []string
+	next  int
+	hold  chan struct{}
+}
+
+func newFakeTelegram(t *testing.T) (*fakeTelegram, *tgbot.Bot) {
+	t.Helper()
+	f := &fakeTelegram{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseMultipartForm(1 << 20)
+		f.mu.Lock()
+		if strings.HasSuffix(r.URL.Path, "/sendMessage") {
+			f.texts = append(f.texts, r.FormValue("text"))
+		}
+		f.next++
+		id, hold := f.next, f.hold
+		f.mu.Unlock()
+		if hold != nil {
+			<-hold
+		}
+		json.NewEncoder(w).Encode(map[string]any{
+			"ok":     true,
+			"result": map[string]any{"message_id": id, "date": 0, "chat": map[string]any{"id": 1, "type": "private"}},
+		})
+	}))
+	t.Cleanup(srv.Close)
+	b, err := tgbot.New("1:test", tgbot.WithServerURL(srv.URL), tgbot.WithSkipGetMe())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return f, b
+}
+
+// block 阻塞之后的请求，直到调用返回的函数
+func (f *fakeTelegram) block() (release func()) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	hold := make(chan struct{})
+	f.hold = hold
+	return func() {
+		f.mu.Lock()
+		f.hold = nil
+		f.mu.Unlock()
+		close(hold)
+	}
+}
+
+func (f *fakeTelegram) sent() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]string(nil), f.texts...)
+}
+
+// waitSent 等待发出 n 条消息并返回它们
+func (f *fakeTelegram) waitSent(t *testing.T, n int) []string {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		texts := f.sent()
+		if len(texts) >= n {
+			return texts
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("sent %d messages, want %d: %q", len(texts), n, texts)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func newTestPusher(t *testing.T, b *tgbot.Bot, queueSize int) *StreamPusher {
+	t.Helper()
+	p := NewStreamPusher(1, 0, b, NewRateLimiter(), false, NewSentLog(), queueSize)
+	p.Start(context.Background())
+	t.Cleanup(p.Stop)
+	return p
+}
+
+// TestOverflowSummary 发送阻塞时大量入队：放不下的消息计数，队列清空后汇总为一条
+func TestOverflowSummary(t *testing.T) {
+	f, b := newFakeTelegram(t)
+	release := f.block()
+	p := newTestPusher(t, b, 5)
+
+	p.Enqueue(MessageTask{Text: "first", ContentType: monitor.ContentToolUse})
+	f.waitSent(t, 1) // worker 此时阻塞在发送这条消息上
+	for i := 0; i < 5; i++ {
+		p.Enqueue(MessageTask{Text: "queued " + strconv.Itoa(i), ContentType: monitor.ContentToolUse})
+	}
+	for i := 0; i < 30; i++ {
+		typ := monitor.ContentToolUse
+		if i%3 == 0 {
+			typ = monitor.ContentToolResult
+		}
+		p.Enqueue(MessageTask{Text: "flood " + strconv.Itoa(i), ContentType: typ})
+	}
+	if n := p.overflowTotal.Load(); n != 30 {
+		t.Fatalf("overflowTotal = %d, want 30", n)
+	}
+	release()
+
+	summary := i18n.T("push.overflow", skippedSummary(map[string]int{kindToolCalls: 20, kindToolResults: 10}))
+	texts := f.waitSent(t, 7)
+	for i := 1; i <= 5; i++ {
+		if want := "queued " + strconv.Itoa(i-1); texts[i] != want {
+			t.Errorf("message %d = %q, want %q", i, texts[i], want)
+		}
+	}
+	if texts[6] != summary {
+		t.Errorf("summary = %q, want %q", texts[6], summary)
+	}
+
+	// 计数已清零：下一条消息原样发送，不再有汇总
+	p.Enqueue(MessageTask{Text: "after", ContentType: monitor.ContentToolUse})
+	if texts = f.waitSent(t, 8); texts[7] != "after" {
+		t.Errorf("message after the summary = %q", texts[7])
+	}
+	p.overflowMu.Lock()
+	left := len(p.overflow)
+	p.overflowMu.Unlock()
+	if left != 0 {
+		t.Errorf("overflow counts not reset: %d kinds left", left)
+	}
+}
+
+func TestTryMergeStopsAtContentTypeChange(t *testing.T) {
+	p := NewStreamPusher(1, 0, nil, NewRateLimiter(), false, NewSentLog(), 10)
+	for _, task := range []MessageTask{
+		{Text: "b", ContentType: monitor.ContentText, Footer: "usage"},
+		{Text: "c", ContentType: monitor.ContentText},
+		{Text: "thought", ContentType: monitor.ContentThinking},
+		{Text: "d", ContentType: monitor.ContentText},
+	} {
+		p.Enqueue(task)
+	}
+
+	merged, next := p.tryMerge(context.Background(), MessageTask{Text: "a", ContentType: monitor.ContentText})
+	if merged.Text != "a\n\nb\n\nc" || merged.ContentType != monitor.ContentText || merged.Footer != "usage" {
+		t.Errorf("merged = %+v", merged)
+	}
+	if next == nil || next.Text != "thought" || next.ContentType != monitor.ContentThinking {
+		t.Fatalf("flushed on %+v, want the thinking message", next)
+	}
+	if n := len(p.queue); n != 1 {
+		t.Errorf("%d messages left queued, want 1", n)
+	}
+
+	// 其他内容类型不合并
+	first := MessageTask{Text: "ls", ContentType: monitor.ContentToolUse}
+	p.Enqueue(MessageTask{Text: "pwd", ContentType: monitor.ContentToolUse})
+	if merged, next := p.tryMerge(context.Background(), first); merged.Text != "ls" || next != nil {
+		t.Errorf("tool_use merged into %q, next %+v", merged.Text, next)
+	}
+}
+
+// TestTryMergeWindowEndsOnTypeChange 其他类型的消息提前结束合并等待
+func TestTryMergeWindowEndsOnTypeChange(t *testing.T) {
+	p := NewStreamPusher(1, 0, nil, NewRateLimiter(), false, NewSentLog(), 10)
+	p.mergeWindow = 5 * time.Second
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		p.Enqueue(MessageTask{Text: "more", ContentType: monitor.ContentText})
+		time.Sleep(20 * time.Millisecond)
+		p.Enqueue(MessageTask{Text: "Bash", ContentType: monitor.ContentToolUse})
+	}()
+
+	start := time.Now()
+	merged, next := p.tryMerge(context.Background(), MessageTask{Text: "answer", ContentType: monitor.ContentText})
+	if elapsed := time.Since(start); elapsed >= p.mergeWindow {
+		t.Errorf("waited the whole window (%v)", elapsed)
+	}
+	if merged.Text != "answer\n\nmore" {
+		t.Errorf("merged = %q", merged.Text)
+	}
+	if next == nil || next.ContentType != monitor.ContentToolUse {
+		t.Errorf("flushed on %+v, want the tool_use", next)
+	}
+}
+
+func TestWorkerSendsFlushedMessageAfterMerge(t *testing.T) {
+	f, b := newFakeTelegram(t)
+	release := f.block()
+	p := newTestPusher(t, b, 10)
+
+	p.Enqueue(MessageTask{Text: "Bash", ContentType: monitor.ContentToolUse})
+	f.waitSent(t, 1)
+	p.Enqueue(MessageTask{Text: "a", ContentType: monitor.ContentText})
+	p.Enqueue(MessageTask{Text: "b", ContentType: monitor.ContentText})
+	p.Enqueue(MessageTask{Text: "thought", ContentType: monitor.ContentThinking})
+	p.Enqueue(MessageTask{Text: "c", ContentType: monitor.ContentText})
+	release()
+
+	texts := f.waitSent(t, 4)
+	if want := []string{"Bash", "a\n\nb", "thought", "c"}; !slices.Equal(texts, want) {
+		t.Errorf("sent %q, want %q", texts, want)
+	}
+}
+
+// TestFloodKeepsText 发送阻塞时混合大量回答与工具调用：工具调用可能被汇总，回答全部按顺序送达
+func TestFloodKeepsText(t *testing.T) {
+	f, b := newFakeTelegram(t)
+	release := f.block()
+	p := newTestPusher(t, b, 5)
+
+	p.Enqueue(MessageTask{Text: "first", ContentType: monitor.ContentToolUse})
+	f.waitSent(t, 1)
+	const n = 20
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < n; i++ {
+			p.Enqueue(MessageTask{Text: "answer " + strconv.Itoa(i), ContentType: monitor.ContentText})
+			p.Enqueue(MessageTask{Text: "call " + strconv.Itoa(i), ContentType: monitor.ContentToolUse})
+		}
+	}()
+	// 等到队列已满、入队阻塞且已有工具调用被汇总
+	deadline := time.Now().Add(5 * time.Second)
+	for len(p.queue) < cap(p.queue) || p.overflowTotal.Load() == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("queue never filled")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	release()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("flood still blocked after the queue drained")
+	}
+	deadline = time.Now().Add(5 * time.Second)
+	for {
+		all := strings.Join(f.sent(), "\n")
+		pos, missing := 0, ""
+		for i := 0; i < n; i++ {
+			want := "answer " + strconv.Itoa(i) + "\n"
+			j := strings.Index(all[pos:]+"\n", want)
+			if j < 0 {
+				missing = want
+				break
+			}
+			pos += j + len(want) - 1
+		}
+		if missing == "" {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("%q missing or out of order in %q", strings.TrimSpace(missing), f.sent())
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
@@ -0,0 +1,76 @@
+package bot
+
+import (
+	"strings"
+
+	"github.com/user/tgmux/i18n"
+)
+
+// thinkingChunkRunes 一条思考消息（含标签与 HTML 实体，按 UTF-16 码元计）的长度上限，低于 Telegram 的 4096 留出余量
+const thinkingChunkRunes = 3500
+
+// thinkingBreaks 按优先级排列的切分点：段落、换行、句末
+var thinkingBreaks = []string{"\n\n", "\n", ". ", "。", "! ", "? ", "！", "？", "; ", "；"}
+
+// formatThinking 将思考内容按消息大小切块，每块包在各自的可折叠引用块中，
+// 避免 splitMessage 从标签中间切断导致 HTML 解析失败
+func formatThinking(text string) []string {
+	var out []string
+	for i, piece := range splitThinking(text, thinkingChunkRunes-64) {
+		marker := "💭 "
+		if i > 0 {
+			marker = i18n.T("thinking.cont") + " "
+		}
+		out = append(out, "<blockquote expandable>"+marker+escapeHTML(piece)+"</blockquote>")
+	}
+	return out
+}
+
+// splitThinking 将文本切成转义后不超过 budget 个 UTF-16 码元的块，优先在块后半部分的段落、句子边界处切分
+func splitThinking(text string, budget int) []string {
+	var pieces []string
+	text = strings.TrimSpace(text)
+	for text != "" {
+		limit := escapedPrefixBytes(text, budget)
+		if limit == len(text) {
+			pieces = append(pieces, text)
+			break
+		}
+		cut := limit
+		for _, sep := range thinkingBreaks {
+			if i := strings.LastIndex(text[:limit], sep); i > limit/2 {
+				cut = i + len(sep)
+				break
+			}
+		}
+		if cut == limit {
+			if i := strings.LastIndexByte(text[:limit], ' '); i > limit/2 {
+				cut = i + 1
+			}
+		}
+		pieces = append(pieces, strings.TrimSpace(text[:cut]))
+		text = strings.TrimSpace(text[cut:])
+	}
+	return pieces
+}
+
+// escapedPrefixBytes 返回 s 的最长前缀的字节长度，该前缀经 escapeHTML 后不超过 budget 个 UTF-16 码元
+func escapedPrefixBytes(s string, budget int) int {
+	n := 0
+	for i, r := range s {
+		w := 1
+		switch {
+		case r == '&':
+			w = len("&amp;")
+		case r == '<', r == '>':
+			w = len("&lt;")
+		case r >= 0x10000:
+			w = 2
+		}
+		if n+w > budget {
+			return i
+		}
+		n += w
+	}
+	return len(s)
+}
@@ -0,0 +1,64 @@
+package bot
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/user/tgmux/i18n"
+)
+
+func TestFormatThinkingLong(t *testing.T) {
+	sentence := "The parser keeps <tags> & entities intact while it reasons about the next step. "
+	tests := []struct {
+		name string
+		text string
+	}{
+		{"sentences", strings.Repeat(sentence, 10000/len(sentence)+1)},
+		{"paragraphs", strings.Repeat(strings.Repeat("word ", 60)+"\n\n", 40)},
+		{"no breaks", strings.Repeat("x", 10000)},
+		{"entities", strings.Repeat("a<b&c>", 2000)},
+		{"emoji", strings.Repeat("🤔💡 ", 4000)},
+		{"chinese", strings.Repeat("先分析问题，再给出方案。", 900)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if n := len([]rune(tt.text)); n < 10000 {
+				t.Fatalf("setup: only %d characters", n)
+			}
+			chunks := formatThinking(tt.text)
+			if len(chunks) < 2 {
+				t.Fatalf("got %d chunks, want several", len(chunks))
+			}
+			var text strings.Builder
+			for i, chunk := range chunks {
+				if n := utf16Len(chunk); n > thinkingChunkRunes {
+					t.Errorf("chunk %d is %d UTF-16 units, over %d", i, n, thinkingChunkRunes)
+				}
+				inner, ok := strings.CutPrefix(chunk, "<blockquote expandable>")
+				inner, ok2 := strings.CutSuffix(inner, "</blockquote>")
+				if !ok || !ok2 {
+					t.Fatalf("chunk %d is not its own blockquote", i)
+				}
+				if strings.ContainsAny(inner, "<>") {
+					t.Errorf("chunk %d has unescaped markup inside the quote", i)
+				}
+				marker := "💭 "
+				if i > 0 {
+					marker = i18n.T("thinking.cont") + " "
+				}
+				if inner, ok = strings.CutPrefix(inner, marker); !ok {
+					t.Errorf("chunk %d does not start with %q", i, marker)
+				}
+				text.WriteString(unescape(inner))
+			}
+			// 切分处的空白会被去掉，比较时忽略空白
+			if got, want := strings.Join(strings.Fields(text.String()), ""), strings.Join(strings.Fields(tt.text), ""); got != want {
+				t.Errorf("chunks lost text: got %d bytes, want %d", len(got), len(want))
+			}
+		})
+	}
+}
+
+func unescape(s string) string {
+	return strings.NewReplacer("&lt;", "<", "&gt;", ">", "&amp;", "&").Replace(s)
+}
@@ -0,0 +1,115 @@
+package bot
+
+import (
+	"bytes"
+	"context"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	tgbot "github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+	"github.com/user/tgmux/i18n"
+)
+
+const (
+	// toolOutputCap 为"查看输出"按钮保留的工具输出数
+	toolOutputCap = 200
+	// toolOutputChunk / toolOutputChunks 以消息发送的输出上限，更长的以文件上传
+	toolOutputChunk  = 3500
+	toolOutputChunks = 3
+)
+
+// ToolOutputs 保存最近过长而未内嵌的工具原始输出（有上限，仅在内存中），供按钮按需发送
+type ToolOutputs struct {
+	mu    sync.Mutex
+	next  int
+	items map[string]string
+	order []string
+}
+
+func NewToolOutputs() *ToolOutputs {
+	return &ToolOutputs{items: make(map[string]string)}
+}
+
+// Put 保存输出并返回其 ID，已满时淘汰最早的
+func (o *ToolOutputs) Put(output string) string {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.next++
+	id := strconv.Itoa(o.next)
+	o.items[id] = output
+	o.order = append(o.order, id)
+	if len(o.order) > toolOutputCap {
+		delete(o.items, o.order[0])
+		o.order = o.order[1:]
+	}
+	return id
+}
+
+// Get 返回保存的输出，已淘汰或重启后为 false
+func (o *ToolOutputs) Get(id string) (string, bool) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	output, ok := o.items[id]
+	return output, ok
+}
+
+// toolOutputExtras 返回工具结果消息（转义后 n 个 UTF-16 码元）的附加内容：
+// 输出足够短且放得下时折叠为可展开的引用，否则为按需发送输出的按钮
+func (p *StreamPusher) toolOutputExtras(n int, output string) (string, *models.InlineKeyboardMarkup) {
+	output = strings.TrimRight(output, "\n")
+	if strings.TrimSpace(output) == "" {
+		return "", nil
+	}
+	quoted := escapeHTML(output)
+	if p.outputInline > 0 && utf8.RuneCountInString(output) <= p.outputInline && n+utf16Len(quoted)+40 <= 4096 {
+		return "\n<blockquote expandable>" + quoted + "</blockquote>", nil
+	}
+	if p.outputs == nil {
+		return "", nil
+	}
+	id := p.outputs.Put(output)
+	return "", &models.InlineKeyboardMarkup{InlineKeyboard: [][]models.InlineKeyboardButton{
+		{{Text: i18n.T("kb.output"), CallbackData: "out:" + id}},
+	}}
+}
+
+// showToolOutput 发送保存的工具输出：几条预格式化消息，更长时为 .txt 文件
+func (b *Bot) showToolOutput(ctx context.Context, chatID int64, threadID int, id string) {
+	output, ok := b.pushers.outputs.Get(id)
+	if !ok {
+		b.sendMsg(ctx, chatID, threadID, i18n.T("push.output_expired"), nil)
+		return
+	}
+	chunks := splitMessage(output, toolOutputChunk)
+	if len(chunks) > toolOutputChunks {
+		params := &tgbot.SendDocumentParams{
+			ChatID:   chatID,
+			Document: &models.InputFileUpload{Filename: "output-" + id + ".txt", Data: bytes.NewReader([]byte(output))},
+		}
+		if threadID != 0 {
+			params.MessageThreadID = threadID
+		}
+		if resp, err := b.bot.SendDocument(ctx, params); err == nil {
+			b.pushers.RecordSent(chatID, threadID, resp.ID)
+		} else {
+			b.sendMsg(ctx, chatID, threadID, i18n.T("transcript.upload_failed")+": "+err.Error(), nil)
+		}
+		return
+	}
+	for _, chunk := range chunks {
+		params := &tgbot.SendMessageParams{
+			ChatID:    chatID,
+			Text:      "<pre>" + escapeHTML(chunk) + "</pre>",
+			ParseMode: models.ParseModeHTML,
+		}
+		if threadID != 0 {
+			params.MessageThreadID = threadID
+		}
+		if b.pushers.sendAndRecord(ctx, params) == 0 {
+			return
+		}
+	}
+}
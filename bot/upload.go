@@ -0,0 +1,143 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+	"github.com/user/tgmux/backend"
+	"github.com/user/tgmux/i18n"
+)
+
+const (
+	// maxUploadSize Bot API 可下载文件的大小上限（20MB）
+	maxUploadSize = 20 << 20
+	// uploadDirName 上传文件在项目目录下的保存位置
+	uploadDirName = ".tgmux-uploads"
+)
+
+// downloadFile 下载 Telegram 文件到 dir/name，返回本地路径
+func (b *Bot) downloadFile(ctx context.Context, fileID string, dir string, name string) (string, error) {
+	f, err := b.bot.GetFile(ctx, &bot.GetFileParams{FileID: fileID})
+	if err != nil {
+		return "", fmt.Errorf("get file: %w", err)
+	}
+	if f.FileSize > maxUploadSize {
+		return "", errors.New(i18n.T("upload.too_large", f.FileSize>>20, maxUploadSize>>20))
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, b.bot.FileDownloadLink(f), nil)
+	if err != nil {
+		return "", err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("download: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("download: %s", resp.Status)
+	}
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", err
+	}
+	path := filepath.Join(dir, safeFilename(name))
+	out, err := os.Create(path)
+	if err != nil {
+		return "", err
+	}
+	n, err := io.Copy(out, io.LimitReader(resp.Body, maxUploadSize+1))
+	if cerr := out.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil && n > maxUploadSize {
+		err = errors.New(i18n.T("upload.too_large_limit", maxUploadSize>>20))
+	}
+	if err != nil {
+		os.Remove(path)
+		return "", err
+	}
+	return path, nil
+}
+
+// safeFilename 去掉路径分隔符和控制字符，避免写出上传目录
+func safeFilename(name string) string {
+	name = filepath.Base(strings.TrimSpace(name))
+	name = strings.Map(func(r rune) rune {
+		if r < 0x20 || r == '/' || r == '\\' {
+			return '_'
+		}
+		return r
+	}, name)
+	if name == "" || name == "." || name == ".." {
+		name = fmt.Sprintf("upload-%d", time.Now().Unix())
+	}
+	return name
+}
+
+// handlePhoto 已绑定 topic 收到图片：保存到项目目录并把路径发给后端
+func (b *Bot) handlePhoto(ctx context.Context, msg *models.Message, prefix string) {
+	// 选取最大尺寸
+	largest := msg.Photo[0]
+	for _, p := range msg.Photo[1:] {
+		if p.Width*p.Height > largest.Width*largest.Height {
+			largest = p
+		}
+	}
+	name := fmt.Sprintf("photo-%s.jpg", time.Now().Format("20060102-150405"))
+	b.handleUpload(ctx, msg, largest.FileID, name, i18n.T("upload.photo"), prefix)
+}
+
+// handleDocument 已绑定 topic 收到文件：保存到项目目录并把路径发给后端
+func (b *Bot) handleDocument(ctx context.Context, msg *models.Message, prefix string) {
+	doc := msg.Document
+	if doc.FileSize > maxUploadSize {
+		b.sendReply(ctx, msg, i18n.T("upload.too_large", doc.FileSize>>20, maxUploadSize>>20))
+		return
+	}
+	name := fmt.Sprintf("%s-%s", time.Now().Format("20060102-150405"), safeFilename(doc.FileName))
+	b.handleUpload(ctx, msg, doc.FileID, name, i18n.T("upload.file"), prefix)
+}
+
+// handleUpload 保存附件；非 bash 会话将 prefix、说明文字与本地路径一起发给后端
+func (b *Bot) handleUpload(ctx context.Context, msg *models.Message, fileID, name, kind, prefix string) {
+	key := topicKeyFromMessage(msg)
+	binding, ok := b.store.GetBinding(key)
+	if !ok {
+		b.sendReply(ctx, msg, i18n.T("upload.not_bound", kind))
+		return
+	}
+	if binding.ProjectPath == "" {
+		b.sendReply(ctx, msg, i18n.T("upload.no_project", kind))
+		return
+	}
+
+	path, err := b.downloadFile(ctx, fileID, filepath.Join(binding.ProjectPath, uploadDirName), name)
+	if err != nil {
+		slog.Error("save upload failed", "key", key, "error", err)
+		b.sendReply(ctx, msg, i18n.T("upload.save_failed", kind, err))
+		return
+	}
+
+	// bash 会话只报告保存位置，不向 shell 注入内容
+	if backend.Type(binding.Backend) == backend.TypeBash {
+		b.sendReply(ctx, msg, i18n.T("upload.saved", kind, path))
+		return
+	}
+	text := path
+	if caption := strings.TrimSpace(msg.Caption); caption != "" {
+		text = caption + "\n\n" + path
+	}
+	b.enqueueInput(binding.WindowID, msg, prefix+text)
+	b.sendReply(ctx, msg, i18n.T("upload.forwarded", kind, path))
+}
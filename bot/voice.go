@@ -0,0 +1,89 @@
+package bot
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/go-telegram/bot/models"
+
+	"github.com/user/tgmux/i18n"
+)
+
+// handleVoice 语音消息：下载并调用配置的转写命令，结果按普通文本消息处理
+func (b *Bot) handleVoice(ctx context.Context, msg *models.Message) {
+	tc := b.cfg.Transcribe
+	if len(tc.Command) == 0 {
+		b.sendReply(ctx, msg, i18n.T("voice.not_configured"))
+		return
+	}
+
+	tmpDir, err := os.MkdirTemp("", "tgmux-voice-*")
+	if err != nil {
+		b.sendReply(ctx, msg, i18n.T("voice.failed", err))
+		return
+	}
+	defer os.RemoveAll(tmpDir)
+
+	path, err := b.downloadFile(ctx, msg.Voice.FileID, tmpDir, "voice.ogg")
+	if err != nil {
+		b.sendReply(ctx, msg, i18n.T("voice.download_failed", err))
+		return
+	}
+
+	text, err := b.transcribe(ctx, path)
+	if err != nil {
+		slog.Error("transcribe failed", "error", err)
+		b.sendReply(ctx, msg, i18n.T("voice.transcribe_failed", err))
+		return
+	}
+	if text == "" {
+		b.sendReply(ctx, msg, i18n.T("voice.empty"))
+		return
+	}
+	b.sendReply(ctx, msg, "🎙 "+text)
+	b.handleText(ctx, msg, text)
+}
+
+// transcribe 运行转写命令：参数含 {file} 时替换为文件路径，否则通过 stdin 传入
+func (b *Bot) transcribe(ctx context.Context, path string) (string, error) {
+	tc := b.cfg.Transcribe
+	ctx, cancel := context.WithTimeout(ctx, tc.Timeout)
+	defer cancel()
+
+	args := make([]string, len(tc.Command)-1)
+	useArg := false
+	for i, a := range tc.Command[1:] {
+		if strings.Contains(a, "{file}") {
+			useArg = true
+		}
+		args[i] = strings.ReplaceAll(a, "{file}", path)
+	}
+	cmd := exec.CommandContext(ctx, tc.Command[0], args...)
+	if !useArg {
+		f, err := os.Open(path)
+		if err != nil {
+			return "", err
+		}
+		defer f.Close()
+		cmd.Stdin = f
+	}
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() == context.DeadlineExceeded {
+			return "", errors.New(i18n.T("common.timeout", tc.Timeout))
+		}
+		if errText := strings.TrimSpace(stderr.String()); errText != "" {
+			return "", fmt.Errorf("%w: %s", err, truncateRunes(errText, 300))
+		}
+		return "", err
+	}
+	return strings.TrimSpace(stdout.String()), nil
+}
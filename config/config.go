@@ -4,6 +4,8 @@ import (
 	"fmt"
 	"log/slog"
 	"os"
+	"regexp"
+	"strings"
 	"time"
 
 	"gopkg.in/yaml.v3"
@@ -12,6 +14,19 @@ import (
 type TelegramConfig struct {
 	Token        string  `yaml:"token"`
 	AllowedUsers []int64 `yaml:"allowed_users"`
+	AdminUsers   []int64 `yaml:"admin_users"` // 可执行管理命令（如 /broadcast）的用户，为空则所有 allowed_users 均为管理员
+	// SyncTopicNames 绑定会话时将论坛 Topic 重命名为会话名（需要 can_manage_topics 权限）
+	SyncTopicNames bool `yaml:"sync_topic_names"`
+	// Language 面向用户文本的语言（zh / en），默认 zh
+	Language string `yaml:"language"`
+	// AckReactions 用 reaction 标记用户消息的送达（👀）与后端回答（👌）
+	AckReactions bool `yaml:"ack_reactions"`
+	// AutoTopics 在论坛 General 中新建会话时自动为其创建独立 Topic（需要管理话题权限）
+	AutoTopics bool `yaml:"auto_topics"`
+	// ReplyKeyboard 绑定后附上常驻快捷键盘（截图 / Esc / 回车 / 状态），可用 /keyboard off 移除
+	ReplyKeyboard bool `yaml:"reply_keyboard"`
+	// MaxInlineChars 超过该字数的回答以文件发送并附开头预览，不再拆成多条消息；负数为总是拆分
+	MaxInlineChars int `yaml:"max_inline_chars"`
 }
 
 type BackendConfig struct {
@@ -19,6 +34,12 @@ type BackendConfig struct {
 	Args          []string `yaml:"args"`
 	LogDirPattern string   `yaml:"log_dir_pattern"`
 	Enabled       *bool    `yaml:"enabled"` // pointer for default true
+	// Profiles 命名的启动参数组合，/new 选择后端后可选，追加在 Args 之后
+	Profiles map[string][]string `yaml:"profiles"`
+	// Monitor 终端输出的监控方式（仅 bash）："capture" 轮询 capture-pane（默认），"pipe" 使用 pipe-pane 增量读取
+	Monitor string `yaml:"monitor"`
+	// Env 启动后端前导出的环境变量，值中的 ${VAR} 取自 tgmux 进程的环境；值为空表示 unset
+	Env map[string]string `yaml:"env"`
 }
 
 type BackendsConfig struct {
@@ -26,16 +47,57 @@ type BackendsConfig struct {
 	Codex  BackendConfig `yaml:"codex"`
 	Gemini BackendConfig `yaml:"gemini"`
 	Bash   BackendConfig `yaml:"bash"`
+	Aider  BackendConfig `yaml:"aider"`
+	Cursor BackendConfig `yaml:"cursor"`
+
+	// Opencode 会话记录默认位于 ~/.local/share/opencode/storage/（遵循 XDG_DATA_HOME）
+	Opencode BackendConfig `yaml:"opencode"`
+
+	// ClaudeHeadless 无终端模式：每条消息运行一次 claude -p 并解析 stream-json 输出，默认不启用
+	ClaudeHeadless BackendConfig `yaml:"claude_headless"`
+
+	// Custom 完全在配置中声明的后端，按名称列在后端选择键盘中
+	Custom []CustomBackendConfig `yaml:"custom"`
+}
+
+// CustomBackendConfig 自定义后端：command / args / log_dir_pattern / enabled / profiles 含义同内置后端
+type CustomBackendConfig struct {
+	Name          string `yaml:"name"`
+	BackendConfig `yaml:",inline"`
+	// LogFormat 输出的监控方式："claude-jsonl" 按 claude 的会话 JSONL 解析 log_dir_pattern 目录，
+	// "plain-append" 跟踪该目录中最新文件追加的行，"none"（默认）捕获终端
+	LogFormat string `yaml:"log_format"`
+	// ConfirmPatterns 额外识别为确认提示的正则，匹配时显示确认键盘
+	ConfirmPatterns []string `yaml:"confirm_patterns"`
 }
 
+// 自定义后端的 log_format 取值
+const (
+	LogFormatNone        = "none"
+	LogFormatClaudeJSONL = "claude-jsonl"
+	LogFormatPlainAppend = "plain-append"
+)
+
 type DirsConfig struct {
 	Favorites []string `yaml:"favorites"`
 	RecentMax int      `yaml:"recent_max"`
+	PageSize  int      `yaml:"page_size"` // 目录选择与浏览键盘每页的目录数
+	Ignore    []string `yaml:"ignore"`    // 浏览时始终隐藏的目录名（含显示隐藏目录时）
+
+	// DefaultBackend 选定目录后直接以该后端创建会话，不显示后端选择键盘；为空时照常选择
+	DefaultBackend string `yaml:"default_backend"`
+	// DirBackends 按目录指定的默认后端（目录 → 后端），优先于 DefaultBackend
+	DirBackends map[string]string `yaml:"dir_backends"`
 }
 
 type SecurityConfig struct {
-	RedactSecrets        bool `yaml:"redact_secrets"`
+	RedactSecrets         bool `yaml:"redact_secrets"`
 	ConfigPermissionCheck bool `yaml:"config_permission_check"`
+
+	// AutoConfirmDeny 权限请求包含这些片段（大小写不敏感）时即使开启 /autoconfirm 也不自动确认
+	AutoConfirmDeny []string `yaml:"auto_confirm_deny"`
+	// AutoApproveTools 这些工具的权限请求自动确认（如 Read、Grep、Glob）
+	AutoApproveTools []string `yaml:"auto_approve_tools"`
 }
 
 type WebConfig struct {
@@ -46,18 +108,72 @@ type WebConfig struct {
 
 type MonitorConfig struct {
 	PollInterval       time.Duration `yaml:"poll_interval"`
-	GroupThrottle      time.Duration `yaml:"group_throttle"`
-	PrivateThrottle    time.Duration `yaml:"private_throttle"`
+	GroupThrottle      time.Duration `yaml:"group_throttle"`   // 群组内消息（含编辑）的平均最小间隔，允许少量突发，0 为不限速
+	PrivateThrottle    time.Duration `yaml:"private_throttle"` // 私聊内消息（含编辑）的平均最小间隔
 	StatusPollInterval time.Duration `yaml:"status_poll_interval"`
+	IdleKillAfter      time.Duration `yaml:"idle_kill_after"` // 会话空闲多久后提醒并回收，0 为关闭
+	IdleKillGrace      time.Duration `yaml:"idle_kill_grace"` // 提醒后无人响应多久即关闭窗口
+	PromptCooldown     time.Duration `yaml:"prompt_cooldown"` // 相同的交互界面/确认提示在此时间内不重复通知，负数为关闭去重
+
+	// OnTruncate 日志文件变小（被重写或轮转）时的处理："reread" 从头重读（默认），"skip" 跳到新的末尾
+	OnTruncate string `yaml:"on_truncate"`
+	// WatchFallbackAfter 文件监听多久没有事件、而日志仍在增长时改为按 poll_interval 轮询，负数为不检测
+	WatchFallbackAfter time.Duration `yaml:"watch_fallback_after"`
+	// RelockAfter 会话日志停止增长多久、且同项目出现正在写入的新会话文件时切换过去（后端重启、claude -c），负数为关闭
+	RelockAfter time.Duration `yaml:"relock_after"`
+	// StallAlertAfter 转发输入后多久仍无任何输出即提醒一次，负数为关闭
+	StallAlertAfter time.Duration `yaml:"stall_alert_after"`
+	// ProgressInterval 终端中进度条/旋转符的刷新最多每隔多久推送一次（替换上一条进度），负数为不过滤
+	ProgressInterval time.Duration `yaml:"progress_interval"`
+	// ProgressPatterns 额外的进度字符正则，与内置规则（百分比、旋转符、进度条等）一起使用
+	ProgressPatterns []string `yaml:"progress_patterns"`
+	// ScrollbackLines 终端输出在两次轮询之间滚过整屏时，从 tmux 滚动历史补推的最大行数，负数为关闭
+	ScrollbackLines int `yaml:"scrollback_lines"`
+	// ShowUsage 在每条最终回答后附上该回答的 token 用量（仅 claude）
+	ShowUsage bool `yaml:"show_usage"`
+	// PushQueueSize 每个 Topic 待推送输出的队列容量；队列满时回答与思考等待入队，其余消息合并为跳过摘要
+	PushQueueSize int `yaml:"push_queue_size"`
+	// MergeWindow 回答或思考到达后等待同类后续内容的最长时间，期间到达的合并为一条消息；0 为不等待
+	MergeWindow time.Duration `yaml:"merge_window"`
+	// ToolOutputInline 工具输出不超过该字数时以可展开引用附在结果后，更长的输出改为「查看输出」按钮；负数为总是用按钮
+	ToolOutputInline int `yaml:"tool_output_inline"`
+	// DefaultFilters 未用 /filter 设置过的 Topic 推送哪些内容
+	DefaultFilters FiltersConfig `yaml:"default_filters"`
+
+	TurnNotify TurnNotifyConfig `yaml:"turn_notify"`
+}
+
+// FiltersConfig 按内容类型的推送开关，true 为推送
+type FiltersConfig struct {
+	Thinking    bool `yaml:"thinking"`
+	ToolCalls   bool `yaml:"tool_calls"`
+	ToolResults bool `yaml:"tool_results"`
+}
+
+// TurnNotifyConfig 回合结束通知（仅 claude）。Detect 为启用的判定方式：
+// result（result 类型日志行）、stop_reason（助手消息 stop_reason 为 end_turn）、
+// quiet（最后一个工具结果之后静默 QuietWindow）
+type TurnNotifyConfig struct {
+	Enabled     bool          `yaml:"enabled"`
+	Detect      []string      `yaml:"detect"`
+	QuietWindow time.Duration `yaml:"quiet_window"`
+}
+
+// TranscribeConfig 语音转文字命令：参数中的 {file} 替换为音频文件路径，
+// 不含 {file} 时音频通过 stdin 传入；识别结果从 stdout 读取
+type TranscribeConfig struct {
+	Command []string      `yaml:"command"`
+	Timeout time.Duration `yaml:"timeout"`
 }
 
 type Config struct {
-	Telegram TelegramConfig `yaml:"telegram"`
-	Backends BackendsConfig `yaml:"backends"`
-	Dirs     DirsConfig     `yaml:"dirs"`
-	Security SecurityConfig `yaml:"security"`
-	Web      WebConfig      `yaml:"web"`
-	Monitor  MonitorConfig  `yaml:"monitor"`
+	Telegram   TelegramConfig   `yaml:"telegram"`
+	Backends   BackendsConfig   `yaml:"backends"`
+	Dirs       DirsConfig       `yaml:"dirs"`
+	Security   SecurityConfig   `yaml:"security"`
+	Web        WebConfig        `yaml:"web"`
+	Monitor    MonitorConfig    `yaml:"monitor"`
+	Transcribe TranscribeConfig `yaml:"transcribe"`
 }
 
 func defaultConfig() *Config {
@@ -68,11 +184,13 @@ func defaultConfig() *Config {
 			Codex:  BackendConfig{Command: "codex", Enabled: &t, LogDirPattern: "~/.codex/sessions/{date}/"},
 			Gemini: BackendConfig{Command: "gemini", Enabled: &t, LogDirPattern: "~/.gemini/tmp/{hash}/"},
 			Bash:   BackendConfig{Enabled: &t},
+			Aider:  BackendConfig{Command: "aider", Enabled: &t},
+			Cursor: BackendConfig{Command: "cursor-agent", Enabled: &t},
 		},
 		Dirs:     DirsConfig{RecentMax: 10},
 		Security: SecurityConfig{RedactSecrets: true, ConfigPermissionCheck: true},
 		Web:      WebConfig{Port: 3030, Bind: "127.0.0.1"},
-		Monitor:  MonitorConfig{PollInterval: 500 * time.Millisecond, GroupThrottle: 3 * time.Second, PrivateThrottle: 1 * time.Second},
+		Monitor:  MonitorConfig{PollInterval: 500 * time.Millisecond, GroupThrottle: 3 * time.Second, PrivateThrottle: 1 * time.Second, DefaultFilters: FiltersConfig{Thinking: true, ToolCalls: true, ToolResults: true}},
 	}
 }
 
@@ -99,10 +217,98 @@ func Load(path string) (*Config, error) {
 	if len(cfg.Telegram.AllowedUsers) == 0 {
 		return nil, fmt.Errorf("telegram.allowed_users must not be empty")
 	}
+	if cfg.Backends.ClaudeHeadless.Enabled == nil {
+		f := false
+		cfg.Backends.ClaudeHeadless.Enabled = &f
+	}
+	if err := validateCustomBackends(cfg.Backends.Custom); err != nil {
+		return nil, err
+	}
+	if cfg.Dirs.PageSize <= 0 {
+		cfg.Dirs.PageSize = 8
+	}
+	if cfg.Dirs.Ignore == nil {
+		cfg.Dirs.Ignore = []string{".git", "node_modules"}
+	}
+	if cfg.Security.AutoConfirmDeny == nil {
+		cfg.Security.AutoConfirmDeny = []string{"rm -rf", "sudo", "push --force", "push -f", "--force-with-lease", "reset --hard", "mkfs", "dd if="}
+	}
+	if cfg.Monitor.PromptCooldown == 0 {
+		cfg.Monitor.PromptCooldown = time.Minute
+	}
+	if cfg.Monitor.PushQueueSize <= 0 {
+		cfg.Monitor.PushQueueSize = 100
+	}
+	if cfg.Telegram.MaxInlineChars == 0 {
+		cfg.Telegram.MaxInlineChars = 12000
+	}
+	if cfg.Monitor.ToolOutputInline == 0 {
+		cfg.Monitor.ToolOutputInline = 1500
+	}
+	if cfg.Monitor.WatchFallbackAfter == 0 {
+		cfg.Monitor.WatchFallbackAfter = 2 * time.Minute
+	}
+	if cfg.Monitor.RelockAfter == 0 {
+		cfg.Monitor.RelockAfter = 3 * time.Minute
+	}
+	if cfg.Monitor.StallAlertAfter == 0 {
+		cfg.Monitor.StallAlertAfter = 5 * time.Minute
+	}
+	if cfg.Monitor.ScrollbackLines == 0 {
+		cfg.Monitor.ScrollbackLines = 500
+	}
+	if cfg.Monitor.ProgressInterval == 0 {
+		cfg.Monitor.ProgressInterval = 5 * time.Second
+	}
+	if cfg.Monitor.IdleKillGrace <= 0 {
+		cfg.Monitor.IdleKillGrace = 30 * time.Minute
+	}
+	if len(cfg.Monitor.TurnNotify.Detect) == 0 {
+		cfg.Monitor.TurnNotify.Detect = []string{"result", "stop_reason"}
+	}
+	if cfg.Monitor.TurnNotify.QuietWindow <= 0 {
+		cfg.Monitor.TurnNotify.QuietWindow = 30 * time.Second
+	}
+	if cfg.Transcribe.Timeout <= 0 {
+		cfg.Transcribe.Timeout = 60 * time.Second
+	}
 
 	return cfg, nil
 }
 
+// validateCustomBackends 校验自定义后端：名称用于回调数据（profile:<名称>:<组合>），不能含冒号且需简短
+func validateCustomBackends(custom []CustomBackendConfig) error {
+	seen := make(map[string]bool)
+	for i, cb := range custom {
+		switch {
+		case cb.Name == "":
+			return fmt.Errorf("backends.custom[%d]: name is required", i)
+		case strings.ContainsAny(cb.Name, ": ") || len(cb.Name) > 32:
+			return fmt.Errorf("backends.custom[%d]: name %q must be at most 32 characters without spaces or colons", i, cb.Name)
+		case seen[cb.Name]:
+			return fmt.Errorf("backends.custom[%d]: duplicate name %q", i, cb.Name)
+		case cb.Command == "":
+			return fmt.Errorf("backends.custom[%d] (%s): command is required", i, cb.Name)
+		}
+		seen[cb.Name] = true
+		switch cb.LogFormat {
+		case "", LogFormatNone:
+		case LogFormatClaudeJSONL, LogFormatPlainAppend:
+			if cb.LogDirPattern == "" {
+				return fmt.Errorf("backends.custom[%d] (%s): log_format %s requires log_dir_pattern", i, cb.Name, cb.LogFormat)
+			}
+		default:
+			return fmt.Errorf("backends.custom[%d] (%s): unknown log_format %q", i, cb.Name, cb.LogFormat)
+		}
+		for _, p := range cb.ConfirmPatterns {
+			if _, err := regexp.Compile(p); err != nil {
+				return fmt.Errorf("backends.custom[%d] (%s): confirm pattern %q: %w", i, cb.Name, p, err)
+			}
+		}
+	}
+	return nil
+}
+
 func CheckFilePermission(path string) {
 	info, err := os.Stat(path)
 	if err != nil {
@@ -0,0 +1,431 @@
+package i18n
+
+// English catalog
+func init() {
+	Register("en", Catalog{
+		"flow.path_empty":          "Path must not be empty, please enter it again:",
+		"flow.path_invalid":        "Path must not contain line breaks, please enter it again:",
+		"flow.dir_not_found":       "Directory does not exist: %s\nPlease enter it again:",
+		"flow.choose_backend":      "🚀 Choose a backend:",
+		"flow.choose_profile":      "🚀 Choose launch flags for %s:",
+		"flow.profile_not_found":   "Launch profile %s not found (the config may have changed)",
+		"flow.use_dir_buttons":     "Tap a button to choose a directory, or tap [📁 Enter path...] to type one",
+		"flow.use_backend_buttons": "Tap a button to choose a backend",
+		"flow.choose_dir":          "📂 Choose a project directory:",
+		"flow.nothing_to_cancel":   "Nothing in progress",
+		"flow.enter_path":          "Enter the full path of the project directory:\n(/cancel to cancel)",
+		"flow.no_dir":              "Error: no directory selected",
+
+		"flow.default_backend_note":   "Using the default backend %s — tap below to pick another one",
+		"flow.change_backend_expired": "That session is closed or not in this topic; the backend can no longer be changed",
+
+		"session.disconnected_unbound":   "⚠️ Session disconnected, automatically unbound",
+		"session.backend_exited_unbound": "⚠️ Backend process exited, automatically unbound",
+		"session.none_available":         "No usable session in this topic",
+		"session.list_failed":            "Failed to list windows: %v",
+		"session.no_windows":             "🖥 No tmux windows",
+		"session.all_windows":            "🖥 All tmux windows\n",
+		"session.window_bound":           "%s  %s  ← bound to %s",
+		"session.window_unbound":         "%s  %s  ← unbound",
+		"session.not_bound_hint":         "No session is bound to this topic\nUse /new to create one",
+		"session.running":                "running",
+		"session.disconnected":           "disconnected",
+		"session.info":                   "📋 Current session\n├─ Window:   %s\n├─ Backend:  %s\n├─ Dir:      %s\n├─ Status:   %s\n└─ Created:  %s ago",
+		"session.not_bound":              "No session is bound to this topic",
+		"session.create_window_failed":   "Failed to create window: %v",
+		"session.shell_not_ready":        "The terminal was not ready within %s (slow shell startup or the project directory is not reachable); the window was closed",
+		"session.created":                "✅ Created %s session @ %s",
+		"session.window_gone":            "⚠️ The session window is gone",
+
+		"shell.send_failed": "Failed to send command: %v",
+
+		"edit.replaced":      "✏️ Replaced the queued message that had not been sent yet",
+		"edit.resend_button": "🔁 Resend",
+		"edit.resend_prompt": "✏️ The original message was already sent to the backend. Resend the corrected version?",
+		"edit.expired":       "This edit was already handled or has expired",
+		"edit.resent":        "🔁 Resent",
+
+		"common.ignore":        "Ignore",
+		"common.send":          "✅ Send",
+		"common.ignore_button": "❌ Ignore",
+		"common.cancelled":     "Cancelled",
+		"common.keep":          "Keep",
+		"common.cancel_button": "❌ Cancel",
+		"common.list_sep":      ", ",
+		"common.truncated":     "\n… (truncated)",
+		"common.timeout":       "timed out (%s)",
+		"common.no":            "no",
+
+		"forward.photo":          "[Photo] ",
+		"forward.document":       "[File] ",
+		"forward.prompt":         "↪️ Forward this message from %s to the session?\n\n%s",
+		"forward.expired":        "This forward was already handled or has expired",
+		"forward.sent":           "↪️ Sent to the session",
+		"forward.header":         "Forwarded message from %s:\n",
+		"forward.unknown_origin": "unknown",
+
+		"unbound.choose": "This topic has no session yet, choose one:",
+
+		"topic.open":    "🧵 Open topic",
+		"topic.created": "🧵 Created topic \"%s\" for the session",
+
+		"turn.done": "✅ Turn finished in %s — %d tool calls",
+
+		"stall.alert": "⏳ No output for %s — want a screenshot?",
+
+		"plan.approved":    "✅ Plan approved",
+		"plan.kept":        "✏️ Keep planning: send your feedback as a message",
+		"plan.rejected":    "❌ Plan rejected",
+		"plan.expired":     "This plan is no longer pending",
+		"plan.unsupported": "Backend %s has no plan approval keys",
+
+		"reap.warning":     "💤 Session %s has been idle for %s and will be closed if nobody responds within %s",
+		"reap.keep_button": "✅ Keep alive",
+		"reap.kill_button": "🗑 Kill now",
+		"reap.killed":      "💤 Session %s was idle for %s with no response and has been closed",
+		"reap.expired":     "This reminder has expired",
+		"reap.kept":        "✅ Kept alive, idle timer restarted",
+
+		"filter.usage":  "Usage: /filter [status]\n/filter thinking|tools|results on|off",
+		"filter.status": "🔎 Output: thinking %s · tool calls %s · tool results %s",
+		"filter.shown":  "✅",
+		"filter.hidden": "🚫",
+
+		"pushmode.usage":    "Usage: /pushmode [stream|messages]",
+		"pushmode.stream":   "📝 Push mode: stream. Answers and thinking keep growing one message (updated about every 2s); tool calls are still sent separately",
+		"pushmode.messages": "💬 Push mode: messages. Each answer and thinking block is sent as its own message",
+
+		"autoconfirm.usage": "Usage: /autoconfirm on|off|status",
+		"autoconfirm.on":    "🔓 Auto-confirm is on: permission prompts are answered yes, except those containing: %s",
+		"autoconfirm.off":   "🔒 Auto-confirm is off",
+		"autoconfirm.tool":  "🔓 auto-approved tool %s",
+		"autoconfirm.done":  "🔓 auto-confirmed: %s",
+
+		"keepalive.usage":    "Usage: /keepalive [on|off]",
+		"keepalive.disabled": "Idle reaping is not enabled (monitor.idle_kill_after)",
+		"keepalive.on":       "📌 This session is exempt from idle reaping",
+		"keepalive.off":      "⏳ This session will be warned and reaped after %s idle",
+
+		"panes.title":    "Panes of window %s (👉 = current target):",
+		"panes.switch":   "Switch to %s (%s)",
+		"panes.switched": "Switched to pane %s (%s); input and output monitoring now target it",
+		"panes.gone":     "Pane %s no longer exists",
+		"panes.failed":   "Failed to list panes: %v",
+
+		"replykb.usage":      "Usage: /keyboard [on|off]",
+		"replykb.shown":      "⌨️ Shortcut keyboard enabled, /keyboard off removes it",
+		"replykb.removed":    "⌨️ Shortcut keyboard removed",
+		"replykb.screenshot": "📸 Screenshot",
+		"replykb.esc":        "⎋ Esc",
+		"replykb.enter":      "⏎ Enter",
+		"replykb.status":     "📊 Status",
+
+		"kill.usage":                  "Usage: /kill [now|--force] (asks for confirmation without arguments)",
+		"kill.confirm":                "⚠️ Really close %s? ",
+		"kill.running_for":            "It has been running for %s",
+		"kill.confirm_button":         "🗑 Yes, kill",
+		"kill.done":                   "✅ Closed session %s",
+		"kill.remove_worktree_button": "🗑 Remove worktree",
+		"kill.done_worktree":          "✅ Closed session %s\nRemove worktree %s?",
+
+		"keys.esc_sent": "⎋ Sent Escape",
+
+		"cmd.usage":       "Usage: /cmd <command>\nExample: /cmd config",
+		"cmd.new":         "Create a new session",
+		"cmd.cancel":      "Cancel the new session flow",
+		"cmd.worktree":    "Use a new git worktree for the new session",
+		"cmd.session":     "Show the current session / list all windows",
+		"cmd.dir":         "Manage favorite directories",
+		"cmd.ping":        "Check bot / tmux / backend / monitor health",
+		"cmd.clear":       "Delete recent bot messages",
+		"cmd.env":         "Manage environment variables exported before the backend starts",
+		"cmd.broadcast":   "Send one message to every session (admin)",
+		"cmd.help":        "Show help",
+		"cmd.clone":       "Start another session with the same directory and backend",
+		"cmd.kill":        "Close the current session",
+		"cmd.archive":     "Export the transcript and close the session",
+		"cmd.interrupt":   "Interrupt the current generation",
+		"cmd.esc":         "Send Escape",
+		"cmd.enter":       "Send Enter",
+		"cmd.key":         "Send arbitrary keys",
+		"cmd.ctrl":        "Send a Ctrl key combination",
+		"cmd.screenshot":  "Terminal screenshot",
+		"cmd.panes":       "List panes of the window and switch target",
+		"cmd.cmd":         "Send a native backend / command",
+		"cmd.logs":        "Re-send recent output",
+		"cmd.transcript":  "Export the full transcript",
+		"cmd.queue":       "Show or clear the input/output queues",
+		"cmd.git":         "Show project git status",
+		"cmd.top":         "Show backend process resource usage",
+		"cmd.search":      "Search the session history",
+		"cmd.schedule":    "Send a message later",
+		"cmd.stats":       "Token usage stats",
+		"cmd.pause":       "Pause output",
+		"cmd.resume":      "Resume output / resume a past claude session",
+		"cmd.mute":        "Mute for a while",
+		"cmd.filter":      "Filter pushed output by type",
+		"cmd.autoconfirm": "Auto-confirm permission prompts",
+		"cmd.keepalive":   "Exempt the session from idle reaping",
+		"cmd.keyboard":    "Toggle the persistent shortcut keyboard",
+		"cmd.pushmode":    "Stream output into one message or send separate messages",
+		"cmd.unmute":      "Unmute",
+
+		"logs.usage":  "Usage: /logs [N]\nExample: /logs 10",
+		"logs.failed": "Failed to read output: %v",
+		"logs.empty":  "No output yet",
+
+		"transcript.usage":         "Usage: /transcript [md|txt|json] [thinking]",
+		"transcript.none":          "This session has no log file to export",
+		"transcript.export_failed": "Export failed",
+		"transcript.upload_failed": "Upload failed",
+
+		"archive.uploaded":           "✅ Transcript uploaded",
+		"archive.no_transcript":      "⏭ No transcript to export",
+		"archive.transcript_failed":  "⚠️ Transcript: ",
+		"archive.kill_failed":        "⚠️ Failed to close window: %v",
+		"archive.killed":             "✅ Window closed",
+		"archive.unbound":            "✅ Unbound",
+		"archive.closing_topic":      "📦 Closing topic…",
+		"archive.done":               "📦 Archived %s\n%s",
+		"archive.close_topic_failed": "⚠️ Failed to close topic (requires the manage topics right): %v",
+
+		"pause.already": "⏸ Already paused, use /resume to resume",
+		"pause.done":    "⏸ Output paused; permission prompts and interactive UIs are still shown\nUse /resume to resume",
+		"pause.resumed": "▶️ Output resumed, ",
+		"pause.summary": "paused for %s, %s",
+
+		"resume.not_paused":   "Not paused (resuming past sessions is only supported for claude sessions)",
+		"resume.no_sessions":  "No past sessions found",
+		"resume.choose":       "📜 Choose a session to resume (it starts in a new window; the current one keeps running):",
+		"resume.file_missing": "Session file does not exist",
+
+		"skipped.total":        "skipped %d messages",
+		"skipped.answers":      "%d answers",
+		"skipped.thinking":     "%d thinking blocks",
+		"skipped.tool_calls":   "%d tool calls",
+		"skipped.tool_results": "%d tool results",
+		"skipped.detail":       " (%s)",
+
+		"mute.not_muted_usage": "Not muted\nUsage: /mute <duration>, e.g. /mute 30m or /mute 2h",
+		"mute.active":          "🔕 Muted, %s left (until %s)",
+		"mute.invalid":         "Invalid duration\nUsage: /mute <duration>, e.g. /mute 30m or /mute 2h",
+		"mute.done":            "🔕 Muted for %s (until %s), use /unmute to end early",
+		"mute.not_muted":       "Not muted",
+		"mute.unmuted":         "🔔 Unmuted, ",
+
+		"stats.unsupported": "The %s backend does not report usage",
+		"stats.report":      "📊 Session stats\n├─ Input tokens:   %s\n├─ Output tokens:  %s\n├─ Cache read:     %s\n├─ Cache write:    %s\n├─ Turns:          %d\n├─ Tool calls:     %d\n└─ Duration:       %s",
+
+		"ping.pong":            "🏓 Pong",
+		"ping.telegram_api":    "├─ Telegram API:  %s (%s)",
+		"ping.rate_limited":    "├─ Rate limited:  %s left",
+		"ping.tmux_session":    "├─ tmux session:  %s",
+		"ping.windows_unbound": "└─ tmux windows:  %d (this topic is not bound)",
+		"ping.ago":             "%s ago",
+		"ping.never":           "never",
+		"ping.window":          "├─ Window %s:     %s",
+		"ping.backend":         "├─ Backend:       %s (%s)",
+		"ping.monitor":         "├─ Monitor:       %s",
+		"ping.poll_interval":   "├─ Poll every:    %s",
+		"ping.push_queue":      "├─ Push queue:    %d",
+		"ping.last_push":       "└─ Last push:     %s",
+
+		"search.usage":       "Usage: /search <query>",
+		"search.unsupported": "The %s backend does not support search",
+		"search.failed":      "Search failed: %v",
+		"search.not_found":   "🔍 No results for \"%s\"",
+		"search.results":     "🔍 %[2]d results for \"%[1]s\"",
+
+		"git.no_project": "The current session has no project directory",
+		"git.usage":      "Usage: /git status | /git log [N] | /git branch",
+		"git.failed":     "git %s failed: %v",
+		"git.no_output":  "(no output)",
+		"git.not_repo":   "%s is not a git repository",
+
+		"top.pane_failed": "Failed to get the window process: %v",
+		"top.ps_failed":   "Failed to read process info: %v",
+		"top.more":        "… %d more processes\n",
+		"top.total":       "Total %d processes  CPU %.1f%%  RSS %s",
+
+		"env.usage":   "Usage: /env set KEY=VALUE | /env unset KEY | /env list",
+		"env.set":     "✅ Set %s (takes effect the next time a session starts)",
+		"env.not_set": "%s is not set",
+		"env.unset":   "✅ Removed %s",
+		"env.empty":   "No environment variables set for this topic",
+		"env.list":    "🌱 Environment variables (exported before the backend starts):",
+
+		"broadcast.admin_only":    "⛔ Only admins can use /broadcast",
+		"broadcast.usage":         "Usage: /broadcast [--no-bash] <message>",
+		"broadcast.no_bindings":   "No bound sessions",
+		"broadcast.confirm":       "📢 Send to %d sessions:\n%s",
+		"broadcast.bash_warning":  "\n\n⚠️ Includes %d bash sessions where the text runs as a shell command (use --no-bash to skip them)",
+		"broadcast.cancelled":     "Broadcast cancelled",
+		"broadcast.expired":       "Broadcast expired, please send /broadcast again",
+		"broadcast.sent":          "✅ sent",
+		"broadcast.skipped_bash":  "⏭ skipped (bash)",
+		"broadcast.window_closed": "⚠️ window closed",
+		"broadcast.queue_full":    "⚠️ input queue full",
+		"broadcast.done":          "📢 Broadcast delivered %d/%d\n%s",
+
+		"worktree.usage":         "Usage: /worktree <branch> (after choosing a directory in /new, before choosing a backend)",
+		"worktree.need_dir":      "Choose a repository directory with /new first, then use /worktree <branch>",
+		"worktree.add_failed":    "❌ git worktree add failed:\n%v",
+		"worktree.created":       "🌿 Created worktree %s (branch %s)",
+		"worktree.expired":       "Worktree info has expired",
+		"worktree.remove_failed": "❌ git worktree remove failed:\n%v",
+		"worktree.removed":       "🗑 Removed worktree %s",
+
+		"clone.missing_info":    "The current session has no directory or backend info and cannot be cloned",
+		"clone.bind_here":       "🔗 Bind to this topic",
+		"clone.created_unbound": "✅ Cloned %s session @ %s (window %s, unbound)\nSend a message in another topic to bind it",
+		"clone.replace_button":  "✅ Replace current binding",
+		"clone.confirm_replace": "Clone %s @ %s and replace the current binding (the old window keeps running)?",
+
+		"queue.cleared":      "🗑 Dropped %d pending inputs",
+		"queue.usage":        "Usage: /queue [clear]",
+		"queue.rate_limited": "yes, until %s (%s left)",
+		"queue.status":       "📥 Queues\n├─ Pending input:   %d\n├─ Pending output:  %d\n├─ 429 back-off:    %s\n└─ Summarized:      %d",
+
+		"clear.usage":   "Usage: /clear [N]\nExample: /clear 100",
+		"clear.nothing": "No messages to delete",
+		"clear.done":    "🧹 Deleted %d messages",
+		"clear.failed":  ", %d failed (messages older than 48 hours cannot be deleted)",
+
+		"backend.disabled":      "⛔ Backend %s is disabled in the config",
+		"backend.not_installed": "⛔ Backend %s is not installed (command not found in PATH)",
+
+		"dir.add_usage":     "Usage: /dir add <path>",
+		"dir.favorited":     "⭐ Added to favorites: %s",
+		"dir.rm_usage":      "Usage: /dir rm <path>",
+		"dir.unfavorited":   "🗑 Removed from favorites: %s",
+		"dir.browse_failed": "Browse failed: %v",
+		"dir.title":         "📂 Directories\n",
+		"dir.favorites":     "⭐ Favorites:",
+		"dir.recent":        "\n🕐 Recent:",
+		"dir.empty":         "No directories yet\nUse /dir add <path> to add a favorite\nUse /dir browse to browse",
+		"dir.token_expired": "⌛ This directory button has expired, please open /dir again",
+
+		"bind.backend_exited": "⚠️ The backend in that window has exited and cannot be bound",
+		"bind.done":           "🔗 Bound to window %s (%s)",
+		"bind.detected":       "Backend: %s",
+		"bind.undetected":     "⚠️ Could not tell which backend runs in that window; output will be captured from the terminal",
+
+		"interrupt.failed":     "Failed to send interrupt: %v",
+		"interrupt.done":       "⏹ Interrupted (%s)",
+		"interrupt.still_busy": "⚠️ Interrupt sent but the backend still looks busy, check with /screenshot",
+		"interrupt.idle":       "Nothing is running",
+
+		"key.usage":   "Usage: /key <key>...\nExample: /key Down Down Enter\nKeys: %s, C-<letter>, M-<letter>",
+		"key.unknown": "Unknown key: %s\n%s",
+		"key.failed":  "Failed to send keys: %v",
+		"key.sent":    "⌨️ Sent: %s",
+
+		"ctrl.usage":     "Usage: /ctrl <letter>\nExamples: /ctrl c (interrupt), /ctrl d (EOF), /ctrl z (suspend), /ctrl l (clear screen)",
+		"ctrl.confirm_d": "⚠️ Ctrl-D may terminate the backend process. Send it anyway?",
+		"ctrl.sent":      "⌨️ Sent Ctrl-%s",
+
+		"kb.enter_path":  "📁 Enter path...",
+		"kb.send_ctrl":   "✅ Send Ctrl-%s",
+		"kb.close":       "❌ Close",
+		"kb.clean":       "🧹 Clean up",
+		"kb.bind":        "🔗 Bind",
+		"kb.new_session": "➕ New session",
+		"kb.page":        "page %d/%d",
+		"kb.prev_page":   "« prev",
+		"kb.next_page":   "next »",
+		"kb.select_dir":  "✅ Select this directory",
+		"kb.show_hidden": "👁 Show hidden",
+		"kb.hide_hidden": "🙈 Hide hidden",
+		"kb.approve":     "✅ Approve plan",
+		"kb.replan":      "✏️ Keep planning",
+		"kb.reject":      "❌ Reject",
+		"kb.default":     "Default flags",
+		"kb.parent_dir":  "⬆️ Up one level",
+		"kb.yes":         "✅ Yes",
+		"kb.no":          "❌ No",
+		"kb.always":      "🔓 Always",
+		"kb.screenshot":  "📸 Screenshot",
+		"kb.continue":    "▶️ Continue",
+		"kb.kill":        "🗑 Kill",
+		"kb.refresh":     "🔄 Refresh",
+		"kb.rebackend":   "🔁 Change backend",
+		"kb.output":      "📄 Show output",
+
+		"args.branch":    "<branch>",
+		"args.dir":       "[add|rm|browse] [path]",
+		"args.broadcast": "[--no-bash] <message>",
+		"args.keys":      "<key>...",
+		"args.letter":    "<letter>",
+		"args.command":   "<command>",
+		"args.query":     "<query>",
+		"args.schedule":  "<duration|HH:MM> <message> | list | cancel <ID>",
+		"args.duration":  "[duration]",
+
+		"hint.idle":                "Send any message or /new to create a session",
+		"hint.awaiting_dir":        "Tap a button to choose the project directory (/cancel to cancel)",
+		"hint.awaiting_path_input": "Enter the full path of the project directory (/cancel to cancel)",
+		"hint.awaiting_backend":    "Tap a button to choose the backend (/worktree <branch> for a new worktree, /cancel to cancel)",
+		"hint.bound":               "Send messages to talk to the backend; prefix with ! to run a shell command",
+
+		"help.bound":                   "📖 Help · bound to %s",
+		"help.unbound":                 "📖 Help · no session bound to this topic",
+		"help.phase":                   "State: %s",
+		"help.next":                    "Next: %s",
+		"help.session_commands":        "\nSession commands:",
+		"help.session_commands_locked": "\nSession commands (🔒 requires a bound session):",
+		"help.general_commands":        "\nGeneral commands:",
+
+		"schedule.usage":     "Usage:\n/schedule <duration|HH:MM> <message> — send later, e.g. /schedule 8h continue the refactor, /schedule 09:30 run the tests\n/schedule list — show pending\n/schedule cancel <ID> — cancel",
+		"schedule.none":      "No scheduled messages in this topic\n\n",
+		"schedule.list":      "⏰ Scheduled messages:",
+		"schedule.not_found": "Scheduled message #%d does not exist",
+		"schedule.cancelled": "✅ Cancelled scheduled message #%d",
+		"schedule.created":   "⏰ Scheduled message #%d for %s (in %s)",
+		"schedule.dropped":   "⚠️ Scheduled message #%d was not sent: the session is gone\n%s",
+		"schedule.sent":      "⏰ Scheduled message #%d sent: %s",
+
+		"upload.too_large":       "File too large (%d MB, limit %d MB)",
+		"upload.too_large_limit": "File too large (limit %d MB)",
+		"upload.photo":           "photo",
+		"upload.file":            "file",
+		"upload.not_bound":       "No session is bound to this topic, cannot accept the %s",
+		"upload.no_project":      "The current session has no project directory, cannot save the %s",
+		"upload.save_failed":     "Failed to save the %s: %v",
+		"upload.saved":           "📎 Saved %s: %s",
+		"upload.forwarded":       "📎 Saved %s and sent it to the backend: %s",
+
+		"voice.not_configured":    "🎙 Voice transcription is not configured, set transcribe.command in the config (e.g. [\"whisper-cli\", \"--model\", \"base\", \"{file}\"])",
+		"voice.failed":            "Voice processing failed: %v",
+		"voice.download_failed":   "Failed to download the voice message: %v",
+		"voice.transcribe_failed": "Transcription failed: %v",
+		"voice.empty":             "🎙 Nothing recognized",
+
+		"push.interactive":     "🎮 Interactive UI detected:",
+		"push.menu":            "🎮 Choose an option:",
+		"push.plan":            "📋 Plan awaiting approval:",
+		"push.usage_footer":    "· %s in / %s out / cache %d%%",
+		"push.permission_tool": "🔐 Permission prompt detected: %s",
+		"push.permission":      "🔐 Permission prompt detected:",
+		"push.mute_ended":      "🔔 Mute ended, ",
+		"push.overflow":        "⏭ Too much output, %s",
+		"push.attached":        "📎 %d characters, full text in the attached file",
+		"push.output_expired":  "This output is no longer available (only recent outputs are kept, and not across restarts)",
+
+		"monitor.gemini_fallback":    "Cannot locate the Gemini log directory, switched to terminal capture mode",
+		"monitor.summary":            "Session summary: %s",
+		"monitor.result_error":       "Backend run failed: %s",
+		"monitor.api_error":          "API error: %s",
+		"monitor.api_retry":          " (retry %d/%d)",
+		"monitor.polling":            "Log file watching is unavailable, switched to polling every %s",
+		"monitor.relocked":           "Detected a new session log, monitor re-attached (%s)",
+		"monitor.parse_fallback":     "Failed to parse the session log, switched to terminal capture mode",
+		"monitor.restarted":          "The output monitor stopped unexpectedly and was restarted",
+		"monitor.scrollback_omitted": "… (%d earlier lines omitted)",
+		"monitor.headless_failed":    "claude failed: %s",
+		"monitor.compacted":          "🗜 Context compacted",
+
+		"todo.more": "… +%d more",
+
+		"thinking.cont": "💭 (cont.)",
+	})
+}
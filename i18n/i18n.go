@@ -0,0 +1,76 @@
+// Package i18n 面向用户文本的消息目录：按标识符查表，各语言一个文件，
+// 新增语言只需添加一个在 init 中调用 Register 的目录文件
+package i18n
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// DefaultLanguage 默认语言，也是缺失翻译时的回退语言
+const DefaultLanguage = "zh"
+
+// Catalog 一种语言的消息目录：标识符 → 文本（可含 fmt 占位符）
+type Catalog map[string]string
+
+var (
+	mu       sync.RWMutex
+	catalogs = map[string]Catalog{}
+	current  = DefaultLanguage
+)
+
+// Register 注册一种语言的消息目录，通常在目录文件的 init 中调用
+func Register(lang string, c Catalog) {
+	mu.Lock()
+	defer mu.Unlock()
+	catalogs[lang] = c
+}
+
+// SetLanguage 切换当前语言，空字符串表示默认语言
+func SetLanguage(lang string) error {
+	if lang == "" {
+		lang = DefaultLanguage
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if _, ok := catalogs[lang]; !ok {
+		return fmt.Errorf("unsupported language %q (available: %v)", lang, languagesLocked())
+	}
+	current = lang
+	return nil
+}
+
+// Languages 返回已注册的语言列表
+func Languages() []string {
+	mu.RLock()
+	defer mu.RUnlock()
+	return languagesLocked()
+}
+
+func languagesLocked() []string {
+	langs := make([]string, 0, len(catalogs))
+	for lang := range catalogs {
+		langs = append(langs, lang)
+	}
+	sort.Strings(langs)
+	return langs
+}
+
+// T 查找当前语言的文本，有参数时按 fmt.Sprintf 格式化；
+// 缺失时回退到默认语言，仍缺失则返回标识符本身
+func T(key string, args ...any) string {
+	mu.RLock()
+	text, ok := catalogs[current][key]
+	if !ok {
+		text, ok = catalogs[DefaultLanguage][key]
+	}
+	mu.RUnlock()
+	if !ok {
+		text = key
+	}
+	if len(args) == 0 {
+		return text
+	}
+	return fmt.Sprintf(text, args...)
+}
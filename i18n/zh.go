@@ -0,0 +1,431 @@
+package i18n
+
+// 简体中文目录（默认语言）
+func init() {
+	Register("zh", Catalog{
+		"flow.path_empty":          "路径不能为空，请重新输入：",
+		"flow.path_invalid":        "路径中不能包含换行符，请重新输入：",
+		"flow.dir_not_found":       "目录不存在: %s\n请重新输入：",
+		"flow.choose_backend":      "🚀 选择启动命令：",
+		"flow.choose_profile":      "🚀 选择 %s 的启动参数：",
+		"flow.profile_not_found":   "启动参数组合 %s 不存在（配置可能已修改）",
+		"flow.use_dir_buttons":     "请点击按钮选择目录，或点击 [📁 输入路径...] 手动输入",
+		"flow.use_backend_buttons": "请点击按钮选择后端",
+		"flow.choose_dir":          "📂 选择项目目录：",
+		"flow.nothing_to_cancel":   "当前没有进行中的操作",
+		"flow.enter_path":          "请输入项目目录的完整路径：\n（/cancel 取消）",
+		"flow.no_dir":              "错误：未选择目录",
+
+		"flow.default_backend_note":   "使用默认后端 %s，需要其他后端请点下方按钮",
+		"flow.change_backend_expired": "该会话已关闭或不在当前 Topic，无法更换后端",
+
+		"session.disconnected_unbound":   "⚠️ 会话已断开，已自动解绑",
+		"session.backend_exited_unbound": "⚠️ 后端进程已退出，已自动解绑",
+		"session.none_available":         "当前 Topic 没有可用的会话",
+		"session.list_failed":            "获取窗口列表失败: %v",
+		"session.no_windows":             "🖥 当前没有 tmux 窗口",
+		"session.all_windows":            "🖥 所有 tmux 窗口\n",
+		"session.window_bound":           "%s  %s  ← 已绑定 %s",
+		"session.window_unbound":         "%s  %s  ← 未绑定",
+		"session.not_bound_hint":         "当前 Topic 尚未绑定会话\n使用 /new 创建新会话",
+		"session.running":                "运行中",
+		"session.disconnected":           "已断开",
+		"session.info":                   "📋 当前会话信息\n├─ 窗口:    %s\n├─ 后端:    %s\n├─ 目录:    %s\n├─ 状态:    %s\n└─ 创建于:  %s ago",
+		"session.not_bound":              "当前 Topic 尚未绑定会话",
+		"session.create_window_failed":   "创建窗口失败: %v",
+		"session.shell_not_ready":        "终端在 %s 内未就绪（shell 启动过慢或无法进入项目目录），已关闭窗口",
+		"session.created":                "✅ 已创建 %s 会话 @ %s",
+		"session.window_gone":            "⚠️ 会话窗口已断开",
+
+		"shell.send_failed": "发送命令失败: %v",
+
+		"edit.replaced":      "✏️ 已替换队列中尚未发送的消息",
+		"edit.resend_button": "🔁 重新发送",
+		"edit.resend_prompt": "✏️ 原消息已发送给后端，是否重新发送修改后的内容？",
+		"edit.expired":       "该修改已处理或已过期",
+		"edit.resent":        "🔁 已重新发送",
+
+		"common.ignore":        "忽略",
+		"common.send":          "✅ 发送",
+		"common.ignore_button": "❌ 忽略",
+		"common.cancelled":     "已取消",
+		"common.keep":          "保留",
+		"common.cancel_button": "❌ 取消",
+		"common.list_sep":      "，",
+		"common.truncated":     "\n…（已截断）",
+		"common.timeout":       "超时（%s）",
+		"common.no":            "否",
+
+		"forward.photo":          "[图片] ",
+		"forward.document":       "[文件] ",
+		"forward.prompt":         "↪️ 将这条来自 %s 的转发消息发送给会话？\n\n%s",
+		"forward.expired":        "该转发已处理或已过期",
+		"forward.sent":           "↪️ 已发送给会话",
+		"forward.header":         "转发自 %s 的消息：\n",
+		"forward.unknown_origin": "未知来源",
+
+		"unbound.choose": "该 Topic 尚未绑定会话，请选择：",
+
+		"topic.open":    "🧵 打开 Topic",
+		"topic.created": "🧵 已为会话创建 Topic「%s」",
+
+		"turn.done": "✅ 回合结束，用时 %s — %d 次工具调用",
+
+		"stall.alert": "⏳ 已 %s 无输出 — 要截图看看吗？",
+
+		"plan.approved":    "✅ 已批准计划",
+		"plan.kept":        "✏️ 已选择继续规划，可直接发送修改意见",
+		"plan.rejected":    "❌ 已拒绝计划",
+		"plan.expired":     "该计划已失效",
+		"plan.unsupported": "后端 %s 不支持计划审批按键",
+
+		"reap.warning":     "💤 会话 %s 已空闲 %s，%s 内无人响应将自动关闭",
+		"reap.keep_button": "✅ 保持运行",
+		"reap.kill_button": "🗑 立即关闭",
+		"reap.killed":      "💤 会话 %s 空闲 %s 且无人响应，已自动关闭",
+		"reap.expired":     "该提醒已失效",
+		"reap.kept":        "✅ 已保持运行，重新开始计时",
+
+		"filter.usage":  "用法: /filter [status]\n/filter thinking|tools|results on|off",
+		"filter.status": "🔎 推送内容：思考 %s · 工具调用 %s · 工具结果 %s",
+		"filter.shown":  "✅",
+		"filter.hidden": "🚫",
+
+		"pushmode.usage":    "用法: /pushmode [stream|messages]",
+		"pushmode.stream":   "📝 推送方式：流式，回答与思考持续追加到同一条消息（约每 2 秒更新），工具调用仍单独发送",
+		"pushmode.messages": "💬 推送方式：分条，每段回答与思考各发一条消息",
+
+		"autoconfirm.usage": "用法: /autoconfirm on|off|status",
+		"autoconfirm.on":    "🔓 自动确认已开启：权限请求将自动回答 yes，包含以下内容时仍会询问：%s",
+		"autoconfirm.off":   "🔒 自动确认已关闭",
+		"autoconfirm.tool":  "🔓 已自动批准工具 %s",
+		"autoconfirm.done":  "🔓 已自动确认: %s",
+
+		"keepalive.usage":    "用法: /keepalive [on|off]",
+		"keepalive.disabled": "未启用空闲回收（monitor.idle_kill_after）",
+		"keepalive.on":       "📌 当前会话不参与空闲回收",
+		"keepalive.off":      "⏳ 当前会话空闲 %s 后将提醒并回收",
+
+		"panes.title":    "窗口 %s 的 pane（👉 为当前目标）：",
+		"panes.switch":   "切换到 %s (%s)",
+		"panes.switched": "已切换到 pane %s（%s），输入与输出监控均针对该 pane",
+		"panes.gone":     "pane %s 已不存在",
+		"panes.failed":   "无法列出 pane: %v",
+
+		"replykb.usage":      "用法: /keyboard [on|off]",
+		"replykb.shown":      "⌨️ 快捷键盘已启用，/keyboard off 可移除",
+		"replykb.removed":    "⌨️ 快捷键盘已移除",
+		"replykb.screenshot": "📸 截图",
+		"replykb.esc":        "⎋ Esc",
+		"replykb.enter":      "⏎ 回车",
+		"replykb.status":     "📊 状态",
+
+		"kill.usage":                  "用法: /kill [now|--force]（不带参数时需二次确认）",
+		"kill.confirm":                "⚠️ 确认关闭 %s？",
+		"kill.running_for":            "已运行 %s",
+		"kill.confirm_button":         "🗑 确认关闭",
+		"kill.done":                   "✅ 已关闭会话 %s",
+		"kill.remove_worktree_button": "🗑 删除 worktree",
+		"kill.done_worktree":          "✅ 已关闭会话 %s\n是否删除 worktree %s？",
+
+		"keys.esc_sent": "⎋ 已发送 Escape",
+
+		"cmd.usage":       "用法: /cmd <命令>\n例如: /cmd config",
+		"cmd.new":         "新建会话",
+		"cmd.cancel":      "取消新建会话流程",
+		"cmd.worktree":    "新建会话时使用新的 git worktree",
+		"cmd.session":     "查看当前会话 / 列出所有窗口",
+		"cmd.dir":         "管理收藏目录",
+		"cmd.ping":        "检查 bot / tmux / 后端 / 监控状态",
+		"cmd.clear":       "删除 bot 最近发送的消息",
+		"cmd.env":         "管理启动后端前导出的环境变量",
+		"cmd.broadcast":   "向所有会话发送同一消息（管理员）",
+		"cmd.help":        "显示帮助",
+		"cmd.clone":       "以相同目录和后端再开一个会话",
+		"cmd.kill":        "关闭当前会话",
+		"cmd.archive":     "导出会话记录并关闭会话",
+		"cmd.interrupt":   "中断当前生成",
+		"cmd.esc":         "发送 Escape",
+		"cmd.enter":       "发送回车",
+		"cmd.key":         "发送任意按键",
+		"cmd.ctrl":        "发送 Ctrl 组合键",
+		"cmd.screenshot":  "终端截图",
+		"cmd.panes":       "列出窗口中的 pane 并切换目标",
+		"cmd.cmd":         "发送后端原生 / 命令",
+		"cmd.logs":        "重新推送最近的输出",
+		"cmd.transcript":  "导出完整会话记录",
+		"cmd.queue":       "查看或清空输入/输出队列",
+		"cmd.git":         "查看项目 git 状态",
+		"cmd.top":         "查看后端进程资源占用",
+		"cmd.search":      "搜索会话历史",
+		"cmd.schedule":    "定时发送消息",
+		"cmd.stats":       "token 用量统计",
+		"cmd.pause":       "暂停推送输出",
+		"cmd.resume":      "恢复推送输出 / 恢复 claude 历史会话",
+		"cmd.mute":        "静音一段时间",
+		"cmd.filter":      "按类型过滤推送内容",
+		"cmd.autoconfirm": "自动确认权限请求",
+		"cmd.keepalive":   "空闲回收豁免设置",
+		"cmd.keyboard":    "常驻快捷键盘开关",
+		"cmd.pushmode":    "推送方式：流式编辑或分条消息",
+		"cmd.unmute":      "解除静音",
+
+		"logs.usage":  "用法: /logs [N]\n例如: /logs 10",
+		"logs.failed": "获取输出失败: %v",
+		"logs.empty":  "暂无输出",
+
+		"transcript.usage":         "用法: /transcript [md|txt|json] [thinking]",
+		"transcript.none":          "该会话没有可导出的日志文件",
+		"transcript.export_failed": "导出失败",
+		"transcript.upload_failed": "上传失败",
+
+		"archive.uploaded":           "✅ 已上传会话记录",
+		"archive.no_transcript":      "⏭ 无会话记录可导出",
+		"archive.transcript_failed":  "⚠️ 会话记录: ",
+		"archive.kill_failed":        "⚠️ 关闭窗口失败: %v",
+		"archive.killed":             "✅ 已关闭窗口",
+		"archive.unbound":            "✅ 已解绑",
+		"archive.closing_topic":      "📦 正在关闭 Topic…",
+		"archive.done":               "📦 已归档 %s\n%s",
+		"archive.close_topic_failed": "⚠️ 关闭 Topic 失败（需要管理话题权限）: %v",
+
+		"pause.already": "⏸ 已处于暂停状态，使用 /resume 恢复",
+		"pause.done":    "⏸ 已暂停推送，权限确认和交互界面仍会提醒\n使用 /resume 恢复",
+		"pause.resumed": "▶️ 已恢复推送，",
+		"pause.summary": "暂停 %s，%s",
+
+		"resume.not_paused":   "当前未暂停（恢复历史会话仅支持 claude 会话）",
+		"resume.no_sessions":  "未找到历史会话",
+		"resume.choose":       "📜 选择要恢复的会话（将在新窗口中启动，原窗口保留运行）：",
+		"resume.file_missing": "会话文件不存在",
+
+		"skipped.total":        "跳过 %d 条消息",
+		"skipped.answers":      "%d 条回答",
+		"skipped.thinking":     "%d 条思考",
+		"skipped.tool_calls":   "%d 次工具调用",
+		"skipped.tool_results": "%d 个工具结果",
+		"skipped.detail":       "（%s）",
+
+		"mute.not_muted_usage": "当前未静音\n用法: /mute <时长>，例如 /mute 30m、/mute 2h",
+		"mute.active":          "🔕 静音中，剩余 %s（至 %s）",
+		"mute.invalid":         "时长格式无效\n用法: /mute <时长>，例如 /mute 30m、/mute 2h",
+		"mute.done":            "🔕 已静音 %s（至 %s），使用 /unmute 提前解除",
+		"mute.not_muted":       "当前未静音",
+		"mute.unmuted":         "🔔 已解除静音，",
+
+		"stats.unsupported": "%s 后端不提供用量统计",
+		"stats.report":      "📊 会话统计\n├─ 输入 tokens:  %s\n├─ 输出 tokens:  %s\n├─ 缓存读取:    %s\n├─ 缓存写入:    %s\n├─ 轮次:        %d\n├─ 工具调用:    %d\n└─ 时长:        %s",
+
+		"ping.pong":            "🏓 Pong",
+		"ping.telegram_api":    "├─ Telegram API: %s (%s)",
+		"ping.rate_limited":    "├─ 限流中:       剩余 %s",
+		"ping.tmux_session":    "├─ tmux 会话:    %s",
+		"ping.windows_unbound": "└─ tmux 窗口数:  %d（当前 Topic 未绑定）",
+		"ping.ago":             "%s 前",
+		"ping.never":           "从未",
+		"ping.window":          "├─ 窗口 %s:     %s",
+		"ping.backend":         "├─ 后端进程:     %s (%s)",
+		"ping.monitor":         "├─ 输出监控:     %s",
+		"ping.poll_interval":   "├─ 轮询间隔:     %s",
+		"ping.push_queue":      "├─ 推送队列:     %d",
+		"ping.last_push":       "└─ 最近推送:     %s",
+
+		"search.usage":       "用法: /search <关键词>",
+		"search.unsupported": "%s 后端不支持搜索",
+		"search.failed":      "搜索失败: %v",
+		"search.not_found":   "🔍 未找到 \"%s\"",
+		"search.results":     "🔍 \"%s\" 共 %d 条结果",
+
+		"git.no_project": "当前会话缺少项目目录",
+		"git.usage":      "用法: /git status | /git log [N] | /git branch",
+		"git.failed":     "git %s 失败: %v",
+		"git.no_output":  "(无输出)",
+		"git.not_repo":   "%s 不是 git 仓库",
+
+		"top.pane_failed": "获取窗口进程失败: %v",
+		"top.ps_failed":   "读取进程信息失败: %v",
+		"top.more":        "… 另有 %d 个进程\n",
+		"top.total":       "合计 %d 个进程  CPU %.1f%%  RSS %s",
+
+		"env.usage":   "用法: /env set KEY=VALUE | /env unset KEY | /env list",
+		"env.set":     "✅ 已设置 %s（下次启动会话时生效）",
+		"env.not_set": "%s 未设置",
+		"env.unset":   "✅ 已删除 %s",
+		"env.empty":   "当前 Topic 未设置环境变量",
+		"env.list":    "🌱 环境变量（启动后端前导出）:",
+
+		"broadcast.admin_only":    "⛔ 仅管理员可使用 /broadcast",
+		"broadcast.usage":         "用法: /broadcast [--no-bash] <消息>",
+		"broadcast.no_bindings":   "当前没有已绑定的会话",
+		"broadcast.confirm":       "📢 将向 %d 个会话发送:\n%s",
+		"broadcast.bash_warning":  "\n\n⚠️ 包含 %d 个 bash 会话，文本将作为 shell 命令执行（使用 --no-bash 跳过）",
+		"broadcast.cancelled":     "已取消广播",
+		"broadcast.expired":       "广播已过期，请重新发送 /broadcast",
+		"broadcast.sent":          "✅ 已发送",
+		"broadcast.skipped_bash":  "⏭ 跳过（bash）",
+		"broadcast.window_closed": "⚠️ 窗口已关闭",
+		"broadcast.queue_full":    "⚠️ 输入队列已满",
+		"broadcast.done":          "📢 广播完成 %d/%d\n%s",
+
+		"worktree.usage":         "用法: /worktree <分支>（在 /new 选择目录后、选择后端前使用）",
+		"worktree.need_dir":      "请先通过 /new 选择仓库目录，再使用 /worktree <分支>",
+		"worktree.add_failed":    "❌ git worktree add 失败:\n%v",
+		"worktree.created":       "🌿 已创建 worktree %s（分支 %s）",
+		"worktree.expired":       "worktree 信息已失效",
+		"worktree.remove_failed": "❌ git worktree remove 失败:\n%v",
+		"worktree.removed":       "🗑 已删除 worktree %s",
+
+		"clone.missing_info":    "当前会话缺少目录或后端信息，无法克隆",
+		"clone.bind_here":       "🔗 绑定到当前 Topic",
+		"clone.created_unbound": "✅ 已克隆 %s 会话 @ %s（窗口 %s，未绑定）\n在其他 Topic 发送消息即可选择绑定",
+		"clone.replace_button":  "✅ 替换当前绑定",
+		"clone.confirm_replace": "将克隆 %s @ %s 并替换当前绑定（原窗口保留运行），确认？",
+
+		"queue.cleared":      "🗑 已丢弃 %d 条待发送的输入",
+		"queue.usage":        "用法: /queue [clear]",
+		"queue.rate_limited": "是，至 %s（剩余 %s）",
+		"queue.status":       "📥 队列状态\n├─ 待发送输入:  %d\n├─ 待推送输出:  %d\n├─ 429 限流:    %s\n└─ 溢出摘要:    %d 条",
+
+		"clear.usage":   "用法: /clear [N]\n例如: /clear 100",
+		"clear.nothing": "没有可删除的消息",
+		"clear.done":    "🧹 已删除 %d 条消息",
+		"clear.failed":  "，%d 条删除失败（超过 48 小时的消息无法删除）",
+
+		"backend.disabled":      "⛔ 后端 %s 已在配置中停用",
+		"backend.not_installed": "⛔ 后端 %s 未安装（PATH 中找不到命令）",
+
+		"dir.add_usage":     "用法: /dir add <路径>",
+		"dir.favorited":     "⭐ 已收藏: %s",
+		"dir.rm_usage":      "用法: /dir rm <路径>",
+		"dir.unfavorited":   "🗑 已移除收藏: %s",
+		"dir.browse_failed": "浏览失败: %v",
+		"dir.title":         "📂 目录管理\n",
+		"dir.favorites":     "⭐ 收藏:",
+		"dir.recent":        "\n🕐 最近使用:",
+		"dir.empty":         "暂无目录记录\n使用 /dir add <路径> 添加收藏\n使用 /dir browse 浏览目录",
+		"dir.token_expired": "⌛ 目录按钮已过期，请重新打开 /dir",
+
+		"bind.backend_exited": "⚠️ 该窗口的后端进程已退出，无法绑定",
+		"bind.done":           "🔗 已绑定到窗口 %s (%s)",
+		"bind.detected":       "后端: %s",
+		"bind.undetected":     "⚠️ 未能识别窗口中的后端，将通过终端截屏推送输出",
+
+		"interrupt.failed":     "发送中断失败: %v",
+		"interrupt.done":       "⏹ 已中断（%s）",
+		"interrupt.still_busy": "⚠️ 已发送中断，但后端似乎仍在运行，可用 /screenshot 查看",
+		"interrupt.idle":       "当前没有正在运行的任务",
+
+		"key.usage":   "用法: /key <键名>...\n例如: /key Down Down Enter\n可用键名: %s, C-<字母>, M-<字母>",
+		"key.unknown": "未知键名: %s\n%s",
+		"key.failed":  "发送按键失败: %v",
+		"key.sent":    "⌨️ 已发送: %s",
+
+		"ctrl.usage":     "用法: /ctrl <字母>\n例如: /ctrl c（中断）、/ctrl d（EOF）、/ctrl z（挂起）、/ctrl l（清屏）",
+		"ctrl.confirm_d": "⚠️ Ctrl-D 可能会终止后端进程，确认发送？",
+		"ctrl.sent":      "⌨️ 已发送 Ctrl-%s",
+
+		"kb.enter_path":  "📁 输入路径...",
+		"kb.send_ctrl":   "✅ 发送 Ctrl-%s",
+		"kb.close":       "❌ 关闭",
+		"kb.clean":       "🧹 清理",
+		"kb.bind":        "🔗 绑定",
+		"kb.new_session": "➕ 新建会话",
+		"kb.page":        "第 %d/%d 页",
+		"kb.prev_page":   "« 上一页",
+		"kb.next_page":   "下一页 »",
+		"kb.select_dir":  "✅ 选择此目录",
+		"kb.show_hidden": "👁 显示隐藏",
+		"kb.hide_hidden": "🙈 不显示隐藏",
+		"kb.approve":     "✅ 批准计划",
+		"kb.replan":      "✏️ 继续规划",
+		"kb.reject":      "❌ 拒绝",
+		"kb.default":     "默认参数",
+		"kb.parent_dir":  "⬆️ 返回上级",
+		"kb.yes":         "✅ Yes",
+		"kb.no":          "❌ No",
+		"kb.always":      "🔓 Always",
+		"kb.screenshot":  "📸 截图",
+		"kb.continue":    "▶️ 继续",
+		"kb.kill":        "🗑 关闭",
+		"kb.refresh":     "🔄 Refresh",
+		"kb.rebackend":   "🔁 更换后端",
+		"kb.output":      "📄 查看输出",
+
+		"args.branch":    "<分支>",
+		"args.dir":       "[add|rm|browse] [路径]",
+		"args.broadcast": "[--no-bash] <消息>",
+		"args.keys":      "<键名>...",
+		"args.letter":    "<字母>",
+		"args.command":   "<命令>",
+		"args.query":     "<关键词>",
+		"args.schedule":  "<时长|HH:MM> <消息> | list | cancel <ID>",
+		"args.duration":  "[时长]",
+
+		"hint.idle":                "发送任意消息或 /new 创建会话",
+		"hint.awaiting_dir":        "点击按钮选择项目目录（/cancel 取消）",
+		"hint.awaiting_path_input": "输入项目目录的完整路径（/cancel 取消）",
+		"hint.awaiting_backend":    "点击按钮选择后端（/worktree <分支> 改用新 worktree，/cancel 取消）",
+		"hint.bound":               "直接发送消息与后端对话，! 前缀直接执行 shell 命令",
+
+		"help.bound":                   "📖 帮助 · 已绑定 %s",
+		"help.unbound":                 "📖 帮助 · 当前 Topic 未绑定会话",
+		"help.phase":                   "状态: %s",
+		"help.next":                    "下一步: %s",
+		"help.session_commands":        "\n会话命令:",
+		"help.session_commands_locked": "\n会话命令（🔒 需先绑定会话）:",
+		"help.general_commands":        "\n通用命令:",
+
+		"schedule.usage":     "用法:\n/schedule <时长|HH:MM> <消息> — 定时发送，例如 /schedule 8h 继续重构、/schedule 09:30 跑一遍测试\n/schedule list — 查看待发送\n/schedule cancel <ID> — 取消",
+		"schedule.none":      "当前 Topic 没有待发送的定时消息\n\n",
+		"schedule.list":      "⏰ 待发送的定时消息:",
+		"schedule.not_found": "定时消息 #%d 不存在",
+		"schedule.cancelled": "✅ 已取消定时消息 #%d",
+		"schedule.created":   "⏰ 已创建定时消息 #%d，将于 %s 发送（%s 后）",
+		"schedule.dropped":   "⚠️ 定时消息 #%d 未发送：会话已断开\n%s",
+		"schedule.sent":      "⏰ 定时消息 #%d 已发送: %s",
+
+		"upload.too_large":       "文件过大（%d MB，上限 %d MB）",
+		"upload.too_large_limit": "文件过大（上限 %d MB）",
+		"upload.photo":           "图片",
+		"upload.file":            "文件",
+		"upload.not_bound":       "当前 Topic 尚未绑定会话，无法接收%s",
+		"upload.no_project":      "当前会话缺少项目目录，无法保存%s",
+		"upload.save_failed":     "保存%s失败: %v",
+		"upload.saved":           "📎 %s已保存: %s",
+		"upload.forwarded":       "📎 %s已保存并发送给后端: %s",
+
+		"voice.not_configured":    "🎙 未配置语音转文字，请在配置中设置 transcribe.command（如 [\"whisper-cli\", \"--model\", \"base\", \"{file}\"]）",
+		"voice.failed":            "语音处理失败: %v",
+		"voice.download_failed":   "下载语音失败: %v",
+		"voice.transcribe_failed": "语音转文字失败: %v",
+		"voice.empty":             "🎙 未识别到内容",
+
+		"push.interactive":     "🎮 检测到交互式界面：",
+		"push.menu":            "🎮 请选择：",
+		"push.plan":            "📋 计划待审批：",
+		"push.usage_footer":    "· 输入 %s / 输出 %s / 缓存 %d%%",
+		"push.permission_tool": "🔐 检测到权限确认请求：%s",
+		"push.permission":      "🔐 检测到权限确认请求：",
+		"push.mute_ended":      "🔔 静音已结束，",
+		"push.overflow":        "⏭ 输出过多，%s",
+		"push.attached":        "📎 共 %d 字，完整内容见附件",
+		"push.output_expired":  "该输出已不可用（只保留最近的输出，重启后清空）",
+
+		"monitor.gemini_fallback":    "无法定位 Gemini 日志目录，已切换为终端捕获模式",
+		"monitor.summary":            "会话摘要: %s",
+		"monitor.result_error":       "后端运行出错: %s",
+		"monitor.api_error":          "API 错误: %s",
+		"monitor.api_retry":          "（第 %d/%d 次重试）",
+		"monitor.polling":            "日志文件监听不可用，已改为每 %s 轮询一次",
+		"monitor.relocked":           "检测到新的会话日志，已重新接入（%s）",
+		"monitor.parse_fallback":     "日志解析失败，已切换为终端捕获模式",
+		"monitor.restarted":          "监控意外停止，已自动重启",
+		"monitor.scrollback_omitted": "…（输出过多，省略了前 %d 行）",
+		"monitor.headless_failed":    "claude 运行失败: %s",
+		"monitor.compacted":          "🗜 上下文已压缩",
+
+		"todo.more": "…还有 %d 项",
+
+		"thinking.cont": "💭（续）",
+	})
+}
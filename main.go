@@ -13,6 +13,7 @@ import (
 	"github.com/user/tgmux/auth"
 	tgbot "github.com/user/tgmux/bot"
 	"github.com/user/tgmux/config"
+	"github.com/user/tgmux/i18n"
 	"github.com/user/tgmux/monitor"
 	"github.com/user/tgmux/state"
 	"github.com/user/tgmux/tmux"
@@ -34,6 +35,12 @@ func main() {
 		os.Exit(1)
 	}
 
+	// 界面语言
+	if err := i18n.SetLanguage(cfg.Telegram.Language); err != nil {
+		slog.Error("invalid telegram.language", "error", err)
+		os.Exit(1)
+	}
+
 	// 配置文件权限检查
 	if cfg.Security.ConfigPermissionCheck {
 		config.CheckFilePermission(*configPath)
@@ -64,7 +71,7 @@ func main() {
 	}
 
 	// 创建 Auth Checker
-	authChecker := auth.New(cfg.Telegram.AllowedUsers)
+	authChecker := auth.New(cfg.Telegram.AllowedUsers, cfg.Telegram.AdminUsers)
 
 	// 创建 Dispatcher
 	dispatcher := monitor.NewDispatcher(cfg, store, tmuxMgr)
@@ -0,0 +1,201 @@
+package monitor
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/user/tgmux/state"
+)
+
+const (
+	// aiderHistoryFile aider 默认的对话记录文件名，位于 git 仓库根目录（不在仓库中时为工作目录）
+	aiderHistoryFile = ".aider.chat.history.md"
+	// aiderHistoryWait 对话记录不存在时等待其出现的时长，aider 启动时即会创建
+	aiderHistoryWait = 20 * time.Second
+)
+
+// AiderMonitor 跟踪 aider 追加写入的 markdown 对话记录：#### 开头的行为用户输入，
+// > 开头的行为 aider 自身的提示与命令输出，# aider chat started at 为启动标记，
+// 其余为助手回复，每段回复推送为一条 ContentText
+type AiderMonitor struct {
+	topicKey     string
+	path         string
+	pollInterval time.Duration
+	handler      OutputHandler
+	store        *state.Store
+	cancel       context.CancelFunc
+	offset       int64
+	inFence      bool   // 位于回复的 ``` 代码块内，其中的 # 与 > 不是记录标记
+	onMissing    func() // 对话记录在 aiderHistoryWait 内未出现时调用
+}
+
+func NewAiderMonitor(topicKey, path string, pollInterval time.Duration, handler OutputHandler, store *state.Store) *AiderMonitor {
+	return &AiderMonitor{
+		topicKey:     topicKey,
+		path:         path,
+		pollInterval: pollInterval,
+		handler:      handler,
+		store:        store,
+	}
+}
+
+// aiderHistoryPath 返回 aider 对话记录的路径：启动参数中 --chat-history-file 指定的文件（相对工作目录），
+// 否则为项目所在 git 仓库根目录下的 .aider.chat.history.md
+func aiderHistoryPath(projectPath string, args []string) string {
+	path := ""
+	for i, arg := range args {
+		if v, ok := strings.CutPrefix(arg, "--chat-history-file="); ok {
+			path = v
+		} else if arg == "--chat-history-file" && i+1 < len(args) {
+			path = args[i+1]
+		}
+	}
+	if path == "" {
+		return filepath.Join(gitRoot(projectPath), aiderHistoryFile)
+	}
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(projectPath, path)
+	}
+	return path
+}
+
+// gitRoot 向上查找包含 .git 的目录，找不到时返回 dir 本身
+func gitRoot(dir string) string {
+	for d := dir; ; {
+		if _, err := os.Stat(filepath.Join(d, ".git")); err == nil {
+			return d
+		}
+		parent := filepath.Dir(d)
+		if parent == d {
+			return dir
+		}
+		d = parent
+	}
+}
+
+func (a *AiderMonitor) Start(ctx context.Context) error {
+	if info, err := os.Stat(a.path); err == nil {
+		// 重启后从上次读到的位置继续；新的绑定从文件末尾开始，不重放以前的对话
+		offset, _ := a.store.GetOffset(a.topicKey)
+		if offset.File == a.path && offset.ByteOffset <= info.Size() {
+			a.offset = offset.ByteOffset
+		} else {
+			a.offset = info.Size()
+		}
+	}
+	ctx, a.cancel = context.WithCancel(ctx)
+	go a.loop(ctx)
+	return nil
+}
+
+func (a *AiderMonitor) Stop() {
+	if a.cancel != nil {
+		a.cancel()
+	}
+}
+
+func (a *AiderMonitor) loop(ctx context.Context) {
+	ticker := time.NewTicker(a.pollInterval)
+	defer ticker.Stop()
+	deadline := time.Now().Add(aiderHistoryWait)
+	found := false
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		if !found {
+			if _, err := os.Stat(a.path); err != nil {
+				if time.Now().After(deadline) {
+					slog.Warn("aider chat history not found", "key", a.topicKey, "path", a.path)
+					if a.onMissing != nil {
+						a.onMissing()
+					}
+					return
+				}
+				continue
+			}
+			found = true
+		}
+		a.poll()
+	}
+}
+
+// poll 读取新增的完整行并推送其中的助手回复
+func (a *AiderMonitor) poll() {
+	info, err := os.Stat(a.path)
+	if err != nil {
+		return
+	}
+	if info.Size() < a.offset {
+		// 文件被截断或重建
+		a.offset, a.inFence = 0, false
+	}
+	if info.Size() == a.offset {
+		return
+	}
+
+	f, err := os.Open(a.path)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+	if _, err := f.Seek(a.offset, io.SeekStart); err != nil {
+		return
+	}
+
+	var reply []string
+	reader := bufio.NewReaderSize(f, 64*1024)
+	for {
+		// 末尾未写完的行留到下一轮
+		line, n, _, err := readLine(reader)
+		if err != nil {
+			break
+		}
+		a.offset += n
+		reply = a.feed(reply, string(line))
+	}
+	// aider 在回复结束后一次写入整段，读到的末尾即为回复结尾；未闭合的代码块不延续到下一段
+	a.emit(reply)
+	a.inFence = false
+	a.store.SetOffset(a.topicKey, state.Offset{File: a.path, ByteOffset: a.offset})
+}
+
+// feed 处理一行记录：回复行累积到 reply，遇到其他记录时推送已累积的回复
+func (a *AiderMonitor) feed(reply []string, line string) []string {
+	switch {
+	case strings.HasPrefix(strings.TrimSpace(line), "```"):
+		a.inFence = !a.inFence
+		return append(reply, line)
+	case a.inFence || !isAiderMarker(line):
+		return append(reply, line)
+	}
+	a.emit(reply)
+	return nil
+}
+
+// isAiderMarker 是否为用户输入、aider 输出或启动标记行
+func isAiderMarker(line string) bool {
+	return line == "####" || strings.HasPrefix(line, "#### ") ||
+		line == ">" || strings.HasPrefix(line, "> ") ||
+		strings.HasPrefix(line, "# aider chat started at")
+}
+
+func (a *AiderMonitor) emit(reply []string) {
+	text := strings.TrimSpace(strings.Join(reply, "\n"))
+	if text == "" {
+		return
+	}
+	if len(text) > maxContentBytes {
+		text = truncateBytes(text, maxContentBytes) + "…"
+	}
+	a.handler(a.topicKey, ParsedContent{Type: ContentText, Text: text})
+}
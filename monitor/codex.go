@@ -0,0 +1,239 @@
+package monitor
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// codexShells 以 [shell, -lc, script] 形式执行命令的 shell，展示时只显示 script
+var codexShells = map[string]bool{"bash": true, "sh": true, "zsh": true}
+
+// codexRecord 新版 codex rollout 的一行：{"type": ..., "payload": {...}}
+type codexRecord struct {
+	Type    string          `json:"type"`
+	Payload json.RawMessage `json:"payload"`
+}
+
+// codexPayload event_msg / response_item 的 payload 中用到的字段
+type codexPayload struct {
+	Type string `json:"type"`
+
+	// event_msg
+	Message          string                     `json:"message"`
+	Text             string                     `json:"text"`
+	CallID           string                     `json:"call_id"`
+	Command          []string                   `json:"command"`
+	ExitCode         int                        `json:"exit_code"`
+	Stdout           string                     `json:"stdout"`
+	Stderr           string                     `json:"stderr"`
+	AggregatedOutput string                     `json:"aggregated_output"`
+	Duration         json.RawMessage            `json:"duration"`
+	Changes          map[string]json.RawMessage `json:"changes"`
+	Success          bool                       `json:"success"`
+
+	// response_item
+	Role    string `json:"role"`
+	Content []struct {
+		Type string `json:"type"`
+		Text string `json:"text"`
+	} `json:"content"`
+	Summary []struct {
+		Text string `json:"text"`
+	} `json:"summary"`
+	Name      string          `json:"name"`
+	Arguments string          `json:"arguments"`
+	Output    json.RawMessage `json:"output"`
+}
+
+// parseCodexRecord 解析新版 rollout 的 event_msg / response_item 记录；
+// 不是这种格式时返回 false，由旧格式的 parseCodexLine 处理。
+// 同一内容会同时写成 event_msg 和 response_item，出现过 event_msg 后只推送 event_msg，
+// response_item 仅用于只写 response_item 的旧版本 CLI
+func (m *JSONLMonitor) parseCodexRecord(raw map[string]json.RawMessage) ([]ParsedContent, bool) {
+	if _, ok := raw["payload"]; !ok {
+		return nil, false
+	}
+	data, _ := json.Marshal(raw)
+	var rec codexRecord
+	if err := json.Unmarshal(data, &rec); err != nil {
+		return nil, false
+	}
+	var p codexPayload
+	if err := json.Unmarshal(rec.Payload, &p); err != nil {
+		// session_meta 等记录的 payload 不一定是这种结构，视为已处理
+		return nil, true
+	}
+
+	switch rec.Type {
+	case "event_msg":
+		m.codexEvents = true
+		return parseCodexEvent(p), true
+	case "response_item":
+		if m.codexEvents {
+			return nil, true
+		}
+		return parseCodexResponseItem(p), true
+	}
+	return nil, true
+}
+
+// parseCodexEvent event_msg：回答、推理、命令执行与补丁应用的开始/结束（token_count 由 accumulateCodexUsage 处理）。
+// 开始与结束事件共用 call_id 作为 ToolUseID，推送时结果会合并进调用消息
+func parseCodexEvent(p codexPayload) []ParsedContent {
+	switch p.Type {
+	case "agent_message":
+		if p.Message != "" {
+			return []ParsedContent{{Type: ContentText, Text: p.Message}}
+		}
+	case "agent_reasoning":
+		if p.Text != "" {
+			return []ParsedContent{{Type: ContentThinking, Text: p.Text}}
+		}
+	case "exec_command_begin":
+		return []ParsedContent{codexExecBegin(p.CallID, p.Command)}
+	case "exec_command_end":
+		output := p.AggregatedOutput
+		if output == "" {
+			output = p.Stdout
+		}
+		text := codexExecResult(output, p.ExitCode)
+		if d := codexDuration(p.Duration); d > 0 {
+			text += " · " + d.Round(time.Millisecond).String()
+		}
+		return []ParsedContent{{Type: ContentToolResult, Text: text, ToolUseID: p.CallID, Output: output}}
+	case "patch_apply_begin":
+		return []ParsedContent{{
+			Type:      ContentToolUse,
+			Text:      FormatToolUseSummary("Patch", map[string]interface{}{"files": codexPatchFiles(p.Changes)}),
+			ToolUseID: p.CallID,
+			ToolName:  "Patch",
+		}}
+	case "patch_apply_end":
+		text := "  ⎿  Applied"
+		if !p.Success {
+			text = "  ⎿  Failed"
+			if line := firstLine(strings.TrimSpace(p.Stderr)); line != "" {
+				text += ": " + line
+			}
+		}
+		return []ParsedContent{{Type: ContentToolResult, Text: text, ToolUseID: p.CallID}}
+	}
+	return nil
+}
+
+// parseCodexResponseItem response_item：助手消息、推理摘要、函数调用及其输出
+func parseCodexResponseItem(p codexPayload) []ParsedContent {
+	switch p.Type {
+	case "message":
+		if p.Role != "assistant" {
+			return nil
+		}
+		var texts []string
+		for _, c := range p.Content {
+			if c.Text != "" {
+				texts = append(texts, c.Text)
+			}
+		}
+		if len(texts) > 0 {
+			return []ParsedContent{{Type: ContentText, Text: strings.Join(texts, "\n")}}
+		}
+	case "reasoning":
+		var texts []string
+		for _, s := range p.Summary {
+			if s.Text != "" {
+				texts = append(texts, s.Text)
+			}
+		}
+		if len(texts) > 0 {
+			return []ParsedContent{{Type: ContentThinking, Text: strings.Join(texts, "\n\n")}}
+		}
+	case "function_call":
+		var args struct {
+			Command []string `json:"command"`
+		}
+		if json.Unmarshal([]byte(p.Arguments), &args) == nil && len(args.Command) > 0 {
+			return []ParsedContent{codexExecBegin(p.CallID, args.Command)}
+		}
+		return []ParsedContent{{Type: ContentToolUse, Text: p.Name, ToolUseID: p.CallID, ToolName: p.Name}}
+	case "function_call_output":
+		output, exitCode := codexFunctionOutput(p.Output)
+		return []ParsedContent{{Type: ContentToolResult, Text: codexExecResult(output, exitCode), ToolUseID: p.CallID, Output: output}}
+	}
+	return nil
+}
+
+// codexExecBegin 命令开始执行，按 claude 的 Bash 工具格式展示
+func codexExecBegin(callID string, command []string) ParsedContent {
+	cmd := strings.Join(command, " ")
+	if len(command) == 3 && codexShells[command[0]] && strings.HasPrefix(command[1], "-") {
+		cmd = command[2]
+	}
+	return ParsedContent{
+		Type:      ContentToolUse,
+		Text:      FormatToolUseSummary("Bash", map[string]interface{}{"command": cmd}),
+		ToolUseID: callID,
+		ToolName:  "Bash",
+	}
+}
+
+// codexExecResult 命令结果摘要：退出码与输出行数
+func codexExecResult(output string, exitCode int) string {
+	return fmt.Sprintf("  ⎿  Exit %d · Output %d lines", exitCode, countLines(strings.TrimRight(output, "\n")))
+}
+
+// codexPatchFiles 补丁涉及的文件列表，新增的文件前加 +，删除的加 -
+func codexPatchFiles(changes map[string]json.RawMessage) string {
+	var files []string
+	for path, raw := range changes {
+		var change map[string]json.RawMessage
+		json.Unmarshal(raw, &change)
+		switch {
+		case change["add"] != nil:
+			path = "+" + path
+		case change["delete"] != nil:
+			path = "-" + path
+		}
+		files = append(files, path)
+	}
+	sort.Strings(files)
+	return strings.Join(files, ", ")
+}
+
+// codexDuration 解析执行耗时：{"secs": N, "nanos": N} 或 "1.2s" 形式的字符串
+func codexDuration(raw json.RawMessage) time.Duration {
+	var d struct {
+		Secs  int64 `json:"secs"`
+		Nanos int64 `json:"nanos"`
+	}
+	if json.Unmarshal(raw, &d) == nil {
+		return time.Duration(d.Secs)*time.Second + time.Duration(d.Nanos)
+	}
+	var s string
+	if json.Unmarshal(raw, &s) == nil {
+		parsed, _ := time.ParseDuration(s)
+		return parsed
+	}
+	return 0
+}
+
+// codexFunctionOutput function_call_output 的 output 可能是纯文本，
+// 也可能是 {"output": ..., "metadata": {"exit_code": ...}} 的 JSON 字符串
+func codexFunctionOutput(raw json.RawMessage) (string, int) {
+	var s string
+	if json.Unmarshal(raw, &s) != nil {
+		return string(raw), 0
+	}
+	var wrapped struct {
+		Output   string `json:"output"`
+		Metadata struct {
+			ExitCode int `json:"exit_code"`
+		} `json:"metadata"`
+	}
+	if json.Unmarshal([]byte(s), &wrapped) == nil && wrapped.Output != "" {
+		return wrapped.Output, wrapped.Metadata.ExitCode
+	}
+	return s, 0
+}
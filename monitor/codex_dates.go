@@ -0,0 +1,69 @@
+package monitor
+
+import (
+	"log/slog"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// codexDateWindow 始终监听最近几天的日期目录（含今天）
+const codexDateWindow = 2
+
+// codexDateRoot 日期目录 .../sessions/YYYY/MM/DD 的根目录（sessions），不是日期目录时返回 false
+func codexDateRoot(dir string) (string, bool) {
+	day := filepath.Base(dir)
+	month := filepath.Base(filepath.Dir(dir))
+	year := filepath.Base(filepath.Dir(filepath.Dir(dir)))
+	if _, err := time.Parse("2006/01/02", year+"/"+month+"/"+day); err != nil {
+		return "", false
+	}
+	return filepath.Dir(filepath.Dir(filepath.Dir(dir))), true
+}
+
+// codexDateDir 某一天的日期目录
+func codexDateDir(root string, t time.Time) string {
+	return filepath.Join(root, t.Format("2006"), t.Format("01"), t.Format("02"))
+}
+
+// codexWantedDirs 应监听的日期目录：now 所在的最近 codexDateWindow 天，加上正在跟踪的文件所在目录
+func codexWantedDirs(root string, now time.Time, files []string) map[string]bool {
+	wanted := make(map[string]bool)
+	for i := 0; i < codexDateWindow; i++ {
+		wanted[codexDateDir(root, now.AddDate(0, 0, -i))] = true
+	}
+	for _, f := range files {
+		wanted[filepath.Dir(f)] = true
+	}
+	return wanted
+}
+
+// syncCodexDirs 按日期窗口调整 codex 的目录监听：添加已存在的新目录，移除窗口外且没有跟踪文件的旧日期目录。
+// logDir 不是日期目录（自定义 log_dir_pattern）时不处理
+func (m *JSONLMonitor) syncCodexDirs(watcher *fsnotify.Watcher, now time.Time) {
+	root, ok := codexDateRoot(m.logDir)
+	if !ok {
+		return
+	}
+	files := make([]string, 0, len(m.trackedFiles))
+	for f := range m.trackedFiles {
+		files = append(files, f)
+	}
+	wanted := codexWantedDirs(root, now, files)
+
+	for dir := range wanted {
+		if _, err := os.Stat(dir); err == nil {
+			m.addDirWatch(watcher, dir)
+		}
+	}
+	for dir := range m.watchedPaths {
+		if r, isDate := codexDateRoot(dir); !isDate || r != root || wanted[dir] {
+			continue
+		}
+		watcher.Remove(dir)
+		delete(m.watchedPaths, dir)
+		slog.Debug("unwatching old codex date dir", "key", m.topicKey, "dir", dir)
+	}
+}
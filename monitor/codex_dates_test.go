@@ -0,0 +1,78 @@
+package monitor
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/user/tgmux/backend"
+	"github.com/user/tgmux/state"
+)
+
+func watchedDirs(m *JSONLMonitor, root string) []string {
+	var dirs []string
+	for dir := range m.watchedPaths {
+		rel, _ := filepath.Rel(root, dir)
+		dirs = append(dirs, rel)
+	}
+	sort.Strings(dirs)
+	return dirs
+}
+
+// TestSyncCodexDirsRollover 时钟跨过午夜后开始监听新的日期目录，移除窗口外的旧目录，
+// 正在跟踪的会话文件所在目录保留
+func TestSyncCodexDirsRollover(t *testing.T) {
+	root := filepath.Join(t.TempDir(), "sessions")
+	day := func(d int) time.Time { return time.Date(2026, 3, d, 0, 0, 0, 0, time.Local) }
+	for d := 28; d <= 31; d++ {
+		if err := os.MkdirAll(codexDateDir(root, day(d)), 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	april1 := codexDateDir(root, time.Date(2026, 4, 1, 0, 0, 0, 0, time.Local))
+
+	// 会话文件在 3 月 29 日的目录中
+	session := filepath.Join(codexDateDir(root, day(29)), "rollout-2026-03-29T10-00-00-abc.jsonl")
+	if err := os.WriteFile(session, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	m := NewJSONLMonitor("k", backend.TypeCodex, filepath.Dir(session), 0, session, state.Usage{}, nil, nil)
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer watcher.Close()
+
+	m.syncCodexDirs(watcher, time.Date(2026, 3, 30, 23, 59, 0, 0, time.Local))
+	want := []string{"2026/03/29", "2026/03/30"}
+	if got := watchedDirs(m, root); !slices.Equal(got, want) {
+		t.Fatalf("before midnight: watching %v, want %v", got, want)
+	}
+
+	// 跨过午夜：新的一天目录已存在
+	m.syncCodexDirs(watcher, time.Date(2026, 3, 31, 0, 1, 0, 0, time.Local))
+	want = []string{"2026/03/29", "2026/03/30", "2026/03/31"}
+	if got := watchedDirs(m, root); !slices.Equal(got, want) {
+		t.Fatalf("after midnight: watching %v, want %v", got, want)
+	}
+
+	// 跨月，且新的一天目录尚未创建：之后的检查再补上
+	next := time.Date(2026, 4, 1, 0, 1, 0, 0, time.Local)
+	m.syncCodexDirs(watcher, next)
+	want = []string{"2026/03/29", "2026/03/31"}
+	if got := watchedDirs(m, root); !slices.Equal(got, want) {
+		t.Fatalf("new month without a dir: watching %v, want %v", got, want)
+	}
+	if err := os.MkdirAll(april1, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	m.syncCodexDirs(watcher, next.Add(time.Hour))
+	want = []string{"2026/03/29", "2026/03/31", "2026/04/01"}
+	if got := watchedDirs(m, root); !slices.Equal(got, want) {
+		t.Fatalf("new month: watching %v, want %v", got, want)
+	}
+}
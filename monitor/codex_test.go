@@ -0,0 +1,85 @@
+package monitor
+
+import (
+	"testing"
+
+	"github.com/user/tgmux/backend"
+	"github.com/user/tgmux/state"
+)
+
+// wantContent 期望推送的内容（只比较这几个字段）
+type wantContent struct {
+	typ    ContentType
+	text   string
+	id     string
+	output string
+}
+
+func checkContents(t *testing.T, got []ParsedContent, want []wantContent) {
+	t.Helper()
+	if len(got) != len(want) {
+		for _, c := range got {
+			t.Logf("got %d %q id=%q", c.Type, c.Text, c.ToolUseID)
+		}
+		t.Fatalf("got %d contents, want %d", len(got), len(want))
+	}
+	for i, w := range want {
+		c := got[i]
+		if c.Type != w.typ || c.Text != w.text || c.ToolUseID != w.id || c.Output != w.output {
+			t.Errorf("content %d = {%d %q %q %q}, want {%d %q %q %q}", i, c.Type, c.Text, c.ToolUseID, c.Output, w.typ, w.text, w.id, w.output)
+		}
+	}
+}
+
+func TestCodexFixtures(t *testing.T) {
+	tests := []struct {
+		fixture string
+		want    []wantContent
+		usage   state.Usage
+	}{
+		{
+			// 新版 rollout：event_msg 与 response_item 重复记录，只推送 event_msg
+			fixture: "codex_events.jsonl",
+			want: []wantContent{
+				{typ: ContentThinking, text: "Listing the directory"},
+				{typ: ContentToolUse, text: "Bash(ls -la)", id: "call_1"},
+				{typ: ContentToolResult, text: "  ⎿  Exit 0 · Output 2 lines · 1.5s", id: "call_1", output: "a.go\nb.go\n"},
+				{typ: ContentToolUse, text: "Patch(+new.go, old.go)", id: "call_2"},
+				{typ: ContentToolResult, text: "  ⎿  Failed: conflict in old.go", id: "call_2"},
+				{typ: ContentText, text: "Done."},
+			},
+			usage: state.Usage{InputTokens: 1200, CacheReadTokens: 800, OutputTokens: 90, Turns: 1, ToolCalls: 2},
+		},
+		{
+			// 只写 response_item 的版本
+			fixture: "codex_response_items.jsonl",
+			want: []wantContent{
+				{typ: ContentThinking, text: "First step\n\nSecond step"},
+				{typ: ContentToolUse, text: "Bash(echo hello)", id: "call_1"},
+				{typ: ContentToolResult, text: "  ⎿  Exit 0 · Output 1 lines", id: "call_1", output: "hello\n"},
+				{typ: ContentToolUse, text: "update_plan", id: "call_2"},
+				{typ: ContentToolResult, text: "  ⎿  Exit 0 · Output 1 lines", id: "call_2", output: "Plan updated"},
+				{typ: ContentText, text: "hello\nbye"},
+			},
+			usage: state.Usage{Turns: 1, ToolCalls: 2},
+		},
+		{
+			// 旧版格式：顶层 role/content/message
+			fixture: "codex_legacy.jsonl",
+			want: []wantContent{
+				{typ: ContentText, text: "Hello there"},
+				{typ: ContentText, text: "Finished"},
+			},
+			usage: state.Usage{Turns: 1, ToolCalls: 1},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.fixture, func(t *testing.T) {
+			tm := newTestMonitor(t, backend.TypeCodex, copyFixture(t, tt.fixture))
+			checkContents(t, tm.read(), tt.want)
+			if tm.usage != tt.usage {
+				t.Errorf("usage = %+v, want %+v", tm.usage, tt.usage)
+			}
+		})
+	}
+}
@@ -0,0 +1,48 @@
+package monitor
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/user/tgmux/backend"
+	"github.com/user/tgmux/i18n"
+)
+
+func TestClaudeCompactionFixture(t *testing.T) {
+	tm := newTestMonitor(t, backend.TypeClaude, copyFixture(t, "claude_compact.jsonl"))
+	failed := false
+	tm.onParseFail = func() { failed = true }
+
+	checkContents(t, tm.read(), []wantContent{
+		{typ: ContentText, text: "Before compaction"},
+		{typ: ContentSystem, text: i18n.T("monitor.compacted")},
+		{typ: ContentText, text: "After compaction"},
+	})
+	if tm.parseErrors != 0 {
+		t.Errorf("malformed line after the boundary counted: parseErrors = %d", tm.parseErrors)
+	}
+	if failed {
+		t.Errorf("parse failure reported during compaction")
+	}
+	if tm.usage.Turns != 1 {
+		t.Errorf("compact summary counted as a user turn: turns = %d", tm.usage.Turns)
+	}
+}
+
+// TestCompactGraceExpires 容错只覆盖边界之后的若干行，之后的坏行照常计数
+func TestCompactGraceExpires(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "session.jsonl")
+	lines := []string{`{"type":"system","subtype":"compact_boundary","content":"Conversation compacted"}`}
+	for i := 0; i < compactGraceLines; i++ {
+		lines = append(lines, `{"type":"user","message":{"role":"user","content":[{"type":"tool_result","tool_use_id":"x","content":"ok"}]}}`)
+	}
+	lines = append(lines, `{"broken`, `{"broken`)
+	appendFile(t, path, strings.Join(lines, "\n")+"\n")
+
+	tm := newTestMonitor(t, backend.TypeClaude, path)
+	tm.read()
+	if tm.parseErrors != 2 {
+		t.Errorf("parseErrors = %d, want 2 once the grace period is over", tm.parseErrors)
+	}
+}
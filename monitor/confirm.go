@@ -12,6 +12,7 @@ var ConfirmPatterns = []string{
 	"(Y/N)",
 	"(y/N)",
 	"(Y/n)",
+	"(Y)es/(N)o", // aider: "(Y)es/(N)o/(A)ll/(S)kip all/(D)on't ask again [Yes]:"
 	"Do you want to proceed",
 	"Are you sure",
 	"allow this",
@@ -29,6 +30,27 @@ func DetectConfirmPrompt(text string) bool {
 	return false
 }
 
+// confirmContextBefore / After ConfirmContext 保留的提示前后行数
+const (
+	confirmContextBefore = 4
+	confirmContextAfter  = 3
+)
+
+// ConfirmContext 返回最后一个确认提示前后的行，便于用户看到待批准的内容；找不到时为最后几行
+func ConfirmContext(text string) string {
+	lines := strings.Split(strings.TrimRight(text, "\n "), "\n")
+	at := len(lines) - 1
+	for i := len(lines) - 1; i >= 0; i-- {
+		if DetectConfirmPrompt(lines[i]) {
+			at = i
+			break
+		}
+	}
+	start := max(0, at-confirmContextBefore)
+	end := min(len(lines), at+confirmContextAfter+1)
+	return strings.Join(lines[start:end], "\n")
+}
+
 // DetectClaudeToolUse checks if the text indicates a Claude tool_use that needs confirmation
 func DetectClaudeToolUse(text string) bool {
 	return strings.Contains(text, "[tool:") && (strings.Contains(text, "Allow") || strings.Contains(text, "allow"))
@@ -36,15 +58,15 @@ func DetectClaudeToolUse(text string) bool {
 
 // InteractivePatterns are patterns that indicate an interactive UI (menus, selectors, etc.)
 var InteractivePatterns = []string{
-	"❯",           // Selector cursor
-	"●",           // Selected radio button
-	"○",           // Unselected radio button
-	"◉",           // Filled circle selector
-	"[ ]",         // Checkbox unchecked
-	"[x]",         // Checkbox checked
-	"[X]",         // Checkbox checked
-	"Use arrow",   // Arrow key instruction
-	"tab to cycle", // Tab instruction
+	"❯",             // Selector cursor
+	"●",             // Selected radio button
+	"○",             // Unselected radio button
+	"◉",             // Filled circle selector
+	"[ ]",           // Checkbox unchecked
+	"[x]",           // Checkbox checked
+	"[X]",           // Checkbox checked
+	"Use arrow",     // Arrow key instruction
+	"tab to cycle",  // Tab instruction
 	"Esc to cancel", // Escape instruction
 }
 
@@ -57,3 +79,24 @@ func DetectInteractiveUI(text string) bool {
 	}
 	return false
 }
+
+// BusyPatterns 后端仍在生成时状态行中出现的内容
+var BusyPatterns = []string{
+	"esc to interrupt",
+	"ctrl+c to interrupt",
+}
+
+// DetectBusy 检查终端内容的最后几行是否有"仍在生成"的标志
+func DetectBusy(text string) bool {
+	lines := strings.Split(strings.TrimRight(text, "\n "), "\n")
+	if len(lines) > 10 {
+		lines = lines[len(lines)-10:]
+	}
+	tail := strings.ToLower(strings.Join(lines, "\n"))
+	for _, pattern := range BusyPatterns {
+		if strings.Contains(tail, pattern) {
+			return true
+		}
+	}
+	return false
+}
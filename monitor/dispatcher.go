@@ -9,6 +9,7 @@ import (
 
 	"github.com/user/tgmux/backend"
 	"github.com/user/tgmux/config"
+	"github.com/user/tgmux/i18n"
 	"github.com/user/tgmux/state"
 	"github.com/user/tgmux/tmux"
 )
@@ -21,6 +22,12 @@ const (
 	ContentThinking                      // 思考过程
 	ContentToolUse                       // 工具调用
 	ContentToolResult                    // 工具结果
+	ContentTurnDone                      // 回合结束（仅在开启 monitor.turn_notify 时产生）
+	ContentPlan                          // claude 计划模式的计划（ExitPlanMode 工具调用，Text 为计划 markdown）
+	ContentSystem                        // 系统事件（API 错误、上下文压缩、会话摘要等），Level 为级别
+	ContentTodo                          // claude 的 TodoWrite 清单（Text 为已格式化的清单）
+	ContentImage                         // 工具结果中的图片（Image 为图片数据，Text 为文字说明）
+	ContentProgress                      // 终端进度条/旋转符的刷新（Text 为最新的进度行），推送时替换上一条进度
 )
 
 // OutputHandler 输出回调
@@ -36,14 +43,18 @@ type Monitor interface {
 type Dispatcher struct {
 	mu       sync.Mutex
 	monitors map[string]Monitor
+	specs    map[string]monitorSpec // topicKey → 启动参数，watchdog 据此重建；主动停止时删除
 	cfg      *config.Config
 	store    *state.Store
 	tmuxMgr  *tmux.Manager
+
+	parseFailed map[string]string // topicKey → 日志解析失败、已改用终端捕获的窗口 ID（按需创建）
 }
 
 func NewDispatcher(cfg *config.Config, store *state.Store, tmuxMgr *tmux.Manager) *Dispatcher {
 	return &Dispatcher{
 		monitors: make(map[string]Monitor),
+		specs:    make(map[string]monitorSpec),
 		cfg:      cfg,
 		store:    store,
 		tmuxMgr:  tmuxMgr,
@@ -61,53 +72,158 @@ func (d *Dispatcher) StartMonitor(ctx context.Context, topicKey string, binding
 		delete(d.monitors, topicKey)
 	}
 
+	mon := d.newMonitor(ctx, topicKey, binding, handler)
+	if err := mon.Start(ctx); err != nil {
+		if _, isPane := mon.(*PaneMonitor); !isPane {
+			slog.Warn("log monitor failed, falling back to capture-pane", "key", topicKey, "error", err)
+			mon = d.newPaneMonitor(topicKey, binding.WindowID, handler)
+			if err2 := mon.Start(ctx); err2 != nil {
+				return fmt.Errorf("fallback pane monitor: %w", err2)
+			}
+		} else {
+			return fmt.Errorf("pane monitor: %w", err)
+		}
+	}
+
+	d.monitors[topicKey] = mon
+	d.specs[topicKey] = monitorSpec{ctx: ctx, binding: binding, handler: handler}
+	slog.Info("monitor started", "key", topicKey, "backend", binding.Backend)
+	return nil
+}
+
+// newPaneMonitor 创建 capture-pane 监控器并套用进度噪声过滤设置
+// newMonitor 按后端选择监控器（内置后端查 monitorFactories，自定义后端按 log_format），无可用日志时为终端捕获
+func (d *Dispatcher) newMonitor(ctx context.Context, topicKey string, binding state.Binding, handler OutputHandler) Monitor {
 	var mon Monitor
 	bt := backend.Type(binding.Backend)
 	be := backend.Get(bt, d.cfg)
 
-	switch bt {
-	case backend.TypeClaude, backend.TypeCodex:
-		if be.LogDirFunc != nil {
-			logDir := be.LogDirFunc(binding.ProjectPath)
-			offset, _ := d.store.GetOffset(topicKey)
-			mon = NewJSONLMonitor(topicKey, bt, logDir, offset.ByteOffset, offset.File, handler, d.store)
-		}
-	case backend.TypeGemini:
-		if be.LogDirFunc != nil {
-			logDir := be.LogDirFunc(binding.ProjectPath)
-			offset, _ := d.store.GetOffset(topicKey)
-			mon = NewJSONDiffMonitor(topicKey, logDir, offset.MessageCount, time.Now(), handler, d.store)
-		}
-	case backend.TypeBash:
-		mon = NewPaneMonitor(topicKey, binding.WindowID, d.tmuxMgr, d.cfg.Monitor.PollInterval, handler)
+	if factory, ok := monitorFactories[bt]; ok {
+		mon = factory(d, ctx, topicKey, be, binding, handler)
+	} else if be.Custom {
+		mon = d.customMonitor(ctx, topicKey, be, binding, handler)
 	}
 
 	if mon == nil {
 		slog.Warn("falling back to capture-pane", "key", topicKey, "backend", binding.Backend)
-		mon = NewPaneMonitor(topicKey, binding.WindowID, d.tmuxMgr, d.cfg.Monitor.PollInterval, handler)
+		mon = d.newPaneMonitor(topicKey, binding.WindowID, handler)
 	}
+	return mon
+}
 
-	if err := mon.Start(ctx); err != nil {
-		if bt != backend.TypeBash {
-			slog.Warn("log monitor failed, falling back to capture-pane", "key", topicKey, "error", err)
-			mon = NewPaneMonitor(topicKey, binding.WindowID, d.tmuxMgr, d.cfg.Monitor.PollInterval, handler)
-			if err2 := mon.Start(ctx); err2 != nil {
-				return fmt.Errorf("fallback pane monitor: %w", err2)
-			}
-		} else {
-			return fmt.Errorf("pane monitor: %w", err)
+func (d *Dispatcher) newPaneMonitor(topicKey, windowID string, handler OutputHandler) *PaneMonitor {
+	pm := NewPaneMonitor(topicKey, windowID, d.tmuxMgr, d.cfg.Monitor.PollInterval, handler)
+	pm.noise = d.newNoiseFilter()
+	pm.scrollback = max(d.cfg.Monitor.ScrollbackLines, 0)
+	return pm
+}
+
+func (d *Dispatcher) newNoiseFilter() *noiseFilter {
+	return newNoiseFilter(d.cfg.Monitor.ProgressPatterns, d.cfg.Monitor.ProgressInterval)
+}
+
+// fallbackToPane JSONL 监控连续解析失败时停止它，为同一绑定改用终端捕获并通知 Topic
+func (d *Dispatcher) fallbackToPane(ctx context.Context, topicKey string, binding state.Binding, handler OutputHandler, failed Monitor) {
+	d.mu.Lock()
+	if d.monitors[topicKey] == failed {
+		if d.parseFailed == nil {
+			d.parseFailed = make(map[string]string)
 		}
+		d.parseFailed[topicKey] = binding.WindowID
+	}
+	swapped := d.swapToPane(ctx, topicKey, binding, handler, failed)
+	d.mu.Unlock()
+	if !swapped {
+		return
+	}
+
+	slog.Warn("JSONL parse failures, switched to capture-pane", "key", topicKey, "window", binding.WindowID)
+	handler(topicKey, ParsedContent{Type: ContentSystem, Level: LevelError, Text: i18n.T("monitor.parse_fallback")})
+}
+
+// aiderFallback aider 对话记录未出现（如 --no-chat-history-file 或位置无法推断）时改用终端捕获
+func (d *Dispatcher) aiderFallback(ctx context.Context, topicKey string, binding state.Binding, handler OutputHandler, failed Monitor) {
+	d.mu.Lock()
+	swapped := d.swapToPane(ctx, topicKey, binding, handler, failed)
+	d.mu.Unlock()
+	if swapped {
+		slog.Warn("aider chat history missing, switched to capture-pane", "key", topicKey, "window", binding.WindowID)
 	}
+}
 
+// swapToPane 将 failed 替换为终端捕获监控，调用方持有 d.mu；failed 已被停止或替换时返回 false
+func (d *Dispatcher) swapToPane(ctx context.Context, topicKey string, binding state.Binding, handler OutputHandler, failed Monitor) bool {
+	if d.monitors[topicKey] != failed {
+		return false
+	}
+	failed.Stop()
+	mon := d.newPaneMonitor(topicKey, binding.WindowID, handler)
+	if err := mon.Start(ctx); err != nil {
+		delete(d.monitors, topicKey)
+		slog.Error("fallback pane monitor failed", "key", topicKey, "error", err)
+		return false
+	}
 	d.monitors[topicKey] = mon
-	slog.Info("monitor started", "key", topicKey, "backend", binding.Backend)
+	return true
+}
+
+// NotifyInput 用户输入已转发到窗口：轮询类监控器立即恢复正常轮询频率
+func (d *Dispatcher) NotifyInput(windowID string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	for key, mon := range d.monitors {
+		if pm, ok := mon.(*PaneMonitor); ok && d.specs[key].binding.WindowID == windowID {
+			pm.Poke()
+		}
+	}
+}
+
+// Headless 返回窗口 ID 对应的 claude-headless 监控器，其他后端返回 nil
+func (d *Dispatcher) Headless(windowID string) *HeadlessMonitor {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	for key, mon := range d.monitors {
+		if hm, ok := mon.(*HeadlessMonitor); ok && d.specs[key].binding.WindowID == windowID {
+			return hm
+		}
+	}
 	return nil
 }
 
+// PollInterval 返回 topic 的 capture-pane 监控当前实际轮询间隔，非轮询监控返回 false
+func (d *Dispatcher) PollInterval(topicKey string) (time.Duration, bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if pm, ok := d.monitors[topicKey].(*PaneMonitor); ok {
+		return pm.Interval(), true
+	}
+	return 0, false
+}
+
+// CapturesPane topic 的输出是否来自终端捕获（capture-pane 或 pipe-pane），此时无需另外轮询状态行
+func (d *Dispatcher) CapturesPane(topicKey string) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	switch d.monitors[topicKey].(type) {
+	case *PaneMonitor, *PipeMonitor:
+		return true
+	}
+	return false
+}
+
+// HasMonitor 检查 topic 是否有运行中的监控器
+func (d *Dispatcher) HasMonitor(topicKey string) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	_, ok := d.monitors[topicKey]
+	return ok
+}
+
 // StopMonitor 停止指定监控器
 func (d *Dispatcher) StopMonitor(topicKey string) {
 	d.mu.Lock()
 	defer d.mu.Unlock()
+	delete(d.specs, topicKey)
 	if mon, ok := d.monitors[topicKey]; ok {
 		mon.Stop()
 		delete(d.monitors, topicKey)
@@ -124,4 +240,54 @@ func (d *Dispatcher) StopAll() {
 		slog.Info("monitor stopped", "key", key)
 	}
 	d.monitors = make(map[string]Monitor)
+	d.specs = make(map[string]monitorSpec)
+}
+
+// LogFile 返回 topic 当前监控的主日志文件路径，未知时回退到持久化的 offset
+func (d *Dispatcher) LogFile(topicKey string) string {
+	d.mu.Lock()
+	mon := d.monitors[topicKey]
+	d.mu.Unlock()
+	switch m := mon.(type) {
+	case *JSONLMonitor:
+		if f := m.MainFile(); f != "" {
+			return f
+		}
+	case *JSONDiffMonitor:
+		if f := m.LogsPath(); f != "" {
+			return f
+		}
+	}
+	offset, _ := d.store.GetOffset(topicKey)
+	return offset.File
+}
+
+// claimedByOther 文件是否为其他 topic 的 JSONL 监控正在跟踪的主文件
+func (d *Dispatcher) claimedByOther(topicKey, path string) bool {
+	d.mu.Lock()
+	var others []*JSONLMonitor
+	for key, mon := range d.monitors {
+		if jm, ok := mon.(*JSONLMonitor); ok && key != topicKey {
+			others = append(others, jm)
+		}
+	}
+	d.mu.Unlock()
+	for _, jm := range others {
+		if jm.MainFile() == path {
+			return true
+		}
+	}
+	return false
+}
+
+// Usage 返回 topic 的累计用量统计，监控未运行时从持久化的 offset 读取
+func (d *Dispatcher) Usage(topicKey string) state.Usage {
+	d.mu.Lock()
+	mon := d.monitors[topicKey]
+	d.mu.Unlock()
+	if jm, ok := mon.(*JSONLMonitor); ok {
+		return jm.Usage()
+	}
+	offset, _ := d.store.GetOffset(topicKey)
+	return offset.Usage
 }
@@ -0,0 +1,120 @@
+package monitor
+
+import (
+	"context"
+	"path/filepath"
+	"time"
+
+	"github.com/user/tgmux/backend"
+	"github.com/user/tgmux/config"
+	"github.com/user/tgmux/state"
+)
+
+// monitorFactory 为一种后端创建监控器，返回 nil 时改用终端捕获
+type monitorFactory func(d *Dispatcher, ctx context.Context, topicKey string, be backend.Backend, binding state.Binding, handler OutputHandler) Monitor
+
+// monitorFactories 内置后端的监控方式；自定义后端按声明的 log_format 选择（customMonitor）
+var monitorFactories = map[backend.Type]monitorFactory{
+	backend.TypeClaude:         (*Dispatcher).jsonlMonitor,
+	backend.TypeCodex:          (*Dispatcher).jsonlMonitor,
+	backend.TypeGemini:         (*Dispatcher).geminiMonitor,
+	backend.TypeOpencode:       (*Dispatcher).opencodeMonitor,
+	backend.TypeCursor:         (*Dispatcher).captureMonitor,
+	backend.TypeAider:          (*Dispatcher).aiderMonitor,
+	backend.TypeClaudeHeadless: (*Dispatcher).headlessMonitor,
+	backend.TypeBash:           (*Dispatcher).bashMonitor,
+}
+
+// jsonlMonitor claude / codex：跟踪会话 JSONL
+func (d *Dispatcher) jsonlMonitor(ctx context.Context, topicKey string, be backend.Backend, binding state.Binding, handler OutputHandler) Monitor {
+	// 日志解析失败过的窗口直接使用终端捕获，重新绑定（新窗口）前不再尝试 JSONL
+	if d.parseFailed[topicKey] == binding.WindowID || be.LogDirFunc == nil {
+		return nil
+	}
+	bt := be.Type
+	logDir := be.LogDirFunc(binding.ProjectPath)
+	offset, _ := d.store.GetOffset(topicKey)
+	// codex 的日志目录按日期划分：恢复时以会话文件所在的日期目录为准，而不是今天
+	if bt == backend.TypeCodex && offset.File != "" {
+		if _, ok := codexDateRoot(filepath.Dir(offset.File)); ok {
+			logDir = filepath.Dir(offset.File)
+		}
+	}
+	jm := NewJSONLMonitor(topicKey, bt, logDir, offset.ByteOffset, offset.File, offset.Usage, handler, d.store)
+	if bt == backend.TypeClaude && d.cfg.Monitor.TurnNotify.Enabled {
+		jm.turn = newTurnTracker(d.cfg.Monitor.TurnNotify)
+	}
+	jm.truncSkip = d.cfg.Monitor.OnTruncate == "skip"
+	jm.pollInterval = d.cfg.Monitor.PollInterval
+	jm.watchFallback = d.cfg.Monitor.WatchFallbackAfter
+	jm.projectPath = binding.ProjectPath
+	jm.relockAfter = d.cfg.Monitor.RelockAfter
+	windowID := binding.WindowID
+	jm.backendAlive = func() bool { return be.Alive(d.tmuxMgr.PaneCommand(windowID)) }
+	jm.claimed = func(path string) bool { return d.claimedByOther(topicKey, path) }
+	jm.onParseFail = func() { d.fallbackToPane(ctx, topicKey, binding, handler, jm) }
+	return jm
+}
+
+// geminiMonitor gemini：对比 logs.json / 会话文件的全量内容
+func (d *Dispatcher) geminiMonitor(ctx context.Context, topicKey string, be backend.Backend, binding state.Binding, handler OutputHandler) Monitor {
+	if be.LogDirFunc == nil {
+		return nil
+	}
+	offset, _ := d.store.GetOffset(topicKey)
+	return NewJSONDiffMonitor(topicKey, be.LogDirFunc(binding.ProjectPath), offset, time.Now(), handler, d.store)
+}
+
+// opencodeMonitor opencode：轮询会话存储目录
+func (d *Dispatcher) opencodeMonitor(ctx context.Context, topicKey string, be backend.Backend, binding state.Binding, handler OutputHandler) Monitor {
+	if be.LogDirFunc == nil {
+		return nil
+	}
+	offset, _ := d.store.GetOffset(topicKey)
+	return NewOpencodeMonitor(topicKey, be.LogDirFunc(binding.ProjectPath), binding.ProjectPath, offset, time.Now(), d.cfg.Monitor.PollInterval, handler, d.store)
+}
+
+// captureMonitor 没有可跟踪日志的后端（如 cursor-agent 的会话存储在 SQLite 中）：捕获终端
+func (d *Dispatcher) captureMonitor(ctx context.Context, topicKey string, be backend.Backend, binding state.Binding, handler OutputHandler) Monitor {
+	return d.newPaneMonitor(topicKey, binding.WindowID, handler)
+}
+
+// aiderMonitor aider：跟踪 markdown 对话记录，记录文件未出现时改用终端捕获
+func (d *Dispatcher) aiderMonitor(ctx context.Context, topicKey string, be backend.Backend, binding state.Binding, handler OutputHandler) Monitor {
+	am := NewAiderMonitor(topicKey, aiderHistoryPath(binding.ProjectPath, be.Args), d.cfg.Monitor.PollInterval, handler, d.store)
+	am.onMissing = func() { d.aiderFallback(ctx, topicKey, binding, handler, am) }
+	return am
+}
+
+// headlessMonitor claude-headless：每条消息运行一次 claude -p
+func (d *Dispatcher) headlessMonitor(ctx context.Context, topicKey string, be backend.Backend, binding state.Binding, handler OutputHandler) Monitor {
+	return NewHeadlessMonitor(topicKey, be, binding, handler, d.store)
+}
+
+// bashMonitor bash：按 backends.bash.monitor 选择 pipe-pane 或 capture-pane
+func (d *Dispatcher) bashMonitor(ctx context.Context, topicKey string, be backend.Backend, binding state.Binding, handler OutputHandler) Monitor {
+	if d.cfg.Backends.Bash.Monitor == "pipe" {
+		pm := NewPipeMonitor(topicKey, binding.WindowID, d.tmuxMgr, d.cfg.Monitor.PollInterval, handler)
+		pm.noise = d.newNoiseFilter()
+		return pm
+	}
+	return d.newPaneMonitor(topicKey, binding.WindowID, handler)
+}
+
+// customMonitor 按自定义后端声明的 log_format 创建监控器，none 为终端捕获
+func (d *Dispatcher) customMonitor(ctx context.Context, topicKey string, be backend.Backend, binding state.Binding, handler OutputHandler) Monitor {
+	offset, _ := d.store.GetOffset(topicKey)
+	switch be.LogFormat {
+	case config.LogFormatClaudeJSONL:
+		jm := NewJSONLMonitor(topicKey, backend.TypeClaude, be.LogDirFunc(binding.ProjectPath), offset.ByteOffset, offset.File, offset.Usage, handler, d.store)
+		jm.truncSkip = d.cfg.Monitor.OnTruncate == "skip"
+		jm.pollInterval = d.cfg.Monitor.PollInterval
+		jm.watchFallback = d.cfg.Monitor.WatchFallbackAfter
+		return jm
+	case config.LogFormatPlainAppend:
+		pm := NewPlainLogMonitor(topicKey, be.LogDirFunc(binding.ProjectPath), offset, d.cfg.Monitor.PollInterval, handler, d.store)
+		pm.noise = d.newNoiseFilter()
+		return pm
+	}
+	return d.newPaneMonitor(topicKey, binding.WindowID, handler)
+}
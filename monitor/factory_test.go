@@ -0,0 +1,71 @@
+package monitor
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"github.com/user/tgmux/backend"
+	"github.com/user/tgmux/config"
+	"github.com/user/tgmux/state"
+)
+
+func TestEveryBackendHasMonitorFactory(t *testing.T) {
+	for _, bt := range backend.AllTypes() {
+		if _, ok := monitorFactories[bt]; !ok {
+			t.Errorf("%s: no entry in monitorFactories", bt)
+		}
+	}
+}
+
+// TestNewMonitor 每种后端（内置与自定义）经 newMonitor 得到的监控器类型，只创建不启动
+func TestNewMonitor(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.Backends.Custom = []config.CustomBackendConfig{
+		{Name: "jsonl-tool", BackendConfig: config.BackendConfig{LogDirPattern: t.TempDir()}, LogFormat: config.LogFormatClaudeJSONL},
+		{Name: "plain-tool", BackendConfig: config.BackendConfig{LogDirPattern: t.TempDir()}, LogFormat: config.LogFormatPlainAppend},
+		{Name: "pane-tool"},
+	}
+	store := state.New(filepath.Join(t.TempDir(), "state.json"), 10)
+	t.Cleanup(store.Close)
+	d := NewDispatcher(cfg, store, nil)
+
+	tests := []struct {
+		backend string
+		want    string
+	}{
+		{"claude", "*monitor.JSONLMonitor"},
+		{"codex", "*monitor.JSONLMonitor"},
+		{"gemini", "*monitor.JSONDiffMonitor"},
+		{"opencode", "*monitor.OpencodeMonitor"},
+		{"cursor", "*monitor.PaneMonitor"},
+		{"aider", "*monitor.AiderMonitor"},
+		{"claude-headless", "*monitor.HeadlessMonitor"},
+		{"bash", "*monitor.PaneMonitor"},
+		{"jsonl-tool", "*monitor.JSONLMonitor"},
+		{"plain-tool", "*monitor.PlainLogMonitor"},
+		{"pane-tool", "*monitor.PaneMonitor"},
+		{"unknown", "*monitor.PaneMonitor"},
+	}
+	for _, tt := range tests {
+		binding := state.Binding{Backend: tt.backend, WindowID: "@1", ProjectPath: t.TempDir()}
+		mon := d.newMonitor(context.Background(), "1:"+tt.backend, binding, nil)
+		if got := fmt.Sprintf("%T", mon); got != tt.want {
+			t.Errorf("%s: monitor = %s, want %s", tt.backend, got, tt.want)
+		}
+	}
+
+	cfg.Backends.Bash.Monitor = "pipe"
+	mon := d.newMonitor(context.Background(), "1:bash", state.Binding{Backend: "bash", WindowID: "@1"}, nil)
+	if _, ok := mon.(*PipeMonitor); !ok {
+		t.Errorf("bash with monitor: pipe = %T, want *PipeMonitor", mon)
+	}
+
+	// 日志解析失败过的窗口不再尝试 JSONL
+	d.parseFailed = map[string]string{"1:claude": "@1"}
+	mon = d.newMonitor(context.Background(), "1:claude", state.Binding{Backend: "claude", WindowID: "@1"}, nil)
+	if _, ok := mon.(*PaneMonitor); !ok {
+		t.Errorf("claude after parse failure = %T, want *PaneMonitor", mon)
+	}
+}
@@ -0,0 +1,181 @@
+package monitor
+
+import (
+	"encoding/json"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/user/tgmux/state"
+)
+
+// geminiChatsDir 新版 gemini-cli 在 hash 目录下保存会话与 checkpoint 的子目录
+const geminiChatsDir = "chats"
+
+// geminiChatMessage chats/*.json 中的一条消息。
+// 会话文件为 {"messages": [{type, content, thoughts, toolCalls}]}，
+// checkpoint 文件为 [{role, parts}]，两种字段都解析
+type geminiChatMessage struct {
+	Type      string          `json:"type"`
+	Role      string          `json:"role"`
+	Timestamp string          `json:"timestamp"`
+	Content   json.RawMessage `json:"content"`
+	Parts     []geminiPart    `json:"parts"`
+	Thoughts  []struct {
+		Subject     string `json:"subject"`
+		Description string `json:"description"`
+	} `json:"thoughts"`
+	ToolCalls []struct {
+		ID   string                 `json:"id"`
+		Name string                 `json:"name"`
+		Args map[string]interface{} `json:"args"`
+	} `json:"toolCalls"`
+}
+
+// geminiPart 消息的一个 part
+type geminiPart struct {
+	Text         string `json:"text"`
+	Thought      bool   `json:"thought"`
+	FunctionCall *struct {
+		ID   string                 `json:"id"`
+		Name string                 `json:"name"`
+		Args map[string]interface{} `json:"args"`
+	} `json:"functionCall"`
+}
+
+// isGeminiChatFile 是否为 hash 目录下 chats 中的会话文件
+func isGeminiChatFile(path string) bool {
+	return filepath.Base(filepath.Dir(path)) == geminiChatsDir && strings.HasSuffix(path, ".json")
+}
+
+// readGeminiChat 读取会话文件的消息列表
+func readGeminiChat(path string) ([]geminiChatMessage, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var chat struct {
+		Messages []geminiChatMessage `json:"messages"`
+	}
+	if err := json.Unmarshal(data, &chat); err == nil {
+		return chat.Messages, nil
+	}
+	var messages []geminiChatMessage
+	if err := json.Unmarshal(data, &messages); err != nil {
+		return nil, err
+	}
+	return messages, nil
+}
+
+// readChat 会话文件变化时按消息下标增量推送。
+// 多个会话文件时跟随正在写入的那个：切换到另一个文件时，跳过其中监控启动前的历史消息
+func (m *JSONDiffMonitor) readChat(path string) {
+	if path != m.chatFile && m.chatFile != "" {
+		cur, err1 := os.Stat(m.chatFile)
+		next, err2 := os.Stat(path)
+		if err2 != nil || (err1 == nil && next.ModTime().Before(cur.ModTime())) {
+			return
+		}
+	}
+
+	messages, err := readGeminiChat(path)
+	if err != nil {
+		slog.Debug("gemini chat parse failed, skipping", "key", m.topicKey, "file", filepath.Base(path), "error", err)
+		return
+	}
+	if path != m.chatFile {
+		slog.Info("following gemini chat file", "key", m.topicKey, "file", filepath.Base(path))
+		m.chatFile = path
+		m.chatIndex = m.historyCount(messages)
+	}
+	if len(messages) <= m.chatIndex {
+		return
+	}
+
+	var outputs []ParsedContent
+	for _, msg := range messages[m.chatIndex:] {
+		outputs = append(outputs, parseGeminiChatMessage(msg)...)
+	}
+	m.chatIndex = len(messages)
+	m.store.SetOffset(m.topicKey, state.Offset{File: m.chatFile, MessageCount: m.chatIndex})
+	for _, c := range outputs {
+		m.handler(m.topicKey, c)
+	}
+}
+
+// historyCount 文件开头早于监控启动时间的消息数（无时间戳的消息视为新消息）
+func (m *JSONDiffMonitor) historyCount(messages []geminiChatMessage) int {
+	n := 0
+	for _, msg := range messages {
+		ts, err := time.Parse(time.RFC3339, msg.Timestamp)
+		if err != nil || !ts.Before(m.startTime) {
+			break
+		}
+		n++
+	}
+	return n
+}
+
+// parseGeminiChatMessage 将一条会话消息转换为推送内容：思考、工具调用与回答，用户消息不推送
+func parseGeminiChatMessage(msg geminiChatMessage) []ParsedContent {
+	kind := msg.Type
+	if kind == "" {
+		kind = msg.Role
+	}
+	if kind == "user" {
+		return nil
+	}
+
+	var out []ParsedContent
+	for _, t := range msg.Thoughts {
+		text := strings.TrimSpace(t.Subject + "\n" + t.Description)
+		if text != "" {
+			out = append(out, ParsedContent{Type: ContentThinking, Text: text})
+		}
+	}
+	for _, p := range msg.Parts {
+		switch {
+		case p.FunctionCall != nil:
+			out = append(out, ParsedContent{
+				Type:      ContentToolUse,
+				Text:      FormatToolUseSummary(p.FunctionCall.Name, p.FunctionCall.Args),
+				ToolUseID: p.FunctionCall.ID,
+				ToolName:  p.FunctionCall.Name,
+			})
+		case p.Thought && p.Text != "":
+			out = append(out, ParsedContent{Type: ContentThinking, Text: p.Text})
+		case p.Text != "":
+			out = append(out, ParsedContent{Type: geminiContentType(kind), Text: p.Text})
+		}
+	}
+	var text string
+	if json.Unmarshal(msg.Content, &text) == nil && strings.TrimSpace(text) != "" {
+		c := ParsedContent{Type: geminiContentType(kind), Text: text}
+		if c.Type == ContentSystem {
+			c.Level = LevelError
+			if kind == "info" {
+				c.Level = LevelInfo
+			}
+		}
+		out = append(out, c)
+	}
+	for _, call := range msg.ToolCalls {
+		out = append(out, ParsedContent{
+			Type:      ContentToolUse,
+			Text:      FormatToolUseSummary(call.Name, call.Args),
+			ToolUseID: call.ID,
+			ToolName:  call.Name,
+		})
+	}
+	return out
+}
+
+// geminiContentType 会话消息类型对应的内容类型：error / info 为系统事件，其余为回答
+func geminiContentType(kind string) ContentType {
+	if kind == "error" || kind == "info" {
+		return ContentSystem
+	}
+	return ContentText
+}
@@ -0,0 +1,212 @@
+package monitor
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+
+	"github.com/user/tgmux/backend"
+	"github.com/user/tgmux/i18n"
+	"github.com/user/tgmux/state"
+)
+
+// headlessStderrBytes 进程失败时随错误推送的 stderr 末尾长度
+const headlessStderrBytes = 1024
+
+// HeadlessMonitor 无终端的 claude 会话：每条用户消息在项目目录运行一次
+// claude -p --output-format stream-json，解析输出并推送；首次运行得到的 session_id
+// 记录到绑定，之后用 --resume 延续同一会话。同一时间只运行一个进程，后来的消息排队等待
+type HeadlessMonitor struct {
+	topicKey  string
+	command   string
+	args      []string
+	env       map[string]string // 后端配置的环境变量，空值表示 unset
+	dir       string
+	handler   OutputHandler
+	store     *state.Store
+	parser    *JSONLMonitor // stream-json 的 assistant / user / result 与会话日志记录格式相同，复用其解析
+	ctx       context.Context
+	cancel    context.CancelFunc
+	runMu     sync.Mutex // 持有期间有进程在运行
+	mu        sync.Mutex
+	sessionID string
+	proc      *os.Process
+	stopped   bool // 进程由 Interrupt 中断，退出码不作为错误报告
+}
+
+func NewHeadlessMonitor(topicKey string, be backend.Backend, binding state.Binding, handler OutputHandler, store *state.Store) *HeadlessMonitor {
+	offset, _ := store.GetOffset(topicKey)
+	return &HeadlessMonitor{
+		topicKey:  topicKey,
+		command:   be.Command,
+		args:      be.Args,
+		env:       be.Env,
+		dir:       binding.ProjectPath,
+		handler:   handler,
+		store:     store,
+		parser:    NewJSONLMonitor(topicKey, backend.TypeClaude, "", 0, "", offset.Usage, handler, store),
+		sessionID: binding.SessionID,
+	}
+}
+
+func (h *HeadlessMonitor) Start(ctx context.Context) error {
+	h.ctx, h.cancel = context.WithCancel(ctx)
+	return nil
+}
+
+// Stop 终止正在运行的进程
+func (h *HeadlessMonitor) Stop() {
+	if h.cancel != nil {
+		h.cancel()
+	}
+}
+
+// Send 为一条消息启动 claude 进程：等待上一个进程结束后启动，启动成功即返回，输出在后台推送
+func (h *HeadlessMonitor) Send(prompt string) error {
+	if h.ctx == nil {
+		return errors.New("headless monitor not started")
+	}
+	h.runMu.Lock()
+
+	h.mu.Lock()
+	args := append(append([]string{}, h.args...), "-p", prompt, "--output-format", "stream-json", "--verbose", "--include-partial-messages")
+	if h.sessionID != "" {
+		args = append(args, "--resume", h.sessionID)
+	}
+	h.mu.Unlock()
+
+	cmd := exec.CommandContext(h.ctx, h.command, args...)
+	cmd.Dir = h.dir
+	for _, kv := range os.Environ() {
+		name, _, _ := strings.Cut(kv, "=")
+		if value, ok := h.env[name]; ok && value == "" {
+			continue // 配置为 unset
+		}
+		cmd.Env = append(cmd.Env, kv)
+	}
+	for name, value := range h.env {
+		if value != "" {
+			cmd.Env = append(cmd.Env, name+"="+value)
+		}
+	}
+	for name, value := range h.store.GetEnv(h.topicKey) {
+		cmd.Env = append(cmd.Env, name+"="+value)
+	}
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		h.runMu.Unlock()
+		return err
+	}
+	if err := cmd.Start(); err != nil {
+		h.runMu.Unlock()
+		return fmt.Errorf("start %s: %w", h.command, err)
+	}
+	h.mu.Lock()
+	h.proc, h.stopped = cmd.Process, false
+	h.mu.Unlock()
+
+	go func() {
+		defer h.runMu.Unlock()
+		h.readStream(stdout)
+		err := cmd.Wait()
+		h.mu.Lock()
+		h.proc = nil
+		stopped := h.stopped
+		h.mu.Unlock()
+		h.store.SetOffset(h.topicKey, state.Offset{Usage: h.parser.Usage()})
+		if err != nil && !stopped && h.ctx.Err() == nil {
+			slog.Warn("headless claude exited with error", "key", h.topicKey, "error", err)
+			detail := strings.TrimSpace(stderr.String())
+			if len(detail) > headlessStderrBytes {
+				detail = "…" + detail[len(detail)-headlessStderrBytes:]
+			}
+			if detail == "" {
+				detail = err.Error()
+			}
+			h.handler(h.topicKey, ParsedContent{Type: ContentSystem, Level: LevelError, Text: i18n.T("monitor.headless_failed", detail)})
+		}
+	}()
+	return nil
+}
+
+// readStream 逐行解析 stream-json 输出直到进程关闭 stdout
+func (h *HeadlessMonitor) readStream(stdout io.Reader) {
+	reader := bufio.NewReaderSize(stdout, 64*1024)
+	for {
+		line, _, _, err := readLine(reader)
+		if err != nil {
+			return
+		}
+		if len(line) == 0 {
+			continue
+		}
+		var head struct {
+			Type      string `json:"type"`
+			Subtype   string `json:"subtype"`
+			SessionID string `json:"session_id"`
+		}
+		if json.Unmarshal(line, &head) != nil {
+			continue
+		}
+		if head.SessionID != "" {
+			h.setSession(head.SessionID)
+		}
+		switch head.Type {
+		case "stream_event":
+			// 增量片段：完整的消息随后以 assistant 记录给出
+			continue
+		case "system":
+			if head.Subtype == "init" {
+				continue
+			}
+		}
+		contents := h.parser.parseLine(string(line))
+		if len(line) > largeLineBytes {
+			truncateContents(contents)
+		}
+		for _, c := range contents {
+			h.handler(h.topicKey, c)
+		}
+	}
+}
+
+// setSession 记录会话 ID，之后的消息以 --resume 延续
+func (h *HeadlessMonitor) setSession(id string) {
+	h.mu.Lock()
+	changed := h.sessionID != id
+	h.sessionID = id
+	h.mu.Unlock()
+	if changed {
+		h.store.SetBindingSession(h.topicKey, id)
+	}
+}
+
+// Interrupt 中断正在运行的进程，没有运行中的进程时返回 false
+func (h *HeadlessMonitor) Interrupt() bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.proc == nil {
+		return false
+	}
+	h.stopped = true
+	h.proc.Signal(os.Interrupt)
+	return true
+}
+
+// Busy 是否有进程正在运行
+func (h *HeadlessMonitor) Busy() bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.proc != nil
+}
@@ -0,0 +1,120 @@
+package monitor
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/user/tgmux/backend"
+)
+
+// newLineParser 创建一个仅用于解析的 JSONLMonitor（不监听、不持久化）
+func newLineParser(bt backend.Type) *JSONLMonitor {
+	return &JSONLMonitor{
+		topicKey:     "history",
+		backendType:  bt,
+		pendingTools: make(map[string]string),
+	}
+}
+
+// ReadRecent 从头解析 JSONL 文件，返回最后 n 个助手输出块（文本与工具调用）
+func ReadRecent(path string, bt backend.Type, n int) ([]ParsedContent, error) {
+	if n <= 0 {
+		return nil, nil
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("open log: %w", err)
+	}
+	defer f.Close()
+
+	parser := newLineParser(bt)
+	var recent []ParsedContent
+	err = forEachLine(f, func(line []byte) bool {
+		contents := parser.parseLine(string(line))
+		if len(line) > largeLineBytes {
+			truncateContents(contents)
+		}
+		for _, c := range contents {
+			if c.Type != ContentText && c.Type != ContentToolUse {
+				continue
+			}
+			recent = append(recent, c)
+			if len(recent) > n {
+				recent = recent[1:]
+			}
+		}
+		return true
+	})
+	if err != nil {
+		return recent, fmt.Errorf("scan log: %w", err)
+	}
+	return recent, nil
+}
+
+// SessionSummary 历史会话摘要
+type SessionSummary struct {
+	UUID    string
+	Path    string
+	ModTime time.Time
+	Preview string // 第一条用户消息
+}
+
+// ListClaudeSessions 列出日志目录下的 claude 会话文件，按修改时间倒序，最多 limit 个
+func ListClaudeSessions(logDir string, limit int) ([]SessionSummary, error) {
+	matches, err := filepath.Glob(filepath.Join(logDir, "*.jsonl"))
+	if err != nil {
+		return nil, err
+	}
+	var sessions []SessionSummary
+	for _, path := range matches {
+		info, err := os.Stat(path)
+		if err != nil || info.Size() == 0 {
+			continue
+		}
+		sessions = append(sessions, SessionSummary{
+			UUID:    extractSessionUUID(path),
+			Path:    path,
+			ModTime: info.ModTime(),
+		})
+	}
+	sort.Slice(sessions, func(i, j int) bool { return sessions[i].ModTime.After(sessions[j].ModTime) })
+	if len(sessions) > limit {
+		sessions = sessions[:limit]
+	}
+	for i := range sessions {
+		sessions[i].Preview = firstUserMessage(sessions[i].Path)
+	}
+	return sessions, nil
+}
+
+// firstUserMessage 返回会话文件中第一条用户输入（仅扫描开头部分）
+func firstUserMessage(path string) string {
+	f, err := os.Open(path)
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+
+	var first string
+	n := 0
+	forEachLine(f, func(line []byte) bool {
+		n++
+		var raw map[string]json.RawMessage
+		if err := json.Unmarshal(line, &raw); err != nil {
+			return n < 200
+		}
+		for _, e := range claudeTranscriptEntries(raw) {
+			if e.Role == "user" && e.Type == "text" && !strings.HasPrefix(e.Text, "<") {
+				first = strings.Join(strings.Fields(e.Text), " ")
+				return false
+			}
+		}
+		return n < 200
+	})
+	return first
+}
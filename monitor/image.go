@@ -0,0 +1,64 @@
+package monitor
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// maxImageBytes 推送图片的大小上限（Telegram sendPhoto 限制 10MB）
+const maxImageBytes = 10 << 20
+
+// imageExts 视为图片的文件扩展名
+var imageExts = map[string]bool{".png": true, ".jpg": true, ".jpeg": true, ".gif": true, ".webp": true}
+
+// imagePath 工具输入中的文件路径是图片时返回该路径
+func imagePath(input map[string]interface{}) string {
+	path := strVal(input, "file_path")
+	if path == "" || !filepath.IsAbs(path) || !imageExts[strings.ToLower(filepath.Ext(path))] {
+		return ""
+	}
+	return path
+}
+
+// extractToolResultImage 从工具结果的 image 内容块（base64）中取出图片，
+// 没有时读取工具输入中的图片文件；解码失败或超过大小上限返回 false
+func extractToolResultImage(content json.RawMessage, path string) ([]byte, bool) {
+	var blocks []struct {
+		Type   string `json:"type"`
+		Source struct {
+			Type string `json:"type"`
+			Data string `json:"data"`
+		} `json:"source"`
+	}
+	if json.Unmarshal(content, &blocks) == nil {
+		for _, b := range blocks {
+			if b.Type != "image" || b.Source.Type != "base64" {
+				continue
+			}
+			if base64.StdEncoding.DecodedLen(len(b.Source.Data)) > maxImageBytes {
+				return nil, false
+			}
+			data, err := base64.StdEncoding.DecodeString(b.Source.Data)
+			if err != nil {
+				return nil, false
+			}
+			return data, true
+		}
+	}
+
+	if path == "" {
+		return nil, false
+	}
+	info, err := os.Stat(path)
+	if err != nil || !info.Mode().IsRegular() || info.Size() > maxImageBytes {
+		return nil, false
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, false
+	}
+	return data, true
+}
@@ -12,6 +12,7 @@ import (
 	"time"
 
 	"github.com/fsnotify/fsnotify"
+	"github.com/user/tgmux/i18n"
 	"github.com/user/tgmux/state"
 )
 
@@ -35,17 +36,28 @@ type JSONDiffMonitor struct {
 	cancel        context.CancelFunc
 	mu            sync.Mutex
 	lockedHashDir string
+	chatFile      string // 正在跟随的 chats/*.json 会话文件（新版 gemini-cli），为空时只看 logs.json
+	chatIndex     int    // chatFile 中已推送的消息数
+	crashed       chan struct{}
 }
 
-func NewJSONDiffMonitor(topicKey, tmpDir string, lastMessageID int, startTime time.Time, handler OutputHandler, store *state.Store) *JSONDiffMonitor {
-	return &JSONDiffMonitor{
-		topicKey:      topicKey,
-		tmpDir:        tmpDir,
-		lastMessageID: lastMessageID,
-		startTime:     startTime,
-		handler:       handler,
-		store:         store,
+// NewJSONDiffMonitor offset 记录的是会话文件时从其消息下标继续，否则为 logs.json 的 messageId
+func NewJSONDiffMonitor(topicKey, tmpDir string, offset state.Offset, startTime time.Time, handler OutputHandler, store *state.Store) *JSONDiffMonitor {
+	m := &JSONDiffMonitor{
+		topicKey:  topicKey,
+		tmpDir:    tmpDir,
+		startTime: startTime,
+		handler:   handler,
+		store:     store,
+		crashed:   make(chan struct{}),
 	}
+	if isGeminiChatFile(offset.File) {
+		m.chatFile = offset.File
+		m.chatIndex = offset.MessageCount
+	} else {
+		m.lastMessageID = offset.MessageCount
+	}
+	return m
 }
 
 func (m *JSONDiffMonitor) Start(ctx context.Context) error {
@@ -67,6 +79,7 @@ func (m *JSONDiffMonitor) Start(ctx context.Context) error {
 			watcher.Close()
 			return fmt.Errorf("watch hash dir: %w", err)
 		}
+		m.watchChats(watcher)
 	} else {
 		if err := watcher.Add(m.tmpDir); err != nil {
 			watcher.Close()
@@ -85,6 +98,21 @@ func (m *JSONDiffMonitor) Stop() {
 	}
 }
 
+// Crashed 实现 watchdog 的 crashReporter：loop 因 watcher 关闭而意外退出时关闭
+func (m *JSONDiffMonitor) Crashed() <-chan struct{} {
+	return m.crashed
+}
+
+// LogsPath 返回已锁定的 logs.json 路径（未锁定时为空）
+func (m *JSONDiffMonitor) LogsPath() string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.lockedHashDir == "" {
+		return ""
+	}
+	return filepath.Join(m.lockedHashDir, "logs.json")
+}
+
 func (m *JSONDiffMonitor) loop(ctx context.Context, watcher *fsnotify.Watcher) {
 	defer watcher.Close()
 
@@ -100,17 +128,19 @@ func (m *JSONDiffMonitor) loop(ctx context.Context, watcher *fsnotify.Watcher) {
 			return
 		case event, ok := <-watcher.Events:
 			if !ok {
+				close(m.crashed)
 				return
 			}
 			m.handleEvent(watcher, event, timeout)
 		case err, ok := <-watcher.Errors:
 			if !ok {
+				close(m.crashed)
 				return
 			}
 			slog.Error("gemini watcher error", "key", m.topicKey, "error", err)
 		case <-timeout.C:
 			slog.Warn("gemini hash dir detection timeout", "key", m.topicKey)
-			m.handler(m.topicKey, ParsedContent{Type: ContentText, Text: "无法定位 Gemini 日志目录，已切换为终端捕获模式"})
+			m.handler(m.topicKey, ParsedContent{Type: ContentText, Text: i18n.T("monitor.gemini_fallback")})
 			return
 		}
 	}
@@ -131,6 +161,7 @@ func (m *JSONDiffMonitor) handleEvent(watcher *fsnotify.Watcher, event fsnotify.
 				slog.Info("locked gemini hash dir", "key", m.topicKey, "dir", event.Name)
 				watcher.Remove(m.tmpDir)
 				watcher.Add(m.lockedHashDir)
+				m.watchChats(watcher)
 				timeout.Stop()
 				m.readAndDiff()
 			}
@@ -139,14 +170,63 @@ func (m *JSONDiffMonitor) handleEvent(watcher *fsnotify.Watcher, event fsnotify.
 	}
 
 	if event.Has(fsnotify.Write) || event.Has(fsnotify.Create) {
-		name := filepath.Base(event.Name)
-		if name == "logs.json" {
+		switch {
+		case event.Name == filepath.Join(m.lockedHashDir, geminiChatsDir):
+			m.watchChats(watcher)
+		case isGeminiChatFile(event.Name):
+			m.readChat(event.Name)
+		case filepath.Base(event.Name) == "logs.json":
 			m.readAndDiff()
 		}
 	}
 }
 
+// watchChats 监听 hash 目录下的 chats 子目录（存在时），并读取其中最新的会话文件
+func (m *JSONDiffMonitor) watchChats(watcher *fsnotify.Watcher) {
+	dir := filepath.Join(m.lockedHashDir, geminiChatsDir)
+	if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+		return
+	}
+	if err := watcher.Add(dir); err != nil {
+		slog.Warn("failed to watch gemini chats dir", "dir", dir, "error", err)
+		return
+	}
+	if m.chatFile != "" {
+		m.readChat(m.chatFile)
+		return
+	}
+	if latest := latestGeminiChat(dir); latest != "" {
+		m.readChat(latest)
+	}
+}
+
+// latestGeminiChat chats 目录中修改时间最新的会话文件
+func latestGeminiChat(dir string) string {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return ""
+	}
+	var latest string
+	var latestTime time.Time
+	for _, e := range entries {
+		path := filepath.Join(dir, e.Name())
+		info, err := e.Info()
+		if err != nil || e.IsDir() || !isGeminiChatFile(path) {
+			continue
+		}
+		if info.ModTime().After(latestTime) {
+			latest, latestTime = path, info.ModTime()
+		}
+	}
+	return latest
+}
+
 func (m *JSONDiffMonitor) readAndDiff() {
+	// 已在跟随会话文件：logs.json 只含用户输入，且不能覆盖会话文件的 offset
+	if m.chatFile != "" {
+		return
+	}
+
 	logsPath := filepath.Join(m.lockedHashDir, "logs.json")
 	data, err := os.ReadFile(logsPath)
 	if err != nil {
@@ -159,24 +239,53 @@ func (m *JSONDiffMonitor) readAndDiff() {
 		return
 	}
 
-	var newTexts []string
+	// 每条新条目单独推送，由 pusher 负责合并与拆分
+	var outputs []ParsedContent
+	lastID := m.lastMessageID
 	for _, entry := range entries {
-		if entry.MessageID > m.lastMessageID && entry.Type == "model" {
-			if entry.Message != "" {
-				newTexts = append(newTexts, entry.Message)
-			}
-			m.lastMessageID = entry.MessageID
+		if entry.MessageID <= m.lastMessageID {
+			continue
 		}
+		lastID = max(lastID, entry.MessageID)
+		if c, ok := parseGeminiEntry(entry); ok {
+			outputs = append(outputs, c)
+		}
+	}
+	if lastID == m.lastMessageID {
+		return
 	}
+	m.lastMessageID = lastID
+	m.store.SetOffset(m.topicKey, state.Offset{
+		File:         logsPath,
+		MessageCount: m.lastMessageID,
+	})
+	for _, c := range outputs {
+		m.handler(m.topicKey, c)
+	}
+}
 
-	if len(newTexts) > 0 {
-		m.store.SetOffset(m.topicKey, state.Offset{
-			File:         filepath.Join(m.lockedHashDir, "logs.json"),
-			MessageCount: m.lastMessageID,
-		})
-		combined := strings.Join(newTexts, "\n")
-		m.handler(m.topicKey, ParsedContent{Type: ContentText, Text: combined})
+// geminiEntryTypes logs.json 条目类型 → 推送的内容类型；user 等未列出的类型不推送
+var geminiEntryTypes = map[string]ContentType{
+	"model":       ContentText,
+	"gemini":      ContentText,
+	"thought":     ContentThinking,
+	"thinking":    ContentThinking,
+	"tool_call":   ContentToolUse,
+	"tool_result": ContentToolResult,
+	"error":       ContentSystem,
+}
+
+// parseGeminiEntry 将一条 logs.json 条目转换为推送内容，无需推送时返回 false
+func parseGeminiEntry(entry GeminiLogEntry) (ParsedContent, bool) {
+	t, ok := geminiEntryTypes[entry.Type]
+	if !ok || strings.TrimSpace(entry.Message) == "" {
+		return ParsedContent{}, false
+	}
+	c := ParsedContent{Type: t, Text: entry.Message}
+	if t == ContentSystem {
+		c.Level = LevelError
 	}
+	return c, true
 }
 
 func (m *JSONDiffMonitor) scanExistingDirs() string {
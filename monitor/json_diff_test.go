@@ -0,0 +1,83 @@
+package monitor
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/user/tgmux/state"
+)
+
+func writeGeminiLogs(t *testing.T, path string, entries []GeminiLogEntry) {
+	t.Helper()
+	data, err := json.Marshal(entries)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestJSONDiffMonitorLogs(t *testing.T) {
+	tmpDir := t.TempDir()
+	hashDir := filepath.Join(tmpDir, "3f2a9c")
+	if err := os.Mkdir(hashDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	logsPath := filepath.Join(hashDir, "logs.json")
+	entries := []GeminiLogEntry{
+		{SessionID: "s1", MessageID: 1, Type: "user", Message: "hello"},
+		{SessionID: "s1", MessageID: 2, Type: "thought", Message: "Considering the greeting"},
+		{SessionID: "s1", MessageID: 3, Type: "tool_call", Message: "ReadFile(main.go)"},
+		{SessionID: "s1", MessageID: 4, Type: "tool_result", Message: "  ⎿  12 lines"},
+		{SessionID: "s1", MessageID: 5, Type: "gemini", Message: "Hi there"},
+		{SessionID: "s1", MessageID: 6, Type: "model", Message: "   "},
+	}
+	writeGeminiLogs(t, logsPath, entries)
+
+	store := state.New(filepath.Join(t.TempDir(), "state.json"), 10)
+	defer store.Close()
+	var got []ParsedContent
+	handler := func(_ string, c ParsedContent) { got = append(got, c) }
+	m := NewJSONDiffMonitor("k", tmpDir, state.Offset{}, time.Now().Add(-time.Minute), handler, store)
+	if m.lockedHashDir = m.scanExistingDirs(); m.lockedHashDir != hashDir {
+		t.Fatalf("locked %q, want %q", m.lockedHashDir, hashDir)
+	}
+
+	m.readAndDiff()
+	checkContents(t, got, []wantContent{
+		{typ: ContentThinking, text: "Considering the greeting"},
+		{typ: ContentToolUse, text: "ReadFile(main.go)"},
+		{typ: ContentToolResult, text: "  ⎿  12 lines"},
+		{typ: ContentText, text: "Hi there"},
+	})
+	if off, _ := store.GetOffset("k"); off.File != logsPath || off.MessageCount != 6 {
+		t.Errorf("offset = %+v", off)
+	}
+
+	// 全量重写的文件只推送新增的条目
+	got = nil
+	entries = append(entries,
+		GeminiLogEntry{SessionID: "s1", MessageID: 7, Type: "user", Message: "and?"},
+		GeminiLogEntry{SessionID: "s1", MessageID: 8, Type: "error", Message: "quota exceeded"},
+	)
+	writeGeminiLogs(t, logsPath, entries)
+	m.readAndDiff()
+	checkContents(t, got, []wantContent{{typ: ContentSystem, text: "quota exceeded"}})
+	if got[0].Level != LevelError {
+		t.Errorf("error entry level = %q", got[0].Level)
+	}
+
+	// 从保存的 offset 恢复时不重复推送
+	got = nil
+	off, _ := store.GetOffset("k")
+	restored := NewJSONDiffMonitor("k", tmpDir, off, time.Now().Add(-time.Minute), handler, store)
+	restored.lockedHashDir = hashDir
+	restored.readAndDiff()
+	if len(got) != 0 {
+		t.Errorf("restored monitor re-sent %d entries", len(got))
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"bufio"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log/slog"
@@ -23,6 +24,15 @@ type fileTracker struct {
 	byteOffset int64
 }
 
+// parseErrorLimit 连续多少行解析失败（中间没有解析出内容）后放弃 JSONL 监控
+const parseErrorLimit = 10
+
+// compactGraceLines 上下文压缩边界之后的若干行中解析失败不计入 parseErrors（压缩过程中可能写入过渡行）
+const compactGraceLines = 20
+
+// pendingToolPruneInterval 清理过期 pendingTools 的间隔
+const pendingToolPruneInterval = 10 * time.Minute
+
 // JSONLMonitor 通过 fsnotify 监听日志目录，增量读取 JSONL 文件
 type JSONLMonitor struct {
 	topicKey      string
@@ -37,11 +47,39 @@ type JSONLMonitor struct {
 	sessionUUID   string                  // 当前会话的 UUID，用于过滤其他会话的文件
 	watchedPaths  map[string]struct{}
 	parseErrors   int
+	compactGrace  int                 // 压缩边界后剩余的容错行数
+	onParseFail   func()              // 连续解析失败达到 parseErrorLimit 时调用一次
+	codexEvents   bool                // codex rollout 中出现过 event_msg（此后不再推送 response_item）
 	baselineFiles map[string]struct{} // 启动时已存在的文件（仅新会话使用）
 	pendingTools  map[string]string   // tool_use_id → tool name，跨 readIncremental 持久化
+	pendingImages map[string]string   // tool_use_id → 工具读写的图片路径（按需创建）
+	usage         state.Usage         // 累计用量，随 offset 持久化
+	lastUsageID   string              // 上一条已计入 usage 的 message.id（同一消息会拆成多行）
+	lastUsage     state.Usage         // lastUsageID 对应的已计入值
+	turn          *turnTracker        // 回合结束检测，nil 为关闭
+	mainLine      bool                // 当前解析的行是否来自主会话文件（subagent 不影响回合）
+	outbox        []ParsedContent     // 已解析、待 deliver 推送的内容
+	truncSkip     bool                // 文件被截断时跳到新的末尾（monitor.on_truncate: skip），默认从头重读
+	pollInterval  time.Duration       // 轮询模式下检查文件的间隔（monitor.poll_interval）
+	watchFallback time.Duration       // 监听期间无事件多久且文件仍在增长时改为轮询，0 为不检测
+	polling       bool                // 已降级为轮询模式
+	lastEvent     time.Time           // 最近一次收到 fsnotify 事件的时间
+	crashed       chan struct{}       // loop 因 watcher 关闭而意外退出时关闭（watchdog 据此重建）
+	seenSize      int64               // 上次读取时主文件的大小
+	readAt        time.Time           // 上次读取主文件的时间
+
+	// 后端重启后切换到新的会话文件（见 relock.go）
+	projectPath  string                 // 会话工作目录，用于核对新会话文件的 cwd
+	relockAfter  time.Duration          // 主文件停止增长多久后可切换到新会话文件，0 为关闭
+	mainGrewAt   time.Time              // 主文件最近一次读到新内容的时间
+	foreignFiles map[string]foreignFile // 因会话不符被忽略的文件（按需创建）
+	backendAlive func() bool            // 后端进程是否在运行，用于发现重启
+	wasAlive     bool                   // 上次检查时后端是否在运行
+	restarted    bool                   // 后端重启过，尚未切换到新会话文件
+	claimed      func(path string) bool // 文件是否已被其他 topic 的监控跟踪
 }
 
-func NewJSONLMonitor(topicKey string, bt backend.Type, logDir string, byteOffset int64, currentFile string, handler OutputHandler, store *state.Store) *JSONLMonitor {
+func NewJSONLMonitor(topicKey string, bt backend.Type, logDir string, byteOffset int64, currentFile string, usage state.Usage, handler OutputHandler, store *state.Store) *JSONLMonitor {
 	m := &JSONLMonitor{
 		topicKey:     topicKey,
 		backendType:  bt,
@@ -51,6 +89,14 @@ func NewJSONLMonitor(topicKey string, bt backend.Type, logDir string, byteOffset
 		trackedFiles: make(map[string]*fileTracker),
 		watchedPaths: make(map[string]struct{}),
 		pendingTools: make(map[string]string),
+		crashed:      make(chan struct{}),
+		usage:        usage,
+	}
+	// 恢复重启前未收到结果的工具调用，结果仍能带上工具名
+	if store != nil {
+		for id, t := range store.PendingTools(topicKey) {
+			m.pendingTools[id] = t.Name
+		}
 	}
 	// 恢复已有文件的 offset
 	if currentFile != "" {
@@ -87,41 +133,14 @@ func (m *JSONLMonitor) belongsToSession(path string) bool {
 }
 
 func (m *JSONLMonitor) Start(ctx context.Context) error {
-	watcher, err := fsnotify.NewWatcher()
-	if err != nil {
-		return fmt.Errorf("create watcher: %w", err)
-	}
-
 	if _, err := os.Stat(m.logDir); os.IsNotExist(err) {
-		watcher.Close()
 		return fmt.Errorf("log dir not found: %s", m.logDir)
 	}
 
-	if err := watcher.Add(m.logDir); err != nil {
-		watcher.Close()
-		return fmt.Errorf("watch dir: %w", err)
-	}
-	m.watchedPaths[m.logDir] = struct{}{}
-
-	// Claude: 扫描已有子目录
-	if m.backendType == backend.TypeClaude {
-		m.scanAndWatchSubdirs(watcher, m.logDir)
-	}
-
-	// Codex: 添加前一天目录
-	if m.backendType == backend.TypeCodex {
-		yesterday := time.Now().AddDate(0, 0, -1)
-		yesterdayDir := filepath.Join(
-			filepath.Dir(filepath.Dir(filepath.Dir(m.logDir))),
-			yesterday.Format("2006"),
-			yesterday.Format("01"),
-			yesterday.Format("02"),
-		)
-		if _, err := os.Stat(yesterdayDir); err == nil {
-			if err := watcher.Add(yesterdayDir); err == nil {
-				m.watchedPaths[yesterdayDir] = struct{}{}
-			}
-		}
+	// 无法监听（inotify 数量上限、NFS 等）时改为轮询
+	watcher, err := m.newWatcher()
+	if err != nil {
+		m.switchToPolling("watch failed: " + err.Error())
 	}
 
 	// 始终记录已有文件作为基线，防止切换到其他 Claude 会话的文件
@@ -142,40 +161,177 @@ func (m *JSONLMonitor) Start(ctx context.Context) error {
 		}
 	}
 
+	m.lastEvent = time.Now()
+	m.mainGrewAt = m.lastEvent
+	m.wasAlive = true
 	ctx, m.cancel = context.WithCancel(ctx)
 	go m.loop(ctx, watcher)
 	return nil
 }
 
+// newWatcher 创建 fsnotify 监听：日志目录、claude 的会话子目录与 codex 的前一天目录
+func (m *JSONLMonitor) newWatcher() (*fsnotify.Watcher, error) {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, fmt.Errorf("create watcher: %w", err)
+	}
+
+	if err := watcher.Add(m.logDir); err != nil {
+		watcher.Close()
+		return nil, fmt.Errorf("watch dir: %w", err)
+	}
+	m.watchedPaths[m.logDir] = struct{}{}
+
+	// Claude: 扫描已有子目录
+	if m.backendType == backend.TypeClaude {
+		m.scanAndWatchSubdirs(watcher, m.logDir)
+	}
+
+	// Codex: 监听最近几天及会话文件所在的日期目录
+	if m.backendType == backend.TypeCodex {
+		m.syncCodexDirs(watcher, time.Now())
+	}
+	return watcher, nil
+}
+
 func (m *JSONLMonitor) Stop() {
 	if m.cancel != nil {
 		m.cancel()
 	}
 }
 
+// Usage 返回当前累计的用量统计
+func (m *JSONLMonitor) Usage() state.Usage {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.usage
+}
+
+// Crashed 实现 watchdog 的 crashReporter
+func (m *JSONLMonitor) Crashed() <-chan struct{} {
+	return m.crashed
+}
+
+// Wedged 主文件在上次读取后继续增长，但已超过 after 没有再读取（事件与轮询都失效）时返回 true。
+// 正在读取（持有锁）时无法判断，返回 false
+func (m *JSONLMonitor) Wedged(now time.Time, after time.Duration) bool {
+	if !m.mu.TryLock() {
+		return false
+	}
+	defer m.mu.Unlock()
+	if m.mainFile == "" || m.readAt.IsZero() || now.Sub(m.readAt) < after {
+		return false
+	}
+	info, err := os.Stat(m.mainFile)
+	return err == nil && info.Size() > m.seenSize
+}
+
+// MainFile 返回当前跟踪的主会话文件路径
+func (m *JSONLMonitor) MainFile() string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.mainFile
+}
+
 func (m *JSONLMonitor) loop(ctx context.Context, watcher *fsnotify.Watcher) {
-	defer watcher.Close()
+	// 没有 watcher（已降级为轮询）时事件 channel 为 nil，永不触发
+	var events <-chan fsnotify.Event
+	var watchErrs <-chan error
+	if watcher != nil {
+		defer watcher.Close()
+		events, watchErrs = watcher.Events, watcher.Errors
+	}
 
 	dayCheckTicker := time.NewTicker(1 * time.Hour)
 	defer dayCheckTicker.Stop()
 
+	// 长时间未收到结果的工具调用定时清理，避免 pendingTools 与 store 无限增长
+	pruneTicker := time.NewTicker(pendingToolPruneInterval)
+	defer pruneTicker.Stop()
+
+	// 轮询：启动时已降级，或监听期间长时间收不到事件时开启
+	var pollTicker *time.Ticker
+	var pollC <-chan time.Time
+	startPolling := func() {
+		pollTicker = time.NewTicker(m.pollEvery())
+		pollC = pollTicker.C
+	}
+	defer func() {
+		if pollTicker != nil {
+			pollTicker.Stop()
+		}
+	}()
+	var watchCheckC <-chan time.Time
+	if m.polling {
+		startPolling()
+	} else if m.watchFallback > 0 {
+		watchCheckTicker := time.NewTicker(watchCheckInterval)
+		defer watchCheckTicker.Stop()
+		watchCheckC = watchCheckTicker.C
+	}
+
+	// 会话重启检测：定时检查是否需要切换到新的会话文件
+	var relockC <-chan time.Time
+	if m.relockAfter > 0 {
+		relockTicker := time.NewTicker(watchCheckInterval)
+		defer relockTicker.Stop()
+		relockC = relockTicker.C
+	}
+
+	// quiet 判定需要定时检查静默时长
+	var quietC <-chan time.Time
+	if m.turn != nil && m.turn.detect[turnDetectQuiet] {
+		quietTicker := time.NewTicker(time.Second)
+		defer quietTicker.Stop()
+		quietC = quietTicker.C
+	}
+
 	for {
 		select {
 		case <-ctx.Done():
 			return
-		case event, ok := <-watcher.Events:
+		case event, ok := <-events:
 			if !ok {
+				close(m.crashed)
 				return
 			}
 			m.handleEvent(watcher, event)
-		case err, ok := <-watcher.Errors:
+			m.deliver()
+		case err, ok := <-watchErrs:
 			if !ok {
+				close(m.crashed)
 				return
 			}
 			slog.Error("watcher error", "key", m.topicKey, "error", err)
-		case <-dayCheckTicker.C:
-			if m.backendType == backend.TypeCodex {
-				m.checkDateChange(watcher)
+		case now := <-watchCheckC:
+			if m.watchStalled(now) {
+				m.switchToPolling("no fsnotify events while the log file keeps growing")
+				startPolling()
+				watchCheckC = nil
+			}
+		case <-pollC:
+			m.pollFiles()
+			m.deliver()
+		case now := <-relockC:
+			m.checkRelock(now)
+			m.deliver()
+		case <-pruneTicker.C:
+			m.mu.Lock()
+			m.prunePendingTools()
+			m.mu.Unlock()
+		case now := <-dayCheckTicker.C:
+			if m.backendType == backend.TypeCodex && watcher != nil {
+				m.mu.Lock()
+				m.syncCodexDirs(watcher, now)
+				m.mu.Unlock()
+			}
+		case now := <-quietC:
+			m.mu.Lock()
+			done, ok := m.turn.quietDone(now)
+			m.mu.Unlock()
+			if ok {
+				slog.Info("JSONL turn done (quiet)", "key", m.topicKey, "elapsed", done.Elapsed)
+				m.handler(m.topicKey, done)
 			}
 		}
 	}
@@ -184,6 +340,7 @@ func (m *JSONLMonitor) loop(ctx context.Context, watcher *fsnotify.Watcher) {
 func (m *JSONLMonitor) handleEvent(watcher *fsnotify.Watcher, event fsnotify.Event) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
+	m.lastEvent = time.Now()
 
 	if event.Has(fsnotify.Create) {
 		info, err := os.Stat(event.Name)
@@ -210,6 +367,12 @@ func (m *JSONLMonitor) handleEvent(watcher *fsnotify.Watcher, event fsnotify.Eve
 		}
 	}
 
+	// 主文件被删除或改名：释放锁定，等待会话的下一个文件
+	if event.Has(fsnotify.Remove) || event.Has(fsnotify.Rename) {
+		m.forgetFile(event.Name)
+		return
+	}
+
 	if event.Has(fsnotify.Write) {
 		if isJSONLFile(event.Name, m.backendType) {
 			// 已跟踪的文件：直接增量读取
@@ -228,6 +391,21 @@ func (m *JSONLMonitor) handleEvent(watcher *fsnotify.Watcher, event fsnotify.Eve
 	}
 }
 
+// forgetFile 停止跟踪已删除或改名的文件；主文件消失时释放锁定，等待会话的下一个文件
+func (m *JSONLMonitor) forgetFile(path string) {
+	if _, tracked := m.trackedFiles[path]; !tracked {
+		return
+	}
+	delete(m.trackedFiles, path)
+	if path != m.mainFile {
+		return
+	}
+	slog.Warn("main JSONL file removed, waiting for next file", "key", m.topicKey, "file", filepath.Base(path))
+	m.mainFile = ""
+	m.sessionUUID = ""
+	m.store.SetOffset(m.topicKey, state.Offset{Usage: m.usage})
+}
+
 // trackFile 开始跟踪一个新文件，并读取初始内容
 func (m *JSONLMonitor) trackFile(path string) {
 	if _, exists := m.trackedFiles[path]; exists {
@@ -237,6 +415,7 @@ func (m *JSONLMonitor) trackFile(path string) {
 	// 检查是否属于当前会话
 	if !m.belongsToSession(path) {
 		slog.Debug("ignoring file from different session", "key", m.topicKey, "file", filepath.Base(path))
+		m.noteForeign(path)
 		return
 	}
 
@@ -267,29 +446,65 @@ func (m *JSONLMonitor) readIncremental(filePath string) {
 	}
 	defer f.Close()
 
+	info, err := f.Stat()
+	if err != nil {
+		return
+	}
+	if filePath == m.mainFile {
+		m.seenSize, m.readAt = info.Size(), time.Now()
+	}
+
+	// 文件变小（被重写或轮转）：offset 已越过 EOF，从头重读或跳到新的末尾
+	if info.Size() < tracker.byteOffset {
+		newOffset := int64(0)
+		if m.truncSkip {
+			newOffset = info.Size()
+		}
+		slog.Warn("JSONL file truncated, resetting offset", "key", m.topicKey, "file", filepath.Base(filePath), "offset", tracker.byteOffset, "size", info.Size(), "new_offset", newOffset)
+		tracker.byteOffset = newOffset
+	}
+
 	if tracker.byteOffset > 0 {
 		if _, err := f.Seek(tracker.byteOffset, io.SeekStart); err != nil {
 			return
 		}
 	}
 
-	scanner := bufio.NewScanner(f)
-	scanner.Buffer(make([]byte, 0, 256*1024), 1024*1024)
+	reader := bufio.NewReaderSize(f, 256*1024)
 
+	m.mainLine = filePath == m.mainFile
 	var outputs []ParsedContent
-	for scanner.Scan() {
-		line := scanner.Text()
-		if line == "" {
+	newOffset := tracker.byteOffset
+	for {
+		line, n, oversized, err := readLine(reader)
+		if err != nil {
+			// 末尾未写完的行下次从行首重读；其他读取错误同样停在已完整读取的位置
+			if !errors.Is(err, io.EOF) && !errors.Is(err, errIncompleteLine) {
+				slog.Warn("JSONL read failed", "key", m.topicKey, "file", filepath.Base(filePath), "offset", newOffset, "error", err)
+			}
+			break
+		}
+		newOffset += n
+		if oversized {
+			slog.Warn("JSONL line exceeds read limit, skipped", "key", m.topicKey, "file", filepath.Base(filePath), "bytes", n)
+			continue
+		}
+		if len(line) == 0 {
 			continue
 		}
-		contents := m.parseLine(line)
+		contents := m.parseLine(string(line))
+		if len(line) > largeLineBytes && truncateContents(contents) {
+			slog.Warn("JSONL large line, text truncated", "key", m.topicKey, "file", filepath.Base(filePath), "bytes", len(line))
+		}
 		outputs = append(outputs, contents...)
 		if len(contents) > 0 {
 			m.parseErrors = 0
 		}
 	}
 
-	newOffset, _ := f.Seek(0, io.SeekCurrent)
+	if filePath == m.mainFile && newOffset > tracker.byteOffset {
+		m.mainGrewAt = time.Now()
+	}
 	tracker.byteOffset = newOffset
 
 	// 只持久化主文件的 offset（用于重启恢复）
@@ -297,10 +512,21 @@ func (m *JSONLMonitor) readIncremental(filePath string) {
 		m.store.SetOffset(m.topicKey, state.Offset{
 			File:       m.mainFile,
 			ByteOffset: tracker.byteOffset,
+			Usage:      m.usage,
 		})
 	}
 
-	// 逐块发送，保持原始顺序
+	// 推送在 m.mu 之外进行（见 deliver）：handler 可能因推送队列已满而阻塞
+	m.outbox = append(m.outbox, outputs...)
+}
+
+// deliver 推送 readIncremental 积累的内容，保持原始顺序；只在 loop 中、不持有 m.mu 时调用
+func (m *JSONLMonitor) deliver() {
+	m.mu.Lock()
+	outputs := m.outbox
+	m.outbox = nil
+	m.mu.Unlock()
+
 	for _, c := range outputs {
 		switch c.Type {
 		case ContentThinking:
@@ -311,6 +537,16 @@ func (m *JSONLMonitor) readIncremental(filePath string) {
 			slog.Info("JSONL tool_use", "key", m.topicKey, "text", truncate(c.Text, 80))
 		case ContentToolResult:
 			slog.Info("JSONL tool_result", "key", m.topicKey, "text", truncate(c.Text, 80))
+		case ContentTurnDone:
+			slog.Info("JSONL turn done", "key", m.topicKey, "elapsed", c.Elapsed, "tools", c.ToolCalls)
+		case ContentPlan:
+			slog.Info("JSONL plan", "key", m.topicKey, "len", len(c.Text))
+		case ContentTodo:
+			slog.Info("JSONL todo list", "key", m.topicKey, "len", len(c.Text))
+		case ContentImage:
+			slog.Info("JSONL image result", "key", m.topicKey, "bytes", len(c.Image))
+		case ContentSystem:
+			slog.Info("JSONL system event", "key", m.topicKey, "level", c.Level, "text", truncate(c.Text, 80))
 		}
 		m.handler(m.topicKey, c)
 	}
@@ -322,15 +558,72 @@ type ParsedContent struct {
 	Text      string
 	ToolUseID string // tool_use ID，用于 tool_result 配对
 	ToolName  string // 工具名称
+
+	// ContentTurnDone 专用：本轮耗时与工具调用次数
+	Elapsed   time.Duration
+	ToolCalls int
+
+	// Usage 所属 assistant 消息的 token 用量（仅 claude 的 ContentText，其他为 nil）
+	Usage *state.Usage
+	// Level ContentSystem 专用：info / warning / error
+	Level string
+	// Image ContentImage 专用：图片数据
+	Image []byte
+	// Output ContentToolResult 专用：工具的原始输出，Text 为其统计摘要
+	Output string
+}
+
+// notePendingTool 记录等待结果的 tool_use，并写入 store 以便重启后恢复（仅解析用的实例不持久化）
+func (m *JSONLMonitor) notePendingTool(id, name string) {
+	m.pendingTools[id] = name
+	if m.store != nil && id != "" {
+		m.store.NotePendingTool(m.topicKey, id, name)
+	}
+}
+
+// resolvePendingTool 收到 tool_result 后取出工具名并删除记录；无论结果随后被推送、过滤还是丢弃，store 中都不再保留
+func (m *JSONLMonitor) resolvePendingTool(id string) string {
+	name := m.pendingTools[id]
+	delete(m.pendingTools, id)
+	if m.store != nil && id != "" {
+		m.store.DeletePendingTool(m.topicKey, id)
+	}
+	return name
+}
+
+// prunePendingTools 定时丢弃超过 pendingToolTTL 仍未收到结果的工具调用（store 负责判定过期）
+func (m *JSONLMonitor) prunePendingTools() {
+	if m.store == nil {
+		return
+	}
+	live := m.store.PendingTools(m.topicKey)
+	for id := range m.pendingTools {
+		if _, ok := live[id]; !ok {
+			delete(m.pendingTools, id)
+		}
+	}
 }
 
 func (m *JSONLMonitor) parseLine(line string) []ParsedContent {
+	grace := m.compactGrace > 0
+	if grace {
+		m.compactGrace--
+	}
 	var raw map[string]json.RawMessage
 	if err := json.Unmarshal([]byte(line), &raw); err != nil {
+		if grace {
+			slog.Debug("skipping malformed line after compaction", "key", m.topicKey)
+			return nil
+		}
 		m.parseErrors++
 		if m.parseErrors >= 3 {
 			slog.Warn("too many parse errors", "key", m.topicKey, "errors", m.parseErrors)
 		}
+		// 连续解析失败（日志格式可能已变化）：交给 Dispatcher 改用终端捕获，只报告一次
+		if m.parseErrors >= parseErrorLimit && m.onParseFail != nil {
+			go m.onParseFail()
+			m.onParseFail = nil
+		}
 		return nil
 	}
 
@@ -338,6 +631,11 @@ func (m *JSONLMonitor) parseLine(line string) []ParsedContent {
 	case backend.TypeClaude:
 		return m.parseClaudeLine(raw)
 	case backend.TypeCodex:
+		m.accumulateCodexUsage(raw)
+		if contents, ok := m.parseCodexRecord(raw); ok {
+			return contents
+		}
+		// 旧版格式：顶层 role/content/message
 		text := parseCodexLine(raw)
 		if text != "" {
 			return []ParsedContent{{Type: ContentText, Text: text}}
@@ -353,9 +651,35 @@ func (m *JSONLMonitor) parseClaudeLine(raw map[string]json.RawMessage) []ParsedC
 	if t, ok := raw["type"]; ok {
 		json.Unmarshal(t, &msgType)
 	}
+	if msgType == "result" {
+		done := m.finishTurn(turnDetectResult)
+		if c, ok := parseClaudeSystem(msgType, raw); ok {
+			return append([]ParsedContent{c}, done...)
+		}
+		return done
+	}
+	if msgType == "system" || msgType == "summary" {
+		if isCompactBoundary(msgType, raw) {
+			m.compactGrace = compactGraceLines
+			m.parseErrors = 0
+		}
+		if c, ok := parseClaudeSystem(msgType, raw); ok {
+			return []ParsedContent{c}
+		}
+		return nil
+	}
 	if msgType != "assistant" && msgType != "user" {
 		return nil
 	}
+	// 压缩后 claude 以 user 消息写入之前对话的摘要（isCompactSummary），不是新的输入，也不推送
+	if msgType == "user" && (boolField(raw, "isCompactSummary") || boolField(raw, "isVisibleInTranscriptOnly")) {
+		return nil
+	}
+	// API 调用失败时 claude 会写入一条带 isApiErrorMessage 的 assistant 消息，按系统错误推送
+	var apiError bool
+	if v, ok := raw["isApiErrorMessage"]; ok {
+		json.Unmarshal(v, &apiError)
+	}
 
 	msgData, ok := raw["message"]
 	if !ok {
@@ -363,14 +687,59 @@ func (m *JSONLMonitor) parseClaudeLine(raw map[string]json.RawMessage) []ParsedC
 	}
 
 	var msg struct {
-		Content []json.RawMessage `json:"content"`
+		ID      string          `json:"id"`
+		Content json.RawMessage `json:"content"`
+		Usage   *struct {
+			InputTokens              int64 `json:"input_tokens"`
+			OutputTokens             int64 `json:"output_tokens"`
+			CacheReadInputTokens     int64 `json:"cache_read_input_tokens"`
+			CacheCreationInputTokens int64 `json:"cache_creation_input_tokens"`
+		} `json:"usage"`
+		StopReason string `json:"stop_reason"`
 	}
 	if err := json.Unmarshal(msgData, &msg); err != nil {
 		return nil
 	}
 
+	// 同一条 assistant 消息的每个内容块各占一行且重复携带 usage（后面的行更完整），
+	// 按 message.id 去重：同 id 时先撤销上一次计入的值再加上新值
+	var msgUsage *state.Usage
+	if msgType == "assistant" && msg.Usage != nil {
+		cur := state.Usage{
+			InputTokens:      msg.Usage.InputTokens,
+			OutputTokens:     msg.Usage.OutputTokens,
+			CacheReadTokens:  msg.Usage.CacheReadInputTokens,
+			CacheWriteTokens: msg.Usage.CacheCreationInputTokens,
+		}
+		if msg.ID != "" && msg.ID == m.lastUsageID {
+			m.usage.InputTokens -= m.lastUsage.InputTokens
+			m.usage.OutputTokens -= m.lastUsage.OutputTokens
+			m.usage.CacheReadTokens -= m.lastUsage.CacheReadTokens
+			m.usage.CacheWriteTokens -= m.lastUsage.CacheWriteTokens
+		}
+		m.usage.InputTokens += cur.InputTokens
+		m.usage.OutputTokens += cur.OutputTokens
+		m.usage.CacheReadTokens += cur.CacheReadTokens
+		m.usage.CacheWriteTokens += cur.CacheWriteTokens
+		m.lastUsageID = msg.ID
+		m.lastUsage = cur
+		msgUsage = &cur
+	}
+
+	var blocks []json.RawMessage
+	if err := json.Unmarshal(msg.Content, &blocks); err != nil {
+		// 用户直接输入的 prompt 是字符串
+		var prompt string
+		if msgType == "user" && json.Unmarshal(msg.Content, &prompt) == nil && prompt != "" {
+			m.usage.Turns++
+			m.beginTurn()
+		}
+		return nil
+	}
+
 	var results []ParsedContent
-	for _, blockRaw := range msg.Content {
+	userPrompt := false
+	for _, blockRaw := range blocks {
 		var block struct {
 			Type      string                 `json:"type"`
 			Text      string                 `json:"text"`
@@ -392,11 +761,32 @@ func (m *JSONLMonitor) parseClaudeLine(raw map[string]json.RawMessage) []ParsedC
 				results = append(results, ParsedContent{Type: ContentThinking, Text: block.Thinking})
 			}
 		case "text":
-			if block.Text != "" {
-				results = append(results, ParsedContent{Type: ContentText, Text: block.Text})
+			if msgType == "user" {
+				userPrompt = true
+			}
+			if block.Text != "" && apiError {
+				results = append(results, ParsedContent{Type: ContentSystem, Level: LevelError, Text: block.Text})
+			} else if block.Text != "" {
+				results = append(results, ParsedContent{Type: ContentText, Text: block.Text, Usage: msgUsage})
 			}
 		case "tool_use":
-			if block.Name != "" {
+			m.usage.ToolCalls++
+			if plan := strVal(block.Input, "plan"); block.Name == "ExitPlanMode" && plan != "" {
+				results = append(results, ParsedContent{
+					Type:      ContentPlan,
+					Text:      plan,
+					ToolUseID: block.ID,
+					ToolName:  block.Name,
+				})
+				m.notePendingTool(block.ID, block.Name)
+			} else if todo, ok := FormatTodoList(block.Input); block.Name == "TodoWrite" && ok {
+				results = append(results, ParsedContent{
+					Type:     ContentTodo,
+					Text:     todo,
+					ToolName: block.Name,
+				})
+				m.notePendingTool(block.ID, block.Name)
+			} else if block.Name != "" {
 				summary := FormatToolUseSummary(block.Name, block.Input)
 				results = append(results, ParsedContent{
 					Type:      ContentToolUse,
@@ -404,10 +794,17 @@ func (m *JSONLMonitor) parseClaudeLine(raw map[string]json.RawMessage) []ParsedC
 					ToolUseID: block.ID,
 					ToolName:  block.Name,
 				})
-				m.pendingTools[block.ID] = block.Name
+				m.notePendingTool(block.ID, block.Name)
+				if path := imagePath(block.Input); path != "" {
+					if m.pendingImages == nil {
+						m.pendingImages = make(map[string]string)
+					}
+					m.pendingImages[block.ID] = path
+				}
 			}
 		case "tool_result":
 			resultText := extractToolResultText(block.Content)
+			toolName := m.resolvePendingTool(block.ToolUseID)
 			var statsText string
 			if block.IsError {
 				errLine := firstLine(resultText)
@@ -416,20 +813,127 @@ func (m *JSONLMonitor) parseClaudeLine(raw map[string]json.RawMessage) []ParsedC
 				}
 				statsText = "  ⎿  Error: " + errLine
 			} else {
-				toolName := m.pendingTools[block.ToolUseID]
-				delete(m.pendingTools, block.ToolUseID)
+				if toolName == "TodoWrite" {
+					// 清单消息原地更新，无需再推送结果
+					continue
+				}
 				statsText = FormatToolResultStats(resultText, toolName)
 			}
+			// 图片结果（base64 内容块或读写的图片文件）以图片推送，失败时仍推送文字摘要
+			imgPath := m.pendingImages[block.ToolUseID]
+			delete(m.pendingImages, block.ToolUseID)
+			if !block.IsError {
+				if img, ok := extractToolResultImage(block.Content, imgPath); ok {
+					results = append(results, ParsedContent{
+						Type:      ContentImage,
+						Text:      statsText,
+						ToolUseID: block.ToolUseID,
+						Image:     img,
+					})
+					continue
+				}
+			}
 			results = append(results, ParsedContent{
 				Type:      ContentToolResult,
 				Text:      statsText,
 				ToolUseID: block.ToolUseID,
+				Output:    resultText,
 			})
 		}
 	}
+	if userPrompt {
+		m.usage.Turns++
+		m.beginTurn()
+	}
+	if m.turn != nil && m.mainLine {
+		now := time.Now()
+		for _, c := range results {
+			m.turn.observe(c, now)
+		}
+	}
+	if msgType == "assistant" && msg.StopReason == "end_turn" {
+		results = append(results, m.finishTurn(turnDetectStopReason)...)
+	}
 	return results
 }
 
+// beginTurn 主会话中的用户输入开启新回合
+func (m *JSONLMonitor) beginTurn() {
+	if m.turn != nil && m.mainLine {
+		m.turn.begin(time.Now())
+	}
+}
+
+// finishTurn 按判定方式结束主会话的当前回合，返回需要推送的 ContentTurnDone
+func (m *JSONLMonitor) finishTurn(how string) []ParsedContent {
+	if m.turn == nil || !m.mainLine {
+		return nil
+	}
+	if done, ok := m.turn.finish(how, time.Now()); ok {
+		return []ParsedContent{done}
+	}
+	return nil
+}
+
+// accumulateCodexUsage 从 codex 的 token_count 事件和对话行中累计用量
+func (m *JSONLMonitor) accumulateCodexUsage(raw map[string]json.RawMessage) {
+	var msgType, role string
+	if t, ok := raw["type"]; ok {
+		json.Unmarshal(t, &msgType)
+	}
+	if r, ok := raw["role"]; ok {
+		json.Unmarshal(r, &role)
+	}
+
+	switch {
+	case role == "user":
+		m.usage.Turns++
+	case msgType == "function_call":
+		m.usage.ToolCalls++
+	case msgType == "event_msg":
+		var payload struct {
+			Type string `json:"type"`
+			Info *struct {
+				Total struct {
+					InputTokens       int64 `json:"input_tokens"`
+					CachedInputTokens int64 `json:"cached_input_tokens"`
+					OutputTokens      int64 `json:"output_tokens"`
+				} `json:"total_token_usage"`
+			} `json:"info"`
+		}
+		if p, ok := raw["payload"]; !ok || json.Unmarshal(p, &payload) != nil {
+			return
+		}
+		switch payload.Type {
+		case "token_count":
+			if payload.Info != nil {
+				// total_token_usage 已是会话累计值，直接覆盖
+				m.usage.InputTokens = payload.Info.Total.InputTokens
+				m.usage.CacheReadTokens = payload.Info.Total.CachedInputTokens
+				m.usage.OutputTokens = payload.Info.Total.OutputTokens
+			}
+		case "user_message":
+			m.usage.Turns++
+		case "exec_command_begin", "patch_apply_begin":
+			m.usage.ToolCalls++
+		}
+	case msgType == "response_item" && !m.codexEvents:
+		// 只写 response_item 的旧版本 CLI
+		var payload struct {
+			Type string `json:"type"`
+			Role string `json:"role"`
+		}
+		if p, ok := raw["payload"]; ok && json.Unmarshal(p, &payload) == nil {
+			switch {
+			case payload.Type == "message" && payload.Role == "user":
+				m.usage.Turns++
+			case payload.Type == "function_call":
+				m.usage.ToolCalls++
+			}
+		}
+	}
+}
+
 func parseCodexLine(raw map[string]json.RawMessage) string {
 	var msgType string
 	if t, ok := raw["type"]; ok {
@@ -495,6 +999,11 @@ func (m *JSONLMonitor) listExistingJSONLFiles() map[string]struct{} {
 	return files
 }
 
+// LatestLog 返回日志目录中最近写入的会话文件，用于绑定已在运行的窗口时定位其会话；没有时返回空
+func LatestLog(dir string, bt backend.Type) string {
+	return findLatestFile(dir, bt)
+}
+
 func findLatestFile(dir string, bt backend.Type) string {
 	var latest string
 	var latestTime time.Time
@@ -557,22 +1066,6 @@ func (m *JSONLMonitor) addDirWatch(watcher *fsnotify.Watcher, dir string) {
 	slog.Debug("watching dir", "key", m.topicKey, "dir", dir)
 }
 
-func (m *JSONLMonitor) checkDateChange(watcher *fsnotify.Watcher) {
-	today := time.Now()
-	todayDir := filepath.Join(
-		filepath.Dir(filepath.Dir(filepath.Dir(m.logDir))),
-		today.Format("2006"),
-		today.Format("01"),
-		today.Format("02"),
-	)
-	if _, ok := m.watchedPaths[todayDir]; ok {
-		return
-	}
-	if _, err := os.Stat(todayDir); err == nil {
-		m.addDirWatch(watcher, todayDir)
-	}
-}
-
 func truncate(s string, maxLen int) string {
 	s = strings.ReplaceAll(s, "\n", "\\n")
 	if len(s) > maxLen {
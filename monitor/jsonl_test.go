@@ -0,0 +1,169 @@
+package monitor
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/user/tgmux/backend"
+	"github.com/user/tgmux/state"
+)
+
+// testMonitor 跟踪单个日志文件的监控，收集推送的内容
+type testMonitor struct {
+	*JSONLMonitor
+	store *state.Store
+	out   []ParsedContent
+}
+
+func newTestMonitor(t *testing.T, bt backend.Type, path string) *testMonitor {
+	t.Helper()
+	store := state.New(filepath.Join(t.TempDir(), "state.json"), 10)
+	t.Cleanup(store.Close)
+	tm := &testMonitor{store: store}
+	tm.JSONLMonitor = NewJSONLMonitor("k", bt, filepath.Dir(path), 0, path, state.Usage{}, func(_ string, c ParsedContent) {
+		tm.out = append(tm.out, c)
+	}, store)
+	return tm
+}
+
+// read 读取新增内容，返回本次推送的内容
+func (tm *testMonitor) read() []ParsedContent {
+	before := len(tm.out)
+	tm.mu.Lock()
+	tm.readIncremental(tm.mainFile)
+	tm.mu.Unlock()
+	tm.deliver()
+	return tm.out[before:]
+}
+
+func (tm *testMonitor) offset() int64 {
+	return tm.trackedFiles[tm.mainFile].byteOffset
+}
+
+func appendFile(t *testing.T, path, s string) {
+	t.Helper()
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if _, err := f.WriteString(s); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func texts(contents []ParsedContent) []string {
+	var s []string
+	for _, c := range contents {
+		if c.Type == ContentText {
+			s = append(s, c.Text)
+		}
+	}
+	return s
+}
+
+// TestReadIncrementalPartialLine 一行分两次写入时，offset 不越过未写完的行
+func TestReadIncrementalPartialLine(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "session.jsonl")
+	first := assistantLine(t, "first") + "\n"
+	second := assistantLine(t, "second") + "\n"
+	appendFile(t, path, first+second[:len(second)/2])
+
+	tm := newTestMonitor(t, backend.TypeClaude, path)
+	if got := texts(tm.read()); len(got) != 1 || got[0] != "first" {
+		t.Fatalf("first read = %q", got)
+	}
+	if tm.offset() != int64(len(first)) {
+		t.Fatalf("offset = %d, want %d (end of the complete line)", tm.offset(), len(first))
+	}
+	if off, ok := tm.store.GetOffset("k"); !ok || off.ByteOffset != int64(len(first)) {
+		t.Errorf("stored offset = %+v", off)
+	}
+
+	// 再次读取时半行仍未写完
+	if got := tm.read(); len(got) != 0 {
+		t.Fatalf("partial line parsed: %+v", got)
+	}
+
+	appendFile(t, path, second[len(second)/2:])
+	if got := texts(tm.read()); len(got) != 1 || got[0] != "second" {
+		t.Fatalf("second read = %q", got)
+	}
+	if tm.offset() != int64(len(first)+len(second)) {
+		t.Errorf("offset = %d, want %d", tm.offset(), len(first)+len(second))
+	}
+}
+
+// TestReadIncrementalTruncated 文件被截断重写后，默认从头重读，on_truncate: skip 时跳到新的末尾
+func TestReadIncrementalTruncated(t *testing.T) {
+	for _, skip := range []bool{false, true} {
+		path := filepath.Join(t.TempDir(), "session.jsonl")
+		appendFile(t, path, assistantLine(t, "old one")+"\n"+assistantLine(t, "old two")+"\n")
+		tm := newTestMonitor(t, backend.TypeClaude, path)
+		tm.truncSkip = skip
+		if got := texts(tm.read()); len(got) != 2 {
+			t.Fatalf("initial read = %q", got)
+		}
+
+		rewritten := assistantLine(t, "new") + "\n"
+		if err := os.WriteFile(path, []byte(rewritten), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		got := texts(tm.read())
+		if skip {
+			if len(got) != 0 {
+				t.Errorf("skip: re-read %q after truncation", got)
+			}
+		} else if len(got) != 1 || got[0] != "new" {
+			t.Errorf("reread: got %q, want [new]", got)
+		}
+		if tm.offset() != int64(len(rewritten)) {
+			t.Errorf("skip=%v: offset = %d, want %d", skip, tm.offset(), len(rewritten))
+		}
+
+		appendFile(t, path, assistantLine(t, "later")+"\n")
+		if got := texts(tm.read()); len(got) != 1 || got[0] != "later" {
+			t.Errorf("skip=%v: after truncation read %q", skip, got)
+		}
+	}
+}
+
+// copyFixture 将 testdata 中的日志复制到临时目录（监控会写入 offset，不直接读取源文件）
+func copyFixture(t *testing.T, name string) string {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// TestDeliverOutsideLock handler 在 m.mu 之外调用：推送阻塞时 Usage / MainFile 不被卡住
+func TestDeliverOutsideLock(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "session.jsonl")
+	appendFile(t, path, assistantLine(t, "first")+"\n"+assistantLine(t, "second")+"\n")
+
+	tm := newTestMonitor(t, backend.TypeClaude, path)
+	var locked []bool
+	tm.handler = func(_ string, c ParsedContent) {
+		free := tm.mu.TryLock()
+		if free {
+			tm.mu.Unlock()
+		}
+		locked = append(locked, !free)
+	}
+	tm.read()
+	if len(locked) != 2 {
+		t.Fatalf("handler called %d times, want 2", len(locked))
+	}
+	for i, l := range locked {
+		if l {
+			t.Errorf("handler %d called with m.mu held", i)
+		}
+	}
+}
@@ -0,0 +1,93 @@
+package monitor
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io"
+)
+
+const (
+	// largeLineBytes 超过该长度的行解析后会截断提取出的文本
+	largeLineBytes = 1 << 20
+	// maxLineBytes 单行读取上限，超出的行无法完整解析，整行跳过
+	maxLineBytes = 64 << 20
+	// maxContentBytes 超长行中单条内容文本保留的长度
+	maxContentBytes = 64 << 10
+)
+
+// errIncompleteLine 文件末尾的行还没有写完（没有换行符）
+var errIncompleteLine = errors.New("incomplete line")
+
+// readLine 读取一整行（不含换行符），n 为消耗的字节数。
+// 超过 maxLineBytes 的行读到换行为止但只保留前 maxLineBytes 字节，oversized 为 true；
+// 到达文件末尾仍没有换行时返回已读到的部分与 errIncompleteLine，跟踪写入的调用方不应推进 offset
+func readLine(r *bufio.Reader) (line []byte, n int64, oversized bool, err error) {
+	for {
+		chunk, err := r.ReadSlice('\n')
+		n += int64(len(chunk))
+		if len(line)+len(chunk) <= maxLineBytes {
+			line = append(line, chunk...)
+		} else {
+			oversized = true
+		}
+		switch {
+		case err == nil:
+			return bytes.TrimRight(line, "\r\n"), n, oversized, nil
+		case errors.Is(err, bufio.ErrBufferFull):
+			continue
+		case n > 0:
+			return bytes.TrimRight(line, "\r\n"), n, oversized, errIncompleteLine
+		default:
+			return nil, 0, false, err
+		}
+	}
+}
+
+// forEachLine 依次读取已写完的文件的每一行（末行可以没有换行符），fn 返回 false 时停止。
+// 与 readLine 相同的上限：超过 maxLineBytes 的行无法完整解析，整行跳过
+func forEachLine(r io.Reader, fn func(line []byte) bool) error {
+	reader := bufio.NewReaderSize(r, 256*1024)
+	for {
+		line, _, oversized, err := readLine(reader)
+		if err != nil && !errors.Is(err, errIncompleteLine) {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return err
+		}
+		if !oversized && len(line) > 0 && !fn(line) {
+			return nil
+		}
+		if err != nil {
+			return nil
+		}
+	}
+}
+
+// truncateContents 截断超长行中提取出的文本，保留记录本身
+func truncateContents(contents []ParsedContent) bool {
+	truncated := false
+	for i := range contents {
+		if len(contents[i].Text) > maxContentBytes {
+			contents[i].Text = truncateBytes(contents[i].Text, maxContentBytes) + "…"
+			truncated = true
+		}
+		if len(contents[i].Output) > maxContentBytes {
+			contents[i].Output = truncateBytes(contents[i].Output, maxContentBytes) + "…"
+			truncated = true
+		}
+	}
+	return truncated
+}
+
+// truncateBytes 按字节截断且不切断 UTF-8 字符
+func truncateBytes(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	for n > 0 && n < len(s) && s[n]&0xC0 == 0x80 {
+		n--
+	}
+	return s[:n]
+}
@@ -0,0 +1,120 @@
+package monitor
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/user/tgmux/backend"
+)
+
+func assistantLine(t *testing.T, text string) string {
+	t.Helper()
+	b, err := json.Marshal(map[string]any{
+		"type":      "assistant",
+		"timestamp": "2026-01-02T03:04:05Z",
+		"message": map[string]any{
+			"role":    "assistant",
+			"content": []map[string]any{{"type": "text", "text": text}},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(b)
+}
+
+// writeHugeSession 写一个中间夹着 5MB 单行的 claude 会话文件
+func writeHugeSession(t *testing.T) string {
+	t.Helper()
+	huge := "needle " + strings.Repeat("x", 5*1024*1024)
+	lines := []string{
+		assistantLine(t, "before"),
+		assistantLine(t, huge),
+		assistantLine(t, "after needle"),
+	}
+	path := filepath.Join(t.TempDir(), "session.jsonl")
+	if err := os.WriteFile(path, []byte(strings.Join(lines, "\n")+"\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestReadRecentHugeLine(t *testing.T) {
+	path := writeHugeSession(t)
+	recent, err := ReadRecent(path, backend.TypeClaude, 10)
+	if err != nil {
+		t.Fatalf("ReadRecent: %v", err)
+	}
+	if len(recent) != 3 {
+		t.Fatalf("got %d entries, want 3", len(recent))
+	}
+	if recent[0].Text != "before" || recent[2].Text != "after needle" {
+		t.Errorf("unexpected entries around the huge line: %q, %q", recent[0].Text, recent[2].Text)
+	}
+	if len(recent[1].Text) > maxContentBytes+64 {
+		t.Errorf("huge entry not truncated: %d bytes", len(recent[1].Text))
+	}
+}
+
+func TestWriteTranscriptHugeLine(t *testing.T) {
+	path := writeHugeSession(t)
+	var buf bytes.Buffer
+	if err := WriteTranscript(&buf, path, backend.TypeClaude, TranscriptText, false); err != nil {
+		t.Fatalf("WriteTranscript: %v", err)
+	}
+	out := buf.String()
+	if !strings.Contains(out, "before") || !strings.Contains(out, "after needle") {
+		t.Errorf("transcript lost entries around the huge line")
+	}
+	if !strings.Contains(out, strings.Repeat("x", 1024)) {
+		t.Errorf("transcript lost the huge entry")
+	}
+}
+
+func TestSearchHugeLine(t *testing.T) {
+	path := writeHugeSession(t)
+	hits, err := SearchSession(path, backend.TypeClaude, "needle", 10)
+	if err != nil {
+		t.Fatalf("SearchSession: %v", err)
+	}
+	if len(hits) != 2 {
+		t.Fatalf("got %d hits, want 2", len(hits))
+	}
+}
+
+func TestForEachLineLastLineWithoutNewline(t *testing.T) {
+	var got []string
+	err := forEachLine(strings.NewReader("a\r\n\nb\nc"), func(line []byte) bool {
+		got = append(got, string(line))
+		return true
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(got, ",") != "a,b,c" {
+		t.Errorf("got %q", got)
+	}
+}
+
+func TestReadLineSkipsOversized(t *testing.T) {
+	input := strings.Repeat("y", maxLineBytes+10) + "\nok\n"
+	r := bufio.NewReader(strings.NewReader(input))
+	line, n, oversized, err := readLine(r)
+	if err != nil || !oversized || n != int64(maxLineBytes+11) {
+		t.Fatalf("oversized line: len=%d n=%d oversized=%v err=%v", len(line), n, oversized, err)
+	}
+	line, _, oversized, err = readLine(r)
+	if err != nil || oversized || string(line) != "ok" {
+		t.Fatalf("next line: %q oversized=%v err=%v", line, oversized, err)
+	}
+	if _, _, _, err = readLine(r); !errors.Is(err, io.EOF) {
+		t.Fatalf("want EOF, got %v", err)
+	}
+}
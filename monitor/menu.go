@@ -0,0 +1,115 @@
+package monitor
+
+import (
+	"strconv"
+	"strings"
+)
+
+// menuMarkers 可选项行开头的标记
+var menuMarkers = []string{"❯", "●", "○", "◉"}
+
+// menuContextLines 第一个选项上方作为问题保留的非空行数
+const menuContextLines = 3
+
+// MenuOption 交互菜单中的一个选项
+type MenuOption struct {
+	Label  string
+	Number int // 选项开头的序号（"2. ..."），没有时为 0
+}
+
+// Menu 从终端内容中解析出的选项块
+type Menu struct {
+	Text    string // 问题与选项块，已去掉边框
+	Options []MenuOption
+	Cursor  int // 高亮选项（❯）的下标，没有标记时为 0
+}
+
+// ParseMenu 提取终端内容中最后一个选项块：选项行以选择标记或序号（"1." / "1)"）开头，
+// 选项之间的行（说明、折行）保留在 Text 中但不作为选项；没有选项时返回 false
+func ParseMenu(pane string) (Menu, bool) {
+	lines := strings.Split(strings.TrimRight(pane, "\n "), "\n")
+	for i, line := range lines {
+		lines[i] = stripBox(line)
+	}
+
+	// 自下而上查找最后一个选项块，间隔超过 2 行即结束
+	first, last := -1, -1
+	for i := len(lines) - 1; i >= 0; i-- {
+		if _, ok := parseOption(lines[i]); !ok {
+			if last >= 0 && first-i > 2 {
+				break
+			}
+			continue
+		}
+		if last < 0 {
+			last = i
+		}
+		first = i
+	}
+	if first < 0 {
+		return Menu{}, false
+	}
+
+	var m Menu
+	for i := first; i <= last; i++ {
+		opt, ok := parseOption(lines[i])
+		if !ok {
+			continue
+		}
+		if strings.HasPrefix(lines[i], "❯") {
+			m.Cursor = len(m.Options)
+		}
+		m.Options = append(m.Options, opt)
+	}
+
+	start := first
+	for start > 0 && first-start < menuContextLines && lines[start-1] != "" {
+		start--
+	}
+	m.Text = strings.Join(lines[start:last+1], "\n")
+	return m, true
+}
+
+// stripBox 去掉行两端的空白与边框字符，纯边框行变为空
+func stripBox(line string) string {
+	line = strings.TrimSpace(line)
+	if strings.Trim(line, "╭╮╰╯─│┌┐└┘ ") == "" {
+		return ""
+	}
+	line = strings.TrimPrefix(line, "│")
+	line = strings.TrimSuffix(line, "│")
+	return strings.TrimSpace(line)
+}
+
+// parseOption 识别单个选项行
+func parseOption(line string) (MenuOption, bool) {
+	rest := line
+	marked := false
+	// 光标可能位于单选标记之前（"❯ ● opus"），因此重复去掉标记
+	for stripped := true; stripped; {
+		stripped = false
+		for _, marker := range menuMarkers {
+			if strings.HasPrefix(rest, marker) {
+				rest = strings.TrimSpace(strings.TrimPrefix(rest, marker))
+				marked, stripped = true, true
+			}
+		}
+	}
+
+	var opt MenuOption
+	digits := 0
+	for digits < len(rest) && rest[digits] >= '0' && rest[digits] <= '9' {
+		digits++
+	}
+	if digits > 0 && digits < len(rest) && (rest[digits] == '.' || rest[digits] == ')') {
+		opt.Number, _ = strconv.Atoi(rest[:digits])
+		rest = strings.TrimSpace(rest[digits+1:])
+	} else if !marked {
+		return MenuOption{}, false
+	}
+	if rest == "" {
+		return MenuOption{}, false
+	}
+	opt.Label = rest
+	return opt, true
+}
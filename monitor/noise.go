@@ -0,0 +1,127 @@
+package monitor
+
+import (
+	"log/slog"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+)
+
+// progressForget 进度行的归一化形式超过该时间未再出现即遗忘
+const progressForget = time.Minute
+
+// defaultProgressPatterns 进度字符：百分比、braille 旋转符、进度条、大小/速率、计数与剩余时间
+var defaultProgressPatterns = []string{
+	`\d+(\.\d+)?\s?%`,
+	`[⠀-⣿]`,
+	`[░▒▓█▏▎▍▌▋▊▉]+`,
+	`\[[#=>\-. ]*\]`,
+	`#{3,}`,
+	`\d+(\.\d+)?\s?[kKMGT]i?B(/s)?`,
+	`\d+/\d+`,
+	`\d+:\d{2}(:\d{2})?`,
+}
+
+// noiseFilter 识别窗口输出中的进度条/旋转符刷新：与最近推送过的行只有进度字符不同的行
+// 视为同一条进度，每条进度最多每 every 推送一次，期间只保留最新的一行
+type noiseFilter struct {
+	patterns []*regexp.Regexp
+	every    time.Duration
+	seen     map[string]*progressLine // 归一化形式 → 最近状态
+}
+
+type progressLine struct {
+	sentAt  time.Time // 最近一次推送
+	seenAt  time.Time // 最近一次出现
+	pending string    // 节流期间最新的、尚未推送的一行
+}
+
+// newNoiseFilter 使用默认规则加上 extra 创建过滤器，无法编译的规则忽略；every <= 0 时返回 nil（不过滤）
+func newNoiseFilter(extra []string, every time.Duration) *noiseFilter {
+	if every <= 0 {
+		return nil
+	}
+	f := &noiseFilter{every: every, seen: make(map[string]*progressLine)}
+	for _, p := range append(append([]string(nil), defaultProgressPatterns...), extra...) {
+		re, err := regexp.Compile(p)
+		if err != nil {
+			slog.Warn("invalid progress pattern, ignored", "pattern", p, "error", err)
+			continue
+		}
+		f.patterns = append(f.patterns, re)
+	}
+	return f
+}
+
+// progressKey 去掉进度字符后的归一化形式，不含进度字符的行返回 false
+func (f *noiseFilter) progressKey(line string) (string, bool) {
+	key := line
+	for _, re := range f.patterns {
+		key = re.ReplaceAllString(key, "")
+	}
+	if key == line {
+		return "", false
+	}
+	return strings.Join(strings.Fields(key), " "), true
+}
+
+// split 将新增的行分为普通文本与进度更新。进度行首次出现按普通文本推送，
+// 之后的刷新每 every 最多一条；节流中积压的最新进度在到期后随下一次调用返回（lines 可为空）
+func (f *noiseFilter) split(lines []string, now time.Time) (text, progress []string) {
+	if f == nil {
+		return lines, nil
+	}
+	for _, line := range lines {
+		key, ok := f.progressKey(line)
+		if !ok {
+			text = append(text, line)
+			continue
+		}
+		pl, known := f.seen[key]
+		if !known || now.Sub(pl.seenAt) > progressForget {
+			f.seen[key] = &progressLine{sentAt: now, seenAt: now}
+			text = append(text, line)
+			continue
+		}
+		pl.seenAt = now
+		pl.pending = line
+	}
+
+	for key, pl := range f.seen {
+		if pl.pending != "" && now.Sub(pl.sentAt) >= f.every {
+			progress = append(progress, pl.pending)
+			pl.pending = ""
+			pl.sentAt = now
+		}
+		if pl.pending == "" && now.Sub(pl.seenAt) > progressForget {
+			delete(f.seen, key)
+		}
+	}
+	slices.Sort(progress)
+	return text, progress
+}
+
+// hasPending 是否有节流中尚未推送的进度
+func (f *noiseFilter) hasPending() bool {
+	if f == nil {
+		return false
+	}
+	for _, pl := range f.seen {
+		if pl.pending != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// emitFiltered 经过滤器推送新增的行：普通文本为 ContentText，进度刷新为 ContentProgress
+func emitFiltered(f *noiseFilter, topicKey string, lines []string, handler OutputHandler) {
+	text, progress := f.split(lines, time.Now())
+	if len(text) > 0 {
+		handler(topicKey, ParsedContent{Type: ContentText, Text: strings.Join(text, "\n")})
+	}
+	if len(progress) > 0 {
+		handler(topicKey, ParsedContent{Type: ContentProgress, Text: strings.Join(progress, "\n")})
+	}
+}
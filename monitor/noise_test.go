@@ -0,0 +1,112 @@
+package monitor
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// replayTranscript 以每行 frame 的间隔把终端输出逐行送入过滤器，最后等待节流到期取出积压的进度
+func replayTranscript(t *testing.T, f *noiseFilter, name string, frame time.Duration) (input, text, progress []string) {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	input = strings.Split(strings.TrimRight(string(data), "\n"), "\n")
+	now := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	for _, line := range input {
+		tx, pr := f.split([]string{line}, now)
+		text, progress = append(text, tx...), append(progress, pr...)
+		now = now.Add(frame)
+	}
+	tx, pr := f.split(nil, now.Add(f.every))
+	return input, append(text, tx...), append(progress, pr...)
+}
+
+func TestNoiseFilterTranscripts(t *testing.T) {
+	tests := []struct {
+		name        string
+		maxProgress int      // 进度刷新推送的上限
+		keep        []string // 必须原样作为普通文本推送的行
+		final       []string // 每条进度最后推送的一行应是它的最终状态
+	}{
+		{
+			name:        "npm_install.txt",
+			maxProgress: 12,
+			keep: []string{
+				"$ npm install",
+				"added 312 packages, and audited 313 packages in 14s",
+				"found 0 vulnerabilities",
+			},
+			final: []string{"⸨█████████████████░⸩ ⠋ reify:typescript: timing reifyNode:node_modules/typescript"},
+		},
+		{
+			name:        "docker_pull.txt",
+			maxProgress: 15,
+			keep: []string{
+				"16: Pulling from library/postgres",
+				"a2abf6c4d29d: Pulling fs layer",
+				"a2abf6c4d29d: Pull complete",
+				"8f3b2a7c1d90: Download complete",
+				"Status: Downloaded newer image for postgres:16",
+			},
+			final: []string{
+				"8f3b2a7c1d90: Downloading [==================================================]  112.6MB/112.6MB",
+				"8f3b2a7c1d90: Extracting [==================================================]  112.6MB/112.6MB",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newNoiseFilter(nil, 2*time.Second)
+			input, text, progress := replayTranscript(t, f, tt.name, 100*time.Millisecond)
+
+			if len(text)+len(progress) > len(input)/3 {
+				t.Errorf("%d input lines produced %d text and %d progress lines", len(input), len(text), len(progress))
+			}
+			if len(progress) > tt.maxProgress {
+				t.Errorf("%d progress updates, want at most %d", len(progress), tt.maxProgress)
+			}
+			for _, line := range tt.keep {
+				if !containsLine(text, line) {
+					t.Errorf("line %q was not pushed as text", line)
+				}
+			}
+			for _, line := range tt.final {
+				if !containsLine(progress, line) && !containsLine(text, line) {
+					t.Errorf("final progress %q was never pushed", line)
+				}
+			}
+			if f.hasPending() {
+				t.Errorf("progress still pending after the throttle expired")
+			}
+		})
+	}
+}
+
+func TestNoiseFilterExtraPatterns(t *testing.T) {
+	f := newNoiseFilter([]string{`step \d+`, `(`}, time.Second)
+	if len(f.patterns) != len(defaultProgressPatterns)+1 {
+		t.Fatalf("invalid pattern not skipped: %d patterns", len(f.patterns))
+	}
+	now := time.Now()
+	text, _ := f.split([]string{"running step 1", "running step 2", "running step 3"}, now)
+	if len(text) != 1 {
+		t.Errorf("custom pattern not applied: %q", text)
+	}
+	if newNoiseFilter(nil, 0) != nil {
+		t.Errorf("zero interval should disable the filter")
+	}
+}
+
+func containsLine(lines []string, want string) bool {
+	for _, l := range lines {
+		if l == want {
+			return true
+		}
+	}
+	return false
+}
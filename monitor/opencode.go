@@ -0,0 +1,381 @@
+package monitor
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/user/tgmux/state"
+)
+
+// opencode 将会话拆成 storage 目录下的多个 JSON 文件：
+//
+//	session/<projectID>/<sessionID>.json  会话信息，directory 为工作目录
+//	message/<sessionID>/<messageID>.json  消息，role 与 time.created / time.completed
+//	part/<messageID>/<partID>.json        消息片段：text、reasoning、tool 等
+//
+// ID 按时间递增，文件名排序即为先后顺序
+
+// opencodeRescanInterval 查找新会话（启动后首次对话、/new）的间隔
+const opencodeRescanInterval = 5 * time.Second
+
+type opencodeSession struct {
+	ID        string `json:"id"`
+	ParentID  string `json:"parentID"` // 子任务会话，与主会话共用工作目录
+	Directory string `json:"directory"`
+	Time      struct {
+		Created int64 `json:"created"`
+	} `json:"time"`
+}
+
+type opencodeMessage struct {
+	ID   string `json:"id"`
+	Role string `json:"role"`
+	Time struct {
+		Created   int64 `json:"created"`
+		Completed int64 `json:"completed"`
+	} `json:"time"`
+}
+
+type opencodePart struct {
+	ID        string `json:"id"`
+	Type      string `json:"type"`
+	Text      string `json:"text"`
+	Synthetic bool   `json:"synthetic"`
+	CallID    string `json:"callID"`
+	Tool      string `json:"tool"`
+	State     struct {
+		Status string                 `json:"status"` // pending / running / completed / error
+		Input  map[string]interface{} `json:"input"`
+		Output string                 `json:"output"`
+		Error  string                 `json:"error"`
+	} `json:"state"`
+	Time struct {
+		End int64 `json:"end"`
+	} `json:"time"`
+}
+
+// part 已推送到的阶段
+const (
+	partToolUse = 1 // 已推送工具调用，等待结果
+	partDone    = 2 // 已全部推送
+)
+
+// opencodeToolNames opencode 工具名 → claude 工具名，沿用其摘要与结果统计格式
+var opencodeToolNames = map[string]string{
+	"bash":      "Bash",
+	"read":      "Read",
+	"write":     "Write",
+	"edit":      "Edit",
+	"multiedit": "Edit",
+	"patch":     "Patch",
+	"glob":      "Glob",
+	"grep":      "Grep",
+	"list":      "LS",
+	"webfetch":  "WebFetch",
+	"websearch": "WebSearch",
+	"task":      "Task",
+	"todowrite": "TodoWrite",
+	"todoread":  "TodoRead",
+}
+
+// OpencodeMonitor 轮询 opencode 的会话存储：跟随工作目录为项目目录的最新会话，
+// 按顺序推送助手消息的文本、推理与工具调用
+type OpencodeMonitor struct {
+	topicKey     string
+	storageDir   string
+	projectPath  string
+	startMs      int64 // 监控开始时间，此前未更新的会话不认领
+	pollInterval time.Duration
+	handler      OutputHandler
+	store        *state.Store
+	cancel       context.CancelFunc
+
+	sessionFile string
+	session     opencodeSession
+	skipBefore  int64                     // 早于该时间创建的消息不推送（新认领的会话中已有的对话）
+	msgDone     int                       // 会话中已全部推送的前导消息数，随 offset 持久化
+	parts       map[string]map[string]int // messageID → partID → 已推送阶段
+	rescanAt    time.Time
+}
+
+// NewOpencodeMonitor offset 记录了会话文件时从其已推送的消息数继续
+func NewOpencodeMonitor(topicKey, storageDir, projectPath string, offset state.Offset, startTime time.Time, pollInterval time.Duration, handler OutputHandler, store *state.Store) *OpencodeMonitor {
+	return &OpencodeMonitor{
+		topicKey:     topicKey,
+		storageDir:   storageDir,
+		projectPath:  filepath.Clean(projectPath),
+		startMs:      startTime.UnixMilli(),
+		pollInterval: pollInterval,
+		handler:      handler,
+		store:        store,
+		sessionFile:  offset.File,
+		msgDone:      offset.MessageCount,
+		parts:        make(map[string]map[string]int),
+	}
+}
+
+func (m *OpencodeMonitor) Start(ctx context.Context) error {
+	if _, err := os.Stat(m.storageDir); err != nil {
+		return fmt.Errorf("opencode storage dir not found: %s", m.storageDir)
+	}
+	if m.sessionFile != "" && readJSONFile(m.sessionFile, &m.session) != nil {
+		m.sessionFile, m.msgDone = "", 0
+	}
+	ctx, m.cancel = context.WithCancel(ctx)
+	go m.loop(ctx)
+	return nil
+}
+
+func (m *OpencodeMonitor) Stop() {
+	if m.cancel != nil {
+		m.cancel()
+	}
+}
+
+func (m *OpencodeMonitor) loop(ctx context.Context) {
+	ticker := time.NewTicker(m.pollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		if now := time.Now(); now.After(m.rescanAt) {
+			m.locate()
+			m.rescanAt = now.Add(opencodeRescanInterval)
+		}
+		if m.sessionFile != "" {
+			m.poll()
+		}
+	}
+}
+
+// locate 查找监控开始后有更新、工作目录为项目目录的最新主会话，比当前会话新时切换过去
+func (m *OpencodeMonitor) locate() {
+	files, _ := filepath.Glob(filepath.Join(m.storageDir, "session", "*", "*.json"))
+	best, bestFile := m.session, ""
+	for _, f := range files {
+		if f == m.sessionFile {
+			continue
+		}
+		info, err := os.Stat(f)
+		if err != nil || info.ModTime().UnixMilli() < m.startMs {
+			continue
+		}
+		var s opencodeSession
+		if readJSONFile(f, &s) != nil || s.ParentID != "" || filepath.Clean(s.Directory) != m.projectPath {
+			continue
+		}
+		if s.Time.Created > best.Time.Created {
+			best, bestFile = s, f
+		}
+	}
+	if bestFile == "" {
+		return
+	}
+	slog.Info("opencode session locked", "key", m.topicKey, "session", best.ID)
+	m.sessionFile, m.session = bestFile, best
+	m.skipBefore, m.msgDone = m.startMs, 0
+	m.parts = make(map[string]map[string]int)
+	m.saveOffset()
+}
+
+// poll 推送会话中尚未推送的内容；前导消息全部推送后推进 msgDone，之后不再读取
+func (m *OpencodeMonitor) poll() {
+	names := jsonFiles(filepath.Join(m.storageDir, "message", m.session.ID))
+	leading := true
+	advanced := false
+	for i := m.msgDone; i < len(names); i++ {
+		var msg opencodeMessage
+		if readJSONFile(names[i], &msg) != nil {
+			// 正在写入，下一轮再读
+			break
+		}
+		if m.pollMessage(msg) && leading {
+			m.msgDone = i + 1
+			delete(m.parts, msg.ID)
+			advanced = true
+		} else {
+			leading = false
+		}
+	}
+	if advanced {
+		m.saveOffset()
+	}
+}
+
+// pollMessage 按顺序推送消息中可推送的片段，返回消息是否已全部推送
+func (m *OpencodeMonitor) pollMessage(msg opencodeMessage) bool {
+	if msg.Role != "assistant" || msg.Time.Created < m.skipBefore {
+		return true
+	}
+	complete := msg.Time.Completed != 0
+	stages := m.parts[msg.ID]
+	if stages == nil {
+		stages = make(map[string]int)
+		m.parts[msg.ID] = stages
+	}
+	for _, name := range jsonFiles(filepath.Join(m.storageDir, "part", msg.ID)) {
+		var p opencodePart
+		if readJSONFile(name, &p) != nil {
+			return false
+		}
+		if stages[p.ID] == partDone {
+			continue
+		}
+		contents, stage := parseOpencodePart(p, stages[p.ID], complete)
+		stages[p.ID] = stage
+		for _, c := range contents {
+			m.handler(m.topicKey, c)
+		}
+		if stage != partDone {
+			// 保持顺序：等这个片段完成后再推送后面的
+			return false
+		}
+	}
+	return complete
+}
+
+func (m *OpencodeMonitor) saveOffset() {
+	m.store.SetOffset(m.topicKey, state.Offset{File: m.sessionFile, MessageCount: m.msgDone})
+}
+
+// parseOpencodePart 将片段从已推送的 stage 推进，返回要推送的内容与新的阶段；
+// msgComplete 为 true 时消息已结束，未完成的片段也视为结束
+func parseOpencodePart(p opencodePart, stage int, msgComplete bool) ([]ParsedContent, int) {
+	switch p.Type {
+	case "text", "reasoning":
+		if p.Time.End == 0 && !msgComplete {
+			return nil, stage
+		}
+		text := strings.TrimSpace(p.Text)
+		if text == "" || p.Synthetic {
+			return nil, partDone
+		}
+		if p.Type == "reasoning" {
+			return []ParsedContent{{Type: ContentThinking, Text: text}}, partDone
+		}
+		return []ParsedContent{{Type: ContentText, Text: text}}, partDone
+	case "tool":
+		return parseOpencodeTool(p, stage, msgComplete)
+	}
+	// step-start、step-finish、snapshot、patch 等不推送
+	return nil, partDone
+}
+
+// parseOpencodeTool 工具片段：输入就绪（running）时推送调用，完成或出错时推送结果，二者以 callID 配对
+func parseOpencodeTool(p opencodePart, stage int, msgComplete bool) ([]ParsedContent, int) {
+	if p.State.Status == "pending" {
+		if msgComplete {
+			return nil, partDone
+		}
+		return nil, stage
+	}
+	name := opencodeToolName(p.Tool)
+	input := opencodeInput(p.State.Input)
+
+	var results []ParsedContent
+	if stage < partToolUse {
+		if todo, ok := FormatTodoList(input); name == "TodoWrite" && ok {
+			// 清单消息原地更新，无需推送结果
+			return []ParsedContent{{Type: ContentTodo, Text: todo, ToolName: name}}, partDone
+		}
+		results = append(results, ParsedContent{
+			Type:      ContentToolUse,
+			Text:      FormatToolUseSummary(name, input),
+			ToolUseID: p.CallID,
+			ToolName:  name,
+		})
+	}
+	switch p.State.Status {
+	case "completed":
+		results = append(results, ParsedContent{
+			Type:      ContentToolResult,
+			Text:      FormatToolResultStats(strings.TrimRight(p.State.Output, "\n"), name),
+			ToolUseID: p.CallID,
+			Output:    p.State.Output,
+		})
+		return results, partDone
+	case "error":
+		errLine := firstLine(p.State.Error)
+		if len(errLine) > 100 {
+			errLine = errLine[:100] + "…"
+		}
+		results = append(results, ParsedContent{
+			Type:      ContentToolResult,
+			Text:      "  ⎿  Error: " + errLine,
+			ToolUseID: p.CallID,
+			Output:    p.State.Error,
+		})
+		return results, partDone
+	}
+	if msgComplete {
+		// 消息已结束而工具仍在运行（被中断），不再等待结果
+		return results, partDone
+	}
+	return results, partToolUse
+}
+
+func opencodeToolName(tool string) string {
+	if name, ok := opencodeToolNames[tool]; ok {
+		return name
+	}
+	return tool
+}
+
+// opencodeInput 将工具参数的驼峰键（filePath、oldString）转换为 claude 的下划线键
+func opencodeInput(input map[string]interface{}) map[string]interface{} {
+	if input == nil {
+		return nil
+	}
+	converted := make(map[string]interface{}, len(input))
+	for k, v := range input {
+		converted[snakeCase(k)] = v
+	}
+	return converted
+}
+
+func snakeCase(s string) string {
+	var b strings.Builder
+	for i, r := range s {
+		if unicode.IsUpper(r) {
+			if i > 0 {
+				b.WriteByte('_')
+			}
+			r = unicode.ToLower(r)
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// jsonFiles 返回目录中按文件名排序的 .json 文件路径
+func jsonFiles(dir string) []string {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil
+	}
+	var files []string
+	for _, e := range entries {
+		if !e.IsDir() && strings.HasSuffix(e.Name(), ".json") {
+			files = append(files, filepath.Join(dir, e.Name()))
+		}
+	}
+	return files
+}
+
+func readJSONFile(path string, v interface{}) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
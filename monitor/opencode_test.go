@@ -0,0 +1,109 @@
+package monitor
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/user/tgmux/state"
+)
+
+// copyTree 将 testdata 中的目录复制到临时目录，测试中可以修改
+func copyTree(t *testing.T, name string) string {
+	t.Helper()
+	src := filepath.Join("testdata", name)
+	dst := filepath.Join(t.TempDir(), name)
+	err := filepath.WalkDir(src, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, _ := filepath.Rel(src, path)
+		if d.IsDir() {
+			return os.MkdirAll(filepath.Join(dst, rel), 0o755)
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		return os.WriteFile(filepath.Join(dst, rel), data, 0o644)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return dst
+}
+
+func TestOpencodeFixture(t *testing.T) {
+	storage := filepath.Join(copyTree(t, "opencode"), "storage")
+	store := state.New(filepath.Join(t.TempDir(), "state.json"), 10)
+	defer store.Close()
+	var got []ParsedContent
+	start := time.UnixMilli(1767322800000)
+	m := NewOpencodeMonitor("k", storage, "/work/app/", state.Offset{}, start, time.Second, func(_ string, c ParsedContent) {
+		got = append(got, c)
+	}, store)
+
+	// 子任务会话与其他目录的会话不认领
+	m.locate()
+	if m.session.ID != "ses_01" {
+		t.Fatalf("locked session %q, want ses_01", m.session.ID)
+	}
+
+	m.poll()
+	checkContents(t, got, []wantContent{
+		{typ: ContentThinking, text: "I should run go test first."},
+		{typ: ContentToolUse, text: "Bash(go test ./...)", id: "call_1"},
+		{typ: ContentToolResult, text: "  ⎿  Output 1 lines", id: "call_1", output: "ok  \tgithub.com/x/app\t0.01s\n"},
+		{typ: ContentToolUse, text: "Read(/work/app/missing.go)", id: "call_2"},
+		{typ: ContentToolResult, text: "  ⎿  Error: File not found: /work/app/missing.go", id: "call_2", output: "File not found: /work/app/missing.go\nstack"},
+		{typ: ContentTodo, text: "✅ Run tests\n▶️ Fix failures"},
+		{typ: ContentText, text: "Tests pass."},
+		// 仍在进行的消息：工具已开始运行，其后的文本等工具结束后再推送
+		{typ: ContentToolUse, text: "Edit(/work/app/main.go)", id: "call_4"},
+	})
+	if m.msgDone != 2 {
+		t.Errorf("msgDone = %d, want 2", m.msgDone)
+	}
+
+	// 再次轮询不重复推送
+	got = nil
+	m.poll()
+	if len(got) != 0 {
+		t.Fatalf("second poll re-sent %d contents", len(got))
+	}
+
+	// 工具完成、消息结束
+	writeJSON(t, filepath.Join(storage, "part", "msg_03", "prt_01.json"), `{"id":"prt_01","type":"tool","callID":"call_4","tool":"edit","state":{"status":"completed","input":{"filePath":"/work/app/main.go"},"output":""}}`)
+	writeJSON(t, filepath.Join(storage, "message", "ses_01", "msg_03.json"), `{"id":"msg_03","role":"assistant","time":{"created":1767322805100,"completed":1767322806000}}`)
+	m.poll()
+	checkContents(t, got, []wantContent{
+		{typ: ContentToolResult, id: "call_4"},
+		{typ: ContentText, text: "Editing main.go"},
+	})
+	if off, _ := store.GetOffset("k"); off.File != m.sessionFile || off.MessageCount != 3 {
+		t.Errorf("offset = %+v, want all 3 messages done", off)
+	}
+
+	// 从 offset 恢复时跳过已推送的消息
+	got = nil
+	off, _ := store.GetOffset("k")
+	restored := NewOpencodeMonitor("k", storage, "/work/app", off, start, time.Second, func(_ string, c ParsedContent) {
+		got = append(got, c)
+	}, store)
+	if readJSONFile(restored.sessionFile, &restored.session) != nil {
+		t.Fatal("restored session file unreadable")
+	}
+	restored.poll()
+	if len(got) != 0 {
+		t.Errorf("restored monitor re-sent %d contents", len(got))
+	}
+}
+
+func writeJSON(t *testing.T, path, data string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -2,31 +2,51 @@ package monitor
 
 import (
 	"context"
+	"hash/fnv"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/user/tgmux/tmux"
 )
 
-// PaneMonitor 通过 capture-pane 轮询监控 bash 输出
+// paneMaxInterval 窗口内容持续不变时轮询间隔退避的上限
+const paneMaxInterval = 10 * time.Second
+
+// paneCapturer PaneMonitor 读取窗口内容所需的 tmux 操作（*tmux.Manager 实现）
+type paneCapturer interface {
+	CapturePaneJoined(windowID string) (string, error)
+	CapturePaneHistory(windowID string, lines int) (string, error)
+}
+
+// PaneMonitor 通过 capture-pane 轮询监控 bash 输出。
+// 内容不变时轮询间隔按指数退避到 paneMaxInterval，内容变化或有用户输入时恢复为 pollInterval
 type PaneMonitor struct {
 	topicKey     string
 	windowID     string
-	tmuxMgr      *tmux.Manager
+	tmuxMgr      paneCapturer
 	pollInterval time.Duration
 	handler      OutputHandler
 	cancel       context.CancelFunc
 	lastSnapshot string
+	lastHash     uint64
+	noise        *noiseFilter
+	scrollback   int           // 整屏滚过时从滚动历史补回的最大行数，0 为不补
+	interval     atomic.Int64  // 当前实际轮询间隔（纳秒）
+	poke         chan struct{} // 用户输入已转发到窗口
 }
 
 func NewPaneMonitor(topicKey, windowID string, tmuxMgr *tmux.Manager, pollInterval time.Duration, handler OutputHandler) *PaneMonitor {
-	return &PaneMonitor{
+	p := &PaneMonitor{
 		topicKey:     topicKey,
 		windowID:     windowID,
 		tmuxMgr:      tmuxMgr,
 		pollInterval: pollInterval,
 		handler:      handler,
+		poke:         make(chan struct{}, 1),
 	}
+	p.interval.Store(int64(pollInterval))
+	return p
 }
 
 func (p *PaneMonitor) Start(ctx context.Context) error {
@@ -41,40 +61,90 @@ func (p *PaneMonitor) Stop() {
 	}
 }
 
+// Poke 用户输入刚转发到窗口：立即恢复为配置的轮询间隔
+func (p *PaneMonitor) Poke() {
+	select {
+	case p.poke <- struct{}{}:
+	default:
+	}
+}
+
+// Interval 返回当前实际的轮询间隔
+func (p *PaneMonitor) Interval() time.Duration {
+	return time.Duration(p.interval.Load())
+}
+
 func (p *PaneMonitor) loop(ctx context.Context) {
-	ticker := time.NewTicker(p.pollInterval)
-	defer ticker.Stop()
+	timer := time.NewTimer(p.pollInterval)
+	defer timer.Stop()
 
 	// 初始快照
-	if snapshot, err := p.tmuxMgr.CapturePaneClean(p.windowID); err == nil {
+	if snapshot, err := p.tmuxMgr.CapturePaneJoined(p.windowID); err == nil {
 		p.lastSnapshot = snapshot
+		p.lastHash = hashSnapshot(snapshot)
 	}
 
 	for {
 		select {
 		case <-ctx.Done():
 			return
-		case <-ticker.C:
-			p.poll()
+		case <-timer.C:
+			p.interval.Store(int64(nextPaneInterval(p.Interval(), p.pollInterval, p.poll())))
+			timer.Reset(p.Interval())
+		case <-p.poke:
+			p.interval.Store(int64(p.pollInterval))
+			if !timer.Stop() {
+				<-timer.C
+			}
+			timer.Reset(p.pollInterval)
 		}
 	}
 }
 
-func (p *PaneMonitor) poll() {
-	current, err := p.tmuxMgr.CapturePaneClean(p.windowID)
+// nextPaneInterval 内容变化时回到 base，否则翻倍直到 paneMaxInterval
+func nextPaneInterval(cur, base time.Duration, changed bool) time.Duration {
+	if changed || cur < base {
+		return base
+	}
+	return min(cur*2, max(base, paneMaxInterval))
+}
+
+func hashSnapshot(s string) uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(s))
+	return h.Sum64()
+}
+
+// poll 捕获一次窗口内容，返回内容是否变化
+func (p *PaneMonitor) poll() bool {
+	current, err := p.tmuxMgr.CapturePaneJoined(p.windowID)
 	if err != nil {
-		return
+		return false
 	}
-	if current == p.lastSnapshot {
-		return
+	hash := hashSnapshot(current)
+	if hash == p.lastHash {
+		if p.noise.hasPending() {
+			emitFiltered(p.noise, p.topicKey, nil, p.handler)
+		}
+		return false
 	}
 
-	newContent := diffSnapshots(p.lastSnapshot, current)
+	var lines []string
+	if p.scrollback > 0 && scrolledPast(p.lastSnapshot, current) {
+		lines = p.recoverScrollback()
+	}
+	if lines == nil {
+		if newContent := diffSnapshots(p.lastSnapshot, current); newContent != "" {
+			lines = strings.Split(newContent, "\n")
+		}
+	}
 	p.lastSnapshot = current
+	p.lastHash = hash
 
-	if newContent != "" {
-		p.handler(p.topicKey, ParsedContent{Type: ContentText, Text: newContent})
+	if len(lines) > 0 {
+		emitFiltered(p.noise, p.topicKey, lines, p.handler)
 	}
+	return true
 }
 
 // diffSnapshots 对比两个快照，提取新增行
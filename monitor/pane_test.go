@@ -0,0 +1,105 @@
+package monitor
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakePane 代替 tmux 返回窗口内容并统计捕获次数
+type fakePane struct {
+	mu       sync.Mutex
+	content  string
+	captures int
+}
+
+func (f *fakePane) set(content string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.content = content
+}
+
+func (f *fakePane) count() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.captures
+}
+
+func (f *fakePane) CapturePaneJoined(string) (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.captures++
+	return f.content, nil
+}
+
+func (f *fakePane) CapturePaneHistory(string, int) (string, error) {
+	return f.CapturePaneJoined("")
+}
+
+func newFakePaneMonitor(base time.Duration, handler OutputHandler) (*PaneMonitor, *fakePane) {
+	fake := &fakePane{content: "$ "}
+	p := NewPaneMonitor("k", "@1", nil, base, handler)
+	p.tmuxMgr = fake
+	return p, fake
+}
+
+func TestPaneMonitorBackoff(t *testing.T) {
+	const base = time.Second
+	var out []ParsedContent
+	p, fake := newFakePaneMonitor(base, func(_ string, c ParsedContent) { out = append(out, c) })
+	p.lastSnapshot, p.lastHash = fake.content, hashSnapshot(fake.content)
+
+	// 内容不变：1s → 2s → 4s → 8s → 10s 封顶
+	iv := p.Interval()
+	for _, want := range []time.Duration{2 * time.Second, 4 * time.Second, 8 * time.Second, paneMaxInterval, paneMaxInterval} {
+		iv = nextPaneInterval(iv, base, p.poll())
+		if iv != want {
+			t.Fatalf("idle interval = %v, want %v", iv, want)
+		}
+	}
+	if len(out) != 0 {
+		t.Fatalf("unchanged pane produced output: %+v", out)
+	}
+
+	// 内容变化：推送新增行并回到基础间隔
+	fake.set("$ \nbuild ok")
+	if iv = nextPaneInterval(iv, base, p.poll()); iv != base {
+		t.Errorf("interval after change = %v, want %v", iv, base)
+	}
+	if len(out) != 1 || out[0].Text != "build ok" {
+		t.Errorf("output after change = %+v", out)
+	}
+
+	// 基础间隔本身大于上限时不缩短
+	if got := nextPaneInterval(20*time.Second, 20*time.Second, false); got != 20*time.Second {
+		t.Errorf("interval above the cap = %v", got)
+	}
+}
+
+// TestPaneMonitorPoke 用户输入后立即按基础间隔轮询，而不是等待退避后的间隔
+func TestPaneMonitorPoke(t *testing.T) {
+	const base = 10 * time.Millisecond
+	p, fake := newFakePaneMonitor(base, func(string, ParsedContent) {})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	p.Start(ctx)
+
+	deadline := time.Now().Add(5 * time.Second)
+	for p.Interval() < 32*base {
+		if time.Now().After(deadline) {
+			t.Fatalf("interval did not back off: %v", p.Interval())
+		}
+		time.Sleep(base)
+	}
+
+	before := fake.count()
+	p.Poke()
+	deadline = time.Now().Add(8 * base)
+	for fake.count() == before {
+		if time.Now().After(deadline) {
+			t.Fatalf("no capture within %v of a poke (interval %v)", 8*base, p.Interval())
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
@@ -0,0 +1,71 @@
+package monitor
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"testing"
+
+	"github.com/user/tgmux/backend"
+	"github.com/user/tgmux/state"
+)
+
+func claudeLine(t *testing.T, typ string, content ...map[string]any) string {
+	t.Helper()
+	b, err := json.Marshal(map[string]any{
+		"type":    typ,
+		"message": map[string]any{"role": typ, "content": content},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(b)
+}
+
+func TestToolResultClearsPendingTool(t *testing.T) {
+	tests := []struct {
+		name   string
+		tool   string
+		input  map[string]any
+		result map[string]any
+	}{
+		{"ok", "Bash", map[string]any{"command": "ls"}, map[string]any{"type": "tool_result", "tool_use_id": "t1", "content": "a\nb"}},
+		{"error", "Bash", map[string]any{"command": "false"}, map[string]any{"type": "tool_result", "tool_use_id": "t1", "content": "boom", "is_error": true}},
+		{"todo", "TodoWrite", map[string]any{"todos": []map[string]any{{"content": "x", "status": "pending"}}}, map[string]any{"type": "tool_result", "tool_use_id": "t1", "content": "ok"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := state.New(filepath.Join(t.TempDir(), "state.json"), 10)
+			defer store.Close()
+			m := NewJSONLMonitor("k", backend.TypeClaude, t.TempDir(), 0, "", state.Usage{}, nil, store)
+
+			m.parseLine(claudeLine(t, "assistant", map[string]any{"type": "tool_use", "id": "t1", "name": tt.tool, "input": tt.input}))
+			if len(store.PendingTools("k")) != 1 || len(m.pendingTools) != 1 {
+				t.Fatalf("tool_use not recorded")
+			}
+			m.parseLine(claudeLine(t, "user", tt.result))
+			if n := len(store.PendingTools("k")); n != 0 {
+				t.Errorf("store still has %d pending tools", n)
+			}
+			if len(m.pendingTools) != 0 {
+				t.Errorf("monitor still has %d pending tools", len(m.pendingTools))
+			}
+		})
+	}
+}
+
+func TestPrunePendingToolsFollowsStore(t *testing.T) {
+	store := state.New(filepath.Join(t.TempDir(), "state.json"), 10)
+	defer store.Close()
+	m := NewJSONLMonitor("k", backend.TypeClaude, t.TempDir(), 0, "", state.Usage{}, nil, store)
+	m.notePendingTool("t1", "Bash")
+	m.notePendingTool("t2", "Read")
+	store.DeletePendingTool("k", "t1")
+
+	m.prunePendingTools()
+	if _, ok := m.pendingTools["t1"]; ok {
+		t.Errorf("t1 should have been pruned")
+	}
+	if m.pendingTools["t2"] != "Read" {
+		t.Errorf("t2 should be kept")
+	}
+}
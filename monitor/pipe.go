@@ -0,0 +1,203 @@
+package monitor
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/user/tgmux/tmux"
+)
+
+const (
+	// pipeAliveInterval 检查窗口是否仍然存在的间隔
+	pipeAliveInterval = 10 * time.Second
+	// pipeCompactBytes 临时文件超过该大小且已全部读完时清空，避免无限增长
+	pipeCompactBytes = 4 << 20
+)
+
+// PipeMonitor 通过 tmux pipe-pane 将窗口输出写入临时文件并增量读取，
+// 相比 capture-pane 轮询不需要反复调用 tmux，也不会漏掉快速滚动的输出
+type PipeMonitor struct {
+	topicKey     string
+	windowID     string
+	tmuxMgr      *tmux.Manager
+	pollInterval time.Duration
+	handler      OutputHandler
+	cancel       context.CancelFunc
+	path         string
+	offset       int64
+	lastSize     int64 // 上一轮看到的文件大小，不再变化时推送末尾未换行的部分（如 shell 提示符）
+	noise        *noiseFilter
+	cleanupOnce  sync.Once
+}
+
+func NewPipeMonitor(topicKey, windowID string, tmuxMgr *tmux.Manager, pollInterval time.Duration, handler OutputHandler) *PipeMonitor {
+	return &PipeMonitor{
+		topicKey:     topicKey,
+		windowID:     windowID,
+		tmuxMgr:      tmuxMgr,
+		pollInterval: pollInterval,
+		handler:      handler,
+	}
+}
+
+func (p *PipeMonitor) Start(ctx context.Context) error {
+	f, err := os.CreateTemp("", "tgmux-pipe-*.log")
+	if err != nil {
+		return err
+	}
+	p.path = f.Name()
+	f.Close()
+
+	// 关闭上次异常退出遗留的管道，否则 -o 不会打开新的
+	p.tmuxMgr.StopPipePane(p.windowID)
+	if err := p.tmuxMgr.PipePane(p.windowID, p.path); err != nil {
+		os.Remove(p.path)
+		return err
+	}
+
+	ctx, p.cancel = context.WithCancel(ctx)
+	go p.loop(ctx)
+	return nil
+}
+
+func (p *PipeMonitor) Stop() {
+	if p.cancel != nil {
+		p.cancel()
+	}
+	p.cleanup()
+}
+
+// cleanup 关闭 pipe-pane 并删除临时文件，可重复调用
+func (p *PipeMonitor) cleanup() {
+	p.cleanupOnce.Do(func() {
+		p.tmuxMgr.StopPipePane(p.windowID)
+		os.Remove(p.path)
+	})
+}
+
+func (p *PipeMonitor) loop(ctx context.Context) {
+	defer p.cleanup()
+	ticker := time.NewTicker(p.pollInterval)
+	defer ticker.Stop()
+	alive := time.NewTicker(pipeAliveInterval)
+	defer alive.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			p.poll(false)
+		case <-alive.C:
+			if !p.tmuxMgr.IsWindowAlive(p.windowID) {
+				// 窗口已被关闭：推送管道中剩余的输出后退出
+				p.poll(true)
+				slog.Info("pipe monitor: window gone", "key", p.topicKey, "window", p.windowID)
+				return
+			}
+		}
+	}
+}
+
+// poll 读取新增输出并推送；flush 为 true 时末尾未换行的部分也立即推送
+func (p *PipeMonitor) poll(flush bool) {
+	info, err := os.Stat(p.path)
+	if err != nil {
+		return
+	}
+	size := info.Size()
+	if size < p.offset {
+		p.offset = 0
+	}
+	if size == p.offset {
+		p.lastSize = size
+		if p.noise.hasPending() {
+			emitFiltered(p.noise, p.topicKey, nil, p.handler)
+		}
+		return
+	}
+
+	f, err := os.Open(p.path)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+	if _, err := f.Seek(p.offset, io.SeekStart); err != nil {
+		return
+	}
+
+	var lines []string
+	reader := bufio.NewReaderSize(f, 64*1024)
+	for {
+		line, n, _, err := readLine(reader)
+		if errors.Is(err, errIncompleteLine) {
+			// 末尾没有换行：文件自上一轮起不再增长时视为完整（提示符、进度条）
+			if flush || size == p.lastSize {
+				rest := make([]byte, n)
+				if _, err := f.ReadAt(rest, p.offset); err == nil {
+					lines = append(lines, cleanTerminalLine(string(rest)))
+					p.offset += n
+				}
+			}
+			break
+		}
+		if err != nil {
+			break
+		}
+		p.offset += n
+		lines = append(lines, cleanTerminalLine(string(line)))
+	}
+	p.lastSize = size
+
+	// 已全部读完且文件过大：清空重新开始。cat 以追加方式写入，之后的输出会从头写起
+	if p.offset >= pipeCompactBytes && p.offset == size {
+		if err := os.Truncate(p.path, 0); err == nil {
+			p.offset, p.lastSize = 0, 0
+		}
+	}
+
+	var nonEmpty []string
+	for _, l := range lines {
+		if strings.TrimSpace(l) == "" {
+			continue
+		}
+		if len(l) > maxContentBytes {
+			l = truncateBytes(l, maxContentBytes) + "…"
+		}
+		nonEmpty = append(nonEmpty, l)
+	}
+	if len(nonEmpty) > 0 || p.noise.hasPending() {
+		emitFiltered(p.noise, p.topicKey, nonEmpty, p.handler)
+	}
+}
+
+// cleanTerminalLine 将终端原始输出的一行转换为纯文本：去除 ANSI 转义，
+// 回车覆盖之前的内容，退格删除前一个字符，丢弃其余控制字符
+func cleanTerminalLine(s string) string {
+	s = tmux.StripANSI(strings.TrimRight(s, "\r\n"))
+	if i := strings.LastIndexByte(s, '\r'); i >= 0 {
+		s = s[i+1:]
+	}
+	var b strings.Builder
+	for _, r := range s {
+		switch {
+		case r == '\b':
+			out := b.String()
+			if _, size := utf8.DecodeLastRuneInString(out); size > 0 {
+				b.Reset()
+				b.WriteString(out[:len(out)-size])
+			}
+		case r == '\t' || r >= 0x20 && r != 0x7f:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
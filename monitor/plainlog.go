@@ -0,0 +1,154 @@
+package monitor
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/user/tgmux/state"
+)
+
+// PlainLogMonitor 跟踪目录中最近修改的文件追加的文本行（自定义后端的 plain-append 格式），
+// 每轮读到的完整行经进度噪声过滤后推送；出现更新的文件时从头读取新文件
+type PlainLogMonitor struct {
+	topicKey     string
+	dir          string
+	pollInterval time.Duration
+	handler      OutputHandler
+	store        *state.Store
+	noise        *noiseFilter
+	cancel       context.CancelFunc
+	file         string
+	offset       int64
+}
+
+// NewPlainLogMonitor offset 记录的文件仍存在时从其位置继续
+func NewPlainLogMonitor(topicKey, dir string, offset state.Offset, pollInterval time.Duration, handler OutputHandler, store *state.Store) *PlainLogMonitor {
+	return &PlainLogMonitor{
+		topicKey:     topicKey,
+		dir:          dir,
+		pollInterval: pollInterval,
+		handler:      handler,
+		store:        store,
+		file:         offset.File,
+		offset:       offset.ByteOffset,
+	}
+}
+
+func (p *PlainLogMonitor) Start(ctx context.Context) error {
+	if _, err := os.Stat(p.dir); err != nil {
+		return fmt.Errorf("log dir not found: %s", p.dir)
+	}
+	if _, err := os.Stat(p.file); p.file == "" || err != nil {
+		// 新的绑定从已有文件的末尾开始，不重放以前的输出
+		p.file, p.offset = "", 0
+		if newest := newestFile(p.dir); newest != "" {
+			if info, err := os.Stat(newest); err == nil {
+				p.file, p.offset = newest, info.Size()
+			}
+		}
+	}
+	ctx, p.cancel = context.WithCancel(ctx)
+	go p.loop(ctx)
+	return nil
+}
+
+func (p *PlainLogMonitor) Stop() {
+	if p.cancel != nil {
+		p.cancel()
+	}
+}
+
+func (p *PlainLogMonitor) loop(ctx context.Context) {
+	ticker := time.NewTicker(p.pollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			p.poll()
+		}
+	}
+}
+
+func (p *PlainLogMonitor) poll() {
+	if newest := newestFile(p.dir); newest != "" && newest != p.file {
+		slog.Info("plain log monitor: following new file", "key", p.topicKey, "file", newest)
+		p.file, p.offset = newest, 0
+	}
+	if p.file == "" {
+		return
+	}
+	info, err := os.Stat(p.file)
+	if err != nil {
+		return
+	}
+	if info.Size() < p.offset {
+		p.offset = 0
+	}
+	if info.Size() == p.offset {
+		if p.noise.hasPending() {
+			emitFiltered(p.noise, p.topicKey, nil, p.handler)
+		}
+		return
+	}
+
+	f, err := os.Open(p.file)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+	if _, err := f.Seek(p.offset, io.SeekStart); err != nil {
+		return
+	}
+	var lines []string
+	reader := bufio.NewReaderSize(f, 64*1024)
+	for {
+		// 末尾未写完的行留到下一轮
+		line, n, _, err := readLine(reader)
+		if err != nil {
+			break
+		}
+		p.offset += n
+		if l := cleanTerminalLine(string(line)); strings.TrimSpace(l) != "" {
+			if len(l) > maxContentBytes {
+				l = truncateBytes(l, maxContentBytes) + "…"
+			}
+			lines = append(lines, l)
+		}
+	}
+	if len(lines) > 0 || p.noise.hasPending() {
+		emitFiltered(p.noise, p.topicKey, lines, p.handler)
+	}
+	p.store.SetOffset(p.topicKey, state.Offset{File: p.file, ByteOffset: p.offset})
+}
+
+// newestFile 返回目录中最近修改的普通文件，没有时返回空
+func newestFile(dir string) string {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return ""
+	}
+	var newest string
+	var newestAt time.Time
+	for _, e := range entries {
+		if !e.Type().IsRegular() {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil {
+			continue
+		}
+		if info.ModTime().After(newestAt) {
+			newest, newestAt = filepath.Join(dir, e.Name()), info.ModTime()
+		}
+	}
+	return newest
+}
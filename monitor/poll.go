@@ -0,0 +1,78 @@
+package monitor
+
+import (
+	"log/slog"
+	"os"
+	"time"
+
+	"github.com/user/tgmux/i18n"
+)
+
+// watchCheckInterval 检查 fsnotify 是否失效的间隔
+const watchCheckInterval = 30 * time.Second
+
+// pollEvery 轮询间隔，未配置时为 1 秒
+func (m *JSONLMonitor) pollEvery() time.Duration {
+	if m.pollInterval > 0 {
+		return m.pollInterval
+	}
+	return time.Second
+}
+
+// switchToPolling 降级为轮询模式，记录一次日志并在 Topic 中提示
+func (m *JSONLMonitor) switchToPolling(reason string) {
+	m.mu.Lock()
+	if m.polling {
+		m.mu.Unlock()
+		return
+	}
+	m.polling = true
+	m.mu.Unlock()
+
+	slog.Warn("JSONL monitor falling back to polling", "key", m.topicKey, "dir", m.logDir, "interval", m.pollEvery(), "reason", reason)
+	m.handler(m.topicKey, ParsedContent{Type: ContentSystem, Level: LevelWarning, Text: i18n.T("monitor.polling", m.pollEvery())})
+}
+
+// watchStalled 监听是否已失效：长时间没有事件，但主文件的修改时间仍在推进且有未读内容
+func (m *JSONLMonitor) watchStalled(now time.Time) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.mainFile == "" || now.Sub(m.lastEvent) < m.watchFallback {
+		return false
+	}
+	tracker, ok := m.trackedFiles[m.mainFile]
+	if !ok {
+		return false
+	}
+	info, err := os.Stat(m.mainFile)
+	if err != nil {
+		return false
+	}
+	return info.ModTime().After(m.lastEvent) && info.Size() > tracker.byteOffset
+}
+
+// pollFiles 轮询一次日志目录：已跟踪的文件大小变化时增量读取（截断由 readIncremental 处理），
+// 消失的文件停止跟踪，不在基线中的新文件开始跟踪
+func (m *JSONLMonitor) pollFiles() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	files := m.listExistingJSONLFiles()
+	for path := range m.trackedFiles {
+		if _, ok := files[path]; !ok {
+			m.forgetFile(path)
+		}
+	}
+	for path := range files {
+		if tracker, tracked := m.trackedFiles[path]; tracked {
+			if info, err := os.Stat(path); err == nil && info.Size() != tracker.byteOffset {
+				m.readIncremental(path)
+			}
+			continue
+		}
+		if _, known := m.baselineFiles[path]; known {
+			continue
+		}
+		m.trackFile(path)
+	}
+}
@@ -0,0 +1,135 @@
+package monitor
+
+import (
+	"encoding/json"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/user/tgmux/i18n"
+)
+
+// cwdScanLines 读取会话文件开头多少行来查找 cwd 字段
+const cwdScanLines = 20
+
+// foreignFile 因会话不符被忽略的文件的增长情况
+type foreignFile struct {
+	size   int64
+	grewAt time.Time
+}
+
+// noteForeign 记录被忽略的文件，大小变化时更新最近写入时间
+func (m *JSONLMonitor) noteForeign(path string) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return
+	}
+	if m.foreignFiles == nil {
+		m.foreignFiles = make(map[string]foreignFile)
+	}
+	if prev, ok := m.foreignFiles[path]; ok && prev.size == info.Size() {
+		return
+	}
+	m.foreignFiles[path] = foreignFile{size: info.Size(), grewAt: time.Now()}
+}
+
+// checkRelock 当前会话的主文件已停止增长 relockAfter（或后端重启过），
+// 而同项目中有在此之后仍在写入的新会话文件时，切换到其中最新的一个并通知 Topic
+func (m *JSONLMonitor) checkRelock(now time.Time) {
+	if m.backendAlive != nil {
+		alive := m.backendAlive()
+		if alive && !m.wasAlive {
+			m.restarted = true
+		}
+		m.wasAlive = alive
+	}
+
+	m.mu.Lock()
+	if m.sessionUUID == "" || (!m.restarted && now.Sub(m.mainGrewAt) < m.relockAfter) {
+		m.mu.Unlock()
+		return
+	}
+	var candidates []string
+	for path, ff := range m.foreignFiles {
+		if _, err := os.Stat(path); err != nil {
+			delete(m.foreignFiles, path)
+			continue
+		}
+		if ff.grewAt.After(m.mainGrewAt) && now.Sub(ff.grewAt) < m.relockAfter && !isSubagentFile(path) {
+			candidates = append(candidates, path)
+		}
+	}
+	m.mu.Unlock()
+
+	// 核对 cwd 与其他 topic 的占用需读文件或加 Dispatcher 锁，在 m.mu 之外进行
+	var newest string
+	var newestAt time.Time
+	for _, path := range candidates {
+		if cwd := readSessionCwd(path); cwd != "" && m.projectPath != "" && filepath.Clean(cwd) != filepath.Clean(m.projectPath) {
+			continue
+		}
+		if m.claimed != nil && m.claimed(path) {
+			continue
+		}
+		info, err := os.Stat(path)
+		if err == nil && info.ModTime().After(newestAt) {
+			newest, newestAt = path, info.ModTime()
+		}
+	}
+	if newest == "" {
+		return
+	}
+
+	// 先通知再读取新文件，提示出现在新会话的输出之前
+	slog.Info("JSONL monitor re-locking onto new session file", "key", m.topicKey, "old", filepath.Base(m.MainFile()), "new", filepath.Base(newest))
+	m.handler(m.topicKey, ParsedContent{Type: ContentSystem, Level: LevelInfo, Text: i18n.T("monitor.relocked", filepath.Base(newest))})
+	m.mu.Lock()
+	m.relock(newest)
+	m.mu.Unlock()
+}
+
+// relock 放弃当前会话的文件，锁定新文件所属的会话并从头读取
+func (m *JSONLMonitor) relock(path string) {
+	for p := range m.trackedFiles {
+		if extractSessionUUID(p) == m.sessionUUID {
+			delete(m.trackedFiles, p)
+		}
+	}
+	delete(m.foreignFiles, path)
+	delete(m.baselineFiles, path)
+	m.mainFile = ""
+	m.sessionUUID = ""
+	m.restarted = false
+	m.mainGrewAt = time.Now()
+	m.trackFile(path)
+}
+
+// isSubagentFile 是否为 subagent 的会话文件
+func isSubagentFile(path string) bool {
+	return filepath.Base(filepath.Dir(path)) == "subagents"
+}
+
+// readSessionCwd 从会话文件开头几行读取 cwd 字段，没有时返回空
+func readSessionCwd(path string) string {
+	f, err := os.Open(path)
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+
+	var cwd string
+	i := 0
+	forEachLine(f, func(raw []byte) bool {
+		i++
+		var line struct {
+			Cwd string `json:"cwd"`
+		}
+		if json.Unmarshal(raw, &line) == nil && line.Cwd != "" {
+			cwd = line.Cwd
+			return false
+		}
+		return i < cwdScanLines
+	})
+	return cwd
+}
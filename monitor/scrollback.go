@@ -0,0 +1,81 @@
+package monitor
+
+import (
+	"strings"
+
+	"github.com/user/tgmux/i18n"
+)
+
+// scrollAnchorLines 在滚动历史中定位上次快照末尾时比对的非空行数
+const scrollAnchorLines = 3
+
+// scrolledPast 新快照的第一个非空行不在旧快照中：两次轮询之间输出滚过了整屏，
+// 只对比快照会丢失滚出屏幕的部分
+func scrolledPast(old, current string) bool {
+	top := ""
+	for _, l := range strings.Split(current, "\n") {
+		if strings.TrimSpace(l) != "" {
+			top = l
+			break
+		}
+	}
+	if top == "" || strings.TrimSpace(old) == "" {
+		return false
+	}
+	for _, l := range strings.Split(old, "\n") {
+		if l == top {
+			return false
+		}
+	}
+	return true
+}
+
+// recoverScrollback 从滚动历史中取出上次快照之后的全部输出，超过 scrollback 行时只保留最后的部分。
+// 捕获失败返回 nil，由调用方退回普通的快照对比
+func (p *PaneMonitor) recoverScrollback() []string {
+	history, err := p.tmuxMgr.CapturePaneHistory(p.windowID, p.scrollback)
+	if err != nil {
+		return nil
+	}
+	lines := nonEmptyLines(history)
+	if start := anchorEnd(lines, nonEmptyLines(p.lastSnapshot)); start >= 0 {
+		lines = lines[start:]
+	}
+	if len(lines) > p.scrollback {
+		omitted := len(lines) - p.scrollback
+		lines = append([]string{i18n.T("monitor.scrollback_omitted", omitted)}, lines[omitted:]...)
+	}
+	return lines
+}
+
+// anchorEnd 在 lines 中从后往前查找 prev 末尾的若干行，返回其后第一行的下标；找不到返回 -1
+func anchorEnd(lines, prev []string) int {
+	n := min(scrollAnchorLines, len(prev))
+	if n == 0 {
+		return -1
+	}
+	anchor := prev[len(prev)-n:]
+	for i := len(lines) - n; i >= 0; i-- {
+		match := true
+		for j := range anchor {
+			if lines[i+j] != anchor[j] {
+				match = false
+				break
+			}
+		}
+		if match {
+			return i + n
+		}
+	}
+	return -1
+}
+
+func nonEmptyLines(s string) []string {
+	var out []string
+	for _, l := range strings.Split(s, "\n") {
+		if strings.TrimSpace(l) != "" {
+			out = append(out, strings.TrimRight(l, " "))
+		}
+	}
+	return out
+}
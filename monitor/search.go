@@ -0,0 +1,134 @@
+package monitor
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/user/tgmux/backend"
+)
+
+// snippetContext 命中位置前后保留的字符数
+const snippetContext = 60
+
+// SearchHit 一条搜索结果
+type SearchHit struct {
+	File      string    // 命中的文件名（subagent 文件带目录前缀）
+	Timestamp time.Time // 日志条目时间，缺失时为零值
+	Snippet   string
+}
+
+// SearchSession 在主会话文件及其 subagent 文件中大小写不敏感地搜索助手文本，
+// 返回最近的至多 limit 条命中
+func SearchSession(mainFile string, bt backend.Type, query string, limit int) ([]SearchHit, error) {
+	files := []string{mainFile}
+	if bt == backend.TypeClaude {
+		subDir := filepath.Join(strings.TrimSuffix(mainFile, filepath.Ext(mainFile)), "subagents")
+		if matches, err := filepath.Glob(filepath.Join(subDir, "*.jsonl")); err == nil {
+			files = append(files, matches...)
+		}
+	}
+
+	var hits []SearchHit
+	for i, f := range files {
+		fileHits, err := searchFile(f, bt, query, limit)
+		if err != nil {
+			if i == 0 {
+				return nil, err
+			}
+			continue
+		}
+		hits = append(hits, fileHits...)
+	}
+
+	// 多文件合并后按时间排序，保留最近的 limit 条
+	sort.SliceStable(hits, func(i, j int) bool { return hits[i].Timestamp.Before(hits[j].Timestamp) })
+	if len(hits) > limit {
+		hits = hits[len(hits)-limit:]
+	}
+	return hits, nil
+}
+
+func searchFile(path string, bt backend.Type, query string, limit int) ([]SearchHit, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	name := filepath.Base(path)
+	if strings.Contains(path, "/subagents/") {
+		name = "subagents/" + name
+	}
+
+	lowerQuery := strings.ToLower(query)
+	// 查询不含需要 JSON 转义的字符时，可先对原始行做快速过滤
+	prefilter := !strings.ContainsAny(query, "\"\\\n\t")
+
+	parser := newLineParser(bt)
+	var hits []SearchHit
+	err = forEachLine(f, func(raw []byte) bool {
+		line := string(raw)
+		if prefilter && !strings.Contains(strings.ToLower(line), lowerQuery) {
+			return true
+		}
+		var meta struct {
+			Type      string `json:"type"`
+			Timestamp string `json:"timestamp"`
+		}
+		if err := json.Unmarshal(raw, &meta); err != nil {
+			return true
+		}
+		if bt == backend.TypeClaude && meta.Type != "assistant" {
+			return true
+		}
+		ts, _ := time.Parse(time.RFC3339, meta.Timestamp)
+		for _, c := range parser.parseLine(line) {
+			if c.Type != ContentText {
+				continue
+			}
+			idx := strings.Index(strings.ToLower(c.Text), lowerQuery)
+			if idx < 0 {
+				continue
+			}
+			hits = append(hits, SearchHit{File: name, Timestamp: ts, Snippet: snippetAround(c.Text, idx, len(query))})
+			if len(hits) > limit {
+				hits = hits[1:]
+			}
+		}
+		return true
+	})
+	return hits, err
+}
+
+// snippetAround 截取命中位置前后的上下文，换行折叠为空格
+func snippetAround(text string, byteIdx, byteLen int) string {
+	if byteIdx > len(text) {
+		byteIdx = len(text) // ToLower 可能改变字节长度
+	}
+	start := byteIdx
+	for n := 0; start > 0 && n < snippetContext; n++ {
+		_, size := utf8.DecodeLastRuneInString(text[:start])
+		start -= size
+	}
+	end := byteIdx + byteLen
+	if end > len(text) {
+		end = len(text)
+	}
+	for n := 0; end < len(text) && n < snippetContext; n++ {
+		_, size := utf8.DecodeRuneInString(text[end:])
+		end += size
+	}
+	snippet := strings.Join(strings.Fields(text[start:end]), " ")
+	if start > 0 {
+		snippet = "…" + snippet
+	}
+	if end < len(text) {
+		snippet += "…"
+	}
+	return snippet
+}
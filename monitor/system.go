@@ -0,0 +1,135 @@
+package monitor
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/user/tgmux/i18n"
+)
+
+// 系统事件级别（ParsedContent.Level）
+const (
+	LevelInfo    = "info"
+	LevelWarning = "warning"
+	LevelError   = "error"
+)
+
+// skippedSystemSubtypes 不推送的 system 子类型（本地命令回显、hook 汇总等，噪音大）
+var skippedSystemSubtypes = map[string]bool{
+	"local_command":     true,
+	"stop_hook_summary": true,
+	"turn_duration":     true,
+}
+
+// isCompactBoundary 是否为上下文压缩的边界记录（system/compact_boundary）
+func isCompactBoundary(msgType string, raw map[string]json.RawMessage) bool {
+	if msgType != "system" {
+		return false
+	}
+	var subtype string
+	json.Unmarshal(raw["subtype"], &subtype)
+	return subtype == "compact_boundary"
+}
+
+// boolField 读取记录顶层的布尔字段，缺失或类型不符时为 false
+func boolField(raw map[string]json.RawMessage, key string) bool {
+	var v bool
+	if data, ok := raw[key]; ok {
+		json.Unmarshal(data, &v)
+	}
+	return v
+}
+
+// parseClaudeSystem 解析 claude 的 system / summary 行以及出错的 result 行，
+// 无需推送时返回 false
+func parseClaudeSystem(msgType string, raw map[string]json.RawMessage) (ParsedContent, bool) {
+	var line struct {
+		Subtype      string          `json:"subtype"`
+		Content      string          `json:"content"`
+		Level        string          `json:"level"`
+		Summary      string          `json:"summary"`
+		IsError      bool            `json:"is_error"`
+		Result       string          `json:"result"`
+		Error        json.RawMessage `json:"error"`
+		RetryAttempt int             `json:"retryAttempt"`
+		MaxRetries   int             `json:"maxRetries"`
+	}
+	data, _ := json.Marshal(raw)
+	if err := json.Unmarshal(data, &line); err != nil {
+		return ParsedContent{}, false
+	}
+
+	switch msgType {
+	case "summary":
+		if line.Summary == "" {
+			return ParsedContent{}, false
+		}
+		return ParsedContent{Type: ContentSystem, Level: LevelInfo, Text: i18n.T("monitor.summary", line.Summary)}, true
+
+	case "result":
+		if !line.IsError && !strings.HasPrefix(line.Subtype, "error") {
+			return ParsedContent{}, false
+		}
+		text := line.Result
+		if text == "" {
+			text = line.Subtype
+		}
+		return ParsedContent{Type: ContentSystem, Level: LevelError, Text: i18n.T("monitor.result_error", text)}, true
+
+	case "system":
+		if skippedSystemSubtypes[line.Subtype] {
+			return ParsedContent{}, false
+		}
+		if line.Subtype == "compact_boundary" {
+			return ParsedContent{Type: ContentSystem, Level: LevelInfo, Text: i18n.T("monitor.compacted")}, true
+		}
+		level := line.Level
+		if level == "" {
+			level = LevelInfo
+		}
+		text := line.Content
+		if line.Subtype == "api_error" {
+			level = LevelError
+			text = i18n.T("monitor.api_error", apiErrorDetail(line.Error))
+			if line.MaxRetries > 0 {
+				text += i18n.T("monitor.api_retry", line.RetryAttempt, line.MaxRetries)
+			}
+		}
+		if text == "" {
+			text = line.Subtype
+		}
+		if text == "" {
+			return ParsedContent{}, false
+		}
+		return ParsedContent{Type: ContentSystem, Level: level, Text: text}, true
+	}
+	return ParsedContent{}, false
+}
+
+// apiErrorDetail 从 api_error 的 error 字段提取状态码与消息
+func apiErrorDetail(raw json.RawMessage) string {
+	var e struct {
+		Status  int    `json:"status"`
+		Message string `json:"message"`
+		Error   struct {
+			Error struct {
+				Message string `json:"message"`
+			} `json:"error"`
+		} `json:"error"`
+	}
+	if len(raw) == 0 || json.Unmarshal(raw, &e) != nil {
+		return strings.Trim(string(raw), `"`)
+	}
+	msg := e.Message
+	if msg == "" {
+		msg = e.Error.Error.Message
+	}
+	switch {
+	case e.Status != 0 && msg != "":
+		return fmt.Sprintf("%d %s", e.Status, msg)
+	case e.Status != 0:
+		return fmt.Sprint(e.Status)
+	}
+	return msg
+}
@@ -2,14 +2,24 @@ package monitor
 
 import (
 	"fmt"
+	"sort"
 	"strings"
+
+	"github.com/user/tgmux/i18n"
 )
 
 const maxSummaryLen = 200
 
 // FormatToolUseSummary formats a tool_use block into a brief summary line.
-// e.g. "Read(src/main.go)", "Bash(go build ./...)"
+// e.g. "Read(src/main.go)", "Bash(go build ./...)", "github:create_pull_request(Fix typo)"
 func FormatToolUseSummary(name string, input map[string]interface{}) string {
+	if server, tool, ok := splitMCPName(name); ok {
+		name = server + ":" + tool
+		if summary := mcpSummary(input); summary != "" {
+			return fmt.Sprintf("%s(%s)", name, truncateSummary(summary))
+		}
+		return name
+	}
 	if input == nil {
 		return name
 	}
@@ -57,10 +67,87 @@ func FormatToolUseSummary(name string, input map[string]interface{}) string {
 	if summary == "" {
 		return name
 	}
-	if len(summary) > maxSummaryLen {
-		summary = summary[:maxSummaryLen] + "…"
+	return fmt.Sprintf("%s(%s)", name, truncateSummary(summary))
+}
+
+func truncateSummary(s string) string {
+	if len(s) > maxSummaryLen {
+		return s[:maxSummaryLen] + "…"
+	}
+	return s
+}
+
+// splitMCPName 拆分 MCP 工具名 "mcp__<server>__<tool>"
+func splitMCPName(name string) (server, tool string, ok bool) {
+	rest, ok := strings.CutPrefix(name, "mcp__")
+	if !ok {
+		return "", "", false
+	}
+	server, tool, ok = strings.Cut(rest, "__")
+	if !ok || server == "" || tool == "" {
+		return "", "", false
+	}
+	return server, tool, true
+}
+
+// mcpSummaryKeys 摘要 MCP 工具调用时优先使用的输入字段
+var mcpSummaryKeys = []string{"title", "query", "path", "file_path", "url", "name"}
+
+// mcpSummary 选取描述性的输入字段，没有时按键名顺序取第一个非空字符串，结果稳定
+func mcpSummary(input map[string]interface{}) string {
+	for _, k := range mcpSummaryKeys {
+		if s := strVal(input, k); s != "" {
+			return s
+		}
+	}
+	keys := make([]string, 0, len(input))
+	for k := range input {
+		keys = append(keys, k)
 	}
-	return fmt.Sprintf("%s(%s)", name, summary)
+	sort.Strings(keys)
+	for _, k := range keys {
+		if s := strVal(input, k); s != "" {
+			return s
+		}
+	}
+	return ""
+}
+
+// maxTodoItems 列出的 TodoWrite 条目上限
+const maxTodoItems = 30
+
+// todoMarks TodoWrite 状态对应的清单标记
+var todoMarks = map[string]string{
+	"completed":   "✅",
+	"in_progress": "▶️",
+	"pending":     "◻️",
+}
+
+// FormatTodoList 将 TodoWrite 的输入格式化为清单，每行一项；输入不含 todos 时返回 false
+func FormatTodoList(input map[string]interface{}) (string, bool) {
+	todos, ok := input["todos"].([]interface{})
+	if !ok || len(todos) == 0 {
+		return "", false
+	}
+	var lines []string
+	for i, t := range todos {
+		item, ok := t.(map[string]interface{})
+		if !ok {
+			return "", false
+		}
+		content := strVal(item, "content")
+		mark, ok := todoMarks[strVal(item, "status")]
+		if !ok || content == "" {
+			return "", false
+		}
+		if i < maxTodoItems {
+			lines = append(lines, mark+" "+content)
+		}
+	}
+	if len(todos) > maxTodoItems {
+		lines = append(lines, i18n.T("todo.more", len(todos)-maxTodoItems))
+	}
+	return strings.Join(lines, "\n"), true
 }
 
 // FormatToolResultStats formats tool result text into a stats summary.
@@ -85,9 +172,11 @@ func FormatToolResultStats(text string, toolName string) string {
 		return fmt.Sprintf("  ⎿  Found %d files", files)
 	case "Edit", "NotebookEdit":
 		return "  ⎿  Edited"
-	default:
-		return fmt.Sprintf("  ⎿  %d lines", lines)
 	}
+	if server, _, ok := splitMCPName(toolName); ok {
+		return fmt.Sprintf("  ⎿  %d lines from %s", lines, server)
+	}
+	return fmt.Sprintf("  ⎿  %d lines", lines)
 }
 
 // FormatEditDiff generates a simple diff summary between old and new strings.
@@ -0,0 +1,53 @@
+package monitor
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFormatToolUseSummaryMCP(t *testing.T) {
+	tests := []struct {
+		name  string
+		tool  string
+		input map[string]interface{}
+		want  string
+	}{
+		{"preferred title", "mcp__github__create_pull_request", map[string]interface{}{"body": "long text", "title": "Fix typo"}, "github:create_pull_request(Fix typo)"},
+		{"key order", "mcp__search__web", map[string]interface{}{"url": "https://example.com", "query": "go generics"}, "search:web(go generics)"},
+		{"file path", "mcp__fs__read", map[string]interface{}{"file_path": "/etc/hosts"}, "fs:read(/etc/hosts)"},
+		{"fallback sorted", "mcp__db__run", map[string]interface{}{"zeta": "z", "alpha": "a", "count": 3}, "db:run(a)"},
+		{"no strings", "mcp__db__ping", map[string]interface{}{"timeout": 5}, "db:ping"},
+		{"nil input", "mcp__db__ping", nil, "db:ping"},
+		{"empty values skipped", "mcp__github__get_issue", map[string]interface{}{"title": "", "name": "tgmux"}, "github:get_issue(tgmux)"},
+		{"tool with underscores", "mcp__claude_ai_Gmail__search_threads", map[string]interface{}{"query": "from:me"}, "claude_ai_Gmail:search_threads(from:me)"},
+		{"long summary", "mcp__notes__add", map[string]interface{}{"title": strings.Repeat("n", 250)}, "notes:add(" + strings.Repeat("n", maxSummaryLen) + "…)"},
+		{"missing tool part", "mcp__github", map[string]interface{}{"title": "x"}, "mcp__github(x)"},
+		{"empty server", "mcp____tool", nil, "mcp____tool"},
+		{"not mcp", "Bash", map[string]interface{}{"command": "ls"}, "Bash(ls)"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := FormatToolUseSummary(tt.tool, tt.input); got != tt.want {
+				t.Errorf("FormatToolUseSummary(%q) = %q, want %q", tt.tool, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFormatToolResultStatsMCP(t *testing.T) {
+	tests := []struct {
+		tool string
+		text string
+		want string
+	}{
+		{"mcp__github__list_issues", "a\nb\nc", "  ⎿  3 lines from github"},
+		{"mcp__fs__read", "one", "  ⎿  1 lines from fs"},
+		{"mcp__fs__read", "", ""},
+		{"mcp__broken", "a\nb", "  ⎿  2 lines"},
+	}
+	for _, tt := range tests {
+		if got := FormatToolResultStats(tt.text, tt.tool); got != tt.want {
+			t.Errorf("FormatToolResultStats(%q, %q) = %q, want %q", tt.text, tt.tool, got, tt.want)
+		}
+	}
+}
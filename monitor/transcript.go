@@ -0,0 +1,300 @@
+package monitor
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/user/tgmux/backend"
+)
+
+// TranscriptFormat 导出格式
+type TranscriptFormat string
+
+const (
+	TranscriptMarkdown TranscriptFormat = "md"
+	TranscriptText     TranscriptFormat = "txt"
+	TranscriptJSON     TranscriptFormat = "json"
+)
+
+// TranscriptEntry 会话记录中的一条内容
+type TranscriptEntry struct {
+	Role string `json:"role"` // "user" | "assistant"
+	Type string `json:"type"` // "text" | "thinking" | "tool_use"
+	Text string `json:"text"`
+}
+
+// WriteTranscript 逐行读取会话日志并按指定格式写出，不在内存中缓存整个会话
+func WriteTranscript(w io.Writer, path string, bt backend.Type, format TranscriptFormat, withThinking bool) error {
+	bw := bufio.NewWriter(w)
+	tw := &transcriptWriter{w: bw, format: format}
+
+	emit := func(e TranscriptEntry) error {
+		if e.Type == "thinking" && !withThinking {
+			return nil
+		}
+		return tw.write(e)
+	}
+
+	if err := tw.begin(); err != nil {
+		return err
+	}
+	var err error
+	if bt == backend.TypeGemini {
+		err = eachGeminiEntry(path, emit)
+	} else {
+		err = eachJSONLEntry(path, bt, emit)
+	}
+	if err != nil {
+		return err
+	}
+	if err := tw.end(); err != nil {
+		return err
+	}
+	return bw.Flush()
+}
+
+// eachJSONLEntry 从头扫描 claude/codex JSONL 文件，依次回调每条内容
+func eachJSONLEntry(path string, bt backend.Type, fn func(TranscriptEntry) error) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("open log: %w", err)
+	}
+	defer f.Close()
+
+	var fnErr error
+	err = forEachLine(f, func(line []byte) bool {
+		var raw map[string]json.RawMessage
+		if err := json.Unmarshal(line, &raw); err != nil {
+			return true
+		}
+		var entries []TranscriptEntry
+		switch bt {
+		case backend.TypeClaude:
+			entries = claudeTranscriptEntries(raw)
+		case backend.TypeCodex:
+			entries = codexTranscriptEntries(raw)
+		}
+		for _, e := range entries {
+			if fnErr = fn(e); fnErr != nil {
+				return false
+			}
+		}
+		return true
+	})
+	if fnErr != nil {
+		return fnErr
+	}
+	if err != nil {
+		return fmt.Errorf("scan log: %w", err)
+	}
+	return nil
+}
+
+// eachGeminiEntry 读取 Gemini logs.json，依次回调每条内容
+func eachGeminiEntry(path string, fn func(TranscriptEntry) error) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("open log: %w", err)
+	}
+	defer f.Close()
+
+	dec := json.NewDecoder(bufio.NewReader(f))
+	if _, err := dec.Token(); err != nil { // '['
+		return fmt.Errorf("parse gemini log: %w", err)
+	}
+	for dec.More() {
+		var entry GeminiLogEntry
+		if err := dec.Decode(&entry); err != nil {
+			return fmt.Errorf("parse gemini log: %w", err)
+		}
+		if entry.Message == "" {
+			continue
+		}
+		role := "user"
+		if entry.Type == "model" {
+			role = "assistant"
+		}
+		if err := fn(TranscriptEntry{Role: role, Type: "text", Text: entry.Message}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func claudeTranscriptEntries(raw map[string]json.RawMessage) []TranscriptEntry {
+	var msgType string
+	if t, ok := raw["type"]; ok {
+		json.Unmarshal(t, &msgType)
+	}
+	if msgType != "assistant" && msgType != "user" {
+		return nil
+	}
+	msgData, ok := raw["message"]
+	if !ok {
+		return nil
+	}
+	var msg struct {
+		Content json.RawMessage `json:"content"`
+	}
+	if err := json.Unmarshal(msgData, &msg); err != nil {
+		return nil
+	}
+
+	// 用户输入可能直接是字符串
+	var plain string
+	if err := json.Unmarshal(msg.Content, &plain); err == nil {
+		if plain == "" {
+			return nil
+		}
+		return []TranscriptEntry{{Role: msgType, Type: "text", Text: plain}}
+	}
+
+	var blocks []struct {
+		Type     string                 `json:"type"`
+		Text     string                 `json:"text"`
+		Thinking string                 `json:"thinking"`
+		Name     string                 `json:"name"`
+		Input    map[string]interface{} `json:"input"`
+	}
+	if err := json.Unmarshal(msg.Content, &blocks); err != nil {
+		return nil
+	}
+	var entries []TranscriptEntry
+	for _, block := range blocks {
+		switch block.Type {
+		case "text":
+			if block.Text != "" {
+				entries = append(entries, TranscriptEntry{Role: msgType, Type: "text", Text: block.Text})
+			}
+		case "thinking":
+			if block.Thinking != "" {
+				entries = append(entries, TranscriptEntry{Role: msgType, Type: "thinking", Text: block.Thinking})
+			}
+		case "tool_use":
+			if block.Name != "" {
+				entries = append(entries, TranscriptEntry{Role: msgType, Type: "tool_use", Text: FormatToolUseSummary(block.Name, block.Input)})
+			}
+		}
+	}
+	return entries
+}
+
+func codexTranscriptEntries(raw map[string]json.RawMessage) []TranscriptEntry {
+	if text := parseCodexLine(raw); text != "" {
+		return []TranscriptEntry{{Role: "assistant", Type: "text", Text: text}}
+	}
+	var role string
+	if r, ok := raw["role"]; ok {
+		json.Unmarshal(r, &role)
+	}
+	if role != "user" {
+		return nil
+	}
+	content, ok := raw["content"]
+	if !ok {
+		return nil
+	}
+	var text string
+	if err := json.Unmarshal(content, &text); err == nil && text != "" {
+		return []TranscriptEntry{{Role: "user", Type: "text", Text: text}}
+	}
+	var items []struct {
+		Text string `json:"text"`
+	}
+	if err := json.Unmarshal(content, &items); err != nil {
+		return nil
+	}
+	var texts []string
+	for _, item := range items {
+		if item.Text != "" {
+			texts = append(texts, item.Text)
+		}
+	}
+	if len(texts) == 0 {
+		return nil
+	}
+	return []TranscriptEntry{{Role: "user", Type: "text", Text: strings.Join(texts, "\n")}}
+}
+
+// transcriptWriter 按格式增量写出会话记录
+type transcriptWriter struct {
+	w        *bufio.Writer
+	format   TranscriptFormat
+	lastRole string
+	count    int
+}
+
+func (t *transcriptWriter) begin() error {
+	switch t.format {
+	case TranscriptJSON:
+		_, err := t.w.WriteString("[\n")
+		return err
+	case TranscriptMarkdown:
+		_, err := t.w.WriteString("# Transcript\n\n")
+		return err
+	}
+	return nil
+}
+
+func (t *transcriptWriter) end() error {
+	if t.format == TranscriptJSON {
+		_, err := t.w.WriteString("\n]\n")
+		return err
+	}
+	return nil
+}
+
+func (t *transcriptWriter) write(e TranscriptEntry) error {
+	defer func() {
+		t.lastRole = e.Role
+		t.count++
+	}()
+
+	if t.format == TranscriptJSON {
+		data, err := json.Marshal(e)
+		if err != nil {
+			return err
+		}
+		if t.count > 0 {
+			t.w.WriteString(",\n")
+		}
+		_, err = t.w.Write(data)
+		return err
+	}
+
+	if e.Role != t.lastRole {
+		header := "## 👤 User\n\n"
+		if e.Role == "assistant" {
+			header = "## 🤖 Assistant\n\n"
+		}
+		if t.format == TranscriptText {
+			header = "[User]\n"
+			if e.Role == "assistant" {
+				header = "[Assistant]\n"
+			}
+		}
+		if _, err := t.w.WriteString(header); err != nil {
+			return err
+		}
+	}
+
+	var body string
+	switch {
+	case e.Type == "thinking" && t.format == TranscriptMarkdown:
+		body = "> 💭 " + strings.ReplaceAll(e.Text, "\n", "\n> ") + "\n\n"
+	case e.Type == "thinking":
+		body = "(thinking) " + e.Text + "\n\n"
+	case e.Type == "tool_use" && t.format == TranscriptMarkdown:
+		body = "- 🔧 `" + e.Text + "`\n\n"
+	case e.Type == "tool_use":
+		body = "> " + e.Text + "\n\n"
+	default:
+		body = e.Text + "\n\n"
+	}
+	_, err := t.w.WriteString(body)
+	return err
+}
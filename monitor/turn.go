@@ -0,0 +1,74 @@
+package monitor
+
+import (
+	"time"
+
+	"github.com/user/tgmux/config"
+)
+
+// 回合结束判定方式
+const (
+	turnDetectResult     = "result"      // result 类型日志行
+	turnDetectStopReason = "stop_reason" // 助手消息 stop_reason 为 end_turn
+	turnDetectQuiet      = "quiet"       // 最后一个工具结果后静默一段时间
+)
+
+// turnTracker 跟踪当前回合（从用户输入开始），在判定结束时产出一次 ContentTurnDone
+type turnTracker struct {
+	detect map[string]bool
+	quiet  time.Duration
+
+	active         bool
+	start          time.Time
+	toolCalls      int
+	lastToolResult time.Time // 最近一个工具结果的时间，之后有其他输出则清零
+}
+
+func newTurnTracker(cfg config.TurnNotifyConfig) *turnTracker {
+	t := &turnTracker{detect: make(map[string]bool), quiet: cfg.QuietWindow}
+	for _, d := range cfg.Detect {
+		t.detect[d] = true
+	}
+	return t
+}
+
+// begin 用户输入开启新回合
+func (t *turnTracker) begin(now time.Time) {
+	t.active = true
+	t.start = now
+	t.toolCalls = 0
+	t.lastToolResult = time.Time{}
+}
+
+// observe 记录回合内的输出
+func (t *turnTracker) observe(c ParsedContent, now time.Time) {
+	if !t.active {
+		return
+	}
+	switch c.Type {
+	case ContentToolUse, ContentPlan, ContentTodo:
+		t.toolCalls++
+		t.lastToolResult = time.Time{}
+	case ContentToolResult, ContentImage:
+		t.lastToolResult = now
+	default:
+		t.lastToolResult = time.Time{}
+	}
+}
+
+// finish 按给定判定方式结束回合；未启用该方式或回合已结束时返回 false，保证每回合只通知一次
+func (t *turnTracker) finish(how string, now time.Time) (ParsedContent, bool) {
+	if !t.active || !t.detect[how] {
+		return ParsedContent{}, false
+	}
+	t.active = false
+	return ParsedContent{Type: ContentTurnDone, Elapsed: now.Sub(t.start), ToolCalls: t.toolCalls}, true
+}
+
+// quietDone 最后一个工具结果之后已静默足够久时结束回合
+func (t *turnTracker) quietDone(now time.Time) (ParsedContent, bool) {
+	if t.lastToolResult.IsZero() || now.Sub(t.lastToolResult) < t.quiet {
+		return ParsedContent{}, false
+	}
+	return t.finish(turnDetectQuiet, now)
+}
@@ -0,0 +1,106 @@
+package monitor
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/user/tgmux/i18n"
+	"github.com/user/tgmux/state"
+)
+
+const (
+	// watchdogInterval 检查监控器是否存活的间隔
+	watchdogInterval = time.Minute
+	// wedgeAfter 日志文件在增长、但监控器超过该时间没有读取时视为卡住
+	wedgeAfter = 5 * time.Minute
+)
+
+// monitorSpec 监控器的启动参数，用于 watchdog 重建
+type monitorSpec struct {
+	ctx     context.Context
+	binding state.Binding
+	handler OutputHandler
+}
+
+// crashReporter 监控循环意外退出（而非 Stop）时关闭 Crashed 返回的 channel
+type crashReporter interface {
+	Crashed() <-chan struct{}
+}
+
+// wedgeReporter 能判断自身是否卡住的监控器
+type wedgeReporter interface {
+	Wedged(now time.Time, after time.Duration) bool
+}
+
+// RunWatchdog 定期检查监控器：意外退出或卡住的按保存的绑定与 offset 重建，并在 Topic 中提示。
+// 经 StopMonitor / StopAll 主动停止的不会被重建
+func (d *Dispatcher) RunWatchdog(ctx context.Context) {
+	ticker := time.NewTicker(watchdogInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			d.checkMonitors(now)
+		}
+	}
+}
+
+func (d *Dispatcher) checkMonitors(now time.Time) {
+	type restart struct {
+		key    string
+		spec   monitorSpec
+		reason string
+	}
+	var restarts []restart
+
+	d.mu.Lock()
+	for key, spec := range d.specs {
+		if spec.ctx.Err() != nil {
+			delete(d.specs, key)
+			continue
+		}
+		reason := monitorFailure(d.monitors[key], now)
+		if reason == "" {
+			continue
+		}
+		// 绑定已解除或换了窗口：不再由 watchdog 负责
+		binding, ok := d.store.GetBinding(key)
+		if !ok || binding.WindowID != spec.binding.WindowID {
+			delete(d.specs, key)
+			continue
+		}
+		spec.binding = binding
+		restarts = append(restarts, restart{key: key, spec: spec, reason: reason})
+	}
+	d.mu.Unlock()
+
+	for _, r := range restarts {
+		slog.Warn("monitor watchdog restarting monitor", "key", r.key, "reason", r.reason)
+		if err := d.StartMonitor(r.spec.ctx, r.key, r.spec.binding, r.spec.handler); err != nil {
+			slog.Error("monitor watchdog restart failed", "key", r.key, "error", err)
+			continue
+		}
+		r.spec.handler(r.key, ParsedContent{Type: ContentSystem, Level: LevelWarning, Text: i18n.T("monitor.restarted")})
+	}
+}
+
+// monitorFailure 返回监控器需要重建的原因，正常时为空
+func monitorFailure(mon Monitor, now time.Time) string {
+	if mon == nil {
+		return "missing"
+	}
+	if c, ok := mon.(crashReporter); ok {
+		select {
+		case <-c.Crashed():
+			return "exited"
+		default:
+		}
+	}
+	if w, ok := mon.(wedgeReporter); ok && w.Wedged(now, wedgeAfter) {
+		return "wedged"
+	}
+	return ""
+}
@@ -1,6 +1,10 @@
 package sanitize
 
-import "regexp"
+import (
+	"regexp"
+	"strings"
+	"sync"
+)
 
 var patterns = []*regexp.Regexp{
 	regexp.MustCompile(`sk-[a-zA-Z0-9]{20,}`),
@@ -12,7 +16,36 @@ var patterns = []*regexp.Regexp{
 	regexp.MustCompile(`-----BEGIN [A-Z ]* PRIVATE KEY-----`),
 }
 
+// minSecretLen 短于该长度的值不登记，避免误替换常见片段
+const minSecretLen = 8
+
+var (
+	secretsMu sync.RWMutex
+	secrets   []string // AddSecret 登记的字面值
+)
+
+// AddSecret 登记需要遮蔽的字面值（如配置中注入后端的密钥）。
+// 这些值确定是密钥，即使关闭 redact_secrets 也会遮蔽
+func AddSecret(value string) {
+	if len(value) < minSecretLen {
+		return
+	}
+	secretsMu.Lock()
+	defer secretsMu.Unlock()
+	for _, s := range secrets {
+		if s == value {
+			return
+		}
+	}
+	secrets = append(secrets, value)
+}
+
 func Redact(text string, enabled bool) string {
+	secretsMu.RLock()
+	for _, s := range secrets {
+		text = strings.ReplaceAll(text, s, "[REDACTED]")
+	}
+	secretsMu.RUnlock()
 	if !enabled {
 		return text
 	}
@@ -21,3 +54,12 @@ func Redact(text string, enabled bool) string {
 	}
 	return text
 }
+
+// Mask 遮蔽敏感值，仅保留开头少量字符用于辨认
+func Mask(value string) string {
+	r := []rune(value)
+	if len(r) <= 8 {
+		return "****"
+	}
+	return string(r[:4]) + "****"
+}
@@ -1,10 +1,13 @@
 package state
 
 import (
+	"crypto/rand"
+	"encoding/hex"
 	"encoding/json"
 	"log/slog"
 	"os"
 	"path/filepath"
+	"sort"
 	"sync"
 	"time"
 )
@@ -16,23 +19,106 @@ type Binding struct {
 	DisplayName string    `json:"display_name"`
 	CreatedAt   time.Time `json:"created_at"`
 	Status      string    `json:"status"` // "running" | "disconnected"
+	// WorktreeRepo 由 /worktree 创建的会话所属主仓库，ProjectPath 为 worktree 路径
+	WorktreeRepo string `json:"worktree_repo,omitempty"`
+	// LastActivity 最近一次输入或输出的时间，空闲回收据此计时
+	LastActivity time.Time `json:"last_activity,omitempty"`
+	// KeepAlive 为 true 时不参与空闲回收
+	KeepAlive bool `json:"keep_alive,omitempty"`
+	// AutoConfirm 为 true 时自动确认不含危险操作的权限请求，随绑定删除而重置
+	AutoConfirm bool `json:"auto_confirm,omitempty"`
+	// Profile 启动时选择的 backends.<name>.profiles 条目，空为默认参数
+	Profile string `json:"profile,omitempty"`
+	// PaneID 会话所在的 tmux pane（如 "%3"），窗口被拆分后发送与捕获仍针对它；空为窗口的活动 pane
+	PaneID string `json:"pane_id,omitempty"`
+	// SessionID claude-headless 会话的 session_id，之后的消息以 --resume 延续
+	SessionID string `json:"session_id,omitempty"`
 }
 
 type Offset struct {
 	File         string `json:"file"`
 	ByteOffset   int64  `json:"byte_offset"`
 	MessageCount int    `json:"message_count"` // Gemini 专用
+	Usage        Usage  `json:"usage"`
 }
 
+// Usage 会话用量统计（claude/codex JSONL 中的 usage 字段累计）
+type Usage struct {
+	InputTokens      int64 `json:"input_tokens"`
+	OutputTokens     int64 `json:"output_tokens"`
+	CacheReadTokens  int64 `json:"cache_read_tokens"`
+	CacheWriteTokens int64 `json:"cache_write_tokens"`
+	Turns            int   `json:"turns"`
+	ToolCalls        int   `json:"tool_calls"`
+}
+
+// Pause 暂停推送状态：暂停期间的输出只计数不发送
+type Pause struct {
+	Since   time.Time      `json:"since"`
+	Skipped map[string]int `json:"skipped"` // 内容类别 → 跳过条数
+}
+
+// Mute 定时静音状态：截止时间前的输出只计数不发送
+type Mute struct {
+	Until   time.Time      `json:"until"`
+	Skipped map[string]int `json:"skipped"` // 内容类别 → 跳过条数
+}
+
+// Filter 按内容类型的输出过滤，true 为推送
+type Filter struct {
+	Thinking    bool `json:"thinking"`
+	ToolCalls   bool `json:"tool_calls"`
+	ToolResults bool `json:"tool_results"`
+}
+
+// Schedule 定时发送的消息
+type Schedule struct {
+	ID        int       `json:"id"`
+	TopicKey  string    `json:"topic_key"`
+	Text      string    `json:"text"`
+	FireAt    time.Time `json:"fire_at"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// EnvVars 启动后端前导出的环境变量
+type EnvVars map[string]string
+
+// PathRef 回调数据中路径令牌指向的目录（callback_data 限 64 字节，长路径用令牌代替）
+type PathRef struct {
+	Path      string    `json:"path"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// PendingTool 尚未收到结果的 tool_use：重启后监控据此恢复工具名，推送据此把结果编辑进原消息
+type PendingTool struct {
+	Name  string    `json:"name"`
+	MsgID int       `json:"msg_id,omitempty"` // 工具调用消息的 Telegram message_id，0 为尚未发送
+	Text  string    `json:"text,omitempty"`   // 发送的原文，结果追加在其后
+	At    time.Time `json:"at"`
+}
+
+// pendingToolTTL 超过该时长仍未收到结果的 tool_use 不再恢复
+const pendingToolTTL = time.Hour
+
 type DirState struct {
 	Favorites []string `json:"favorites"`
 	Recent    []string `json:"recent"`
 }
 
 type stateData struct {
-	Bindings map[string]Binding `json:"bindings"`
-	Offsets  map[string]Offset  `json:"offsets"`
-	Dirs     DirState           `json:"dirs"`
+	Bindings  map[string]Binding `json:"bindings"`
+	Offsets   map[string]Offset  `json:"offsets"`
+	Dirs      DirState           `json:"dirs"`
+	Paused    map[string]Pause   `json:"paused,omitempty"`
+	Muted     map[string]Mute    `json:"muted,omitempty"`
+	Env       map[string]EnvVars `json:"env,omitempty"`
+	Schedules []Schedule         `json:"schedules,omitempty"`
+	Tokens    map[string]PathRef `json:"path_tokens,omitempty"`
+	Filters   map[string]Filter  `json:"filters,omitempty"`
+	PushModes map[string]string  `json:"push_modes,omitempty"`
+
+	// topic → tool_use_id → 未配对的工具调用
+	PendingTools map[string]map[string]PendingTool `json:"pending_tools,omitempty"`
 }
 
 type Store struct {
@@ -53,6 +139,13 @@ func New(path string, recentMax int) *Store {
 		data: stateData{
 			Bindings: make(map[string]Binding),
 			Offsets:  make(map[string]Offset),
+			Paused:   make(map[string]Pause),
+			Muted:    make(map[string]Mute),
+			Env:      make(map[string]EnvVars),
+			Tokens:   make(map[string]PathRef),
+			Filters:  make(map[string]Filter),
+
+			PushModes: make(map[string]string),
 		},
 	}
 
@@ -70,6 +163,27 @@ func New(path string, recentMax int) *Store {
 	if s.data.Offsets == nil {
 		s.data.Offsets = make(map[string]Offset)
 	}
+	if s.data.Paused == nil {
+		s.data.Paused = make(map[string]Pause)
+	}
+	if s.data.Muted == nil {
+		s.data.Muted = make(map[string]Mute)
+	}
+	if s.data.Env == nil {
+		s.data.Env = make(map[string]EnvVars)
+	}
+	if s.data.Tokens == nil {
+		s.data.Tokens = make(map[string]PathRef)
+	}
+	if s.data.Filters == nil {
+		s.data.Filters = make(map[string]Filter)
+	}
+	if s.data.PushModes == nil {
+		s.data.PushModes = make(map[string]string)
+	}
+	if s.data.PendingTools == nil {
+		s.data.PendingTools = make(map[string]map[string]PendingTool)
+	}
 
 	// 启动异步刷盘 goroutine
 	go s.asyncSaveLoop()
@@ -122,6 +236,11 @@ func (s *Store) GetBinding(topicKey string) (Binding, bool) {
 func (s *Store) DeleteBinding(topicKey string) {
 	s.mu.Lock()
 	delete(s.data.Bindings, topicKey)
+	delete(s.data.PendingTools, topicKey)
+	// 话题级设置随绑定一起清除，重新绑定时从默认值开始
+	delete(s.data.Filters, topicKey)
+	delete(s.data.PushModes, topicKey)
+	delete(s.data.Env, topicKey)
 	s.mu.Unlock()
 	s.triggerSave()
 }
@@ -136,6 +255,80 @@ func (s *Store) AllBindings() map[string]Binding {
 	return result
 }
 
+// TouchBinding 记录会话的最近活动时间
+func (s *Store) TouchBinding(topicKey string) {
+	s.mu.Lock()
+	b, ok := s.data.Bindings[topicKey]
+	if ok {
+		b.LastActivity = time.Now()
+		s.data.Bindings[topicKey] = b
+	}
+	s.mu.Unlock()
+	if ok {
+		s.triggerSave()
+	}
+}
+
+// SetBindingPane 记录会话所在的 pane，未绑定时返回 false
+func (s *Store) SetBindingPane(topicKey, paneID string) bool {
+	s.mu.Lock()
+	b, ok := s.data.Bindings[topicKey]
+	if ok {
+		b.PaneID = paneID
+		s.data.Bindings[topicKey] = b
+	}
+	s.mu.Unlock()
+	if ok {
+		s.triggerSave()
+	}
+	return ok
+}
+
+// SetBindingSession 记录 claude-headless 会话的 session_id，未绑定时返回 false
+func (s *Store) SetBindingSession(topicKey, sessionID string) bool {
+	s.mu.Lock()
+	b, ok := s.data.Bindings[topicKey]
+	if ok {
+		b.SessionID = sessionID
+		s.data.Bindings[topicKey] = b
+	}
+	s.mu.Unlock()
+	if ok {
+		s.triggerSave()
+	}
+	return ok
+}
+
+// SetKeepAlive 设置会话是否豁免空闲回收，未绑定时返回 false
+func (s *Store) SetKeepAlive(topicKey string, keep bool) bool {
+	s.mu.Lock()
+	b, ok := s.data.Bindings[topicKey]
+	if ok {
+		b.KeepAlive = keep
+		s.data.Bindings[topicKey] = b
+	}
+	s.mu.Unlock()
+	if ok {
+		s.triggerSave()
+	}
+	return ok
+}
+
+// SetAutoConfirm 设置会话是否自动确认权限请求，未绑定时返回 false
+func (s *Store) SetAutoConfirm(topicKey string, on bool) bool {
+	s.mu.Lock()
+	b, ok := s.data.Bindings[topicKey]
+	if ok {
+		b.AutoConfirm = on
+		s.data.Bindings[topicKey] = b
+	}
+	s.mu.Unlock()
+	if ok {
+		s.triggerSave()
+	}
+	return ok
+}
+
 // Offset 操作
 func (s *Store) SetOffset(topicKey string, o Offset) {
 	s.mu.Lock()
@@ -158,6 +351,380 @@ func (s *Store) DeleteOffset(topicKey string) {
 	s.triggerSave()
 }
 
+// Pause 操作
+func (s *Store) SetPause(topicKey string, since time.Time) {
+	s.mu.Lock()
+	s.data.Paused[topicKey] = Pause{Since: since, Skipped: make(map[string]int)}
+	s.mu.Unlock()
+	s.triggerSave()
+}
+
+func (s *Store) GetPause(topicKey string) (Pause, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	p, ok := s.data.Paused[topicKey]
+	return p, ok
+}
+
+// CountPaused 若 topic 处于暂停状态则累加对应类别的跳过计数并返回 true
+func (s *Store) CountPaused(topicKey string, kind string) bool {
+	s.mu.Lock()
+	p, ok := s.data.Paused[topicKey]
+	if ok {
+		if p.Skipped == nil {
+			p.Skipped = make(map[string]int)
+		}
+		p.Skipped[kind]++
+		s.data.Paused[topicKey] = p
+	}
+	s.mu.Unlock()
+	if ok {
+		s.triggerSave()
+	}
+	return ok
+}
+
+// ClearPause 解除暂停，返回解除前的状态
+func (s *Store) ClearPause(topicKey string) (Pause, bool) {
+	s.mu.Lock()
+	p, ok := s.data.Paused[topicKey]
+	delete(s.data.Paused, topicKey)
+	s.mu.Unlock()
+	if ok {
+		s.triggerSave()
+	}
+	return p, ok
+}
+
+// Mute 操作
+func (s *Store) SetMute(topicKey string, until time.Time) {
+	s.mu.Lock()
+	m, ok := s.data.Muted[topicKey]
+	if !ok || m.Skipped == nil {
+		m.Skipped = make(map[string]int)
+	}
+	m.Until = until
+	s.data.Muted[topicKey] = m
+	s.mu.Unlock()
+	s.triggerSave()
+}
+
+func (s *Store) GetMute(topicKey string) (Mute, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	m, ok := s.data.Muted[topicKey]
+	return m, ok
+}
+
+// CountMuted 累加静音期间对应类别的跳过计数
+func (s *Store) CountMuted(topicKey string, kind string) {
+	s.mu.Lock()
+	m, ok := s.data.Muted[topicKey]
+	if ok {
+		if m.Skipped == nil {
+			m.Skipped = make(map[string]int)
+		}
+		m.Skipped[kind]++
+		s.data.Muted[topicKey] = m
+	}
+	s.mu.Unlock()
+	if ok {
+		s.triggerSave()
+	}
+}
+
+// ClearMute 解除静音，返回解除前的状态
+func (s *Store) ClearMute(topicKey string) (Mute, bool) {
+	s.mu.Lock()
+	m, ok := s.data.Muted[topicKey]
+	delete(s.data.Muted, topicKey)
+	s.mu.Unlock()
+	if ok {
+		s.triggerSave()
+	}
+	return m, ok
+}
+
+// GetFilter 返回 topic 的输出过滤设置，未设置时返回 false（使用全局默认）
+func (s *Store) GetFilter(topicKey string) (Filter, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	f, ok := s.data.Filters[topicKey]
+	return f, ok
+}
+
+func (s *Store) SetFilter(topicKey string, f Filter) {
+	s.mu.Lock()
+	s.data.Filters[topicKey] = f
+	s.mu.Unlock()
+	s.triggerSave()
+}
+
+// PushModeStream 回答与思考追加编辑到同一条消息，而非每段一条
+const PushModeStream = "stream"
+
+// GetPushMode 返回 topic 的推送方式，未设置时为空（每段一条消息）
+func (s *Store) GetPushMode(topicKey string) string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.data.PushModes[topicKey]
+}
+
+// SetPushMode 设置 topic 的推送方式，空值恢复默认
+func (s *Store) SetPushMode(topicKey, mode string) {
+	s.mu.Lock()
+	if mode == "" {
+		delete(s.data.PushModes, topicKey)
+	} else {
+		s.data.PushModes[topicKey] = mode
+	}
+	s.mu.Unlock()
+	s.triggerSave()
+}
+
+// PendingTool 操作
+// NotePendingTool 记录监控解析到的 tool_use
+func (s *Store) NotePendingTool(topicKey, id, name string) {
+	s.mu.Lock()
+	tools := s.data.PendingTools[topicKey]
+	if tools == nil {
+		tools = make(map[string]PendingTool)
+		s.data.PendingTools[topicKey] = tools
+	}
+	t := tools[id]
+	t.Name, t.At = name, time.Now()
+	tools[id] = t
+	s.mu.Unlock()
+	s.triggerSave()
+}
+
+// SetPendingToolMsg 记录 tool_use 发送后的消息 ID 与原文
+func (s *Store) SetPendingToolMsg(topicKey, id string, msgID int, text string) {
+	s.mu.Lock()
+	tools := s.data.PendingTools[topicKey]
+	if tools == nil {
+		tools = make(map[string]PendingTool)
+		s.data.PendingTools[topicKey] = tools
+	}
+	t := tools[id]
+	if t.At.IsZero() {
+		t.At = time.Now()
+	}
+	t.MsgID, t.Text = msgID, text
+	tools[id] = t
+	s.mu.Unlock()
+	s.triggerSave()
+}
+
+// DeletePendingTool 结果已配对后删除
+func (s *Store) DeletePendingTool(topicKey, id string) {
+	s.mu.Lock()
+	tools, ok := s.data.PendingTools[topicKey]
+	if ok {
+		if _, ok = tools[id]; ok {
+			delete(tools, id)
+			if len(tools) == 0 {
+				delete(s.data.PendingTools, topicKey)
+			}
+		}
+	}
+	s.mu.Unlock()
+	if ok {
+		s.triggerSave()
+	}
+}
+
+// PendingTools 返回 topic 未配对的工具调用，顺带清理超过 pendingToolTTL 的条目
+func (s *Store) PendingTools(topicKey string) map[string]PendingTool {
+	s.mu.Lock()
+	tools := s.data.PendingTools[topicKey]
+	result := make(map[string]PendingTool, len(tools))
+	expired := false
+	for id, t := range tools {
+		if time.Since(t.At) > pendingToolTTL {
+			delete(tools, id)
+			expired = true
+			continue
+		}
+		result[id] = t
+	}
+	if tools != nil && len(tools) == 0 {
+		delete(s.data.PendingTools, topicKey)
+	}
+	s.mu.Unlock()
+	if expired {
+		s.triggerSave()
+	}
+	return result
+}
+
+// Env 操作
+func (s *Store) SetEnv(topicKey, name, value string) {
+	s.mu.Lock()
+	env := s.data.Env[topicKey]
+	if env == nil {
+		env = make(EnvVars)
+		s.data.Env[topicKey] = env
+	}
+	env[name] = value
+	s.mu.Unlock()
+	s.triggerSave()
+}
+
+// UnsetEnv 删除环境变量，不存在时返回 false
+func (s *Store) UnsetEnv(topicKey, name string) bool {
+	s.mu.Lock()
+	env := s.data.Env[topicKey]
+	_, ok := env[name]
+	delete(env, name)
+	if len(env) == 0 {
+		delete(s.data.Env, topicKey)
+	}
+	s.mu.Unlock()
+	if ok {
+		s.triggerSave()
+	}
+	return ok
+}
+
+// GetEnv 返回 topic 环境变量的副本
+func (s *Store) GetEnv(topicKey string) EnvVars {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	env := make(EnvVars, len(s.data.Env[topicKey]))
+	for k, v := range s.data.Env[topicKey] {
+		env[k] = v
+	}
+	return env
+}
+
+// AllEnv 返回所有 topic 环境变量的副本
+func (s *Store) AllEnv() map[string]EnvVars {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	result := make(map[string]EnvVars, len(s.data.Env))
+	for key, env := range s.data.Env {
+		c := make(EnvVars, len(env))
+		for k, v := range env {
+			c[k] = v
+		}
+		result[key] = c
+	}
+	return result
+}
+
+// Schedule 操作
+func (s *Store) AddSchedule(topicKey, text string, fireAt time.Time) Schedule {
+	s.mu.Lock()
+	id := 1
+	for _, sc := range s.data.Schedules {
+		if sc.ID >= id {
+			id = sc.ID + 1
+		}
+	}
+	sc := Schedule{ID: id, TopicKey: topicKey, Text: text, FireAt: fireAt, CreatedAt: time.Now()}
+	s.data.Schedules = append(s.data.Schedules, sc)
+	s.mu.Unlock()
+	s.triggerSave()
+	return sc
+}
+
+// ListSchedules 返回 topic 的待执行定时消息，按触发时间排序
+func (s *Store) ListSchedules(topicKey string) []Schedule {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var list []Schedule
+	for _, sc := range s.data.Schedules {
+		if sc.TopicKey == topicKey {
+			list = append(list, sc)
+		}
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].FireAt.Before(list[j].FireAt) })
+	return list
+}
+
+// CancelSchedule 取消 topic 下指定 ID 的定时消息，不存在时返回 false
+func (s *Store) CancelSchedule(topicKey string, id int) bool {
+	s.mu.Lock()
+	found := false
+	for i, sc := range s.data.Schedules {
+		if sc.ID == id && sc.TopicKey == topicKey {
+			s.data.Schedules = append(s.data.Schedules[:i], s.data.Schedules[i+1:]...)
+			found = true
+			break
+		}
+	}
+	s.mu.Unlock()
+	if found {
+		s.triggerSave()
+	}
+	return found
+}
+
+// PopDueSchedules 取出并删除所有已到期的定时消息
+func (s *Store) PopDueSchedules(now time.Time) []Schedule {
+	s.mu.Lock()
+	var due, pending []Schedule
+	for _, sc := range s.data.Schedules {
+		if now.Before(sc.FireAt) {
+			pending = append(pending, sc)
+		} else {
+			due = append(due, sc)
+		}
+	}
+	if len(due) > 0 {
+		s.data.Schedules = pending
+	}
+	s.mu.Unlock()
+	if len(due) > 0 {
+		s.triggerSave()
+	}
+	return due
+}
+
+// RegisterPathToken 为路径分配令牌，同一路径复用已有令牌并刷新时间；顺带清理超过 ttl 的令牌
+func (s *Store) RegisterPathToken(path string, ttl time.Duration) string {
+	now := time.Now()
+	s.mu.Lock()
+	token := ""
+	for t, ref := range s.data.Tokens {
+		switch {
+		case ref.Path == path:
+			token = t
+		case now.Sub(ref.CreatedAt) > ttl:
+			delete(s.data.Tokens, t)
+		}
+	}
+	if token == "" {
+		token = newToken()
+		for _, exists := s.data.Tokens[token]; exists; _, exists = s.data.Tokens[token] {
+			token = newToken()
+		}
+	}
+	s.data.Tokens[token] = PathRef{Path: path, CreatedAt: now}
+	s.mu.Unlock()
+	s.triggerSave()
+	return token
+}
+
+// ResolvePathToken 查找令牌对应的路径，不存在或超过 ttl 时返回 false
+func (s *Store) ResolvePathToken(token string, ttl time.Duration) (string, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	ref, ok := s.data.Tokens[token]
+	if !ok || time.Since(ref.CreatedAt) > ttl {
+		return "", false
+	}
+	return ref.Path, true
+}
+
+// newToken 生成 8 位十六进制随机令牌
+func newToken() string {
+	var buf [4]byte
+	rand.Read(buf[:])
+	return hex.EncodeToString(buf[:])
+}
+
 // Dir 操作
 func (s *Store) AddFavorite(path string) {
 	s.mu.Lock()
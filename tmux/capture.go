@@ -29,6 +29,36 @@ func (m *Manager) CapturePaneClean(windowID string) (string, error) {
 	return StripANSI(raw), nil
 }
 
+// CapturePaneJoined 捕获并清理窗口内容，按窗口宽度折行的逻辑行合并为一行（-J），
+// 窗口缩放或重新折行不会改变结果。截图等需要反映屏幕实际样子的场景应使用 CapturePaneClean
+func (m *Manager) CapturePaneJoined(windowID string) (string, error) {
+	cmd := exec.Command("tmux", "capture-pane", "-t", m.target(windowID), "-p", "-J")
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("capture-pane: %w", err)
+	}
+	return trimLineEnds(StripANSI(string(out))), nil
+}
+
+// CapturePaneHistory 与 CapturePaneJoined 相同，另外包含上方最多 lines 行滚动历史
+func (m *Manager) CapturePaneHistory(windowID string, lines int) (string, error) {
+	cmd := exec.Command("tmux", "capture-pane", "-t", m.target(windowID), "-p", "-J", "-S", fmt.Sprintf("-%d", lines))
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("capture-pane history: %w", err)
+	}
+	return trimLineEnds(StripANSI(string(out))), nil
+}
+
+// trimLineEnds 去掉每行末尾的空格（-J 会保留行尾空白）
+func trimLineEnds(s string) string {
+	lines := strings.Split(s, "\n")
+	for i, l := range lines {
+		lines[i] = strings.TrimRight(l, " ")
+	}
+	return strings.Join(lines, "\n")
+}
+
 // StripANSI 去除 ANSI 转义序列
 func StripANSI(text string) string {
 	return ansiRegex.ReplaceAllString(text, "")
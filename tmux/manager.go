@@ -3,7 +3,9 @@ package tmux
 import (
 	"fmt"
 	"os/exec"
+	"strconv"
 	"strings"
+	"sync"
 )
 
 const SessionName = "tgmux"
@@ -11,12 +13,16 @@ const SessionName = "tgmux"
 type WindowInfo struct {
 	ID   string // e.g. "@0"
 	Name string // e.g. "claude-my-project"
+	Path string // 活动 pane 的工作目录
 }
 
-type Manager struct{}
+type Manager struct {
+	mu    sync.Mutex
+	panes map[string]string // windowID → 会话所在的 pane ID（如 "%3"），窗口被拆分后仍以它为目标
+}
 
 func NewManager() *Manager {
-	return &Manager{}
+	return &Manager{panes: make(map[string]string)}
 }
 
 // EnsureSession 检查 tgmux session 是否存在，不存在则创建
@@ -46,13 +52,20 @@ func (m *Manager) NewWindow(name string) (string, error) {
 
 // KillWindow 关闭窗口
 func (m *Manager) KillWindow(windowID string) error {
+	m.SetPane(windowID, "")
 	target := fmt.Sprintf("%s:%s", SessionName, windowID)
 	cmd := exec.Command("tmux", "kill-window", "-t", target)
 	return cmd.Run()
 }
 
-// target 返回 tmux target 格式
+// target 返回 tmux target 格式：记录了 pane 时直接指向该 pane，否则为窗口（即其当前活动 pane）
 func (m *Manager) target(windowID string) string {
+	m.mu.Lock()
+	paneID := m.panes[windowID]
+	m.mu.Unlock()
+	if paneID != "" {
+		return paneID
+	}
 	return fmt.Sprintf("%s:%s", SessionName, windowID)
 }
 
@@ -112,9 +125,23 @@ func (m *Manager) SendText(windowID string, text string) error {
 	return m.SendEnter(windowID)
 }
 
+// PanePID 返回窗口当前 pane 中 shell 进程的 PID
+func (m *Manager) PanePID(windowID string) (int, error) {
+	cmd := exec.Command("tmux", "display-message", "-p", "-t", m.target(windowID), "#{pane_pid}")
+	out, err := cmd.Output()
+	if err != nil {
+		return 0, fmt.Errorf("display-message: %w", err)
+	}
+	pid, err := strconv.Atoi(strings.TrimSpace(string(out)))
+	if err != nil {
+		return 0, fmt.Errorf("parse pane_pid: %w", err)
+	}
+	return pid, nil
+}
+
 // ListWindows 列出当前 session 中的所有窗口
 func (m *Manager) ListWindows() ([]WindowInfo, error) {
-	cmd := exec.Command("tmux", "list-windows", "-t", SessionName, "-F", "#{window_id}\t#{window_name}")
+	cmd := exec.Command("tmux", "list-windows", "-t", SessionName, "-F", "#{window_id}\t#{window_name}\t#{pane_current_path}")
 	out, err := cmd.Output()
 	if err != nil {
 		return nil, fmt.Errorf("list-windows: %w", err)
@@ -124,9 +151,9 @@ func (m *Manager) ListWindows() ([]WindowInfo, error) {
 		if line == "" {
 			continue
 		}
-		parts := strings.SplitN(line, "\t", 2)
-		if len(parts) == 2 {
-			windows = append(windows, WindowInfo{ID: parts[0], Name: parts[1]})
+		parts := strings.SplitN(line, "\t", 3)
+		if len(parts) == 3 {
+			windows = append(windows, WindowInfo{ID: parts[0], Name: parts[1], Path: parts[2]})
 		}
 	}
 	return windows, nil
@@ -157,17 +184,50 @@ func (m *Manager) PaneCommand(windowID string) string {
 	return strings.TrimSpace(string(out))
 }
 
-// IsBackendAlive 检查窗口中的后端进程是否还在运行（未回退到 shell）
-func (m *Manager) IsBackendAlive(windowID string) bool {
-	proc := m.PaneCommand(windowID)
-	if proc == "" {
-		return false
+// PanePath 返回窗口当前 pane 的工作目录
+func (m *Manager) PanePath(windowID string) string {
+	cmd := exec.Command("tmux", "display-message", "-t", m.target(windowID), "-p", "#{pane_current_path}")
+	out, err := cmd.Output()
+	if err != nil {
+		return ""
 	}
-	switch proc {
-	case "bash", "zsh", "sh", "fish", "dash", "ksh", "csh", "tcsh":
-		return false
+	return strings.TrimSpace(string(out))
+}
+
+// PaneProcesses 返回窗口 pane 中的进程命令行：pane 的 shell 在前，随后为其子孙进程
+func (m *Manager) PaneProcesses(windowID string) []string {
+	root, err := m.PanePID(windowID)
+	if err != nil {
+		return nil
+	}
+	out, err := exec.Command("ps", "-A", "-o", "pid=,ppid=,args=").Output()
+	if err != nil {
+		return nil
+	}
+	args := make(map[int]string)
+	children := make(map[int][]int)
+	for _, line := range strings.Split(string(out), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 3 {
+			continue
+		}
+		pid, err1 := strconv.Atoi(fields[0])
+		ppid, err2 := strconv.Atoi(fields[1])
+		if err1 != nil || err2 != nil {
+			continue
+		}
+		args[pid] = strings.Join(fields[2:], " ")
+		children[ppid] = append(children[ppid], pid)
+	}
+	var procs []string
+	for queue := []int{root}; len(queue) > 0; queue = queue[1:] {
+		pid := queue[0]
+		if a, ok := args[pid]; ok {
+			procs = append(procs, a)
+		}
+		queue = append(queue, children[pid]...)
 	}
-	return true
+	return procs
 }
 
 // SessionAlive 检查 tgmux session 是否存在
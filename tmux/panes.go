@@ -0,0 +1,63 @@
+package tmux
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// PaneInfo 窗口中一个 pane 的信息
+type PaneInfo struct {
+	ID      string // e.g. "%3"
+	Index   string // 窗口内序号
+	Command string // pane_current_command
+	Size    string // 如 "120x40"
+	Active  bool   // 窗口当前的活动 pane
+}
+
+// SetPane 指定窗口的发送、捕获与进程检测所针对的 pane，paneID 为空时恢复为窗口的活动 pane
+func (m *Manager) SetPane(windowID, paneID string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if paneID == "" {
+		delete(m.panes, windowID)
+		return
+	}
+	m.panes[windowID] = paneID
+}
+
+// Pane 返回窗口当前针对的 pane ID，未指定时为空
+func (m *Manager) Pane(windowID string) string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.panes[windowID]
+}
+
+// ActivePane 返回窗口当前活动 pane 的 ID
+func (m *Manager) ActivePane(windowID string) (string, error) {
+	target := fmt.Sprintf("%s:%s", SessionName, windowID)
+	out, err := exec.Command("tmux", "display-message", "-p", "-t", target, "#{pane_id}").Output()
+	if err != nil {
+		return "", fmt.Errorf("display-message: %w", err)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// ListPanes 列出窗口中的所有 pane
+func (m *Manager) ListPanes(windowID string) ([]PaneInfo, error) {
+	target := fmt.Sprintf("%s:%s", SessionName, windowID)
+	format := "#{pane_id}\t#{pane_index}\t#{pane_current_command}\t#{pane_width}x#{pane_height}\t#{pane_active}"
+	out, err := exec.Command("tmux", "list-panes", "-t", target, "-F", format).Output()
+	if err != nil {
+		return nil, fmt.Errorf("list-panes: %w", err)
+	}
+	var panes []PaneInfo
+	for _, line := range strings.Split(strings.TrimSpace(string(out)), "\n") {
+		parts := strings.Split(line, "\t")
+		if len(parts) != 5 {
+			continue
+		}
+		panes = append(panes, PaneInfo{ID: parts[0], Index: parts[1], Command: parts[2], Size: parts[3], Active: parts[4] == "1"})
+	}
+	return panes, nil
+}
@@ -0,0 +1,28 @@
+package tmux
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// PipePane 将窗口输出追加写入 path（pipe-pane -o：已有管道时不重复打开）
+func (m *Manager) PipePane(windowID, path string) error {
+	shellCmd := "cat >> " + shellQuote(path)
+	cmd := exec.Command("tmux", "pipe-pane", "-t", m.target(windowID), "-o", shellCmd)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("pipe-pane: %w (%s)", err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// StopPipePane 关闭窗口上的 pipe-pane（不带命令调用即关闭）
+func (m *Manager) StopPipePane(windowID string) error {
+	cmd := exec.Command("tmux", "pipe-pane", "-t", m.target(windowID))
+	return cmd.Run()
+}
+
+// shellQuote 用单引号包裹参数，供 tmux 交给 /bin/sh 执行
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
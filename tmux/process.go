@@ -0,0 +1,69 @@
+package tmux
+
+import (
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// ProcessInfo 进程资源占用
+type ProcessInfo struct {
+	PID     int
+	PPID    int
+	Depth   int     // 在进程树中的层级，根为 0
+	CPU     float64 // %CPU
+	RSSKB   int64   // 常驻内存（KB）
+	Elapsed string  // 运行时长，ps etime 格式
+	Command string
+}
+
+// ProcessTree 返回以 rootPID 为根的进程树（深度优先，父进程在前）
+func ProcessTree(rootPID int) ([]ProcessInfo, error) {
+	// etime/comm 在 Linux 与 macOS 的 ps 中均可用
+	out, err := exec.Command("ps", "-A", "-o", "pid=,ppid=,pcpu=,rss=,etime=,comm=").Output()
+	if err != nil {
+		return nil, fmt.Errorf("ps: %w", err)
+	}
+
+	procs := make(map[int]ProcessInfo)
+	children := make(map[int][]int)
+	for _, line := range strings.Split(string(out), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 6 {
+			continue
+		}
+		pid, err1 := strconv.Atoi(fields[0])
+		ppid, err2 := strconv.Atoi(fields[1])
+		if err1 != nil || err2 != nil {
+			continue
+		}
+		cpu, _ := strconv.ParseFloat(fields[2], 64)
+		rss, _ := strconv.ParseInt(fields[3], 10, 64)
+		procs[pid] = ProcessInfo{
+			PID:     pid,
+			PPID:    ppid,
+			CPU:     cpu,
+			RSSKB:   rss,
+			Elapsed: fields[4],
+			Command: strings.Join(fields[5:], " "),
+		}
+		children[ppid] = append(children[ppid], pid)
+	}
+
+	if _, ok := procs[rootPID]; !ok {
+		return nil, fmt.Errorf("process %d not found", rootPID)
+	}
+	var tree []ProcessInfo
+	var walk func(pid, depth int)
+	walk = func(pid, depth int) {
+		p := procs[pid]
+		p.Depth = depth
+		tree = append(tree, p)
+		for _, c := range children[pid] {
+			walk(c, depth+1)
+		}
+	}
+	walk(rootPID, 0)
+	return tree, nil
+}